@@ -0,0 +1,150 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// CheckTimeout bounds a check that sets no timeout of its own
+	CheckTimeout = time.Second * 2
+	// ReportTTL is how long a report is served before the checks run again, so frequent
+	// probes do not hammer the dependencies
+	ReportTTL = time.Second * 2
+)
+
+// Statuses of a report and its checks
+const (
+	StatusOK       = "ok"
+	StatusFailing  = "failing"
+	StatusShutdown = "shutting_down"
+)
+
+// Check is a dependency the server needs to serve requests
+type Check struct {
+	Name    string
+	Timeout time.Duration
+	Run     func(ctx context.Context) error
+}
+
+// CheckResult is the outcome of a check
+type CheckResult struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Report is the readiness of the server, with a breakdown by check
+type Report struct {
+	Status    string        `json:"status"`
+	Checks    []CheckResult `json:"checks"`
+	CheckedAt time.Time     `json:"checked_at"`
+}
+
+// Ready tells whether the server can take requests
+func (r *Report) Ready() bool {
+	return r.Status == StatusOK
+}
+
+// Checker runs the readiness checks, all at once, and caches the report for ReportTTL
+type Checker struct {
+	checks   []Check
+	ttl      time.Duration
+	draining int32
+
+	mu   sync.Mutex
+	last *Report
+}
+
+// NewChecker returns a checker of checks
+func NewChecker(checks ...Check) *Checker {
+	return &Checker{checks: checks, ttl: ReportTTL}
+}
+
+// Drain makes the server report not ready from now on, while it shuts down
+func (c *Checker) Drain() {
+	atomic.StoreInt32(&c.draining, 1)
+}
+
+// Report returns the cached report, or runs the checks if it is older than ReportTTL.
+// Checks do not run under the context of a probe, a probe that gave up would fail them
+// for everyone served the report.
+func (c *Checker) Report() *Report {
+	if atomic.LoadInt32(&c.draining) == 1 {
+		return &Report{Status: StatusShutdown, Checks: []CheckResult{}, CheckedAt: time.Now()}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.last != nil && time.Since(c.last.CheckedAt) < c.ttl {
+		return c.last
+	}
+	c.last = c.run(context.Background())
+
+	return c.last
+}
+
+func (c *Checker) run(ctx context.Context) *Report {
+	report := &Report{
+		Status:    StatusOK,
+		Checks:    make([]CheckResult, len(c.checks)),
+		CheckedAt: time.Now(),
+	}
+
+	wg := sync.WaitGroup{}
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			report.Checks[i] = runCheck(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for _, res := range report.Checks {
+		if res.Status != StatusOK {
+			report.Status = StatusFailing
+		}
+	}
+
+	return report
+}
+
+// runCheck gives up on check after its timeout, even if check does not watch ctx
+func runCheck(ctx context.Context, check Check) CheckResult {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = CheckTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- check.Run(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	res := CheckResult{
+		Name:       check.Name,
+		Status:     StatusOK,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		res.Status = StatusFailing
+		res.Error = err.Error()
+	}
+
+	return res
+}
@@ -1,11 +1,32 @@
 package health
 
 import (
+	"github.com/iamrz1/ab-auth/i18n"
 	"github.com/iamrz1/ab-auth/utils"
 	"net/http"
 )
 
 func status(w http.ResponseWriter, r *http.Request) {
-	utils.ServeJSONObject(w, http.StatusOK, "OK", nil, nil, true)
+	utils.ServeJSONObject(w, http.StatusOK, i18n.MsgOK, nil, nil, true)
 	return
 }
+
+// live tells the process is up and serving, whatever the state of its dependencies
+func live(w http.ResponseWriter, r *http.Request) {
+	utils.ServeJSONObject(w, http.StatusOK, i18n.MsgOK, map[string]string{"status": StatusOK}, nil, true)
+}
+
+// ready serves the report of checker, with a 503 while any check fails or the server
+// shuts down
+func ready(checker *Checker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := checker.Report()
+		w.Header().Set("Cache-Control", "no-store")
+		if !report.Ready() {
+			utils.ServeJSONObject(w, http.StatusServiceUnavailable, i18n.MsgNotReady, report, nil, false)
+			return
+		}
+
+		utils.ServeJSONObject(w, http.StatusOK, i18n.MsgOK, report, nil, true)
+	}
+}
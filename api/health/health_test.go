@@ -0,0 +1,75 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func probe(t *testing.T, checker *Checker, path string) (int, *Report) {
+	rec := httptest.NewRecorder()
+	Router(checker).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+	body := struct {
+		Data *Report `json:"data"`
+	}{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+
+	return rec.Code, body.Data
+}
+
+func TestReadyz_Breakdown(t *testing.T) {
+	checker := NewChecker(
+		Check{Name: "mongo", Run: func(context.Context) error { return nil }},
+		Check{Name: "redis", Run: func(context.Context) error { return errors.New("connection refused") }},
+		Check{Name: "slow", Timeout: time.Millisecond * 10, Run: func(context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		}},
+	)
+
+	code, report := probe(t, checker, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusFailing, report.Status)
+	assert.Equal(t, CheckResult{Name: "mongo", Status: StatusOK}, report.Checks[0])
+	assert.Equal(t, "connection refused", report.Checks[1].Error)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks[2].Error, "a check ignoring its context still times out")
+}
+
+func TestReadyz_CachesReport(t *testing.T) {
+	var runs int32
+	checker := NewChecker(Check{Name: "mongo", Run: func(context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	}})
+
+	for i := 0; i < 3; i++ {
+		code, _ := probe(t, checker, "/readyz")
+		assert.Equal(t, http.StatusOK, code)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&runs))
+
+	checker.ttl = 0
+	probe(t, checker, "/readyz")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&runs))
+}
+
+func TestReadyz_Draining(t *testing.T) {
+	checker := NewChecker(Check{Name: "mongo", Run: func(context.Context) error { return nil }})
+	checker.Drain()
+
+	code, report := probe(t, checker, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusShutdown, report.Status)
+
+	// the process is still alive while it drains
+	rec := httptest.NewRecorder()
+	Router(checker).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
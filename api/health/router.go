@@ -4,11 +4,14 @@ import (
 	"github.com/go-chi/chi"
 )
 
-// Router return health router
-func Router() *chi.Mux {
+// Router return health router, with the liveness probe on /healthz and the readiness
+// probe on /readyz
+func Router(checker *Checker) *chi.Mux {
 	r := chi.NewRouter()
 
 	r.Get("/", status)
+	r.Get("/healthz", live)
+	r.Get("/readyz", ready(checker))
 
 	return r
 }
@@ -0,0 +1,145 @@
+package internal
+
+import (
+	"encoding/json"
+	"github.com/go-chi/chi"
+	"github.com/iamrz1/ab-auth/auth"
+	rest_error "github.com/iamrz1/ab-auth/error"
+	"github.com/iamrz1/ab-auth/i18n"
+	"github.com/iamrz1/ab-auth/model"
+	"github.com/iamrz1/ab-auth/utils"
+	"net/http"
+)
+
+// decodeStatusUpdate reads the status update of the account in the path of r, made by the
+// internal caller
+func decodeStatusUpdate(r *http.Request) (*model.AccountStatusUpdateReq, error) {
+	req := &model.AccountStatusUpdateReq{}
+	err := json.NewDecoder(r.Body).Decode(req)
+	if err != nil {
+		return nil, rest_error.NewCodedValidationError(rest_error.CodeInvalidJSON, i18n.MsgInvalidJSON, err)
+	}
+
+	req.Username = chi.URLParam(r, "username")
+	req.Actor = auth.Username(r.Context())
+	err = model.Validate(req)
+	if err != nil {
+		return nil, rest_error.NewValidationError(i18n.MsgMissingRequiredFields, err)
+	}
+
+	return req, nil
+}
+
+// updateCustomerStatusHandler godoc
+// @Summary Update the status of a customer
+// @Description Move a customer to active, blocked, inactive or pending, for back office services. The caller is recorded as the actor. Blocking or deactivating the account revokes all of its sessions.
+// @Tags Internal
+// @Accept  json
+// @Produce  json
+// @Param Secret-Key header string false "Secret of the caller, unless a client certificate is used"
+// @Param username path string true "Username of the customer"
+// @Param  Body body model.AccountStatusUpdateReq true "The new status and why"
+// @Success 200 {object} response.CustomerSuccessRes
+// @Failure 400 {object} response.EmptyErrorRes "Invalid request body, invalid status (INVALID_STATUS) or customer not found (NOT_FOUND)."
+// @Failure 401 {object} response.EmptyErrorRes "Missing or invalid secret key or client certificate."
+// @Failure 403 {object} response.EmptyErrorRes "The caller is not allowed to use this endpoint."
+// @Failure 500 {object} response.EmptyErrorRes "API sever or db unreachable."
+// @Router /api/v1/internal/customers/{username}/status [put]
+func (ir *internalRouter) updateCustomerStatusHandler(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeStatusUpdate(r)
+	if err != nil {
+		utils.HandleObjectError(w, r, err)
+		return
+	}
+
+	res, err := ir.Services.CustomerService.UpdateCustomerStatus(r.Context(), req)
+	if err != nil {
+		ir.Log.Errorln(r.Context(), "updateCustomerStatusHandler", err.Error())
+		utils.HandleObjectError(w, r, err)
+		return
+	}
+
+	utils.ServeJSONObject(w, http.StatusOK, i18n.MsgStatusUpdated, res, nil, true)
+}
+
+// updateMerchantStatusHandler godoc
+// @Summary Update the status of a merchant
+// @Description Move a merchant to active, blocked, inactive or pending, for back office services. The caller is recorded as the actor. Blocking or deactivating the account revokes all of its sessions.
+// @Tags Internal
+// @Accept  json
+// @Produce  json
+// @Param Secret-Key header string false "Secret of the caller, unless a client certificate is used"
+// @Param username path string true "Username of the merchant"
+// @Param  Body body model.AccountStatusUpdateReq true "The new status and why"
+// @Success 200 {object} response.MerchantSuccessRes
+// @Failure 400 {object} response.EmptyErrorRes "Invalid request body, invalid status (INVALID_STATUS) or merchant not found (NOT_FOUND)."
+// @Failure 401 {object} response.EmptyErrorRes "Missing or invalid secret key or client certificate."
+// @Failure 403 {object} response.EmptyErrorRes "The caller is not allowed to use this endpoint."
+// @Failure 500 {object} response.EmptyErrorRes "API sever or db unreachable."
+// @Router /api/v1/internal/merchants/{username}/status [put]
+func (ir *internalRouter) updateMerchantStatusHandler(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeStatusUpdate(r)
+	if err != nil {
+		utils.HandleObjectError(w, r, err)
+		return
+	}
+
+	res, err := ir.Services.MerchantService.UpdateMerchantStatus(r.Context(), req)
+	if err != nil {
+		ir.Log.Errorln(r.Context(), "updateMerchantStatusHandler", err.Error())
+		utils.HandleObjectError(w, r, err)
+		return
+	}
+
+	utils.ServeJSONObject(w, http.StatusOK, i18n.MsgStatusUpdated, res, nil, true)
+}
+
+// restoreCustomerHandler godoc
+// @Summary Restore a deleted customer
+// @Description Undo the soft delete of a customer, for back office services. Sessions revoked by the delete stay revoked.
+// @Tags Internal
+// @Produce  json
+// @Param Secret-Key header string false "Secret of the caller, unless a client certificate is used"
+// @Param username path string true "Username of the customer"
+// @Success 200 {object} response.CustomerSuccessRes
+// @Failure 400 {object} response.EmptyErrorRes "No such customer, or the customer is not deleted (NOT_FOUND)."
+// @Failure 401 {object} response.EmptyErrorRes "Missing or invalid secret key or client certificate."
+// @Failure 403 {object} response.EmptyErrorRes "The caller is not allowed to use this endpoint."
+// @Failure 500 {object} response.EmptyErrorRes "API sever or db unreachable."
+// @Router /api/v1/internal/customers/{username}/restore [post]
+func (ir *internalRouter) restoreCustomerHandler(w http.ResponseWriter, r *http.Request) {
+	req := &model.CustomerDeleteReq{Username: chi.URLParam(r, "username")}
+	res, err := ir.Services.CustomerService.RestoreCustomer(r.Context(), req)
+	if err != nil {
+		ir.Log.Errorln(r.Context(), "restoreCustomerHandler", err.Error())
+		utils.HandleObjectError(w, r, err)
+		return
+	}
+
+	utils.ServeJSONObject(w, http.StatusOK, i18n.MsgCustomerRestored, res, nil, true)
+}
+
+// restoreMerchantHandler godoc
+// @Summary Restore a deleted merchant
+// @Description Undo the soft delete of a merchant, for back office services. Sessions revoked by the delete stay revoked.
+// @Tags Internal
+// @Produce  json
+// @Param Secret-Key header string false "Secret of the caller, unless a client certificate is used"
+// @Param username path string true "Username of the merchant"
+// @Success 200 {object} response.MerchantSuccessRes
+// @Failure 400 {object} response.EmptyErrorRes "No such merchant, or the merchant is not deleted (NOT_FOUND)."
+// @Failure 401 {object} response.EmptyErrorRes "Missing or invalid secret key or client certificate."
+// @Failure 403 {object} response.EmptyErrorRes "The caller is not allowed to use this endpoint."
+// @Failure 500 {object} response.EmptyErrorRes "API sever or db unreachable."
+// @Router /api/v1/internal/merchants/{username}/restore [post]
+func (ir *internalRouter) restoreMerchantHandler(w http.ResponseWriter, r *http.Request) {
+	req := &model.MerchantDeleteReq{Username: chi.URLParam(r, "username")}
+	res, err := ir.Services.MerchantService.RestoreMerchant(r.Context(), req)
+	if err != nil {
+		ir.Log.Errorln(r.Context(), "restoreMerchantHandler", err.Error())
+		utils.HandleObjectError(w, r, err)
+		return
+	}
+
+	utils.ServeJSONObject(w, http.StatusOK, i18n.MsgMerchantRestored, res, nil, true)
+}
@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"encoding/json"
+	"github.com/go-chi/chi"
+	rest_error "github.com/iamrz1/ab-auth/error"
+	"github.com/iamrz1/ab-auth/i18n"
+	"github.com/iamrz1/ab-auth/model"
+	"github.com/iamrz1/ab-auth/utils"
+	"net/http"
+)
+
+// searchAddressesByLocationHandler godoc
+// @Summary Find addresses by location
+// @Description Find customer addresses within radius_km of a center, nearest first, or inside a polygon of [longitude, latitude] points. Give either the center and radius or the polygon. Meant for logistics to group deliveries and check service coverage.
+// @Tags Internal
+// @Produce  json
+// @Param Secret-Key header string false "Secret of the caller, unless a client certificate is used"
+// @Param  Body body model.AddressGeoSearchReq true "A center with a radius, or a polygon"
+// @Success 200 {object} response.AddressListSuccessRes
+// @Failure 400 {object} response.EmptyListErrorRes "Invalid request body or geo query (INVALID_GEO_QUERY)."
+// @Failure 401 {object} response.EmptyListErrorRes "Missing or invalid secret key or client certificate."
+// @Failure 403 {object} response.EmptyListErrorRes "The caller is not allowed to use this endpoint."
+// @Failure 500 {object} response.EmptyListErrorRes "API sever or db unreachable."
+// @Router /api/v1/internal/addresses/geo-search [post]
+func (ir *internalRouter) searchAddressesByLocationHandler(w http.ResponseWriter, r *http.Request) {
+	req := &model.AddressGeoSearchReq{}
+	err := json.NewDecoder(r.Body).Decode(req)
+	if err != nil {
+		ir.Log.Errorln(r.Context(), "searchAddressesByLocationHandler", err.Error())
+		utils.HandleListError(w, r, rest_error.NewCodedValidationError(rest_error.CodeInvalidJSON, i18n.MsgInvalidJSON, err))
+		return
+	}
+
+	res, err := ir.Services.CustomerService.SearchAddressesByLocation(r.Context(), req)
+	if err != nil {
+		ir.Log.Errorln(r.Context(), "searchAddressesByLocationHandler", err.Error())
+		utils.HandleListError(w, r, err)
+		return
+	}
+
+	utils.ServeJSONList(w, http.StatusOK, i18n.MsgListFetched, res, nil, true)
+}
+
+// getPrimaryAddressHandler godoc
+// @Summary Get the primary address of a customer
+// @Description Get the primary address of a customer by username, for other services
+// @Tags Internal
+// @Produce  json
+// @Param Secret-Key header string false "Secret of the caller, unless a client certificate is used"
+// @Param username path string true "Username of the customer"
+// @Success 200 {object} response.AddressSuccessRes
+// @Failure 401 {object} response.EmptyErrorRes "Missing or invalid secret key or client certificate."
+// @Failure 403 {object} response.EmptyErrorRes "The caller is not allowed to use this endpoint."
+// @Failure 417 {object} response.EmptyErrorRes "The customer is yet to set a primary address (ADDRESS_NO_PRIMARY)."
+// @Failure 500 {object} response.EmptyErrorRes "API sever or db unreachable."
+// @Router /api/v1/internal/customers/{username}/primary-address [get]
+func (ir *internalRouter) getPrimaryAddressHandler(w http.ResponseWriter, r *http.Request) {
+	res, err := ir.Services.CustomerService.GetPrimaryAddress(r.Context(), chi.URLParam(r, "username"))
+	if err != nil {
+		ir.Log.Errorln(r.Context(), "getPrimaryAddressHandler", err.Error())
+		utils.HandleObjectError(w, r, err)
+		return
+	}
+
+	utils.ServeJSONObject(w, http.StatusOK, i18n.MsgSuccessful, res, nil, true)
+}
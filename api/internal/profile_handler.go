@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"github.com/go-chi/chi"
+	"github.com/iamrz1/ab-auth/i18n"
+	"github.com/iamrz1/ab-auth/utils"
+	"net/http"
+)
+
+// getCustomerShortProfileHandler godoc
+// @Summary Get the short profile of a customer
+// @Description Get the short profile of a customer by username, for other services
+// @Tags Internal
+// @Produce  json
+// @Param Secret-Key header string false "Secret of the caller, unless a client certificate is used"
+// @Param username path string true "Username of the customer"
+// @Success 200 {object} response.CustomerResShort
+// @Failure 401 {object} response.EmptyErrorRes "Missing or invalid secret key or client certificate."
+// @Failure 403 {object} response.EmptyErrorRes "The caller is not allowed to use this endpoint."
+// @Failure 404 {object} response.EmptyErrorRes "Customer not found."
+// @Failure 500 {object} response.EmptyErrorRes "API sever or db unreachable."
+// @Router /api/v1/internal/customers/{username}/short-profile [get]
+func (ir *internalRouter) getCustomerShortProfileHandler(w http.ResponseWriter, r *http.Request) {
+	res, err := ir.Services.CustomerService.GetShortProfileByUsername(r.Context(), chi.URLParam(r, "username"))
+	if err != nil {
+		ir.Log.Errorln(r.Context(), "getCustomerShortProfileHandler", err.Error())
+		utils.HandleObjectError(w, r, err)
+		return
+	}
+
+	utils.ServeJSONObject(w, http.StatusOK, i18n.MsgSuccessful, res, nil, true)
+}
+
+// getMerchantStatusHandler godoc
+// @Summary Get the status of a merchant
+// @Description Get the account status of a merchant by username, for other services to check whether the merchant can do business
+// @Tags Internal
+// @Produce  json
+// @Param Secret-Key header string false "Secret of the caller, unless a client certificate is used"
+// @Param username path string true "Username of the merchant"
+// @Success 200 {object} response.MerchantStatusSuccessRes
+// @Failure 401 {object} response.EmptyErrorRes "Missing or invalid secret key or client certificate."
+// @Failure 403 {object} response.EmptyErrorRes "The caller is not allowed to use this endpoint."
+// @Failure 404 {object} response.EmptyErrorRes "Merchant not found."
+// @Failure 500 {object} response.EmptyErrorRes "API sever or db unreachable."
+// @Router /api/v1/internal/merchants/{username}/status [get]
+func (ir *internalRouter) getMerchantStatusHandler(w http.ResponseWriter, r *http.Request) {
+	res, err := ir.Services.MerchantService.GetMerchantStatus(r.Context(), chi.URLParam(r, "username"))
+	if err != nil {
+		ir.Log.Errorln(r.Context(), "getMerchantStatusHandler", err.Error())
+		utils.HandleObjectError(w, r, err)
+		return
+	}
+
+	utils.ServeJSONObject(w, http.StatusOK, i18n.MsgSuccessful, res, nil, true)
+}
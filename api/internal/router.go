@@ -0,0 +1,48 @@
+package internal
+
+import (
+	"github.com/go-chi/chi"
+	"github.com/iamrz1/ab-auth/api/middleware"
+	"github.com/iamrz1/ab-auth/logger"
+	"github.com/iamrz1/ab-auth/service"
+)
+
+// Internal endpoint names, as listed in the endpoint allowlist of a caller
+const (
+	EndpointCustomerShortProfile   = "customers.short_profile"
+	EndpointCustomerPrimaryAddress = "customers.primary_address"
+	EndpointMerchantStatus         = "merchants.status"
+	EndpointCustomerStatusUpdate   = "customers.status_update"
+	EndpointMerchantStatusUpdate   = "merchants.status_update"
+	EndpointCustomerRestore        = "customers.restore"
+	EndpointMerchantRestore        = "merchants.restore"
+	EndpointAddressGeoSearch       = "addresses.geo_search"
+)
+
+type internalRouter struct {
+	Services *service.Config
+	Log      logger.Logger
+}
+
+func NewInternalRouter(svc *service.Config, lgr logger.Logger) *internalRouter {
+	return &internalRouter{
+		Services: svc,
+		Log:      lgr,
+	}
+}
+
+// Router returns a router for apis called by other services, never by end users
+func (ir *internalRouter) Router() *chi.Mux {
+	r := chi.NewRouter()
+
+	r.Use(middleware.InternalOnly(ir.Services.CustomerService.Config.Internal.Callers))
+	r.With(middleware.AllowEndpoint(EndpointCustomerShortProfile)).Get("/customers/{username}/short-profile", ir.getCustomerShortProfileHandler)
+	r.With(middleware.AllowEndpoint(EndpointCustomerPrimaryAddress)).Get("/customers/{username}/primary-address", ir.getPrimaryAddressHandler)
+	r.With(middleware.AllowEndpoint(EndpointMerchantStatus)).Get("/merchants/{username}/status", ir.getMerchantStatusHandler)
+	r.With(middleware.AllowEndpoint(EndpointCustomerStatusUpdate)).Put("/customers/{username}/status", ir.updateCustomerStatusHandler)
+	r.With(middleware.AllowEndpoint(EndpointMerchantStatusUpdate)).Put("/merchants/{username}/status", ir.updateMerchantStatusHandler)
+	r.With(middleware.AllowEndpoint(EndpointCustomerRestore)).Post("/customers/{username}/restore", ir.restoreCustomerHandler)
+	r.With(middleware.AllowEndpoint(EndpointMerchantRestore)).Post("/merchants/{username}/restore", ir.restoreMerchantHandler)
+	r.With(middleware.AllowEndpoint(EndpointAddressGeoSearch)).Post("/addresses/geo-search", ir.searchAddressesByLocationHandler)
+	return r
+}
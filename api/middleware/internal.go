@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"github.com/iamrz1/ab-auth/auth"
+	"github.com/iamrz1/ab-auth/config"
+	rest_error "github.com/iamrz1/ab-auth/error"
+	"github.com/iamrz1/ab-auth/i18n"
+	"github.com/iamrz1/ab-auth/model"
+	"github.com/iamrz1/ab-auth/utils"
+	"net/http"
+)
+
+type internalCallerKey struct{}
+
+// InternalCallerFromContext returns the caller authenticated by InternalOnly
+func InternalCallerFromContext(ctx context.Context) (*config.InternalCaller, bool) {
+	c, ok := ctx.Value(internalCallerKey{}).(*config.InternalCaller)
+	return c, ok
+}
+
+// InternalOnly lets through services listed in callers, identified by a verified client
+// certificate or by one of their secrets in the Secret-Key header. The caller is put in
+// the request context for AllowEndpoint. The current callers are read on every request.
+func InternalOnly(callers *config.InternalCallerSet) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			caller, method := authenticateInternalCaller(r, callers.Get())
+			if caller == nil {
+				utils.HandleObjectError(w, r, rest_error.NewCodedError(http.StatusUnauthorized, rest_error.CodeAuthInvalidCredentials, i18n.MsgInvalidSecret))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(withInternalCaller(r.Context(), caller, method)))
+		})
+	}
+}
+
+// AllowEndpoint lets through internal callers whose allowlist has endpoint
+func AllowEndpoint(endpoint string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			caller, ok := InternalCallerFromContext(r.Context())
+			if !ok || !caller.Allows(endpoint) {
+				utils.HandleObjectError(w, r, rest_error.NewCodedError(http.StatusForbidden, rest_error.CodeAuthForbidden, i18n.MsgEndpointNotAllowed))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// withInternalCaller stores caller in ctx, for AllowEndpoint, along with its principal
+func withInternalCaller(ctx context.Context, caller *config.InternalCaller, method string) context.Context {
+	ctx = context.WithValue(ctx, internalCallerKey{}, caller)
+	return auth.WithPrincipal(ctx, &auth.Principal{Username: caller.Name, UserType: auth.UserTypeService, Method: method})
+}
+
+// authenticateInternalCaller returns the caller of r and how it was authenticated
+func authenticateInternalCaller(r *http.Request, callers []config.InternalCaller) (*config.InternalCaller, string) {
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+		leaf := r.TLS.VerifiedChains[0][0]
+		names := append([]string{leaf.Subject.CommonName}, leaf.DNSNames...)
+		for i := range callers {
+			for _, want := range callers[i].CertNames {
+				for _, name := range names {
+					if name != "" && name == want {
+						return &callers[i], auth.MethodClientCertificate
+					}
+				}
+			}
+		}
+	}
+
+	key := r.Header.Get(utils.KeyForSecretKey)
+	if key == "" {
+		return nil, ""
+	}
+
+	// every secret is compared, so timing tells nothing about which caller came close
+	var match *config.InternalCaller
+	for i := range callers {
+		for _, secret := range callers[i].Secrets {
+			if subtle.ConstantTimeCompare([]byte(key), []byte(secret)) == 1 && match == nil {
+				match = &callers[i]
+			}
+		}
+	}
+
+	return match, auth.MethodSecret
+}
+
+// ClientCredentialsOnly lets through internal callers authenticated by their name and one
+// of their secrets as OAuth client credentials, sent with HTTP Basic auth or as the
+// client_id and client_secret form fields, and allowed on endpoint. Failures get an
+// RFC 6749 invalid_client error.
+func ClientCredentialsOnly(callers *config.InternalCallerSet, endpoint string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, secret, ok := r.BasicAuth()
+			if !ok {
+				id, secret = r.PostFormValue("client_id"), r.PostFormValue("client_secret")
+			}
+
+			caller := authenticateClient(id, secret, callers.Get())
+			if caller == nil || !caller.Allows(endpoint) {
+				w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+				utils.ServeOAuthJSON(w, http.StatusUnauthorized, &model.OAuthError{Error: "invalid_client"})
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(withInternalCaller(r.Context(), caller, auth.MethodSecret)))
+		})
+	}
+}
+
+func authenticateClient(id, secret string, callers []config.InternalCaller) *config.InternalCaller {
+	if id == "" || secret == "" {
+		return nil
+	}
+
+	var match *config.InternalCaller
+	for i := range callers {
+		for _, s := range callers[i].Secrets {
+			if subtle.ConstantTimeCompare([]byte(secret), []byte(s)) == 1 && callers[i].Name == id && match == nil {
+				match = &callers[i]
+			}
+		}
+	}
+
+	return match
+}
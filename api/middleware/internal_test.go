@@ -0,0 +1,175 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"github.com/iamrz1/ab-auth/config"
+	"github.com/iamrz1/ab-auth/utils"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testInternalCallers = []config.InternalCaller{
+	{
+		Name:      "order",
+		Secrets:   []string{"old-order-secret-0123456789abcdef", "new-order-secret-0123456789abcdef"},
+		Endpoints: []string{"customers.short_profile"},
+	},
+	{
+		Name:      "logistics",
+		CertNames: []string{"logistics.internal"},
+		Endpoints: []string{"*"},
+	},
+}
+
+func serveInternal(r *http.Request, endpoint string) int {
+	h := InternalOnly(config.NewInternalCallerSet(testInternalCallers))(AllowEndpoint(endpoint)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w.Code
+}
+
+func TestInternalOnly_Secrets(t *testing.T) {
+	cases := []struct {
+		secret string
+		want   int
+	}{
+		{secret: "old-order-secret-0123456789abcdef", want: http.StatusOK},
+		{secret: "new-order-secret-0123456789abcdef", want: http.StatusOK},
+		{secret: "new-order-secret", want: http.StatusUnauthorized},
+		{secret: "", want: http.StatusUnauthorized},
+	}
+
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set(utils.KeyForSecretKey, c.secret)
+		if got := serveInternal(r, "customers.short_profile"); got != c.want {
+			t.Errorf("secret %q: expected %d, got %d", c.secret, c.want, got)
+		}
+	}
+}
+
+func TestInternalOnly_ClientCertificate(t *testing.T) {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "svc"}, DNSNames: []string{"logistics.internal"}}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	if got := serveInternal(r, "merchants.status"); got != http.StatusOK {
+		t.Errorf("expected a verified certificate to be accepted, got %d", got)
+	}
+
+	// an unverified certificate proves nothing
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	if got := serveInternal(r, "merchants.status"); got != http.StatusUnauthorized {
+		t.Errorf("expected an unverified certificate to be rejected, got %d", got)
+	}
+}
+
+func TestAllowEndpoint(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(utils.KeyForSecretKey, "new-order-secret-0123456789abcdef")
+	if got := serveInternal(r, "merchants.status"); got != http.StatusForbidden {
+		t.Errorf("expected an endpoint outside the allowlist to be forbidden, got %d", got)
+	}
+}
+
+func TestClientCredentialsOnly(t *testing.T) {
+	h := ClientCredentialsOnly(config.NewInternalCallerSet(testInternalCallers), "customers.short_profile")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	cases := []struct {
+		id, secret string
+		form       bool
+		want       int
+	}{
+		{id: "order", secret: "new-order-secret-0123456789abcdef", want: http.StatusOK},
+		{id: "order", secret: "old-order-secret-0123456789abcdef", form: true, want: http.StatusOK},
+		// the secret of one caller does not work under the name of another
+		{id: "logistics", secret: "new-order-secret-0123456789abcdef", want: http.StatusUnauthorized},
+		{id: "order", secret: "", want: http.StatusUnauthorized},
+	}
+
+	for _, c := range cases {
+		var r *http.Request
+		if c.form {
+			form := url.Values{"client_id": {c.id}, "client_secret": {c.secret}}
+			r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		} else {
+			r = httptest.NewRequest(http.MethodPost, "/", nil)
+			r.SetBasicAuth(c.id, c.secret)
+		}
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != c.want {
+			t.Errorf("%s/%q: expected %d, got %d", c.id, c.secret, c.want, w.Code)
+		}
+	}
+}
+
+func TestInternalOnly_RotatedSecret(t *testing.T) {
+	dir, err := ioutil.TempDir("", "callers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "callers.json")
+	write := func(content string) {
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	serve := func(h http.Handler, secret string) int {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set(utils.KeyForSecretKey, secret)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	write(`[{"name": "order", "secrets": ["old-order-secret-0123456789abcdef"], "endpoints": ["*"]}]`)
+	callers := config.NewInternalCallerSet(nil)
+	if err := callers.Reload(path); err != nil {
+		t.Fatal(err)
+	}
+	h := InternalOnly(callers)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	if got := serve(h, "new-order-secret-0123456789abcdef"); got != http.StatusUnauthorized {
+		t.Errorf("expected the new secret to be rejected before the rotation, got %d", got)
+	}
+
+	write(`[{"name": "order", "secrets": ["new-order-secret-0123456789abcdef"], "endpoints": ["*"]}]`)
+	if err := callers.Reload(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := serve(h, "new-order-secret-0123456789abcdef"); got != http.StatusOK {
+		t.Errorf("expected the rotated secret to be accepted by the same handler, got %d", got)
+	}
+	if got := serve(h, "old-order-secret-0123456789abcdef"); got != http.StatusUnauthorized {
+		t.Errorf("expected the dropped secret to be rejected, got %d", got)
+	}
+
+	// a broken edit keeps the last callers
+	write(`[{"name": "order", "secrets": ["short"], "endpoints": ["*"]}]`)
+	if err := callers.Reload(path); err == nil {
+		t.Error("expected the invalid callers file to be refused")
+	}
+	if got := serve(h, "new-order-secret-0123456789abcdef"); got != http.StatusOK {
+		t.Errorf("expected the last valid secret to still be accepted, got %d", got)
+	}
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"github.com/iamrz1/ab-auth/i18n"
+	"net/http"
+)
+
+// Language negotiates the response language from Accept-Language, stores it in the request
+// context and announces it with Content-Language, which the response helpers translate into
+func Language(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.Negotiate(r.Header.Get("Accept-Language"))
+
+		w.Header().Set("Content-Language", lang)
+		w.Header().Add("Vary", "Accept-Language")
+
+		next.ServeHTTP(w, r.WithContext(i18n.WithLanguage(r.Context(), lang)))
+	})
+}
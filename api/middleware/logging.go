@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	chiMiddleware "github.com/go-chi/chi/middleware"
+	"github.com/iamrz1/ab-auth/logger"
+	"net/http"
+	"time"
+)
+
+// RequestLogger opens the log scope of every request and logs the request once it was
+// served. Middlewares further down add to the scope, like the user the request was
+// authenticated as, so the entry carries what they learned.
+func RequestLogger(lgr logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ctx := logger.WithFields(r.Context(), logger.Fields{"method": r.Method, "path": r.URL.Path})
+			ww := chiMiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			lgr.Infof(ctx, "RequestLogger", "%s %s %d %dB in %s", r.Method, r.URL.Path, status, ww.BytesWritten(), time.Since(start))
+		})
+	}
+}
@@ -1,142 +1,112 @@
 package middleware
 
 import (
+	"github.com/iamrz1/ab-auth/auth"
 	rest_error "github.com/iamrz1/ab-auth/error"
+	"github.com/iamrz1/ab-auth/i18n"
 	"github.com/iamrz1/ab-auth/utils"
 	"net/http"
-	"strings"
 )
 
 func JWTTokenOnly(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		jwtTkn := r.Header.Get(utils.AuthorizationKey)
 		if jwtTkn == "" {
-			utils.HandleObjectError(w, rest_error.NewGenericError(http.StatusUnauthorized, "Missing access token"))
+			utils.HandleObjectError(w, r, rest_error.NewCodedError(http.StatusUnauthorized, rest_error.CodeAuthTokenMissing, i18n.MsgMissingAccessToken))
 			return
 		}
 
-		jwtTkn = stripBearerFromToken(jwtTkn)
+		jwtTkn = utils.StripBearer(jwtTkn)
 		r.Header.Set(utils.AuthorizationKey, jwtTkn)
 
 		next.ServeHTTP(w, r)
 	})
 }
 
-func AuthenticatedOnly(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		jwtTkn := r.Header.Get(utils.AuthorizationKey)
-		if jwtTkn == "" {
-			utils.HandleObjectError(w, rest_error.NewGenericError(http.StatusUnauthorized, "Missing access token"))
-			return
-		}
-
-		jwtTkn = stripBearerFromToken(jwtTkn)
+// AuthOption configures Authenticate
+type AuthOption func(*authConfig)
 
-		claims, err := utils.VerifyToken(jwtTkn, false)
-		if err != nil {
-			utils.HandleObjectError(w, rest_error.NewGenericError(http.StatusUnauthorized, err.Error()))
-			return
-		}
-
-		if !isTokenFresh(claims.Username, claims.IssuedAt) {
-			utils.HandleObjectError(w, rest_error.NewGenericError(http.StatusUnauthorized, "Session expired"))
-			return
-		}
-
-		r.Header.Set(utils.UsernameKey, claims.Username)
-
-		next.ServeHTTP(w, r)
-	})
+type authConfig struct {
+	userTypes []string
 }
 
-func AuthenticatedCustomerOnly(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		jwtTkn := r.Header.Get(utils.AuthorizationKey)
-		if jwtTkn == "" {
-			utils.HandleObjectError(w, rest_error.NewGenericError(http.StatusUnauthorized, "Missing access token"))
-			return
-		}
-
-		jwtTkn = stripBearerFromToken(jwtTkn)
-
-		claims, err := utils.VerifyToken(jwtTkn, false)
-		if err != nil {
-			utils.HandleObjectError(w, rest_error.NewGenericError(http.StatusUnauthorized, err.Error()))
-			return
-		}
-
-		if !isTokenFresh(claims.Username, claims.IssuedAt) {
-			utils.HandleObjectError(w, rest_error.NewGenericError(http.StatusUnauthorized, "Session expired"))
-			return
-		}
-
-		if claims.UserType != utils.UserTypeCustomer {
-			utils.HandleObjectError(w, rest_error.NewGenericError(http.StatusForbidden, "Not a customer"))
-			return
-		}
-
-		r.Header.Set(utils.UsernameKey, claims.Username)
-
-		next.ServeHTTP(w, r)
-	})
+// UserTypes restricts Authenticate to principals of the given user types
+func UserTypes(types ...string) AuthOption {
+	return func(c *authConfig) {
+		c.userTypes = append(c.userTypes, types...)
+	}
 }
 
-func AuthenticatedMerchantOnly(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		jwtTkn := r.Header.Get(utils.AuthorizationKey)
-		if jwtTkn == "" {
-			utils.HandleObjectError(w, rest_error.NewGenericError(http.StatusUnauthorized, "Missing access token"))
-			return
-		}
-
-		jwtTkn = stripBearerFromToken(jwtTkn)
-
-		claims, err := utils.VerifyToken(jwtTkn, false)
-		if err != nil {
-			utils.HandleObjectError(w, rest_error.NewGenericError(http.StatusUnauthorized, err.Error()))
-			return
-		}
-
-		if !isTokenFresh(claims.Username, claims.IssuedAt) {
-			utils.HandleObjectError(w, rest_error.NewGenericError(http.StatusUnauthorized, "Session expired"))
-			return
-		}
-
-		if claims.UserType != utils.UserTypeMerchant {
-			utils.HandleObjectError(w, rest_error.NewGenericError(http.StatusForbidden, "Not a merchant"))
-			return
-		}
-
-		r.Header.Set(utils.UsernameKey, claims.Username)
-
-		next.ServeHTTP(w, r)
-	})
+// forbiddenMessages explains why a user type was turned away from a route meant for one type
+var forbiddenMessages = map[string]string{
+	utils.UserTypeCustomer: i18n.MsgNotACustomer,
+	utils.UserTypeMerchant: i18n.MsgNotAMerchant,
 }
 
-func isTokenFresh(username string, issueTime int64) bool {
-	lastResetAt, err := utils.GetLastResetAt(username)
-	if err != nil {
-		return false
+// Authenticate verifies the bearer access token of a request, including its freshness and
+// revocation, and stores the resulting auth.Principal in the request context. Handlers
+// read the principal from there, never from headers a client could set.
+func Authenticate(opts ...AuthOption) func(http.Handler) http.Handler {
+	cfg := &authConfig{}
+	for _, opt := range opts {
+		opt(cfg)
 	}
 
-	if lastResetAt > issueTime {
-		return false
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			jwtTkn := utils.StripBearer(r.Header.Get(utils.AuthorizationKey))
+			if jwtTkn == "" {
+				utils.HandleObjectError(w, r, rest_error.NewCodedError(http.StatusUnauthorized, rest_error.CodeAuthTokenMissing, i18n.MsgMissingAccessToken))
+				return
+			}
+
+			claims, err := utils.VerifyFreshToken(r.Context(), jwtTkn, false)
+			if err != nil {
+				utils.HandleObjectError(w, r, err)
+				return
+			}
+
+			if !cfg.allows(claims.UserType) {
+				msg := i18n.MsgForbidden
+				if len(cfg.userTypes) == 1 && forbiddenMessages[cfg.userTypes[0]] != "" {
+					msg = forbiddenMessages[cfg.userTypes[0]]
+				}
+				utils.HandleObjectError(w, r, rest_error.NewCodedError(http.StatusForbidden, rest_error.CodeAuthForbidden, msg))
+				return
+			}
+
+			p := &auth.Principal{
+				Username:  claims.Username,
+				UserType:  claims.UserType,
+				Role:      claims.Role,
+				SessionID: claims.Id,
+				Method:    auth.MethodAccessToken,
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), p)))
+		})
 	}
-
-	return true
-
 }
 
-func stripBearerFromToken(token string) string {
-	if strings.HasPrefix(token, "Bearer ") {
-		token = strings.TrimPrefix(token, "Bearer ")
-		//log.Println("token contains Bearer")
+func (c *authConfig) allows(userType string) bool {
+	if len(c.userTypes) == 0 {
+		return true
 	}
 
-	if strings.HasPrefix(token, "bearer ") {
-		token = strings.TrimPrefix(token, "bearer ")
-		//log.Println("token contains bearer")
+	for _, t := range c.userTypes {
+		if t == userType {
+			return true
+		}
 	}
 
-	return token
+	return false
 }
+
+// AuthenticatedOnly lets through any user with a valid access token
+var AuthenticatedOnly = Authenticate()
+
+// AuthenticatedCustomerOnly lets through customers with a valid access token
+var AuthenticatedCustomerOnly = Authenticate(UserTypes(utils.UserTypeCustomer))
+
+// AuthenticatedMerchantOnly lets through merchants with a valid access token
+var AuthenticatedMerchantOnly = Authenticate(UserTypes(utils.UserTypeMerchant))
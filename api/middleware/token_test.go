@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"github.com/iamrz1/ab-auth/auth"
+	"github.com/iamrz1/ab-auth/config"
+	"github.com/iamrz1/ab-auth/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthenticate_Rejects(t *testing.T) {
+	reached := false
+	h := AuthenticatedOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+
+	for _, token := range []string{"", "Bearer ", "Bearer not-a-jwt"} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set(utils.AuthorizationKey, token)
+		// identity headers from clients mean nothing
+		r.Header.Set("username", "01700000001")
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusUnauthorized || reached {
+			t.Errorf("token %q: expected 401, got %d", token, w.Code)
+		}
+	}
+}
+
+func TestInternalOnly_SetsPrincipal(t *testing.T) {
+	var p *auth.Principal
+	h := InternalOnly(config.NewInternalCallerSet(testInternalCallers))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, _ = auth.FromContext(r.Context())
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(utils.KeyForSecretKey, "new-order-secret-0123456789abcdef")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	if p == nil || p.Username != "order" || p.UserType != auth.UserTypeService || p.Method != auth.MethodSecret {
+		t.Errorf("expected the order service principal, got %+v", p)
+	}
+}
@@ -0,0 +1,33 @@
+package oauth
+
+import (
+	"github.com/iamrz1/ab-auth/model"
+	"github.com/iamrz1/ab-auth/service"
+	"github.com/iamrz1/ab-auth/utils"
+	"net/http"
+)
+
+// introspectHandler godoc
+// @Summary Introspect a token
+// @Description RFC 7662 token introspection for resource servers. Tells whether an access or refresh token of any user type is active, running the same expiry and session checks as the private apis, along with its claims. The caller authenticates with its client credentials, through HTTP Basic auth or the client_id and client_secret fields.
+// @Tags OAuth
+// @Accept  x-www-form-urlencoded
+// @Produce  json
+// @Param authorization header string false "Basic auth with the client id and secret"
+// @Param token formData string true "The token to introspect"
+// @Param token_type_hint formData string false "access_token or refresh_token"
+// @Param client_id formData string false "Client id, unless Basic auth is used"
+// @Param client_secret formData string false "Client secret, unless Basic auth is used"
+// @Success 200 {object} model.TokenIntrospection
+// @Failure 400 {object} model.OAuthError "Missing token (invalid_request)."
+// @Failure 401 {object} model.OAuthError "Invalid client credentials (invalid_client)."
+// @Router /oauth/introspect [post]
+func (o *oauthRouter) introspectHandler(w http.ResponseWriter, r *http.Request) {
+	token := r.PostFormValue("token")
+	if token == "" {
+		utils.ServeOAuthJSON(w, http.StatusBadRequest, &model.OAuthError{Error: "invalid_request", ErrorDescription: "token is required"})
+		return
+	}
+
+	utils.ServeOAuthJSON(w, http.StatusOK, service.IntrospectToken(r.Context(), token, r.PostFormValue("token_type_hint")))
+}
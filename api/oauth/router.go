@@ -0,0 +1,32 @@
+package oauth
+
+import (
+	"github.com/go-chi/chi"
+	"github.com/iamrz1/ab-auth/api/middleware"
+	"github.com/iamrz1/ab-auth/logger"
+	"github.com/iamrz1/ab-auth/service"
+)
+
+// EndpointIntrospect is the name of the introspection endpoint in the allowlist of a caller
+const EndpointIntrospect = "oauth.introspect"
+
+type oauthRouter struct {
+	Services *service.Config
+	Log      logger.Logger
+}
+
+func NewOAuthRouter(svc *service.Config, lgr logger.Logger) *oauthRouter {
+	return &oauthRouter{
+		Services: svc,
+		Log:      lgr,
+	}
+}
+
+// Router returns a router for the OAuth endpoints used by resource servers
+func (o *oauthRouter) Router() *chi.Mux {
+	r := chi.NewRouter()
+
+	callers := o.Services.CustomerService.Config.Internal.Callers
+	r.With(middleware.ClientCredentialsOnly(callers, EndpointIntrospect)).Post("/introspect", o.introspectHandler)
+	return r
+}
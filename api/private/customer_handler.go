@@ -2,27 +2,32 @@ package private
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/go-chi/chi"
 	"github.com/iamrz1/ab-auth/api/middleware"
+	"github.com/iamrz1/ab-auth/auth"
 	_ "github.com/iamrz1/ab-auth/docs"
 	rest_error "github.com/iamrz1/ab-auth/error"
+	"github.com/iamrz1/ab-auth/i18n"
+	"github.com/iamrz1/ab-auth/infra"
+	"github.com/iamrz1/ab-auth/logger"
+	"github.com/iamrz1/ab-auth/metrics"
 	"github.com/iamrz1/ab-auth/model"
 	"github.com/iamrz1/ab-auth/service"
 	"github.com/iamrz1/ab-auth/utils"
-	rLog "github.com/iamrz1/rest-log"
 	"net/http"
 )
 
-func newCustomerRouter(svc *service.Config, rLogger rLog.Logger) *customerRouter {
+func newCustomerRouter(svc *service.Config, lgr logger.Logger) *customerRouter {
 	return &customerRouter{
 		Services: svc,
-		Log:      rLogger,
+		Log:      lgr,
 	}
 }
 
 type customerRouter struct {
 	Services *service.Config
-	Log      rLog.Logger
+	Log      logger.Logger
 }
 
 func (pr *privateRouter) customerRouter() *chi.Mux {
@@ -35,6 +40,9 @@ func (pr *privateRouter) customerRouter() *chi.Mux {
 	r.With(middleware.AuthenticatedCustomerOnly).Get("/verify-token", cr.verifyAccessToken)
 	r.With(middleware.JWTTokenOnly).Get("/refresh-token", cr.refreshToken)
 	r.With(middleware.AuthenticatedCustomerOnly).Put("/password", cr.updatePassword)
+	r.With(middleware.AuthenticatedCustomerOnly).Get("/data-export", cr.exportData)
+	r.With(middleware.AuthenticatedCustomerOnly).Post("/erasure", cr.requestErasure) //empty body
+	r.With(middleware.AuthenticatedCustomerOnly).Delete("/erasure", cr.cancelErasure)
 
 	r.Mount("/address", pr.addressRouter())
 
@@ -43,7 +51,7 @@ func (pr *privateRouter) customerRouter() *chi.Mux {
 
 // verifyAccessToken godoc
 // @Summary Verify customer's access token
-// @Description verifyAccessToken lets apps to verify that a provided token is in-fact valid
+// @Description verifyAccessToken lets apps to verify that a provided token is in-fact valid. Services that need the claims should use /oauth/introspect instead.
 // @Tags Customers
 // @Accept  json
 // @Produce  json
@@ -52,7 +60,7 @@ func (pr *privateRouter) customerRouter() *chi.Mux {
 // @Failure 401 {object} response.EmptyErrorRes
 // @Router /api/v1/private/customers/verify-token [get]
 func (pr *customerRouter) verifyAccessToken(w http.ResponseWriter, r *http.Request) {
-	utils.ServeJSONObject(w, http.StatusOK, "Token verified", nil, nil, true)
+	utils.ServeJSONObject(w, http.StatusOK, i18n.MsgTokenVerified, nil, nil, true)
 }
 
 // getCustomerProfile godoc
@@ -67,20 +75,21 @@ func (pr *customerRouter) verifyAccessToken(w http.ResponseWriter, r *http.Reque
 // @Failure 500 {object} response.EmptyErrorRes "API sever or db unreachable."
 // @Router /api/v1/private/customers/profile [get]
 func (pr *customerRouter) getCustomerProfile(w http.ResponseWriter, r *http.Request) {
-	username := r.Header.Get(utils.UsernameKey)
+	username := auth.Username(r.Context())
 	if username == "" {
-		utils.HandleObjectError(w, rest_error.NewGenericError(http.StatusUnauthorized, "Missing username"))
+		utils.HandleObjectError(w, r, rest_error.NewGenericError(http.StatusUnauthorized, i18n.MsgMissingUsername))
+		return
 	}
 
 	req := &model.Customer{Username: username}
 
 	data, err := pr.Services.CustomerService.GetCustomer(r.Context(), req)
 	if err != nil {
-		utils.HandleObjectError(w, err)
+		utils.HandleObjectError(w, r, err)
 		return
 	}
 
-	utils.ServeJSONObject(w, http.StatusOK, "Successful", &data, nil, true)
+	utils.ServeJSONObject(w, http.StatusOK, i18n.MsgSuccessful, &data, nil, true)
 }
 
 // updateCustomerProfile godoc
@@ -97,32 +106,33 @@ func (pr *customerRouter) getCustomerProfile(w http.ResponseWriter, r *http.Requ
 // @Failure 500 {object} response.EmptyErrorRes
 // @Router /api/v1/private/customers/profile [patch]
 func (pr *customerRouter) updateCustomerProfile(w http.ResponseWriter, r *http.Request) {
-	username := r.Header.Get(utils.UsernameKey)
+	username := auth.Username(r.Context())
 	if username == "" {
-		utils.HandleObjectError(w, rest_error.NewGenericError(http.StatusUnauthorized, "Missing username"))
+		utils.HandleObjectError(w, r, rest_error.NewGenericError(http.StatusUnauthorized, i18n.MsgMissingUsername))
+		return
 	}
 
 	req := model.CustomerProfileUpdateReq{}
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		utils.HandleObjectError(w, rest_error.NewValidationError("Invalid JSON", err))
+		utils.HandleObjectError(w, r, rest_error.NewCodedValidationError(rest_error.CodeInvalidJSON, i18n.MsgInvalidJSON, err))
 		return
 	}
 	req.Username = username
 
 	err = model.Validate(req)
 	if err != nil {
-		utils.HandleObjectError(w, rest_error.NewValidationError("missing required field(s)", err))
+		utils.HandleObjectError(w, r, rest_error.NewValidationError(i18n.MsgMissingRequiredFields, err))
 		return
 	}
 
 	data, err := pr.Services.CustomerService.UpdateCustomer(r.Context(), &req)
 	if err != nil {
-		utils.HandleObjectError(w, err)
+		utils.HandleObjectError(w, r, err)
 		return
 	}
 
-	utils.ServeJSONObject(w, http.StatusOK, "Profile updated", &data, nil, true)
+	utils.ServeJSONObject(w, http.StatusOK, i18n.MsgProfileUpdated, &data, nil, true)
 }
 
 // refreshToken godoc
@@ -134,35 +144,49 @@ func (pr *customerRouter) updateCustomerProfile(w http.ResponseWriter, r *http.R
 // @Param authorization header string true "Value of refresh token"
 // @Success 200 {object} response.TokenSuccessRes
 // @Failure 401 {object} response.EmptyErrorRes
+// @Failure 403 {object} response.EmptyErrorRes "Account is blocked, inactive or pending"
+// @Failure 500 {object} response.EmptyErrorRes "API sever or db unreachable."
 // @Router /api/v1/private/customers/refresh-token [get]
 func (pr *customerRouter) refreshToken(w http.ResponseWriter, r *http.Request) {
+	result := metrics.ResultFailure
+	defer func() {
+		metrics.TokenRefreshes.WithLabelValues("customer", result).Inc()
+	}()
+
 	jwtTkn := r.Header.Get(utils.AuthorizationKey)
 	if jwtTkn == "" {
-		utils.HandleObjectError(w, rest_error.NewGenericError(http.StatusUnauthorized, "Missing refresh token"))
+		utils.HandleObjectError(w, r, rest_error.NewCodedError(http.StatusUnauthorized, rest_error.CodeAuthTokenMissing, i18n.MsgMissingRefreshToken))
 		return
 	}
 
-	claims, err := utils.VerifyToken(jwtTkn, true)
+	claims, err := utils.VerifyFreshToken(r.Context(), jwtTkn, true)
 	if err != nil {
-		utils.HandleObjectError(w, rest_error.NewGenericError(http.StatusUnauthorized, err.Error()))
+		utils.HandleObjectError(w, r, err)
 		return
 	}
 
 	cus, err := pr.Services.CustomerService.GetCustomer(r.Context(), &model.Customer{Username: claims.Username})
+	if err == infra.ErrNotFound {
+		// the account of the token is gone
+		utils.HandleObjectError(w, r, rest_error.NewCodedError(http.StatusUnauthorized, rest_error.CodeAuthTokenInvalid, i18n.MsgInvalidToken))
+		return
+	}
 	if err != nil {
-		utils.HandleObjectError(w, rest_error.NewGenericError(http.StatusUnauthorized, err.Error()))
+		utils.HandleObjectError(w, r, err)
 		return
 	}
 
-	if claims.IssuedAt < cus.LastResetAt.Unix() {
-		utils.HandleObjectError(w, rest_error.NewGenericError(http.StatusUnauthorized, "Session expired"))
+	err = utils.ValidateAccountStatus(cus.Status, false)
+	if err != nil {
+		utils.HandleObjectError(w, r, err)
 		return
 	}
 
 	access, refresh := utils.GenerateTokens(cus.Username, "", "customer")
 	token := model.Token{AccessToken: access, RefreshToken: refresh}
 
-	utils.ServeJSONObject(w, http.StatusOK, "Token refreshed", &token, nil, true)
+	result = metrics.ResultSuccess
+	utils.ServeJSONObject(w, http.StatusOK, i18n.MsgTokenRefreshed, &token, nil, true)
 }
 
 // updatePassword godoc
@@ -179,32 +203,115 @@ func (pr *customerRouter) refreshToken(w http.ResponseWriter, r *http.Request) {
 // @Failure 500 {object} response.EmptyErrorRes
 // @Router /api/v1/private/customers/password [put]
 func (pr *customerRouter) updatePassword(w http.ResponseWriter, r *http.Request) {
-	username := r.Header.Get(utils.UsernameKey)
+	username := auth.Username(r.Context())
 	if username == "" {
-		utils.HandleObjectError(w, rest_error.NewGenericError(http.StatusUnauthorized, "Missing username"))
+		utils.HandleObjectError(w, r, rest_error.NewGenericError(http.StatusUnauthorized, i18n.MsgMissingUsername))
+		return
 	}
 
 	req := model.UpdatePasswordReq{}
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		utils.HandleObjectError(w, rest_error.NewValidationError("Invalid JSON", err))
+		utils.HandleObjectError(w, r, rest_error.NewCodedValidationError(rest_error.CodeInvalidJSON, i18n.MsgInvalidJSON, err))
 		return
 	}
 	req.Username = username
 
 	err = model.Validate(req)
 	if err != nil {
-		utils.HandleObjectError(w, rest_error.NewValidationError("missing required field(s)", err))
+		utils.HandleObjectError(w, r, rest_error.NewValidationError(i18n.MsgMissingRequiredFields, err))
 		return
 	}
 
 	data, err := pr.Services.CustomerService.UpdatePassword(r.Context(), &req)
 	if err != nil {
-		utils.HandleObjectError(w, err)
+		utils.HandleObjectError(w, r, err)
+		return
+	}
+
+	utils.ServeJSONObject(w, http.StatusOK, i18n.MsgPasswordUpdated, &data, nil, true)
+}
+
+// exportData godoc
+// @Summary Download personal data
+// @Description Returns customer's profile, addresses and audit history as a single JSON document
+// @Tags Customers
+// @Produce  json
+// @Param authorization header string true "Set access token here"
+// @Success 200 {object} response.CustomerDataExportSuccessRes
+// @Failure 400 {object} response.EmptyErrorRes
+// @Failure 401 {object} response.EmptyErrorRes "Unauthorized access attempt."
+// @Failure 500 {object} response.EmptyErrorRes "API sever or db unreachable."
+// @Router /api/v1/private/customers/data-export [get]
+func (pr *customerRouter) exportData(w http.ResponseWriter, r *http.Request) {
+	username := auth.Username(r.Context())
+	if username == "" {
+		utils.HandleObjectError(w, r, rest_error.NewGenericError(http.StatusUnauthorized, i18n.MsgMissingUsername))
+		return
+	}
+
+	data, err := pr.Services.CustomerService.ExportCustomerData(r.Context(), username)
+	if err != nil {
+		utils.HandleObjectError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.json", username))
+	utils.ServeJSONObject(w, http.StatusOK, i18n.MsgSuccessful, data, nil, true)
+}
+
+// requestErasure godoc
+// @Summary Delete account
+// @Description Schedule customer's account and personal data for deletion after the grace period. Can be cancelled until then.
+// @Tags Customers
+// @Produce  json
+// @Param authorization header string true "Set access token here"
+// @Success 202 {object} response.CustomerErasureSuccessRes
+// @Failure 400 {object} response.EmptyErrorRes "Erasure already requested"
+// @Failure 401 {object} response.EmptyErrorRes "Unauthorized access attempt."
+// @Failure 500 {object} response.EmptyErrorRes "API sever or db unreachable."
+// @Router /api/v1/private/customers/erasure [post]
+func (pr *customerRouter) requestErasure(w http.ResponseWriter, r *http.Request) {
+	username := auth.Username(r.Context())
+	if username == "" {
+		utils.HandleObjectError(w, r, rest_error.NewGenericError(http.StatusUnauthorized, i18n.MsgMissingUsername))
+		return
+	}
+
+	data, err := pr.Services.CustomerService.RequestErasure(r.Context(), username)
+	if err != nil {
+		utils.HandleObjectError(w, r, err)
+		return
+	}
+
+	utils.ServeJSONObject(w, http.StatusAccepted, i18n.MsgErasureScheduled, data, nil, true)
+}
+
+// cancelErasure godoc
+// @Summary Cancel account deletion
+// @Description Cancel a pending account deletion during the grace period
+// @Tags Customers
+// @Produce  json
+// @Param authorization header string true "Set access token here"
+// @Success 200 {object} response.EmptySuccessRes
+// @Failure 400 {object} response.EmptyErrorRes "No pending erasure"
+// @Failure 401 {object} response.EmptyErrorRes "Unauthorized access attempt."
+// @Failure 500 {object} response.EmptyErrorRes "API sever or db unreachable."
+// @Router /api/v1/private/customers/erasure [delete]
+func (pr *customerRouter) cancelErasure(w http.ResponseWriter, r *http.Request) {
+	username := auth.Username(r.Context())
+	if username == "" {
+		utils.HandleObjectError(w, r, rest_error.NewGenericError(http.StatusUnauthorized, i18n.MsgMissingUsername))
+		return
+	}
+
+	err := pr.Services.CustomerService.CancelErasure(r.Context(), username)
+	if err != nil {
+		utils.HandleObjectError(w, r, err)
 		return
 	}
 
-	utils.ServeJSONObject(w, http.StatusOK, "Password updated", &data, nil, true)
+	utils.ServeJSONObject(w, http.StatusOK, i18n.MsgErasureCancelled, nil, nil, true)
 }
 
 func (pr *customerRouter) purgeCustomer(w http.ResponseWriter, r *http.Request) {
@@ -214,9 +321,9 @@ func (pr *customerRouter) purgeCustomer(w http.ResponseWriter, r *http.Request)
 
 	data, err := pr.Services.CustomerService.PurgeCustomer(r.Context(), &req)
 	if err != nil {
-		utils.HandleObjectError(w, err)
+		utils.HandleObjectError(w, r, err)
 		return
 	}
 
-	utils.ServeJSONObject(w, http.StatusOK, "Purged customer successfully", &data, nil, true)
+	utils.ServeJSONObject(w, http.StatusOK, i18n.MsgCustomerPurged, &data, nil, true)
 }
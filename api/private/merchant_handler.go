@@ -4,25 +4,29 @@ import (
 	"encoding/json"
 	"github.com/go-chi/chi"
 	"github.com/iamrz1/ab-auth/api/middleware"
+	"github.com/iamrz1/ab-auth/auth"
 	_ "github.com/iamrz1/ab-auth/docs"
 	rest_error "github.com/iamrz1/ab-auth/error"
+	"github.com/iamrz1/ab-auth/i18n"
+	"github.com/iamrz1/ab-auth/infra"
+	"github.com/iamrz1/ab-auth/logger"
+	"github.com/iamrz1/ab-auth/metrics"
 	"github.com/iamrz1/ab-auth/model"
 	"github.com/iamrz1/ab-auth/service"
 	"github.com/iamrz1/ab-auth/utils"
-	rLog "github.com/iamrz1/rest-log"
 	"net/http"
 )
 
-func newMerchantRouter(svc *service.Config, rLogger rLog.Logger) *merchantRouter {
+func newMerchantRouter(svc *service.Config, lgr logger.Logger) *merchantRouter {
 	return &merchantRouter{
 		Services: svc,
-		Log:      rLogger,
+		Log:      lgr,
 	}
 }
 
 type merchantRouter struct {
 	Services *service.Config
-	Log      rLog.Logger
+	Log      logger.Logger
 }
 
 func (pr *privateRouter) merchantRouter() *chi.Mux {
@@ -43,7 +47,7 @@ func (pr *privateRouter) merchantRouter() *chi.Mux {
 
 // verifyAccessToken godoc
 // @Summary Verify merchant's access token
-// @Description verifyAccessToken lets apps to verify that a provided token is in-fact valid
+// @Description verifyAccessToken lets apps to verify that a provided token is in-fact valid. Services that need the claims should use /oauth/introspect instead.
 // @Tags Merchants
 // @Accept  json
 // @Produce  json
@@ -52,7 +56,7 @@ func (pr *privateRouter) merchantRouter() *chi.Mux {
 // @Failure 401 {object} response.EmptyErrorRes
 // @Router /api/v1/private/merchants/verify-token [get]
 func (pr *merchantRouter) verifyAccessToken(w http.ResponseWriter, r *http.Request) {
-	utils.ServeJSONObject(w, http.StatusOK, "Token verified", nil, nil, true)
+	utils.ServeJSONObject(w, http.StatusOK, i18n.MsgTokenVerified, nil, nil, true)
 }
 
 // getMerchantProfile godoc
@@ -67,20 +71,21 @@ func (pr *merchantRouter) verifyAccessToken(w http.ResponseWriter, r *http.Reque
 // @Failure 500 {object} response.EmptyErrorRes "API sever or db unreachable."
 // @Router /api/v1/private/merchants/profile [get]
 func (pr *merchantRouter) getMerchantProfile(w http.ResponseWriter, r *http.Request) {
-	username := r.Header.Get(utils.UsernameKey)
+	username := auth.Username(r.Context())
 	if username == "" {
-		utils.HandleObjectError(w, rest_error.NewGenericError(http.StatusUnauthorized, "Missing username"))
+		utils.HandleObjectError(w, r, rest_error.NewGenericError(http.StatusUnauthorized, i18n.MsgMissingUsername))
+		return
 	}
 
 	req := &model.Merchant{Username: username}
 
 	data, err := pr.Services.MerchantService.GetMerchant(r.Context(), req)
 	if err != nil {
-		utils.HandleObjectError(w, err)
+		utils.HandleObjectError(w, r, err)
 		return
 	}
 
-	utils.ServeJSONObject(w, http.StatusOK, "Successful", &data, nil, true)
+	utils.ServeJSONObject(w, http.StatusOK, i18n.MsgSuccessful, &data, nil, true)
 }
 
 // updateMerchantProfile godoc
@@ -97,32 +102,33 @@ func (pr *merchantRouter) getMerchantProfile(w http.ResponseWriter, r *http.Requ
 // @Failure 500 {object} response.EmptyErrorRes
 // @Router /api/v1/private/merchants/profile [patch]
 func (pr *merchantRouter) updateMerchantProfile(w http.ResponseWriter, r *http.Request) {
-	username := r.Header.Get(utils.UsernameKey)
+	username := auth.Username(r.Context())
 	if username == "" {
-		utils.HandleObjectError(w, rest_error.NewGenericError(http.StatusUnauthorized, "Missing username"))
+		utils.HandleObjectError(w, r, rest_error.NewGenericError(http.StatusUnauthorized, i18n.MsgMissingUsername))
+		return
 	}
 
 	req := model.MerchantProfileUpdateReq{}
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		utils.HandleObjectError(w, rest_error.NewValidationError("Invalid JSON", err))
+		utils.HandleObjectError(w, r, rest_error.NewCodedValidationError(rest_error.CodeInvalidJSON, i18n.MsgInvalidJSON, err))
 		return
 	}
 	req.Username = username
 
 	err = model.Validate(req)
 	if err != nil {
-		utils.HandleObjectError(w, rest_error.NewValidationError("missing required field(s)", err))
+		utils.HandleObjectError(w, r, rest_error.NewValidationError(i18n.MsgMissingRequiredFields, err))
 		return
 	}
 
 	data, err := pr.Services.MerchantService.UpdateMerchant(r.Context(), &req)
 	if err != nil {
-		utils.HandleObjectError(w, err)
+		utils.HandleObjectError(w, r, err)
 		return
 	}
 
-	utils.ServeJSONObject(w, http.StatusOK, "Profile updated", &data, nil, true)
+	utils.ServeJSONObject(w, http.StatusOK, i18n.MsgProfileUpdated, &data, nil, true)
 }
 
 // refreshToken godoc
@@ -134,35 +140,49 @@ func (pr *merchantRouter) updateMerchantProfile(w http.ResponseWriter, r *http.R
 // @Param authorization header string true "Value of refresh token"
 // @Success 200 {object} response.TokenSuccessRes
 // @Failure 401 {object} response.EmptyErrorRes
+// @Failure 403 {object} response.EmptyErrorRes "Account is blocked, inactive or pending"
+// @Failure 500 {object} response.EmptyErrorRes "API sever or db unreachable."
 // @Router /api/v1/private/merchants/refresh-token [get]
 func (pr *merchantRouter) refreshToken(w http.ResponseWriter, r *http.Request) {
+	result := metrics.ResultFailure
+	defer func() {
+		metrics.TokenRefreshes.WithLabelValues("merchant", result).Inc()
+	}()
+
 	jwtTkn := r.Header.Get(utils.AuthorizationKey)
 	if jwtTkn == "" {
-		utils.HandleObjectError(w, rest_error.NewGenericError(http.StatusUnauthorized, "Missing refresh token"))
+		utils.HandleObjectError(w, r, rest_error.NewCodedError(http.StatusUnauthorized, rest_error.CodeAuthTokenMissing, i18n.MsgMissingRefreshToken))
 		return
 	}
 
-	claims, err := utils.VerifyToken(jwtTkn, true)
+	claims, err := utils.VerifyFreshToken(r.Context(), jwtTkn, true)
 	if err != nil {
-		utils.HandleObjectError(w, rest_error.NewGenericError(http.StatusUnauthorized, err.Error()))
+		utils.HandleObjectError(w, r, err)
 		return
 	}
 
 	cus, err := pr.Services.MerchantService.GetMerchant(r.Context(), &model.Merchant{Username: claims.Username})
+	if err == infra.ErrNotFound {
+		// the account of the token is gone
+		utils.HandleObjectError(w, r, rest_error.NewCodedError(http.StatusUnauthorized, rest_error.CodeAuthTokenInvalid, i18n.MsgInvalidToken))
+		return
+	}
 	if err != nil {
-		utils.HandleObjectError(w, rest_error.NewGenericError(http.StatusUnauthorized, err.Error()))
+		utils.HandleObjectError(w, r, err)
 		return
 	}
 
-	if claims.IssuedAt < cus.LastResetAt.Unix() {
-		utils.HandleObjectError(w, rest_error.NewGenericError(http.StatusUnauthorized, "Session expired"))
+	err = utils.ValidateAccountStatus(cus.Status, true)
+	if err != nil {
+		utils.HandleObjectError(w, r, err)
 		return
 	}
 
 	access, refresh := utils.GenerateTokens(cus.Username, "", "merchant")
 	token := model.Token{AccessToken: access, RefreshToken: refresh}
 
-	utils.ServeJSONObject(w, http.StatusOK, "Token refreshed", &token, nil, true)
+	result = metrics.ResultSuccess
+	utils.ServeJSONObject(w, http.StatusOK, i18n.MsgTokenRefreshed, &token, nil, true)
 }
 
 // updatePassword godoc
@@ -179,32 +199,33 @@ func (pr *merchantRouter) refreshToken(w http.ResponseWriter, r *http.Request) {
 // @Failure 500 {object} response.EmptyErrorRes
 // @Router /api/v1/private/merchants/password [put]
 func (pr *merchantRouter) updatePassword(w http.ResponseWriter, r *http.Request) {
-	username := r.Header.Get(utils.UsernameKey)
+	username := auth.Username(r.Context())
 	if username == "" {
-		utils.HandleObjectError(w, rest_error.NewGenericError(http.StatusUnauthorized, "Missing username"))
+		utils.HandleObjectError(w, r, rest_error.NewGenericError(http.StatusUnauthorized, i18n.MsgMissingUsername))
+		return
 	}
 
 	req := model.UpdatePasswordReq{}
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		utils.HandleObjectError(w, rest_error.NewValidationError("Invalid JSON", err))
+		utils.HandleObjectError(w, r, rest_error.NewCodedValidationError(rest_error.CodeInvalidJSON, i18n.MsgInvalidJSON, err))
 		return
 	}
 	req.Username = username
 
 	err = model.Validate(req)
 	if err != nil {
-		utils.HandleObjectError(w, rest_error.NewValidationError("missing required field(s)", err))
+		utils.HandleObjectError(w, r, rest_error.NewValidationError(i18n.MsgMissingRequiredFields, err))
 		return
 	}
 
 	data, err := pr.Services.MerchantService.UpdatePassword(r.Context(), &req)
 	if err != nil {
-		utils.HandleObjectError(w, err)
+		utils.HandleObjectError(w, r, err)
 		return
 	}
 
-	utils.ServeJSONObject(w, http.StatusOK, "Password updated", &data, nil, true)
+	utils.ServeJSONObject(w, http.StatusOK, i18n.MsgPasswordUpdated, &data, nil, true)
 }
 
 func (pr *merchantRouter) purgeMerchant(w http.ResponseWriter, r *http.Request) {
@@ -214,9 +235,9 @@ func (pr *merchantRouter) purgeMerchant(w http.ResponseWriter, r *http.Request)
 
 	data, err := pr.Services.MerchantService.PurgeMerchant(r.Context(), &req)
 	if err != nil {
-		utils.HandleObjectError(w, err)
+		utils.HandleObjectError(w, r, err)
 		return
 	}
 
-	utils.ServeJSONObject(w, http.StatusOK, "Purged merchant successfully", &data, nil, true)
+	utils.ServeJSONObject(w, http.StatusOK, i18n.MsgMerchantPurged, &data, nil, true)
 }
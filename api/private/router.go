@@ -2,19 +2,20 @@ package private
 
 import (
 	"github.com/go-chi/chi"
+	"github.com/iamrz1/ab-auth/api/middleware"
+	"github.com/iamrz1/ab-auth/logger"
 	"github.com/iamrz1/ab-auth/service"
-	rLog "github.com/iamrz1/rest-log"
 )
 
 type privateRouter struct {
 	Services *service.Config
-	Log      rLog.Logger
+	Log      logger.Logger
 }
 
-func NewPrivateRouter(svc *service.Config, rLogger rLog.Logger) *privateRouter {
+func NewPrivateRouter(svc *service.Config, lgr logger.Logger) *privateRouter {
 	return &privateRouter{
 		Services: svc,
-		Log:      rLogger,
+		Log:      lgr,
 	}
 }
 
@@ -22,6 +23,7 @@ func NewPrivateRouter(svc *service.Config, rLogger rLog.Logger) *privateRouter {
 func (pr *privateRouter) Router() *chi.Mux {
 	r := chi.NewRouter()
 
+	r.With(middleware.AuthenticatedOnly).Post("/logout", pr.logoutHandler)
 	r.Mount("/customers", pr.customerRouter())
 	return r
 }
@@ -0,0 +1,43 @@
+package private
+
+import (
+	"encoding/json"
+	rest_error "github.com/iamrz1/ab-auth/error"
+	"github.com/iamrz1/ab-auth/i18n"
+	"github.com/iamrz1/ab-auth/model"
+	"github.com/iamrz1/ab-auth/service"
+	"github.com/iamrz1/ab-auth/utils"
+	"io"
+	"net/http"
+)
+
+// logoutHandler godoc
+// @Summary Log out
+// @Description Revokes the access token until it expires, for customers and merchants alike. Send the refresh token of the session in the body to revoke it as well. Other sessions stay logged in. Other instances of the service stop accepting the token as soon as the revocation reaches them over Redis, or within 10 seconds if that message is lost.
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Param authorization header string true "Set access token here"
+// @Param  Body body model.LogoutReq false "Optional refresh token"
+// @Success 200 {object} response.EmptySuccessRes
+// @Failure 400 {object} response.EmptyErrorRes "Invalid request body, or a refresh token of another user."
+// @Failure 401 {object} response.EmptyErrorRes "Unauthorized access attempt."
+// @Failure 500 {object} response.EmptyErrorRes "API sever or cache unreachable."
+// @Router /api/v1/private/logout [post]
+func (pr *privateRouter) logoutHandler(w http.ResponseWriter, r *http.Request) {
+	req := model.LogoutReq{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil && err != io.EOF {
+		utils.HandleObjectError(w, r, rest_error.NewCodedValidationError(rest_error.CodeInvalidJSON, i18n.MsgInvalidJSON, err))
+		return
+	}
+
+	err = service.Logout(r.Context(), utils.StripBearer(r.Header.Get(utils.AuthorizationKey)), req.RefreshToken)
+	if err != nil {
+		pr.Log.Errorln(r.Context(), "logoutHandler", err.Error())
+		utils.HandleObjectError(w, r, err)
+		return
+	}
+
+	utils.ServeJSONObject(w, http.StatusOK, i18n.MsgLoggedOut, nil, nil, true)
+}
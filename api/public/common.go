@@ -1,35 +1,93 @@
 package public
 
 import (
+	rest_error "github.com/iamrz1/ab-auth/error"
+	"github.com/iamrz1/ab-auth/i18n"
 	"github.com/iamrz1/ab-auth/model"
 	"github.com/iamrz1/ab-auth/model/response"
 	"github.com/iamrz1/ab-auth/utils"
 	"net/http"
+	"strings"
 )
 
 // listBDArea godoc
 // @Summary Fetch BD area presets (division, district, sub-district)
-// @Description Get a list of BD areas under selected parent (slug value). No parent returns list of divisions. Division as parent will return districts and so on)
+// @Description Get a list of BD areas under selected parent (slug value). No parent returns list of divisions. Division as parent will return districts and so on). Meta carries the dataset version presets can be cached by, and the next_cursor and prev_cursor of the pages around. Pages asked for by cursor are not counted, so their meta has no page, pages and count.
 // @Tags Common
 // @Accept  json
 // @Produce  json
 // @Param parent query string false "Default value: empty-string"
-// @Param page query integer false "Default value: 1"
+// @Param page query integer false "Default value: 1, ignored along with a cursor"
 // @Param limit query integer false "Default value: 10"
+// @Param cursor query string false "next_cursor or prev_cursor of the meta of another page"
 // @Success 200 {object} response.BDLocationListSuccessRes
+// @Failure 400 {object} response.EmptyListErrorRes "Invalid cursor (INVALID_CURSOR)."
 // @Failure 500 {object} response.EmptyListErrorRes
 // @Router /api/v1/public/bd-area [get]
 func (pr *publicRouter) listBDArea(w http.ResponseWriter, r *http.Request) {
 	parent := r.URL.Query().Get("parent")
 	page, limit := utils.GetPageLimit(r)
+	cursor := r.URL.Query().Get("cursor")
 
-	req := model.BDLocationReq{Parent: parent, Page: page, Limit: limit}
+	req := model.BDLocationReq{Parent: parent, Page: page, Limit: limit, Cursor: cursor}
 
-	res, count, err := pr.Services.CustomerService.GetBDLocation(r.Context(), &req)
+	res, count, info, err := pr.Services.CustomerService.GetBDLocation(r.Context(), &req)
 	if err != nil {
-		utils.HandleListError(w, err)
+		utils.HandleListError(w, r, err)
 		return
 	}
 
-	utils.ServeJSONObject(w, http.StatusOK, "Success. Very nice!", res, response.GetListMeta(page, limit, count), true)
+	version, err := pr.Services.CustomerService.GetBDLocationVersion(r.Context())
+	if err != nil {
+		utils.HandleListError(w, r, err)
+		return
+	}
+
+	listMeta := response.GetCursorListMeta(limit, info.NextCursor, info.PrevCursor)
+	if cursor == "" {
+		listMeta = response.GetListMeta(page, limit, count)
+		listMeta.NextCursor, listMeta.PrevCursor = info.NextCursor, info.PrevCursor
+	}
+	meta := response.BDLocationListMeta{ListMeta: *listMeta, DatasetVersion: version}
+
+	utils.ServeJSONObject(w, http.StatusOK, i18n.MsgListFetched, res, meta, true)
+}
+
+// searchBDArea godoc
+// @Summary Search BD area presets
+// @Description Prefix and fuzzy search on English and Bangla names of divisions, districts, sub-districts and unions. Every result carries its ancestors, nearest first.
+// @Tags Common
+// @Accept  json
+// @Produce  json
+// @Param q query string true "Search text, in English or Bangla"
+// @Param type query string false "Restrict to one level: division, district, sub_district or union"
+// @Param limit query integer false "Default value: 10, max: 50"
+// @Success 200 {object} response.BDLocationSearchSuccessRes
+// @Failure 400 {object} response.EmptyListErrorRes
+// @Failure 500 {object} response.EmptyListErrorRes
+// @Router /api/v1/public/bd-area/search [get]
+func (pr *publicRouter) searchBDArea(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		utils.HandleListError(w, r, rest_error.NewValidationError(i18n.MsgMissingSearchQuery, nil))
+		return
+	}
+
+	locationType := model.BDLocationType(r.URL.Query().Get("type"))
+	if locationType != "" && !model.IsValidBDLocationType(locationType) {
+		utils.HandleListError(w, r, rest_error.NewValidationError(i18n.MsgInvalidLocationType, nil))
+		return
+	}
+
+	_, limit := utils.GetPageLimit(r)
+
+	req := model.BDLocationSearchReq{Query: q, Type: locationType, Limit: limit}
+
+	res, err := pr.Services.CustomerService.SearchBDLocations(r.Context(), &req)
+	if err != nil {
+		utils.HandleListError(w, r, err)
+		return
+	}
+
+	utils.ServeJSONList(w, http.StatusOK, i18n.MsgSuccessful, res, nil, true)
 }
@@ -5,23 +5,25 @@ import (
 	"github.com/go-chi/chi"
 	_ "github.com/iamrz1/ab-auth/docs"
 	rest_error "github.com/iamrz1/ab-auth/error"
+	"github.com/iamrz1/ab-auth/i18n"
+	"github.com/iamrz1/ab-auth/logger"
+	"github.com/iamrz1/ab-auth/metrics"
 	"github.com/iamrz1/ab-auth/model"
 	"github.com/iamrz1/ab-auth/service"
 	"github.com/iamrz1/ab-auth/utils"
-	rLog "github.com/iamrz1/rest-log"
 	"net/http"
 )
 
-func newMerchantRouter(svc *service.Config, rLogger rLog.Logger) *merchantRouter {
+func newMerchantRouter(svc *service.Config, lgr logger.Logger) *merchantRouter {
 	return &merchantRouter{
 		Services: svc,
-		Log:      rLogger,
+		Log:      lgr,
 	}
 }
 
 type merchantRouter struct {
 	Services *service.Config
-	Log      rLog.Logger
+	Log      logger.Logger
 }
 
 func (pr *publicRouter) merchantRouter() *chi.Mux {
@@ -43,7 +45,7 @@ func (pr *publicRouter) merchantRouter() *chi.Mux {
 // @Accept  json
 // @Produce  json
 // @Param  Body body model.MerchantSignupReq true "All fields are mandatory"
-// @Success 201 {object} response.EmptySuccessRes
+// @Success 201 {object} response.OTPSentSuccessRes
 // @Failure 400 {object} response.EmptyErrorRes
 // @Failure 404 {object} response.EmptyErrorRes
 // @Failure 500 {object} response.EmptyErrorRes
@@ -53,26 +55,26 @@ func (pr *merchantRouter) signup(w http.ResponseWriter, r *http.Request) {
 
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		utils.HandleObjectError(w, rest_error.NewValidationError("Invalid JSON", err))
+		utils.HandleObjectError(w, r, rest_error.NewCodedValidationError(rest_error.CodeInvalidJSON, i18n.MsgInvalidJSON, err))
 		return
 	}
 	err = model.Validate(req)
 	if err != nil {
-		utils.HandleObjectError(w, rest_error.NewValidationError("Missing required field(s)", err))
+		utils.HandleObjectError(w, r, rest_error.NewValidationError(i18n.MsgMissingRequiredFields, err))
 		return
 	}
 
 	if pr.Services.MerchantService.Config.Environment == utils.EnvProduction || req.CaptchaValue != utils.DefaultCaptchaValue {
 		_, err = utils.VerifyCaptcha(req.CaptchaID, req.CaptchaValue)
 		if err != nil {
-			utils.HandleObjectError(w, rest_error.NewValidationError("", err))
+			utils.HandleObjectError(w, r, rest_error.NewCodedValidationError(rest_error.CodeCaptchaFailed, "", err))
 			return
 		}
 	}
 
 	otp, err := pr.Services.MerchantService.CreateMerchant(r.Context(), &req)
 	if err != nil {
-		utils.HandleObjectError(w, err)
+		utils.HandleObjectError(w, r, err)
 		return
 	}
 
@@ -82,7 +84,7 @@ func (pr *merchantRouter) signup(w http.ResponseWriter, r *http.Request) {
 		meta = map[string]string{"otp": otp}
 	}
 
-	utils.ServeJSONObject(w, http.StatusCreated, "OTP sent", nil, meta, true)
+	utils.ServeJSONObject(w, http.StatusCreated, i18n.MsgOTPSent, nil, meta, true)
 }
 
 // verifySignUp godoc
@@ -102,22 +104,22 @@ func (pr *merchantRouter) verifySignUp(w http.ResponseWriter, r *http.Request) {
 
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		utils.HandleObjectError(w, rest_error.NewValidationError("Invalid JSON", err))
+		utils.HandleObjectError(w, r, rest_error.NewCodedValidationError(rest_error.CodeInvalidJSON, i18n.MsgInvalidJSON, err))
 		return
 	}
 	err = model.Validate(req)
 	if err != nil {
-		utils.HandleObjectError(w, rest_error.NewValidationError("Missing required field(s)", err))
+		utils.HandleObjectError(w, r, rest_error.NewValidationError(i18n.MsgMissingRequiredFields, err))
 		return
 	}
 
 	err = pr.Services.MerchantService.VerifyMerchantSignUp(r.Context(), &req)
 	if err != nil {
-		utils.HandleObjectError(w, err)
+		utils.HandleObjectError(w, r, err)
 		return
 	}
 
-	utils.ServeJSONObject(w, http.StatusOK, "Verified", nil, nil, true)
+	utils.ServeJSONObject(w, http.StatusOK, i18n.MsgVerified, nil, nil, true)
 }
 
 // login godoc
@@ -129,6 +131,7 @@ func (pr *merchantRouter) verifySignUp(w http.ResponseWriter, r *http.Request) {
 // @Param  Body body model.LoginReq true "All fields are mandatory"
 // @Success 200 {object} response.TokenSuccessRes
 // @Failure 400 {object} response.EmptyErrorRes
+// @Failure 403 {object} response.EmptyErrorRes "Account is blocked, inactive or pending"
 // @Failure 404 {object} response.EmptyErrorRes
 // @Failure 500 {object} response.EmptyErrorRes
 // @Router /api/v1/public/merchants/login [post]
@@ -137,22 +140,23 @@ func (pr *merchantRouter) login(w http.ResponseWriter, r *http.Request) {
 
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		utils.HandleObjectError(w, rest_error.NewValidationError("Invalid JSON", err))
+		utils.HandleObjectError(w, r, rest_error.NewCodedValidationError(rest_error.CodeInvalidJSON, i18n.MsgInvalidJSON, err))
 		return
 	}
 	err = model.Validate(req)
 	if err != nil {
-		utils.HandleObjectError(w, rest_error.NewValidationError("Missing required field(s)", err))
+		utils.HandleObjectError(w, r, rest_error.NewValidationError(i18n.MsgMissingRequiredFields, err))
 		return
 	}
 
 	res, err := pr.Services.MerchantService.Login(r.Context(), &req)
+	metrics.Logins.WithLabelValues("merchant", metrics.Result(err)).Inc()
 	if err != nil {
-		utils.HandleObjectError(w, err)
+		utils.HandleObjectError(w, r, err)
 		return
 	}
 
-	utils.ServeJSONObject(w, http.StatusOK, "Logged in", res, nil, true)
+	utils.ServeJSONObject(w, http.StatusOK, i18n.MsgLoggedIn, res, nil, true)
 }
 
 // forgotPassword godoc
@@ -162,7 +166,7 @@ func (pr *merchantRouter) login(w http.ResponseWriter, r *http.Request) {
 // @Accept  json
 // @Produce  json
 // @Param  Body body model.ForgotPasswordReq true "All fields are mandatory"
-// @Success 201 {object} response.EmptySuccessRes
+// @Success 201 {object} response.OTPSentSuccessRes
 // @Failure 400 {object} response.EmptyErrorRes
 // @Failure 404 {object} response.EmptyErrorRes
 // @Failure 500 {object} response.EmptyErrorRes
@@ -172,27 +176,27 @@ func (pr *merchantRouter) forgotPassword(w http.ResponseWriter, r *http.Request)
 
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		utils.HandleObjectError(w, rest_error.NewValidationError("Invalid JSON", err))
+		utils.HandleObjectError(w, r, rest_error.NewCodedValidationError(rest_error.CodeInvalidJSON, i18n.MsgInvalidJSON, err))
 		return
 	}
 
 	err = model.Validate(req)
 	if err != nil {
-		utils.HandleObjectError(w, rest_error.NewValidationError("Missing required field(s)", err))
+		utils.HandleObjectError(w, r, rest_error.NewValidationError(i18n.MsgMissingRequiredFields, err))
 		return
 	}
 
 	if pr.Services.MerchantService.Config.Environment == utils.EnvProduction || req.CaptchaValue != utils.DefaultCaptchaValue {
 		_, err = utils.VerifyCaptcha(req.CaptchaID, req.CaptchaValue)
 		if err != nil {
-			utils.HandleObjectError(w, rest_error.NewValidationError("", err))
+			utils.HandleObjectError(w, r, rest_error.NewCodedValidationError(rest_error.CodeCaptchaFailed, "", err))
 			return
 		}
 	}
 
 	otp, err := pr.Services.MerchantService.ForgotPassword(r.Context(), &req)
 	if err != nil {
-		utils.HandleObjectError(w, err)
+		utils.HandleObjectError(w, r, err)
 		return
 	}
 
@@ -202,7 +206,7 @@ func (pr *merchantRouter) forgotPassword(w http.ResponseWriter, r *http.Request)
 		meta = map[string]string{"otp": otp}
 	}
 
-	utils.ServeJSONObject(w, http.StatusCreated, "OTP sent", nil, meta, true)
+	utils.ServeJSONObject(w, http.StatusCreated, i18n.MsgOTPSent, nil, meta, true)
 }
 
 // setPassword godoc
@@ -222,21 +226,21 @@ func (pr *merchantRouter) setPassword(w http.ResponseWriter, r *http.Request) {
 
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		utils.HandleObjectError(w, rest_error.NewValidationError("Invalid JSON", err))
+		utils.HandleObjectError(w, r, rest_error.NewCodedValidationError(rest_error.CodeInvalidJSON, i18n.MsgInvalidJSON, err))
 		return
 	}
 
 	err = model.Validate(req)
 	if err != nil {
-		utils.HandleObjectError(w, rest_error.NewValidationError("Missing required field(s)", err))
+		utils.HandleObjectError(w, r, rest_error.NewValidationError(i18n.MsgMissingRequiredFields, err))
 		return
 	}
 
 	err = pr.Services.MerchantService.SetPassword(r.Context(), &req)
 	if err != nil {
-		utils.HandleObjectError(w, err)
+		utils.HandleObjectError(w, r, err)
 		return
 	}
 
-	utils.ServeJSONObject(w, http.StatusOK, "Password set", nil, nil, true)
+	utils.ServeJSONObject(w, http.StatusOK, i18n.MsgPasswordSet, nil, nil, true)
 }
@@ -2,19 +2,19 @@ package public
 
 import (
 	"github.com/go-chi/chi"
+	"github.com/iamrz1/ab-auth/logger"
 	"github.com/iamrz1/ab-auth/service"
-	rLog "github.com/iamrz1/rest-log"
 )
 
 type publicRouter struct {
 	Services *service.Config
-	Log      rLog.Logger
+	Log      logger.Logger
 }
 
-func NewPublicRouter(svc *service.Config, rLogger rLog.Logger) *publicRouter {
+func NewPublicRouter(svc *service.Config, lgr logger.Logger) *publicRouter {
 	return &publicRouter{
 		Services: svc,
-		Log:      rLogger,
+		Log:      lgr,
 	}
 }
 
@@ -25,5 +25,6 @@ func (pr *publicRouter) Router() *chi.Mux {
 	r.Mount("/customers", pr.customerRouter())
 	r.Mount("/merchants", pr.merchantRouter())
 	r.Get("/bd-area", pr.listBDArea)
+	r.Get("/bd-area/search", pr.searchBDArea)
 	return r
 }
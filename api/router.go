@@ -2,18 +2,21 @@ package api
 
 import (
 	"github.com/go-chi/chi"
+	"github.com/iamrz1/ab-auth/api/internal"
 	"github.com/iamrz1/ab-auth/api/private"
 	"github.com/iamrz1/ab-auth/api/public"
+	"github.com/iamrz1/ab-auth/logger"
 	"github.com/iamrz1/ab-auth/service"
-	rLog "github.com/iamrz1/rest-log"
 )
 
-func V1Router(svc *service.Config, logger rLog.Logger) *chi.Mux {
+func V1Router(svc *service.Config, lgr logger.Logger) *chi.Mux {
 	r := chi.NewRouter()
-	publicRouter := public.NewPublicRouter(svc, logger)
-	privateRouter := private.NewPrivateRouter(svc, logger)
+	publicRouter := public.NewPublicRouter(svc, lgr)
+	privateRouter := private.NewPrivateRouter(svc, lgr)
+	internalRouter := internal.NewInternalRouter(svc, lgr)
 	r.Mount("/public", publicRouter.Router())
 	r.Mount("/private", privateRouter.Router())
+	r.Mount("/internal", internalRouter.Router())
 
 	return r
 }
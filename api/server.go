@@ -2,13 +2,15 @@ package api
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"github.com/iamrz1/ab-auth/config"
+	"github.com/iamrz1/ab-auth/logger"
 	"github.com/iamrz1/ab-auth/service"
 	"github.com/iamrz1/ab-auth/utils"
-	rLog "github.com/iamrz1/rest-log"
 	httpSwagger "github.com/swaggo/http-swagger"
-	"log"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"time"
@@ -17,15 +19,24 @@ import (
 	chiMiddleware "github.com/go-chi/chi/middleware"
 	"github.com/go-chi/cors"
 	"github.com/iamrz1/ab-auth/api/health"
+	"github.com/iamrz1/ab-auth/api/middleware"
+	"github.com/iamrz1/ab-auth/api/oauth"
+	"github.com/iamrz1/ab-auth/metrics"
+	"github.com/iamrz1/ab-auth/tracing"
 )
 
-func Start(cfg *config.AppConfig, svc *service.Config, logger rLog.Logger) (*http.Server, error) {
+// metricsServer serves /metrics apart from the api, when a metrics port is set
+var metricsServer *http.Server
 
-	addr := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
+// Start serves the api in the background, and the metrics on their own port. readiness
+// backs /readyz.
+func Start(cfg *config.AppConfig, svc *service.Config, lgr logger.Logger, readiness *health.Checker) (*http.Server, error) {
 
-	handler, err := SetupRouter(cfg, svc, logger)
+	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
+
+	handler, err := SetupRouter(cfg, svc, lgr, readiness)
 	if err != nil {
-		log.Println("cant setup router:", err)
+		logger.Errorln(context.Background(), "Start", "cant setup router: "+err.Error())
 		return nil, err
 	}
 
@@ -36,10 +47,28 @@ func Start(cfg *config.AppConfig, svc *service.Config, logger rLog.Logger) (*htt
 		Handler:      handler,
 	}
 
+	if cfg.Server.TLS.CertFile != "" {
+		srv.TLSConfig, err = serverTLSConfig(cfg)
+		if err != nil {
+			logger.Errorln(context.Background(), "Start", "cant setup tls: "+err.Error())
+			return nil, err
+		}
+	}
+
+	if cfg.Server.MetricsPort > 0 {
+		startMetrics(fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.MetricsPort))
+	}
+
 	go func() {
-		log.Println("Staring server with address ", addr)
-		if err := srv.ListenAndServe(); err != nil {
-			log.Println("Stopping server:", err)
+		logger.Infoln(context.Background(), "Start", "Starting server with address "+addr)
+		var err error
+		if srv.TLSConfig != nil {
+			err = srv.ListenAndServeTLS(cfg.Server.TLS.CertFile, cfg.Server.TLS.KeyFile)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil {
+			logger.Errorln(context.Background(), "Start", "Stopping server: "+err.Error())
 			os.Exit(-1)
 		}
 	}()
@@ -47,27 +76,87 @@ func Start(cfg *config.AppConfig, svc *service.Config, logger rLog.Logger) (*htt
 	return srv, nil
 }
 
-func Stop(server *http.Server) error {
+// startMetrics serves /metrics on addr in the background. It is kept off the api router,
+// so per-route traffic and rate limit rejections are only seen by who can reach addr.
+func startMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	metricsServer = &http.Server{
+		Addr:         addr,
+		WriteTimeout: time.Second * 15,
+		ReadTimeout:  time.Second * 15,
+		Handler:      mux,
+	}
+
+	go func() {
+		logger.Infoln(context.Background(), "startMetrics", "Serving metrics with address "+addr)
+		err := metricsServer.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			logger.Errorln(context.Background(), "startMetrics", "Stopping metrics server: "+err.Error())
+		}
+	}()
+}
+
+// serverTLSConfig asks clients for a certificate signed by the client CA, if one is set.
+// Certificates are optional, end users and callers with secrets do without them.
+func serverTLSConfig(cfg *config.AppConfig) (*tls.Config, error) {
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.Server.TLS.ClientCAFile == "" {
+		return tlsCfg, nil
+	}
+
+	pem, err := ioutil.ReadFile(cfg.Server.TLS.ClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", cfg.Server.TLS.ClientCAFile)
+	}
+	tlsCfg.ClientCAs = pool
+	tlsCfg.ClientAuth = tls.VerifyClientCertIfGiven
+
+	return tlsCfg, nil
+}
+
+// Stop reports not ready for the drain delay, then shuts server down gracefully
+func Stop(server *http.Server, readiness *health.Checker) error {
 	cfg := config.GetConfig()
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.GracefulTimeout)*time.Second)
+	readiness.Drain()
+	if cfg.Server.DrainDelay > 0 {
+		logger.Infof(context.Background(), "Stop", "draining for %d seconds", cfg.Server.DrainDelay)
+		time.Sleep(time.Duration(cfg.Server.DrainDelay) * time.Second)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Server.GracefulTimeout)*time.Second)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
-		log.Println("Http server couldn't shutdown gracefully", err)
+		logger.Errorln(context.Background(), "Stop", "Http server couldn't shutdown gracefully: "+err.Error())
 		return err
 	}
 
-	log.Println("shutting down")
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(ctx); err != nil {
+			logger.Errorln(context.Background(), "Stop", "Metrics server couldn't shutdown gracefully: "+err.Error())
+		}
+	}
+
+	logger.Infoln(context.Background(), "Stop", "shutting down")
 	return nil
 }
 
-func SetupRouter(cfg *config.AppConfig, svc *service.Config, logger rLog.Logger) (*chi.Mux, error) {
+func SetupRouter(cfg *config.AppConfig, svc *service.Config, lgr logger.Logger, readiness *health.Checker) (*chi.Mux, error) {
 	r := chi.NewRouter()
 
+	r.Use(tracing.Middleware)
+	r.Use(metrics.Middleware)
 	r.Use(chiMiddleware.RequestID)
 	r.Use(chiMiddleware.RealIP)
-	r.Use(chiMiddleware.Logger)
+	r.Use(middleware.RequestLogger(lgr))
 	r.Use(chiMiddleware.Recoverer)
+	r.Use(middleware.Language)
 
 	// enforce cors
 	r.Use(cors.Handler(cors.Options{
@@ -85,14 +174,15 @@ func SetupRouter(cfg *config.AppConfig, svc *service.Config, logger rLog.Logger)
 		r.Get("/doc/*", httpSwagger.Handler())
 	}
 
-	r.Mount("/", health.Router())
-	r.Mount("/api/v1", V1Router(svc, logger))
+	r.Mount("/", health.Router(readiness))
+	r.Mount("/api/v1", V1Router(svc, lgr))
+	r.Mount("/oauth", oauth.NewOAuthRouter(svc, lgr).Router())
 
 	return r, nil
 }
 
 func verifyOrigin(r *http.Request, origin string) bool {
-	log.Println("cors from ", origin)
+	logger.Debugln(r.Context(), "verifyOrigin", "cors from "+origin)
 	// todo: write a function to allow only valid origins
 	return true
 }
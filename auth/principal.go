@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"context"
+	"github.com/iamrz1/ab-auth/logger"
+)
+
+// Ways a principal can be authenticated
+const (
+	MethodAccessToken       = "access_token"
+	MethodSecret            = "secret"
+	MethodClientCertificate = "client_certificate"
+)
+
+// UserTypeService is the user type of other services calling the internal apis
+const UserTypeService = "service"
+
+// Principal is the authenticated identity behind a request
+type Principal struct {
+	Username string
+	UserType string
+	Role     string
+	// SessionID is the jti of the access token, empty for services
+	SessionID string
+	Method    string
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p. The user is logged with every entry of
+// the request from now on, the access log entry included.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	logger.AddFields(ctx, logger.Fields{"username": p.Username, "user_type": p.UserType})
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal stored in ctx by the authentication middleware
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// Username returns the username of the principal in ctx, or an empty string if the
+// request is not authenticated
+func Username(ctx context.Context) string {
+	if p, ok := FromContext(ctx); ok {
+		return p.Username
+	}
+
+	return ""
+}
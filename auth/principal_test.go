@@ -0,0 +1,24 @@
+package auth
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFromContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Error("expected no principal in an empty context")
+	}
+	if u := Username(context.Background()); u != "" {
+		t.Errorf("expected no username, got %q", u)
+	}
+
+	ctx := WithPrincipal(context.Background(), &Principal{Username: "01700000001", Method: MethodAccessToken})
+	p, ok := FromContext(ctx)
+	if !ok || p.Username != "01700000001" || p.Method != MethodAccessToken {
+		t.Errorf("expected the stored principal, got %+v", p)
+	}
+	if u := Username(ctx); u != "01700000001" {
+		t.Errorf("expected the username of the principal, got %q", u)
+	}
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"context"
+	"github.com/iamrz1/ab-auth/config"
+	"github.com/iamrz1/ab-auth/logger"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+	"os"
+)
+
+// ConfigCmd groups the config sub commands
+var ConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "config inspects the application config",
+}
+
+// configPrintCmd prints the effective config as yaml
+var configPrintCmd = &cobra.Command{
+	Use:   "print",
+	Short: "print prints the effective config as yaml",
+	Long: `print loads the config from the config file, env vars and flags like serve does,
+validates it and prints the result as yaml. Pass --redacted to hide secrets.`,
+	RunE: printConfig,
+}
+
+var configPrintOpts struct {
+	redacted bool
+}
+
+func init() {
+	configPrintCmd.Flags().BoolVar(&configPrintOpts.redacted, "redacted", false, "hide secrets and passwords in urls")
+	ConfigCmd.AddCommand(configPrintCmd)
+}
+
+func printConfig(cmd *cobra.Command, args []string) error {
+	err := config.LoadConfig(cmd.Flags())
+	if err != nil {
+		logger.Errorln(context.Background(), "printConfig", "could not load one or more config")
+		return err
+	}
+
+	cfg := config.GetConfig()
+	if configPrintOpts.redacted {
+		cfg = cfg.Redacted()
+	}
+
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	defer enc.Close()
+
+	return enc.Encode(cfg)
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"github.com/iamrz1/ab-auth/config"
+	infraCache "github.com/iamrz1/ab-auth/infra/cache"
+	infraMongo "github.com/iamrz1/ab-auth/infra/mongo"
+	"github.com/iamrz1/ab-auth/logger"
+	"github.com/iamrz1/ab-auth/service"
+	"github.com/spf13/cobra"
+	"time"
+)
+
+// ImportPresetsCmd imports BD location presets (division, district, sub-district, union)
+var ImportPresetsCmd = &cobra.Command{
+	Use:   "import-presets",
+	Short: "import-presets imports BD location presets from a json or csv dataset",
+	Long: `import-presets validates a BD location dataset and upserts it into the preset collection by slug.
+--file is required. data/bd_locations.sample.json is a sample for development with every division
+and district but only the sub-districts and unions of Dhaka, it is refused in production. Csv files need the columns
+id,name,name_bn,slug,parent,type, optionally followed by latitude,longitude
+of the centroid, and a --version.`,
+	RunE: importPresets,
+}
+
+var importPresetsOpts struct {
+	file    string
+	version string
+	prune   bool
+	force   bool
+}
+
+func init() {
+	ImportPresetsCmd.Flags().StringVar(&importPresetsOpts.file, "file", "", "dataset to import, json or csv")
+	ImportPresetsCmd.Flags().StringVar(&importPresetsOpts.version, "version", "", "dataset version, overrides the version in a json dataset")
+	ImportPresetsCmd.Flags().BoolVar(&importPresetsOpts.prune, "prune", false, "remove presets that are not part of the dataset")
+	ImportPresetsCmd.Flags().BoolVar(&importPresetsOpts.force, "force", false, "import even if this dataset version was imported already")
+	ImportPresetsCmd.MarkFlagRequired("file")
+}
+
+func importPresets(cmd *cobra.Command, args []string) error {
+	ds, checksum, err := service.LoadBDLocationDataset(importPresetsOpts.file, importPresetsOpts.version)
+	if err != nil {
+		return err
+	}
+
+	err = service.ValidateBDLocationDataset(ds)
+	if err != nil {
+		return err
+	}
+
+	err = config.LoadConfig(cmd.Flags())
+	if err != nil {
+		logger.Errorln(context.Background(), "importPresets", "could not load one or more config")
+		return err
+	}
+	cfg := config.GetConfig()
+	lgr, err := newLogger(cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute*5)
+	defer cancel()
+
+	store, err := openSecrets(cfg)
+	if err != nil {
+		return err
+	}
+
+	mongoDB, err := infraMongo.New(ctx, cfg.Database.URL, cfg.Database.Name, time.Second*time.Duration(cfg.Server.GracefulTimeout), infraMongo.SetLogger(lgr), mongoCredentials(store))
+	if err != nil {
+		return err
+	}
+	defer mongoDB.Close(context.Background())
+
+	redisCache := infraCache.NewCacheDB(cfg.Cache.URL, redisPassword(store))
+	defer redisCache.Client.Close()
+
+	svc := service.SetupServiceConfig(cfg, mongoDB, redisCache, lgr)
+
+	res, err := svc.CustomerService.ImportBDLocations(ctx, ds, checksum, service.BDLocationImportOptions{
+		Prune: importPresetsOpts.prune,
+		Force: importPresetsOpts.force,
+	})
+	if err != nil {
+		return err
+	}
+
+	if res.Skipped {
+		logger.Infof(context.Background(), "importPresets", "BD location presets %s are already imported, use --force to import again", res.Version)
+		return nil
+	}
+
+	logger.Infof(context.Background(), "importPresets", "imported %d BD location presets, version %s", res.Count, res.Version)
+	return nil
+}
@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"github.com/iamrz1/ab-auth/config"
+	"github.com/iamrz1/ab-auth/logger"
+)
+
+// newLogger returns a logger at the level of cfg and makes it the default logger, the one
+// of code that is not handed a logger
+func newLogger(cfg *config.AppConfig) (logger.Logger, error) {
+	level, err := logger.ParseLevel(cfg.LogLevel())
+	if err != nil {
+		return nil, err
+	}
+	lgr := logger.NewZeroLevelLogger(level)
+	logger.SetDefault(lgr)
+
+	return lgr, nil
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/iamrz1/ab-auth/config"
+	infraMongo "github.com/iamrz1/ab-auth/infra/mongo"
+	"github.com/iamrz1/ab-auth/logger"
+	"github.com/iamrz1/ab-auth/migrations"
+	"github.com/spf13/cobra"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// MigrateCmd groups the schema migration sub commands
+var MigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "migrate applies and reverts mongo schema migrations",
+	Long: `migrate applies and reverts the versioned mongo schema migrations, like the indices of
+customers, merchants and addresses. Applied migrations are recorded in the migration collection,
+which also holds a lock so only one run changes the schema at a time.`,
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "up applies the pending migrations",
+	Long: `up applies the pending migrations, oldest first, up to --to or all of them. Creating a unique
+index fails while the collection holds duplicates, they have to be resolved before running it again.`,
+	RunE: migrateUp,
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "down reverts the last applied migrations",
+	RunE:  migrateDown,
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "status lists the migrations and when they were applied",
+	RunE:  migrateStatus,
+}
+
+var migrateOpts struct {
+	to    int
+	steps int
+}
+
+func init() {
+	migrateUpCmd.Flags().IntVar(&migrateOpts.to, "to", 0, "version to migrate up to, 0 for the latest")
+	migrateDownCmd.Flags().IntVar(&migrateOpts.steps, "steps", 1, "number of migrations to revert")
+
+	MigrateCmd.AddCommand(migrateUpCmd)
+	MigrateCmd.AddCommand(migrateDownCmd)
+	MigrateCmd.AddCommand(migrateStatusCmd)
+}
+
+// openMigrator connects to mongo and returns a migrator along with a func closing the
+// connection
+func openMigrator(ctx context.Context, cmd *cobra.Command) (*migrations.Migrator, func(), error) {
+	err := config.LoadConfig(cmd.Flags())
+	if err != nil {
+		logger.Errorln(context.Background(), "openMigrator", "could not load one or more config")
+		return nil, nil, err
+	}
+	cfg := config.GetConfig()
+	lgr, err := newLogger(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	store, err := openSecrets(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mongoDB, err := infraMongo.New(ctx, cfg.Database.URL, cfg.Database.Name, time.Second*time.Duration(cfg.Server.GracefulTimeout), infraMongo.SetLogger(lgr), mongoCredentials(store))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return migrations.NewMigrator(mongoDB, cfg.Database, lgr), func() { mongoDB.Close(context.Background()) }, nil
+}
+
+func migrateUp(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), migrations.LockTTL)
+	defer cancel()
+
+	m, closeDB, err := openMigrator(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	done, err := m.Up(ctx, migrateOpts.to)
+	logger.Infof(context.Background(), "migrateUp", "applied %d migrations", len(done))
+
+	return err
+}
+
+func migrateDown(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), migrations.LockTTL)
+	defer cancel()
+
+	m, closeDB, err := openMigrator(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	done, err := m.Down(ctx, migrateOpts.steps)
+	logger.Infof(context.Background(), "migrateDown", "reverted %d migrations", len(done))
+
+	return err
+}
+
+func migrateStatus(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	m, closeDB, err := openMigrator(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	list, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, s := range list {
+		appliedAt := "pending"
+		if s.AppliedAt != nil {
+			appliedAt = s.AppliedAt.Format(time.RFC3339)
+		}
+		if s.Unknown {
+			appliedAt += " (unknown to this build)"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+	}
+
+	return w.Flush()
+}
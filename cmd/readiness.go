@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"context"
+	"github.com/iamrz1/ab-auth/api/health"
+	"github.com/iamrz1/ab-auth/infra"
+	infraCache "github.com/iamrz1/ab-auth/infra/cache"
+	"github.com/iamrz1/ab-auth/utils"
+)
+
+// readinessChecker checks what the api can not serve requests without. OTPs are not
+// delivered over a backend yet, so there is no notifier to check.
+func readinessChecker(db infra.DB, cache *infraCache.Redis) *health.Checker {
+	return health.NewChecker(
+		health.Check{Name: "mongo", Run: db.Ping},
+		health.Check{Name: "redis", Run: func(ctx context.Context) error {
+			return cache.WithContext(ctx).Ping().Err()
+		}},
+		health.Check{Name: "signing_keys", Run: func(context.Context) error {
+			return utils.CheckSigningKeys()
+		}},
+	)
+}
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
 	"github.com/iamrz1/ab-auth/cmd"
+	"github.com/iamrz1/ab-auth/config"
+	"github.com/iamrz1/ab-auth/logger"
 	"github.com/joho/godotenv"
 	"github.com/spf13/cobra"
 	"log"
+	"os"
 )
 
 // rootCmd is the root of all sub commands in the binary
@@ -17,17 +21,26 @@ var rootCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(cmd.SrvCmd)
+	rootCmd.AddCommand(cmd.ImportPresetsCmd)
+	rootCmd.AddCommand(cmd.ConfigCmd)
+	rootCmd.AddCommand(cmd.MigrateCmd)
+
+	config.RegisterFlags(rootCmd.PersistentFlags())
 }
 
 func main() {
+	// settings may come from a config file instead, so a .env file is optional
 	err := godotenv.Load()
 	if err != nil {
-		log.Fatal("Error loading .env file")
+		logger.Infoln(context.Background(), "main", "no .env file loaded")
 	}
 
-	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	// libraries logging with the standard logger go through ours too
+	log.SetFlags(0)
+	log.SetOutput(logger.Writer("log", logger.Info))
 
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatal(err)
+		logger.Errorln(context.Background(), "main", err.Error())
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"context"
+	"github.com/iamrz1/ab-auth/config"
+	infraMongo "github.com/iamrz1/ab-auth/infra/mongo"
+	"github.com/iamrz1/ab-auth/logger"
+	"github.com/iamrz1/ab-auth/secrets"
+	"github.com/iamrz1/ab-auth/utils"
+	"time"
+)
+
+// openSecrets returns the secret store configured by cfg and hands the signing keys in it to utils
+func openSecrets(cfg *config.AppConfig) (*secrets.Store, error) {
+	var provider secrets.Provider = secrets.EnvProvider{}
+	if cfg.Secrets.Dir != "" {
+		interval := time.Second * time.Duration(cfg.Secrets.ReloadInterval)
+		provider = secrets.Chain(secrets.NewFileProvider(cfg.Secrets.Dir, interval), secrets.EnvProvider{})
+	}
+	store := secrets.NewStore(provider)
+
+	utils.Configure(cfg)
+	err := utils.UseSecrets(store)
+	if err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// mongoCredentials returns the option connecting with the database credentials of store
+func mongoCredentials(store *secrets.Store) infraMongo.Option {
+	return infraMongo.SetCredentials(store.Get(secrets.DBUsername), store.Get(secrets.DBPassword))
+}
+
+// redisPassword returns the redis password of store
+func redisPassword(store *secrets.Store) func() string {
+	return func() string {
+		return store.Get(secrets.RedisPassword)
+	}
+}
+
+// reconnectOnRotation reconnects db whenever its username or password is rotated
+func reconnectOnRotation(store *secrets.Store, db *infraMongo.Mongo, timeout time.Duration) {
+	reconnect := func(string) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		err := db.Reconnect(ctx, store.Get(secrets.DBUsername), store.Get(secrets.DBPassword))
+		if err != nil {
+			logger.Errorln(context.Background(), "reconnectOnRotation", "could not reconnect to mongo with the rotated credentials: "+err.Error())
+		}
+	}
+
+	store.OnChange(secrets.DBUsername, reconnect)
+	store.OnChange(secrets.DBPassword, reconnect)
+}
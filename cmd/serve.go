@@ -3,13 +3,18 @@ package cmd
 import (
 	"context"
 	"github.com/iamrz1/ab-auth/api"
+	"github.com/iamrz1/ab-auth/api/health"
 	"github.com/iamrz1/ab-auth/config"
 	infraCache "github.com/iamrz1/ab-auth/infra/cache"
 	infraMongo "github.com/iamrz1/ab-auth/infra/mongo"
+	"github.com/iamrz1/ab-auth/logger"
+	"github.com/iamrz1/ab-auth/metrics"
+	"github.com/iamrz1/ab-auth/migrations"
 	"github.com/iamrz1/ab-auth/service"
-	rLog "github.com/iamrz1/rest-log"
+	"github.com/iamrz1/ab-auth/tracing"
+	"github.com/iamrz1/ab-auth/utils"
 	"github.com/spf13/cobra"
-	"log"
+	"github.com/spf13/pflag"
 	"net/http"
 	"os"
 	"os/signal"
@@ -26,11 +31,12 @@ var SrvCmd = &cobra.Command{
 
 var db *infraMongo.Mongo
 var cache *infraCache.Redis
+var stopWorkers context.CancelFunc
+var stopTracing func(context.Context) error
+var readiness *health.Checker
 
 func serve(cmd *cobra.Command, args []string) error {
-	log.SetFlags(log.LstdFlags | log.Lshortfile)
-
-	server, err := StartServer()
+	server, err := StartServer(cmd.Flags())
 	if err != nil {
 		return err
 	}
@@ -43,39 +49,73 @@ func serve(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func StartServer() (*http.Server, error) {
-	err := config.LoadConfig()
+// StartServer loads the config, taking overrides from flags, and starts the api server
+func StartServer(flags *pflag.FlagSet) (*http.Server, error) {
+	err := config.LoadConfig(flags)
 	if err != nil {
-		log.Println("could not load one or more config")
+		logger.Errorln(context.Background(), "StartServer", "could not load one or more config")
 		return nil, err
 	}
-	//rLogger
-	verbose := false
-	if os.Getenv("VERBOSE") == "true" {
-		verbose = true
+	cfg := config.GetConfig()
+
+	lgr, err := newLogger(cfg)
+	if err != nil {
+		return nil, err
 	}
-	rLogger := rLog.New(verbose)
 	ctx, cancel := context.WithTimeout(context.Background(), time.Minute*5)
 	defer cancel()
 
-	cfg := config.GetConfig()
+	gracefulTimeout := time.Second * time.Duration(cfg.Server.GracefulTimeout)
 
-	gracefulTimeout := time.Second * time.Duration(cfg.GracefulTimeout)
+	stopTracing, err = tracing.Setup(ctx, cfg.Tracing, cfg.Environment)
+	if err != nil {
+		return nil, err
+	}
 
-	db, err = infraMongo.New(ctx, cfg.DSN, cfg.Database, gracefulTimeout, infraMongo.SetLogger(rLogger))
+	store, err := openSecrets(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	cache = infraCache.NewCacheDB(cfg.CacheURL, "")
+	db, err = infraMongo.New(ctx, cfg.Database.URL, cfg.Database.Name, gracefulTimeout, infraMongo.SetLogger(lgr), mongoCredentials(store))
+	if err != nil {
+		return nil, err
+	}
+	reconnectOnRotation(store, db, gracefulTimeout)
 
-	svc := service.SetupServiceConfig(cfg, db, cache, rLogger)
+	cache = infraCache.NewCacheDB(cfg.Cache.URL, redisPassword(store))
 
-	log.Println("db initialized")
+	svc := service.SetupServiceConfig(cfg, db, cache, lgr)
 
-	server, err := api.Start(cfg, svc, rLogger)
+	err = metrics.RegisterPools(db, cache)
 	if err != nil {
-		log.Println("err:", err)
+		return nil, err
+	}
+
+	logger.Infoln(context.Background(), "StartServer", "db initialized")
+
+	// replicas of a deployment start at once, so migrations are left to migrate up
+	pending, err := migrations.NewMigrator(db, cfg.Database, lgr).Pending(ctx)
+	if err != nil {
+		logger.Errorln(context.Background(), "StartServer", "could not read the migration state: "+err.Error())
+	} else if len(pending) > 0 {
+		logger.Warnf(context.Background(), "StartServer", "%d migrations are pending, run migrate up", len(pending))
+	}
+
+	var workerCtx context.Context
+	workerCtx, stopWorkers = context.WithCancel(context.Background())
+	go svc.CustomerService.RunErasureWorker(workerCtx, utils.ErasureJobInterval)
+	go utils.WatchLastResets(workerCtx)
+	go utils.WatchRevocations(workerCtx)
+	go store.Watch(workerCtx)
+	if cfg.Internal.CallersFile != "" {
+		go cfg.Internal.Callers.Watch(workerCtx, cfg.Internal.CallersFile, time.Second*time.Duration(cfg.Secrets.ReloadInterval))
+	}
+
+	readiness = readinessChecker(db, cache)
+	server, err := api.Start(cfg, svc, lgr, readiness)
+	if err != nil {
+		logger.Errorln(context.Background(), "StartServer", err.Error())
 		return nil, err
 	}
 
@@ -85,14 +125,16 @@ func StartServer() (*http.Server, error) {
 func StopServer(server *http.Server) error {
 	defer db.Close(context.Background())
 	defer cache.Client.Close()
+	defer stopWorkers()
+	defer flushSpans()
 	var err error
 	graceful := func() error {
-		log.Println("Shutting down server gracefully")
+		logger.Infoln(context.Background(), "StopServer", "Shutting down server gracefully")
 		return nil
 	}
 
 	forced := func() error {
-		log.Println("Shutting down server forcefully")
+		logger.Infoln(context.Background(), "StopServer", "Shutting down server forcefully")
 		return nil
 	}
 
@@ -102,19 +144,29 @@ func StopServer(server *http.Server) error {
 		errCh <- HandleSignals(sigs, graceful, forced)
 	}()
 	if err = <-errCh; err != nil {
-		log.Println(err)
+		logger.Errorln(context.Background(), "StopServer", err.Error())
 		return err
 	}
 
-	err = api.Stop(server)
+	err = api.Stop(server, readiness)
 	if err != nil {
-		log.Println("server stop err:", err)
+		logger.Errorln(context.Background(), "StopServer", "server stop err: "+err.Error())
 		return err
 	}
 
 	return nil
 }
 
+// flushSpans exports the spans still buffered before the process exits
+func flushSpans() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	if err := stopTracing(ctx); err != nil {
+		logger.Errorln(context.Background(), "flushSpans", "could not flush spans: "+err.Error())
+	}
+}
+
 // HandleSignals listen on the registered signals and fires the gracefulHandler for the
 // first signal and the forceHandler (if any) for the next this function blocks and
 // return any error that returned by any of the api first
@@ -1,25 +1,133 @@
 package config
 
 import (
+	"context"
+	"fmt"
+	"github.com/iamrz1/ab-auth/logger"
 	"github.com/joho/godotenv"
-	"log"
+	"github.com/spf13/pflag"
 	"os"
-	"strconv"
+	"strings"
 )
 
-// AppConfig holds application configurations
+// ConfigFlag names the flag holding the config file, which CONFIG_FILE sets too
+const ConfigFlag = "config"
+
+// AppConfig holds application configurations. Every setting comes from its default, then
+// the config file, then its env var, then its flag, each overriding the one before.
 type AppConfig struct {
-	Environment     string
-	Host            string
-	Port            int
-	OtpTtlMinutes   int
-	GracefulTimeout int
-	DSN             string
-	Database        string
-	CustomerTable   string
-	MerchantTable   string
-	AddressTable    string
-	CacheURL        string
+	Environment string         `yaml:"environment" toml:"environment" env:"ENV" usage:"deployment environment, dev or prod" validate:"nonzero"`
+	Verbose     bool           `yaml:"verbose" toml:"verbose" env:"VERBOSE" usage:"log verbosely, like log.level debug"`
+	Log         LogConfig      `yaml:"log" toml:"log"`
+	Server      ServerConfig   `yaml:"server" toml:"server"`
+	Database    DatabaseConfig `yaml:"database" toml:"database"`
+	Cache       CacheConfig    `yaml:"cache" toml:"cache"`
+	Auth        AuthConfig     `yaml:"auth" toml:"auth"`
+	Captcha     CaptchaConfig  `yaml:"captcha" toml:"captcha"`
+	Account     AccountConfig  `yaml:"account" toml:"account"`
+	Internal    InternalConfig `yaml:"internal" toml:"internal"`
+	Secrets     SecretsConfig  `yaml:"secrets" toml:"secrets"`
+	Tracing     TracingConfig  `yaml:"tracing" toml:"tracing"`
+}
+
+// LogConfig configures the logger
+type LogConfig struct {
+	Level string `yaml:"level" toml:"level" env:"LOG_LEVEL" usage:"lowest level logged, debug, info, warn or error" validate:"regexp=^(debug|info|warn|error)$"`
+}
+
+// LogLevel returns the level to log at, debug when verbose
+func (c *AppConfig) LogLevel() string {
+	if c.Verbose {
+		return "debug"
+	}
+
+	return c.Log.Level
+}
+
+// ServerConfig configures the http server
+type ServerConfig struct {
+	Host string `yaml:"host" toml:"host" env:"REST_HOST" usage:"host to listen on"`
+	Port int    `yaml:"port" toml:"port" env:"REST_PORT" usage:"port to listen on" validate:"min=1,max=65535"`
+	// GracefulTimeout is how many seconds in flight requests get to finish on shutdown
+	GracefulTimeout int `yaml:"graceful_timeout" toml:"graceful_timeout" env:"GRACEFUL_TIMEOUT_SECONDS" usage:"seconds to wait for requests on shutdown" validate:"min=1"`
+	// DrainDelay is how many seconds the server reports not ready on shutdown before it
+	// stops taking connections, giving load balancers time to stop sending requests
+	DrainDelay int `yaml:"drain_delay" toml:"drain_delay" env:"DRAIN_DELAY_SECONDS" usage:"seconds to report not ready before shutting down" validate:"min=0"`
+	// MetricsPort serves /metrics on a listener of its own, kept off the public api
+	MetricsPort int       `yaml:"metrics_port" toml:"metrics_port" env:"METRICS_PORT" usage:"port serving /metrics apart from the api, 0 disables it" validate:"min=0,max=65535"`
+	TLS         TLSConfig `yaml:"tls" toml:"tls"`
+}
+
+// TLSConfig makes the server listen on https. With ClientCAFile set too, client
+// certificates signed by that CA authenticate internal callers.
+type TLSConfig struct {
+	CertFile     string `yaml:"cert_file" toml:"cert_file" env:"TLS_CERT_FILE" usage:"tls certificate file"`
+	KeyFile      string `yaml:"key_file" toml:"key_file" env:"TLS_KEY_FILE" usage:"tls key file"`
+	ClientCAFile string `yaml:"client_ca_file" toml:"client_ca_file" env:"TLS_CLIENT_CA_FILE" usage:"CA file for client certificates"`
+}
+
+// DatabaseConfig configures mongo
+type DatabaseConfig struct {
+	URL                  string `yaml:"url" toml:"url" env:"DB_URL" usage:"mongo connection string" secret:"url" validate:"nonzero"`
+	Name                 string `yaml:"name" toml:"name" env:"DB_DATABASE_NAME" usage:"mongo database" validate:"nonzero"`
+	CustomerCollection   string `yaml:"customer_collection" toml:"customer_collection" env:"DB_CUSTOMER_COLLECTION_NAME" usage:"customer collection" validate:"nonzero"`
+	MerchantCollection   string `yaml:"merchant_collection" toml:"merchant_collection" env:"DB_MERCHANT_COLLECTION_NAME" usage:"merchant collection" validate:"nonzero"`
+	AddressCollection    string `yaml:"address_collection" toml:"address_collection" env:"DB_ADDRESS_COLLECTION_NAME" usage:"address collection" validate:"nonzero"`
+	AuditCollection      string `yaml:"audit_collection" toml:"audit_collection" env:"DB_AUDIT_COLLECTION_NAME" usage:"audit log collection" validate:"nonzero"`
+	BDLocationCollection string `yaml:"bd_location_collection" toml:"bd_location_collection" env:"DB_BD_LOCATION_COLLECTION_NAME" usage:"bd location preset collection" validate:"nonzero"`
+	DatasetCollection    string `yaml:"dataset_collection" toml:"dataset_collection" env:"DB_DATASET_COLLECTION_NAME" usage:"dataset version collection" validate:"nonzero"`
+	MigrationCollection  string `yaml:"migration_collection" toml:"migration_collection" env:"DB_MIGRATION_COLLECTION_NAME" usage:"schema migration state collection" validate:"nonzero"`
+}
+
+// CacheConfig configures redis
+type CacheConfig struct {
+	URL string `yaml:"url" toml:"url" env:"REDIS_URL" usage:"redis address" secret:"url" validate:"nonzero"`
+}
+
+// AuthConfig configures tokens and one time passwords
+type AuthConfig struct {
+	AccessTokenTTLMinutes  int `yaml:"access_token_ttl_minutes" toml:"access_token_ttl_minutes" env:"ACCESS_TOKEN_EXPIRATION_MINUTES" usage:"access token lifetime in minutes" validate:"min=1"`
+	RefreshTokenTTLMinutes int `yaml:"refresh_token_ttl_minutes" toml:"refresh_token_ttl_minutes" env:"REFRESH_TOKEN_EXPIRATION_MINUTES" usage:"refresh token lifetime in minutes" validate:"min=1"`
+	OTPTTLMinutes          int `yaml:"otp_ttl_minutes" toml:"otp_ttl_minutes" env:"OTP_TTL_MINUTES" usage:"one time password lifetime in minutes" validate:"min=1"`
+}
+
+// CaptchaConfig configures the captcha verification service
+type CaptchaConfig struct {
+	BaseURL string `yaml:"base_url" toml:"base_url" env:"CAPTCHA_BASE_URL" usage:"captcha service address"`
+}
+
+// AccountConfig configures account life cycles
+type AccountConfig struct {
+	// ErasureGraceDays is the number of days an account erasure request
+	// waits before the customer's data is purged
+	ErasureGraceDays int `yaml:"erasure_grace_days" toml:"erasure_grace_days" env:"ACCOUNT_ERASURE_GRACE_DAYS" usage:"days before an erasure request is carried out" validate:"min=1"`
+}
+
+// InternalConfig configures the internal apis
+type InternalConfig struct {
+	CallersFile string `yaml:"callers_file" toml:"callers_file" env:"INTERNAL_API_CALLERS_FILE" usage:"json file listing internal api callers"`
+	// Callers are the services allowed on the internal apis, read from CallersFile and
+	// reloaded when it changes. The internal apis are closed to everyone while it is empty.
+	Callers *InternalCallerSet `yaml:"-" toml:"-"`
+}
+
+// SecretsConfig tells where signing keys and credentials come from. Secrets are read from
+// files named after them in Dir, falling back to env vars, and files are checked for
+// rotation every ReloadInterval seconds, as is the internal api callers file.
+type SecretsConfig struct {
+	Dir            string `yaml:"dir" toml:"dir" env:"SECRETS_DIR" usage:"directory of mounted secret files"`
+	ReloadInterval int    `yaml:"reload_interval" toml:"reload_interval" env:"SECRETS_RELOAD_SECONDS" usage:"seconds between checks of the secret files and the internal api callers file" validate:"min=1"`
+}
+
+// TracingConfig configures OpenTelemetry tracing. Trace ids are logged whatever the
+// exporter, spans only leave the process with stdout or otlp.
+type TracingConfig struct {
+	Exporter     string `yaml:"exporter" toml:"exporter" env:"TRACING_EXPORTER" usage:"span exporter, none, stdout or otlp" validate:"regexp=^(none|stdout|otlp)$"`
+	OTLPEndpoint string `yaml:"otlp_endpoint" toml:"otlp_endpoint" env:"TRACING_OTLP_ENDPOINT" usage:"host:port of the otlp http collector"`
+	Insecure     bool   `yaml:"insecure" toml:"insecure" env:"TRACING_INSECURE" usage:"send spans to the collector over plain http"`
+	// SamplePercent is the share of traces started here that are sampled. Traces started
+	// upstream keep their sampling decision.
+	SamplePercent int `yaml:"sample_percent" toml:"sample_percent" env:"TRACING_SAMPLE_PERCENT" usage:"percent of new traces to sample" validate:"min=0,max=100"`
 }
 
 var myConfig *AppConfig
@@ -28,61 +136,113 @@ func init() {
 	godotenv.Load("../.env")
 }
 
-// LoadConfig loads config from path
-func LoadConfig() error {
-	port, err := strconv.Atoi(os.Getenv("REST_PORT"))
-	if err != nil {
-		log.Println("rest port not found or invalid")
-		port = 8080
+// Defaults returns the config every source is applied on top of
+func Defaults() *AppConfig {
+	return &AppConfig{
+		Log: LogConfig{
+			Level: "info",
+		},
+		Server: ServerConfig{
+			Port:            8080,
+			GracefulTimeout: 30,
+			DrainDelay:      5,
+			MetricsPort:     9090,
+		},
+		Database: DatabaseConfig{
+			AuditCollection:      "audit_log",
+			BDLocationCollection: "address_preset",
+			DatasetCollection:    "dataset_version",
+			MigrationCollection:  "schema_migration",
+		},
+		Auth: AuthConfig{
+			AccessTokenTTLMinutes:  30,
+			RefreshTokenTTLMinutes: 7 * 24 * 60,
+		},
+		Account: AccountConfig{
+			ErasureGraceDays: 30,
+		},
+		Internal: InternalConfig{
+			Callers: NewInternalCallerSet(nil),
+		},
+		Secrets: SecretsConfig{
+			ReloadInterval: 30,
+		},
+		Tracing: TracingConfig{
+			Exporter:      "none",
+			SamplePercent: 100,
+		},
 	}
+}
 
-	dsn := os.Getenv("DB_URL")
-	if dsn == "" {
-		log.Fatal("missing env DB_URL")
+// RegisterFlags adds the config file flag and a flag for every setting to fs
+func RegisterFlags(fs *pflag.FlagSet) {
+	fs.String(ConfigFlag, "", "yaml or toml config file, also set by CONFIG_FILE")
+	for _, s := range settings(Defaults()) {
+		s.register(fs)
 	}
+}
 
-	dbname := os.Getenv("DB_DATABASE_NAME")
-	if dbname == "" {
-		log.Fatal("missing env DB_DATABASE_NAME")
+// Load builds the config from the defaults, the file at path, env vars and the flags of fs
+// that were set, then validates it. Every problem found is reported in one error.
+func Load(path string, fs *pflag.FlagSet) (*AppConfig, error) {
+	cfg := Defaults()
+	if path != "" {
+		err := readFile(path, cfg)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	ct := os.Getenv("DB_CUSTOMER_COLLECTION_NAME")
-	if ct == "" {
-		log.Fatal("missing env DB_CUSTOMER_COLLECTION_NAME")
-	}
-	mt := os.Getenv("DB_MERCHANT_COLLECTION_NAME")
-	if mt == "" {
-		log.Fatal("missing env DB_MERCHANT_COLLECTION_NAME")
+	problems := make([]string, 0)
+	for _, s := range settings(cfg) {
+		if raw := os.Getenv(s.Env); s.Env != "" && raw != "" {
+			if err := s.set(raw); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", s.Env, err))
+			}
+		}
+		if fs == nil {
+			continue
+		}
+		if f := fs.Lookup(s.flagName()); f != nil && f.Changed {
+			if err := s.set(f.Value.String()); err != nil {
+				problems = append(problems, fmt.Sprintf("--%s: %v", s.flagName(), err))
+			}
+		}
 	}
 
-	at := os.Getenv("DB_ADDRESS_COLLECTION_NAME")
-	if at == "" {
-		log.Fatal("missing env DB_ADDRESS_COLLECTION_NAME")
+	problems = append(problems, cfg.validate()...)
+
+	if cfg.Internal.CallersFile != "" {
+		err := cfg.Internal.Callers.Reload(cfg.Internal.CallersFile)
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+	} else {
+		logger.Warnln(context.Background(), "Load", "internal api callers not found, internal apis are disabled")
 	}
 
-	cacheURL := os.Getenv("REDIS_URL")
-	if cacheURL == "" {
-		log.Fatal("missing env REDIS_URL")
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid config:\n  %s", strings.Join(problems, "\n  "))
 	}
 
-	otpttl, err := strconv.Atoi(os.Getenv("OTP_TTL_MINUTES"))
-	if err != nil {
-		log.Fatal("missing env OTP_TTL_MINUTES")
+	return cfg, nil
+}
+
+// LoadConfig loads the config with Load and keeps it for GetConfig. The file comes from
+// the config flag of fs, or CONFIG_FILE.
+func LoadConfig(fs *pflag.FlagSet) error {
+	path := os.Getenv("CONFIG_FILE")
+	if fs != nil {
+		if f := fs.Lookup(ConfigFlag); f != nil && f.Changed {
+			path = f.Value.String()
+		}
 	}
 
-	myConfig = &AppConfig{
-		Environment:     os.Getenv("ENV"),
-		Host:            os.Getenv("REST_HOST"),
-		Port:            port,
-		OtpTtlMinutes:   otpttl,
-		GracefulTimeout: 30,
-		DSN:             dsn,
-		Database:        dbname,
-		CustomerTable:   ct,
-		MerchantTable:   mt,
-		AddressTable:    at,
-		CacheURL:        cacheURL,
+	cfg, err := Load(path, fs)
+	if err != nil {
+		return err
 	}
+	myConfig = cfg
 
 	return nil
 }
@@ -91,3 +251,27 @@ func LoadConfig() error {
 func GetConfig() *AppConfig {
 	return myConfig
 }
+
+// validate checks every setting against its validate tag, then the settings that depend on each other
+func (c *AppConfig) validate() []string {
+	problems := make([]string, 0)
+	for _, s := range settings(c) {
+		if msg := s.check(); msg != "" {
+			problems = append(problems, fmt.Sprintf("%s: %s", s.Key, msg))
+		}
+	}
+
+	if c.Server.MetricsPort != 0 && c.Server.MetricsPort == c.Server.Port {
+		problems = append(problems, "server.metrics_port: must differ from server.port")
+	}
+
+	tls := c.Server.TLS
+	if (tls.CertFile == "") != (tls.KeyFile == "") {
+		problems = append(problems, "server.tls: cert_file and key_file must be set together")
+	}
+	if tls.ClientCAFile != "" && tls.CertFile == "" {
+		problems = append(problems, "server.tls.client_ca_file: needs cert_file and key_file")
+	}
+
+	return problems
+}
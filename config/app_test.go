@@ -6,7 +6,7 @@ import (
 )
 
 func TestGetConfig(t *testing.T) {
-	err := LoadConfig()
+	err := LoadConfig(nil)
 	assert.NoError(t, err, "failed to load config")
 
 	cfg := GetConfig()
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/iamrz1/ab-auth/logger"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+)
+
+// minInternalSecretLength keeps guessable secrets out of the callers file
+const minInternalSecretLength = 32
+
+// InternalCaller is a service allowed to call the internal apis
+type InternalCaller struct {
+	Name string `json:"name"`
+	// Secrets are accepted in the Secret-Key header. While rotating, list the new secret
+	// next to the old one, move the caller over, then drop the old one.
+	Secrets []string `json:"secrets"`
+	// CertNames are matched against the common name and DNS names of a verified client certificate
+	CertNames []string `json:"cert_names"`
+	// Endpoints lists the internal endpoints the caller may use, "*" allows all of them
+	Endpoints []string `json:"endpoints"`
+}
+
+// Allows reports whether the caller may use endpoint
+func (c *InternalCaller) Allows(endpoint string) bool {
+	for _, e := range c.Endpoints {
+		if e == "*" || e == endpoint {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LoadInternalCallers reads the internal api callers from a json file holding a list of callers
+func LoadInternalCallers(path string) ([]InternalCaller, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseInternalCallers(path, b)
+}
+
+func parseInternalCallers(path string, b []byte) ([]InternalCaller, error) {
+	callers := make([]InternalCaller, 0)
+	err := json.Unmarshal(b, &callers)
+	if err != nil {
+		return nil, err
+	}
+
+	problems := make([]string, 0)
+	seen := map[string]bool{}
+	for i, c := range callers {
+		switch {
+		case c.Name == "":
+			problems = append(problems, fmt.Sprintf("caller #%d has no name", i+1))
+			continue
+		case seen[c.Name]:
+			problems = append(problems, fmt.Sprintf("%s: duplicate caller", c.Name))
+		}
+		seen[c.Name] = true
+
+		if len(c.Secrets) == 0 && len(c.CertNames) == 0 {
+			problems = append(problems, fmt.Sprintf("%s: needs secrets or cert_names", c.Name))
+		}
+		for _, s := range c.Secrets {
+			if len(s) < minInternalSecretLength {
+				problems = append(problems, fmt.Sprintf("%s: secrets must be at least %d characters", c.Name, minInternalSecretLength))
+				break
+			}
+		}
+		if len(c.Endpoints) == 0 {
+			problems = append(problems, fmt.Sprintf("%s: no endpoints allowed", c.Name))
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid internal callers in %s:\n  %s", path, strings.Join(problems, "\n  "))
+	}
+
+	return callers, nil
+}
+
+// InternalCallerSet holds the current internal api callers. The middleware reads it on
+// every request, so secrets rotated in the callers file are taken without a restart.
+type InternalCallerSet struct {
+	mu      sync.RWMutex
+	callers []InternalCaller
+	content []byte
+}
+
+// NewInternalCallerSet returns a set holding callers
+func NewInternalCallerSet(callers []InternalCaller) *InternalCallerSet {
+	return &InternalCallerSet{callers: callers}
+}
+
+// Get returns the current callers, none for a nil set
+func (s *InternalCallerSet) Get() []InternalCaller {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.callers
+}
+
+// Reload reads the callers from path when the file changed since the last load. An
+// invalid file keeps the current callers, a bad edit must not lock every service out.
+func (s *InternalCallerSet) Reload(path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	same := s.content != nil && bytes.Equal(s.content, b)
+	s.mu.RUnlock()
+	if same {
+		return nil
+	}
+
+	callers, err := parseInternalCallers(path, b)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.callers, s.content = callers, b
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Watch reloads the callers from path every interval until ctx is done
+func (s *InternalCallerSet) Watch(ctx context.Context, path string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := s.Reload(path)
+			if err != nil {
+				logger.Errorln(ctx, "Watch", "keeping the last internal api callers: "+err.Error())
+			}
+		}
+	}
+}
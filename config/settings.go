@@ -0,0 +1,203 @@
+package config
+
+import (
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/pflag"
+	"gopkg.in/validator.v2"
+	"gopkg.in/yaml.v3"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// redactedValue replaces secrets in a redacted config
+const redactedValue = "******"
+
+// setting is a leaf of the config tree, described by the tags of its field
+type setting struct {
+	// Key is the dotted path of the setting in the config file, like server.port
+	Key   string
+	Env   string
+	Usage string
+	// Secret is "true" for values hidden whole when redacted, "url" for urls of which only the password is
+	Secret string
+	Rules  string
+	Value  reflect.Value
+}
+
+// settings lists the leaves of cfg, which they point into
+func settings(cfg *AppConfig) []setting {
+	return walk(reflect.ValueOf(cfg).Elem(), "")
+}
+
+func walk(v reflect.Value, prefix string) []setting {
+	out := make([]setting, 0)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		key := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if key == "" || key == "-" {
+			continue
+		}
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		if f.Type.Kind() == reflect.Struct {
+			out = append(out, walk(v.Field(i), key)...)
+			continue
+		}
+
+		out = append(out, setting{
+			Key:    key,
+			Env:    f.Tag.Get("env"),
+			Usage:  f.Tag.Get("usage"),
+			Secret: f.Tag.Get("secret"),
+			Rules:  f.Tag.Get("validate"),
+			Value:  v.Field(i),
+		})
+	}
+
+	return out
+}
+
+func (s setting) flagName() string {
+	return strings.NewReplacer(".", "-", "_", "-").Replace(s.Key)
+}
+
+func (s setting) register(fs *pflag.FlagSet) {
+	usage := s.Usage
+	if s.Env != "" {
+		usage = fmt.Sprintf("%s, also set by %s", usage, s.Env)
+	}
+
+	switch s.Value.Kind() {
+	case reflect.String:
+		fs.String(s.flagName(), s.Value.String(), usage)
+	case reflect.Int:
+		fs.Int(s.flagName(), int(s.Value.Int()), usage)
+	case reflect.Bool:
+		fs.Bool(s.flagName(), s.Value.Bool(), usage)
+	}
+}
+
+// set parses raw into the setting
+func (s setting) set(raw string) error {
+	switch s.Value.Kind() {
+	case reflect.String:
+		s.Value.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", raw)
+		}
+		s.Value.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", raw)
+		}
+		s.Value.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported setting type %s", s.Value.Kind())
+	}
+
+	return nil
+}
+
+// check validates the setting against its rules, returning why it is invalid
+func (s setting) check() string {
+	if s.Rules == "" {
+		return ""
+	}
+
+	err := validator.Valid(s.Value.Interface(), s.Rules)
+	if err == nil {
+		return ""
+	}
+	if errs, ok := err.(validator.ErrorArray); ok && len(errs) > 0 {
+		err = errs[0]
+	}
+
+	switch err {
+	case validator.ErrZeroValue:
+		return "is required"
+	case validator.ErrMin:
+		return fmt.Sprintf("must be at least %s", ruleArg(s.Rules, "min"))
+	case validator.ErrMax:
+		return fmt.Sprintf("must be at most %s", ruleArg(s.Rules, "max"))
+	case validator.ErrRegexp:
+		return fmt.Sprintf("must match %s", ruleArg(s.Rules, "regexp"))
+	}
+
+	return err.Error()
+}
+
+func ruleArg(rules, name string) string {
+	for _, r := range strings.Split(rules, ",") {
+		if strings.HasPrefix(r, name+"=") {
+			return strings.TrimPrefix(r, name+"=")
+		}
+	}
+
+	return ""
+}
+
+// readFile reads a yaml or toml config file over cfg, refusing keys it does not know
+func readFile(path string, cfg *AppConfig) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		dec := yaml.NewDecoder(f)
+		dec.KnownFields(true)
+		err = dec.Decode(cfg)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+	case ".toml":
+		md, err := toml.DecodeFile(path, cfg)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		if unknown := md.Undecoded(); len(unknown) > 0 {
+			keys := make([]string, 0, len(unknown))
+			for _, k := range unknown {
+				keys = append(keys, k.String())
+			}
+			return fmt.Errorf("%s: unknown keys %s", path, strings.Join(keys, ", "))
+		}
+	default:
+		return fmt.Errorf("%s: config files must be yaml or toml", path)
+	}
+
+	return nil
+}
+
+// Redacted returns a copy of c with its secrets hidden, for printing
+func (c *AppConfig) Redacted() *AppConfig {
+	cp := *c
+	for _, s := range settings(&cp) {
+		if s.Secret == "" || s.Value.Kind() != reflect.String || s.Value.String() == "" {
+			continue
+		}
+
+		if s.Secret == "url" {
+			if u, err := url.Parse(s.Value.String()); err == nil {
+				s.Value.SetString(u.Redacted())
+				continue
+			}
+		}
+		s.Value.SetString(redactedValue)
+	}
+
+	return &cp
+}
@@ -28,16 +28,668 @@ var doc = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/api/v1/internal/addresses/geo-search": {
+            "post": {
+                "description": "Find customer addresses within radius_km of a center, nearest first, or inside a polygon of [longitude, latitude] points. Give either the center and radius or the polygon. Meant for logistics to group deliveries and check service coverage.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Internal"
+                ],
+                "summary": "Find addresses by location",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Secret of the caller, unless a client certificate is used",
+                        "name": "Secret-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "A center with a radius, or a polygon",
+                        "name": "Body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.AddressGeoSearchReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.AddressListSuccessRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or geo query (INVALID_GEO_QUERY).",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyListErrorRes"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid secret key or client certificate.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyListErrorRes"
+                        }
+                    },
+                    "403": {
+                        "description": "The caller is not allowed to use this endpoint.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyListErrorRes"
+                        }
+                    },
+                    "500": {
+                        "description": "API sever or db unreachable.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyListErrorRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/internal/customers/{username}/primary-address": {
+            "get": {
+                "description": "Get the primary address of a customer by username, for other services",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Internal"
+                ],
+                "summary": "Get the primary address of a customer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Secret of the caller, unless a client certificate is used",
+                        "name": "Secret-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Username of the customer",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.AddressSuccessRes"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid secret key or client certificate.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    },
+                    "403": {
+                        "description": "The caller is not allowed to use this endpoint.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    },
+                    "417": {
+                        "description": "The customer is yet to set a primary address (ADDRESS_NO_PRIMARY).",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    },
+                    "500": {
+                        "description": "API sever or db unreachable.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/internal/customers/{username}/restore": {
+            "post": {
+                "description": "Undo the soft delete of a customer, for back office services. Sessions revoked by the delete stay revoked.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Internal"
+                ],
+                "summary": "Restore a deleted customer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Secret of the caller, unless a client certificate is used",
+                        "name": "Secret-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Username of the customer",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.CustomerSuccessRes"
+                        }
+                    },
+                    "400": {
+                        "description": "No such customer, or the customer is not deleted (NOT_FOUND).",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid secret key or client certificate.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    },
+                    "403": {
+                        "description": "The caller is not allowed to use this endpoint.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    },
+                    "500": {
+                        "description": "API sever or db unreachable.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/internal/customers/{username}/short-profile": {
+            "get": {
+                "description": "Get the short profile of a customer by username, for other services",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Internal"
+                ],
+                "summary": "Get the short profile of a customer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Secret of the caller, unless a client certificate is used",
+                        "name": "Secret-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Username of the customer",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.CustomerResShort"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid secret key or client certificate.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    },
+                    "403": {
+                        "description": "The caller is not allowed to use this endpoint.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Customer not found.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    },
+                    "500": {
+                        "description": "API sever or db unreachable.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/internal/customers/{username}/status": {
+            "put": {
+                "description": "Move a customer to active, blocked, inactive or pending, for back office services. The caller is recorded as the actor. Blocking or deactivating the account revokes all of its sessions.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Internal"
+                ],
+                "summary": "Update the status of a customer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Secret of the caller, unless a client certificate is used",
+                        "name": "Secret-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Username of the customer",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "The new status and why",
+                        "name": "Body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.AccountStatusUpdateReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.CustomerSuccessRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body, invalid status (INVALID_STATUS) or customer not found (NOT_FOUND).",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid secret key or client certificate.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    },
+                    "403": {
+                        "description": "The caller is not allowed to use this endpoint.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    },
+                    "500": {
+                        "description": "API sever or db unreachable.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/internal/merchants/{username}/restore": {
+            "post": {
+                "description": "Undo the soft delete of a merchant, for back office services. Sessions revoked by the delete stay revoked.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Internal"
+                ],
+                "summary": "Restore a deleted merchant",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Secret of the caller, unless a client certificate is used",
+                        "name": "Secret-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Username of the merchant",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.MerchantSuccessRes"
+                        }
+                    },
+                    "400": {
+                        "description": "No such merchant, or the merchant is not deleted (NOT_FOUND).",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid secret key or client certificate.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    },
+                    "403": {
+                        "description": "The caller is not allowed to use this endpoint.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    },
+                    "500": {
+                        "description": "API sever or db unreachable.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/internal/merchants/{username}/status": {
+            "get": {
+                "description": "Get the account status of a merchant by username, for other services to check whether the merchant can do business",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Internal"
+                ],
+                "summary": "Get the status of a merchant",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Secret of the caller, unless a client certificate is used",
+                        "name": "Secret-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Username of the merchant",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.MerchantStatusSuccessRes"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid secret key or client certificate.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    },
+                    "403": {
+                        "description": "The caller is not allowed to use this endpoint.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Merchant not found.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    },
+                    "500": {
+                        "description": "API sever or db unreachable.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Move a merchant to active, blocked, inactive or pending, for back office services. The caller is recorded as the actor. Blocking or deactivating the account revokes all of its sessions.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Internal"
+                ],
+                "summary": "Update the status of a merchant",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Secret of the caller, unless a client certificate is used",
+                        "name": "Secret-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Username of the merchant",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "The new status and why",
+                        "name": "Body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.AccountStatusUpdateReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.MerchantSuccessRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body, invalid status (INVALID_STATUS) or merchant not found (NOT_FOUND).",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid secret key or client certificate.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    },
+                    "403": {
+                        "description": "The caller is not allowed to use this endpoint.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    },
+                    "500": {
+                        "description": "API sever or db unreachable.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/private/customers/address": {
             "post": {
-                "description": "Add a customer address as long as the total address count for the customer is not greater than 5",
+                "description": "Add a customer address as long as the total address count for the customer is not greater than 5. Area slugs must form a chain in the BD location presets, area names are filled from them, and coordinates must lie within Bangladesh near the chosen district.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Customers"
+                ],
+                "summary": "Add a customer address",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Set access token here",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Some fields are mandatory",
+                        "name": "Body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.AddressCreateReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/response.AddressListSuccessRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body, missing required fields, or an invalid location (INVALID_LOCATION, LOCATION_OUT_OF_BOUNDS).",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyListErrorRes"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized access attempt.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyListErrorRes"
+                        }
+                    },
+                    "500": {
+                        "description": "API sever or db unreachable.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyListErrorRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/private/customers/address/all": {
+            "get": {
+                "description": "Get all the addresses of the requesting customer",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Customers"
+                ],
+                "summary": "Get customer's addresses",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Set access token here",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.AddressListSuccessRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body, or missing required fields.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyListErrorRes"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized access attempt.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyListErrorRes"
+                        }
+                    },
+                    "500": {
+                        "description": "API sever or db unreachable.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyListErrorRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/private/customers/address/primary": {
+            "get": {
+                "description": "Get the primary address of the requesting customer",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Customers"
+                ],
+                "summary": "Get primary address",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Set access token here",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.AddressSuccessRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body, or missing required fields.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized access attempt.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    },
+                    "417": {
+                        "description": "User is yet to set a primary address",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    },
+                    "500": {
+                        "description": "API sever or db unreachable.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/private/customers/address/primary/{id}": {
+            "post": {
+                "description": "Set an address using address id as the primary address for customer. The previous primary address is demoted in the same transaction.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Customers"
                 ],
-                "summary": "Add a customer address",
+                "summary": "Set a primary address",
                 "parameters": [
                     {
                         "type": "string",
@@ -45,20 +697,11 @@ var doc = `{
                         "name": "authorization",
                         "in": "header",
                         "required": true
-                    },
-                    {
-                        "description": "Some fields are mandatory",
-                        "name": "Body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.AddressCreateReq"
-                        }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/response.AddressListSuccessRes"
                         }
@@ -84,16 +727,16 @@ var doc = `{
                 }
             }
         },
-        "/api/v1/private/customers/address/all": {
-            "get": {
-                "description": "Get all the addresses of the requesting customer",
+        "/api/v1/private/customers/address/{id}": {
+            "delete": {
+                "description": "Remove an address for customer using address id. If it was the primary address, the most recently added of the remaining addresses becomes primary.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Customers"
                 ],
-                "summary": "Get customer's addresses",
+                "summary": "Remove a customer address",
                 "parameters": [
                     {
                         "type": "string",
@@ -104,8 +747,8 @@ var doc = `{
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/response.AddressListSuccessRes"
                         }
@@ -129,18 +772,16 @@ var doc = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/private/customers/address/primary": {
-            "get": {
-                "description": "Get the primary address of the requesting customer",
+            },
+            "patch": {
+                "description": "Update an address for customer using address id. Changed area slugs and coordinates are checked together with the stored ones.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Customers"
                 ],
-                "summary": "Get primary address",
+                "summary": "Update address by id",
                 "parameters": [
                     {
                         "type": "string",
@@ -148,52 +789,55 @@ var doc = `{
                         "name": "authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "description": "Some fields are mandatory",
+                        "name": "Body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.AddressUpdateReq"
+                        }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/response.AddressSuccessRes"
+                            "$ref": "#/definitions/response.AddressListSuccessRes"
                         }
                     },
                     "400": {
-                        "description": "Invalid request body, or missing required fields.",
+                        "description": "Invalid request body, missing required fields, or an invalid location (INVALID_LOCATION, LOCATION_OUT_OF_BOUNDS).",
                         "schema": {
-                            "$ref": "#/definitions/response.EmptyErrorRes"
+                            "$ref": "#/definitions/response.EmptyListErrorRes"
                         }
                     },
                     "401": {
                         "description": "Unauthorized access attempt.",
                         "schema": {
-                            "$ref": "#/definitions/response.EmptyErrorRes"
-                        }
-                    },
-                    "417": {
-                        "description": "User is yet to set a primary address",
-                        "schema": {
-                            "$ref": "#/definitions/response.EmptyErrorRes"
+                            "$ref": "#/definitions/response.EmptyListErrorRes"
                         }
                     },
                     "500": {
                         "description": "API sever or db unreachable.",
                         "schema": {
-                            "$ref": "#/definitions/response.EmptyErrorRes"
+                            "$ref": "#/definitions/response.EmptyListErrorRes"
                         }
                     }
                 }
             }
         },
-        "/api/v1/private/customers/address/primary/{id}": {
-            "post": {
-                "description": "Set an address using address id as the primary address for customer, remove the previous primary address if needed",
+        "/api/v1/private/customers/data-export": {
+            "get": {
+                "description": "Returns customer's profile, addresses and audit history as a single JSON document",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Customers"
                 ],
-                "summary": "Set a primary address",
+                "summary": "Download personal data",
                 "parameters": [
                     {
                         "type": "string",
@@ -204,43 +848,43 @@ var doc = `{
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/response.AddressListSuccessRes"
+                            "$ref": "#/definitions/response.CustomerDataExportSuccessRes"
                         }
                     },
                     "400": {
-                        "description": "Invalid request body, or missing required fields.",
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/response.EmptyListErrorRes"
+                            "$ref": "#/definitions/response.EmptyErrorRes"
                         }
                     },
                     "401": {
                         "description": "Unauthorized access attempt.",
                         "schema": {
-                            "$ref": "#/definitions/response.EmptyListErrorRes"
+                            "$ref": "#/definitions/response.EmptyErrorRes"
                         }
                     },
                     "500": {
                         "description": "API sever or db unreachable.",
                         "schema": {
-                            "$ref": "#/definitions/response.EmptyListErrorRes"
+                            "$ref": "#/definitions/response.EmptyErrorRes"
                         }
                     }
                 }
             }
         },
-        "/api/v1/private/customers/address/{id}": {
-            "delete": {
-                "description": "Remove an address for customer using address id",
+        "/api/v1/private/customers/erasure": {
+            "post": {
+                "description": "Schedule customer's account and personal data for deletion after the grace period. Can be cancelled until then.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Customers"
                 ],
-                "summary": "Remove a customer address",
+                "summary": "Delete account",
                 "parameters": [
                     {
                         "type": "string",
@@ -251,41 +895,41 @@ var doc = `{
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "202": {
+                        "description": "Accepted",
                         "schema": {
-                            "$ref": "#/definitions/response.AddressListSuccessRes"
+                            "$ref": "#/definitions/response.CustomerErasureSuccessRes"
                         }
                     },
                     "400": {
-                        "description": "Invalid request body, or missing required fields.",
+                        "description": "Erasure already requested",
                         "schema": {
-                            "$ref": "#/definitions/response.EmptyListErrorRes"
+                            "$ref": "#/definitions/response.EmptyErrorRes"
                         }
                     },
                     "401": {
                         "description": "Unauthorized access attempt.",
                         "schema": {
-                            "$ref": "#/definitions/response.EmptyListErrorRes"
+                            "$ref": "#/definitions/response.EmptyErrorRes"
                         }
                     },
                     "500": {
                         "description": "API sever or db unreachable.",
                         "schema": {
-                            "$ref": "#/definitions/response.EmptyListErrorRes"
+                            "$ref": "#/definitions/response.EmptyErrorRes"
                         }
                     }
                 }
             },
-            "patch": {
-                "description": "Update an address for customer using address id",
+            "delete": {
+                "description": "Cancel a pending account deletion during the grace period",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Customers"
                 ],
-                "summary": "Update address by id",
+                "summary": "Cancel account deletion",
                 "parameters": [
                     {
                         "type": "string",
@@ -293,40 +937,31 @@ var doc = `{
                         "name": "authorization",
                         "in": "header",
                         "required": true
-                    },
-                    {
-                        "description": "Some fields are mandatory",
-                        "name": "Body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.AddressUpdateReq"
-                        }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/response.AddressListSuccessRes"
+                            "$ref": "#/definitions/response.EmptySuccessRes"
                         }
                     },
                     "400": {
-                        "description": "Invalid request body, or missing required fields.",
+                        "description": "No pending erasure",
                         "schema": {
-                            "$ref": "#/definitions/response.EmptyListErrorRes"
+                            "$ref": "#/definitions/response.EmptyErrorRes"
                         }
                     },
                     "401": {
                         "description": "Unauthorized access attempt.",
                         "schema": {
-                            "$ref": "#/definitions/response.EmptyListErrorRes"
+                            "$ref": "#/definitions/response.EmptyErrorRes"
                         }
                     },
                     "500": {
                         "description": "API sever or db unreachable.",
                         "schema": {
-                            "$ref": "#/definitions/response.EmptyListErrorRes"
+                            "$ref": "#/definitions/response.EmptyErrorRes"
                         }
                     }
                 }
@@ -529,13 +1164,25 @@ var doc = `{
                         "schema": {
                             "$ref": "#/definitions/response.EmptyErrorRes"
                         }
+                    },
+                    "403": {
+                        "description": "Account is blocked, inactive or pending",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    },
+                    "500": {
+                        "description": "API sever or db unreachable.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
                     }
                 }
             }
         },
         "/api/v1/private/customers/verify-token": {
             "get": {
-                "description": "verifyAccessToken lets apps to verify that a provided token is in-fact valid",
+                "description": "verifyAccessToken lets apps to verify that a provided token is in-fact valid. Services that need the claims should use /oauth/introspect instead.",
                 "consumes": [
                     "application/json"
                 ],
@@ -571,6 +1218,64 @@ var doc = `{
                 }
             }
         },
+        "/api/v1/private/logout": {
+            "post": {
+                "description": "Revokes the access token until it expires, for customers and merchants alike. Send the refresh token of the session in the body to revoke it as well. Other sessions stay logged in. Other instances of the service stop accepting the token as soon as the revocation reaches them over Redis, or within 10 seconds if that message is lost.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Log out",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Set access token here",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Optional refresh token",
+                        "name": "Body",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/model.LogoutReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptySuccessRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body, or a refresh token of another user.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized access attempt.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    },
+                    "500": {
+                        "description": "API sever or cache unreachable.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/private/merchants/password": {
             "put": {
                 "description": "Update to a new password using merchant's existing password",
@@ -768,13 +1473,25 @@ var doc = `{
                         "schema": {
                             "$ref": "#/definitions/response.EmptyErrorRes"
                         }
+                    },
+                    "403": {
+                        "description": "Account is blocked, inactive or pending",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    },
+                    "500": {
+                        "description": "API sever or db unreachable.",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
                     }
                 }
             }
         },
         "/api/v1/private/merchants/verify-token": {
             "get": {
-                "description": "verifyAccessToken lets apps to verify that a provided token is in-fact valid",
+                "description": "verifyAccessToken lets apps to verify that a provided token is in-fact valid. Services that need the claims should use /oauth/introspect instead.",
                 "consumes": [
                     "application/json"
                 ],
@@ -798,21 +1515,82 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/response.EmptySuccessRes"
+                            "$ref": "#/definitions/response.EmptySuccessRes"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/public/bd-area": {
+            "get": {
+                "description": "Get a list of BD areas under selected parent (slug value). No parent returns list of divisions. Division as parent will return districts and so on). Meta carries the dataset version presets can be cached by, and the next_cursor and prev_cursor of the pages around. Pages asked for by cursor are not counted, so their meta has no page, pages and count.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Common"
+                ],
+                "summary": "Fetch BD area presets (division, district, sub-district)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Default value: empty-string",
+                        "name": "parent",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Default value: 1, ignored along with a cursor",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Default value: 10",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor or prev_cursor of the meta of another page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.BDLocationListSuccessRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid cursor (INVALID_CURSOR).",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyListErrorRes"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/response.EmptyErrorRes"
+                            "$ref": "#/definitions/response.EmptyListErrorRes"
                         }
                     }
                 }
             }
         },
-        "/api/v1/public/bd-area": {
+        "/api/v1/public/bd-area/search": {
             "get": {
-                "description": "Get a list of BD areas under selected parent (slug value). No parent returns list of divisions. Division as parent will return districts and so on)",
+                "description": "Prefix and fuzzy search on English and Bangla names of divisions, districts, sub-districts and unions. Every result carries its ancestors, nearest first.",
                 "consumes": [
                     "application/json"
                 ],
@@ -822,23 +1600,24 @@ var doc = `{
                 "tags": [
                     "Common"
                 ],
-                "summary": "Fetch BD area presets (division, district, sub-district)",
+                "summary": "Search BD area presets",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Default value: empty-string",
-                        "name": "parent",
-                        "in": "query"
+                        "description": "Search text, in English or Bangla",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "Default value: 1",
-                        "name": "page",
+                        "type": "string",
+                        "description": "Restrict to one level: division, district, sub_district or union",
+                        "name": "type",
                         "in": "query"
                     },
                     {
                         "type": "integer",
-                        "description": "Default value: 10",
+                        "description": "Default value: 10, max: 50",
                         "name": "limit",
                         "in": "query"
                     }
@@ -847,7 +1626,13 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/response.BDLocationListSuccessRes"
+                            "$ref": "#/definitions/response.BDLocationSearchSuccessRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyListErrorRes"
                         }
                     },
                     "500": {
@@ -887,7 +1672,7 @@ var doc = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/response.EmptySuccessRes"
+                            "$ref": "#/definitions/response.OTPSentSuccessRes"
                         }
                     },
                     "400": {
@@ -948,6 +1733,12 @@ var doc = `{
                             "$ref": "#/definitions/response.EmptyErrorRes"
                         }
                     },
+                    "403": {
+                        "description": "Account is blocked, inactive or pending",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -1043,7 +1834,7 @@ var doc = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/response.EmptySuccessRes"
+                            "$ref": "#/definitions/response.OTPSentSuccessRes"
                         }
                     },
                     "400": {
@@ -1147,7 +1938,7 @@ var doc = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/response.EmptySuccessRes"
+                            "$ref": "#/definitions/response.OTPSentSuccessRes"
                         }
                     },
                     "400": {
@@ -1208,6 +1999,12 @@ var doc = `{
                             "$ref": "#/definitions/response.EmptyErrorRes"
                         }
                     },
+                    "403": {
+                        "description": "Account is blocked, inactive or pending",
+                        "schema": {
+                            "$ref": "#/definitions/response.EmptyErrorRes"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -1303,7 +2100,7 @@ var doc = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/response.EmptySuccessRes"
+                            "$ref": "#/definitions/response.OTPSentSuccessRes"
                         }
                     },
                     "400": {
@@ -1378,9 +2175,100 @@ var doc = `{
                     }
                 }
             }
+        },
+        "/oauth/introspect": {
+            "post": {
+                "description": "RFC 7662 token introspection for resource servers. Tells whether an access or refresh token of any user type is active, running the same expiry and session checks as the private apis, along with its claims. The caller authenticates with its client credentials, through HTTP Basic auth or the client_id and client_secret fields.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OAuth"
+                ],
+                "summary": "Introspect a token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Basic auth with the client id and secret",
+                        "name": "authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "The token to introspect",
+                        "name": "token",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "access_token or refresh_token",
+                        "name": "token_type_hint",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client id, unless Basic auth is used",
+                        "name": "client_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client secret, unless Basic auth is used",
+                        "name": "client_secret",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.TokenIntrospection"
+                        }
+                    },
+                    "400": {
+                        "description": "Missing token (invalid_request).",
+                        "schema": {
+                            "$ref": "#/definitions/model.OAuthError"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid client credentials (invalid_client).",
+                        "schema": {
+                            "$ref": "#/definitions/model.OAuthError"
+                        }
+                    }
+                }
+            }
         }
     },
     "definitions": {
+        "http_error.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.AccountStatusUpdateReq": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "active/blocked/inactive/pending"
+                }
+            }
+        },
         "model.Address": {
             "type": "object",
             "properties": {
@@ -1414,6 +2302,10 @@ var doc = `{
                 "latitude": {
                     "type": "number"
                 },
+                "location": {
+                    "description": "Location mirrors Longitude and Latitude as GeoJSON, for the 2dsphere index",
+                    "$ref": "#/definitions/model.GeoPoint"
+                },
                 "longitude": {
                     "type": "number"
                 },
@@ -1484,6 +2376,36 @@ var doc = `{
                 }
             }
         },
+        "model.AddressGeoSearchReq": {
+            "type": "object",
+            "properties": {
+                "latitude": {
+                    "type": "number",
+                    "example": 23.8103
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 100
+                },
+                "longitude": {
+                    "type": "number",
+                    "example": 90.4125
+                },
+                "polygon": {
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "number"
+                        }
+                    }
+                },
+                "radius_km": {
+                    "type": "number",
+                    "example": 5
+                }
+            }
+        },
         "model.AddressUpdateReq": {
             "type": "object",
             "properties": {
@@ -1531,12 +2453,84 @@ var doc = `{
                 }
             }
         },
+        "model.AuditEvent": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "user_type": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
         "model.BDLocation": {
             "type": "object",
             "properties": {
                 "id": {
                     "type": "integer"
                 },
+                "latitude": {
+                    "description": "Latitude and Longitude hold the centroid, where known",
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "name_bn": {
+                    "type": "string"
+                },
+                "parent": {
+                    "type": "string"
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.BDLocationSearchResult": {
+            "type": "object",
+            "properties": {
+                "ancestors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.BDLocation"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "latitude": {
+                    "description": "Latitude and Longitude hold the centroid, where known",
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                },
                 "name": {
                     "type": "string"
                 },
@@ -1566,6 +2560,12 @@ var doc = `{
                 "email": {
                     "type": "string"
                 },
+                "erasure_requested_at": {
+                    "type": "string"
+                },
+                "erasure_scheduled_at": {
+                    "type": "string"
+                },
                 "full_name": {
                     "type": "string"
                 },
@@ -1593,6 +2593,12 @@ var doc = `{
                 "status": {
                     "type": "string"
                 },
+                "status_changed_at": {
+                    "type": "string"
+                },
+                "status_reason": {
+                    "type": "string"
+                },
                 "updated_at": {
                     "type": "string"
                 },
@@ -1601,6 +2607,43 @@ var doc = `{
                 }
             }
         },
+        "model.CustomerDataExport": {
+            "type": "object",
+            "properties": {
+                "addresses": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Address"
+                    }
+                },
+                "audit_history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.AuditEvent"
+                    }
+                },
+                "exported_at": {
+                    "type": "string"
+                },
+                "profile": {
+                    "$ref": "#/definitions/model.Customer"
+                }
+            }
+        },
+        "model.CustomerErasureRes": {
+            "type": "object",
+            "properties": {
+                "erasure_requested_at": {
+                    "type": "string"
+                },
+                "erasure_scheduled_at": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
         "model.CustomerProfileUpdateReq": {
             "type": "object",
             "properties": {
@@ -1629,6 +2672,23 @@ var doc = `{
                 }
             }
         },
+        "model.CustomerShort": {
+            "type": "object",
+            "properties": {
+                "full_name": {
+                    "type": "string"
+                },
+                "gender": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
         "model.CustomerSignupReq": {
             "type": "object",
             "properties": {
@@ -1660,16 +2720,42 @@ var doc = `{
                 }
             }
         },
-        "model.EmptyObject": {
-            "type": "object"
-        },
-        "model.ForgotPasswordReq": {
+        "model.EmptyObject": {
+            "type": "object"
+        },
+        "model.ForgotPasswordReq": {
+            "type": "object",
+            "properties": {
+                "captcha_id": {
+                    "type": "string"
+                },
+                "captcha_value": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.GeoPoint": {
+            "type": "object",
+            "properties": {
+                "coordinates": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "type": {
+                    "type": "string",
+                    "example": "Point"
+                }
+            }
+        },
+        "model.LoginReq": {
             "type": "object",
             "properties": {
-                "captcha_id": {
-                    "type": "string"
-                },
-                "captcha_value": {
+                "password": {
                     "type": "string"
                 },
                 "username": {
@@ -1677,13 +2763,10 @@ var doc = `{
                 }
             }
         },
-        "model.LoginReq": {
+        "model.LogoutReq": {
             "type": "object",
             "properties": {
-                "password": {
-                    "type": "string"
-                },
-                "username": {
+                "refresh_token": {
                     "type": "string"
                 }
             }
@@ -1727,6 +2810,12 @@ var doc = `{
                 "status": {
                     "type": "string"
                 },
+                "status_changed_at": {
+                    "type": "string"
+                },
+                "status_reason": {
+                    "type": "string"
+                },
                 "updated_at": {
                     "type": "string"
                 },
@@ -1794,6 +2883,38 @@ var doc = `{
                 }
             }
         },
+        "model.MerchantStatus": {
+            "type": "object",
+            "properties": {
+                "is_active": {
+                    "type": "boolean"
+                },
+                "is_verified": {
+                    "type": "boolean"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "status_reason": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.OAuthError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "invalid_client"
+                },
+                "error_description": {
+                    "type": "string"
+                }
+            }
+        },
         "model.SetPasswordReq": {
             "type": "object",
             "properties": {
@@ -1819,6 +2940,47 @@ var doc = `{
                 }
             }
         },
+        "model.TokenIntrospection": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "exp": {
+                    "type": "integer",
+                    "example": 1609459200
+                },
+                "iat": {
+                    "type": "integer",
+                    "example": 1609457400
+                },
+                "jti": {
+                    "description": "Jti identifies the session the token belongs to",
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string",
+                    "example": "user"
+                },
+                "sub": {
+                    "type": "string",
+                    "example": "01712345678"
+                },
+                "token_type": {
+                    "type": "string",
+                    "example": "access_token"
+                },
+                "user_type": {
+                    "type": "string",
+                    "example": "customer"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "01712345678"
+                }
+            }
+        },
         "model.UpdatePasswordReq": {
             "type": "object",
             "properties": {
@@ -1884,6 +3046,35 @@ var doc = `{
                 }
             }
         },
+        "response.BDLocationListMeta": {
+            "type": "object",
+            "properties": {
+                "Limit": {
+                    "type": "integer"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "dataset_version": {
+                    "type": "string",
+                    "example": "2021.1"
+                },
+                "next_cursor": {
+                    "type": "string",
+                    "example": "eyJmIjoiX2lkIn0"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "pages": {
+                    "type": "integer"
+                },
+                "prev_cursor": {
+                    "type": "string",
+                    "example": "eyJmIjoiX2lkIiwicCI6dHJ1ZX0"
+                }
+            }
+        },
         "response.BDLocationListSuccessRes": {
             "type": "object",
             "properties": {
@@ -1898,7 +3089,106 @@ var doc = `{
                     "example": "success message"
                 },
                 "meta": {
-                    "$ref": "#/definitions/response.ListMeta"
+                    "$ref": "#/definitions/response.BDLocationListMeta"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "OK"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "timestamp": {
+                    "type": "string",
+                    "example": "2006-01-02T15:04:05.000Z"
+                }
+            }
+        },
+        "response.BDLocationSearchSuccessRes": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.BDLocationSearchResult"
+                    }
+                },
+                "message": {
+                    "type": "string",
+                    "example": "success message"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "OK"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "timestamp": {
+                    "type": "string",
+                    "example": "2006-01-02T15:04:05.000Z"
+                }
+            }
+        },
+        "response.CustomerDataExportSuccessRes": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/model.CustomerDataExport"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "success message"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "OK"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "timestamp": {
+                    "type": "string",
+                    "example": "2006-01-02T15:04:05.000Z"
+                }
+            }
+        },
+        "response.CustomerErasureSuccessRes": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/model.CustomerErasureRes"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "success message"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "Accepted"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "timestamp": {
+                    "type": "string",
+                    "example": "2006-01-02T15:04:05.000Z"
+                }
+            }
+        },
+        "response.CustomerResShort": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/model.CustomerShort"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "failure message"
                 },
                 "status": {
                     "type": "string",
@@ -1941,13 +3231,29 @@ var doc = `{
         "response.EmptyErrorRes": {
             "type": "object",
             "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "ACCOUNT_BLOCKED"
+                },
                 "data": {
                     "$ref": "#/definitions/model.EmptyObject"
                 },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/http_error.FieldError"
+                    }
+                },
                 "message": {
                     "type": "string",
                     "example": "failure message"
                 },
+                "meta": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
                 "status": {
                     "type": "string",
                     "example": "Status string corresponding to the error"
@@ -1965,16 +3271,32 @@ var doc = `{
         "response.EmptyListErrorRes": {
             "type": "object",
             "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "ACCOUNT_BLOCKED"
+                },
                 "data": {
                     "type": "array",
                     "items": {
                         "$ref": "#/definitions/model.EmptyObject"
                     }
                 },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/http_error.FieldError"
+                    }
+                },
                 "message": {
                     "type": "string",
                     "example": "failure message"
                 },
+                "meta": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
                 "status": {
                     "type": "string",
                     "example": "Status string corresponding to the error"
@@ -2022,11 +3344,43 @@ var doc = `{
                 "count": {
                     "type": "integer"
                 },
+                "next_cursor": {
+                    "type": "string",
+                    "example": "eyJmIjoiX2lkIn0"
+                },
                 "page": {
                     "type": "integer"
                 },
                 "pages": {
                     "type": "integer"
+                },
+                "prev_cursor": {
+                    "type": "string",
+                    "example": "eyJmIjoiX2lkIiwicCI6dHJ1ZX0"
+                }
+            }
+        },
+        "response.MerchantStatusSuccessRes": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/model.MerchantStatus"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "success message"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "OK"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "timestamp": {
+                    "type": "string",
+                    "example": "2006-01-02T15:04:05.000Z"
                 }
             }
         },
@@ -2054,6 +3408,42 @@ var doc = `{
                 }
             }
         },
+        "response.OTPMeta": {
+            "type": "object",
+            "properties": {
+                "otp": {
+                    "type": "string",
+                    "example": "12345"
+                }
+            }
+        },
+        "response.OTPSentSuccessRes": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/model.EmptyObject"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "success message"
+                },
+                "meta": {
+                    "$ref": "#/definitions/response.OTPMeta"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "Created"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "timestamp": {
+                    "type": "string",
+                    "example": "2006-01-02T15:04:05.000Z"
+                }
+            }
+        },
         "response.TokenSuccessRes": {
             "type": "object",
             "properties": {
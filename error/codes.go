@@ -0,0 +1,115 @@
+package http_error
+
+import "net/http"
+
+// Stable, machine-readable error codes. Clients should branch on these instead of messages,
+// which are meant for humans and may change or be translated.
+const (
+	CodeInternal          = "INTERNAL_ERROR"
+	CodeValidationFailed  = "VALIDATION_FAILED"
+	CodeInvalidJSON       = "INVALID_JSON"
+	CodeNotFound          = "NOT_FOUND"
+	CodeNothingToUpdate   = "NOTHING_TO_UPDATE"
+	CodeRateLimited       = "RATE_LIMITED"
+	CodeConcurrentRequest = "CONCURRENT_REQUEST"
+	CodeCaptchaFailed     = "CAPTCHA_FAILED"
+
+	CodeAuthTokenMissing       = "AUTH_TOKEN_MISSING"
+	CodeAuthTokenInvalid       = "AUTH_TOKEN_INVALID"
+	CodeAuthTokenExpired       = "AUTH_TOKEN_EXPIRED"
+	CodeAuthTokenRevoked       = "AUTH_TOKEN_REVOKED"
+	CodeAuthSessionExpired     = "AUTH_SESSION_EXPIRED"
+	CodeAuthInvalidCredentials = "AUTH_INVALID_CREDENTIALS"
+	CodeAuthForbidden          = "AUTH_FORBIDDEN"
+
+	CodeOTPRateLimited = "OTP_RATE_LIMITED"
+	CodeOTPExpired     = "OTP_EXPIRED"
+	CodeOTPIncorrect   = "OTP_INCORRECT"
+
+	CodeAccountExists   = "ACCOUNT_EXISTS"
+	CodeAccountBlocked  = "ACCOUNT_BLOCKED"
+	CodeAccountInactive = "ACCOUNT_INACTIVE"
+	CodeAccountPending  = "ACCOUNT_PENDING"
+	CodeInvalidPhone    = "INVALID_PHONE_NUMBER"
+	CodeWeakPassword    = "WEAK_PASSWORD"
+	CodeInvalidStatus   = "INVALID_STATUS"
+
+	CodeErasurePending    = "ERASURE_ALREADY_REQUESTED"
+	CodeErasureNotPending = "ERASURE_NOT_REQUESTED"
+
+	CodeAddressLimitReached = "ADDRESS_LIMIT_REACHED"
+	CodeAddressNotFound     = "ADDRESS_NOT_FOUND"
+	CodeAddressNoPrimary    = "ADDRESS_NO_PRIMARY"
+	CodeInvalidLocation     = "INVALID_LOCATION"
+	CodeLocationOutOfBounds = "LOCATION_OUT_OF_BOUNDS"
+	CodeInvalidID           = "INVALID_ID"
+	CodeInvalidGeoQuery     = "INVALID_GEO_QUERY"
+	CodeInvalidCursor       = "INVALID_CURSOR"
+)
+
+// catalogue holds a short, human-readable title for every error code
+var catalogue = map[string]string{
+	CodeInternal:               "Internal server error",
+	CodeValidationFailed:       "Validation failed",
+	CodeInvalidJSON:            "Invalid JSON",
+	CodeNotFound:               "Not found",
+	CodeNothingToUpdate:        "Nothing to update",
+	CodeRateLimited:            "Too many requests",
+	CodeConcurrentRequest:      "Concurrent request",
+	CodeCaptchaFailed:          "Captcha verification failed",
+	CodeAuthTokenMissing:       "Missing token",
+	CodeAuthTokenInvalid:       "Invalid token",
+	CodeAuthTokenExpired:       "Token expired",
+	CodeAuthTokenRevoked:       "Token revoked",
+	CodeAuthSessionExpired:     "Session expired",
+	CodeAuthInvalidCredentials: "Incorrect username or password",
+	CodeAuthForbidden:          "Forbidden",
+	CodeOTPRateLimited:         "Too many OTP requests",
+	CodeOTPExpired:             "OTP expired",
+	CodeOTPIncorrect:           "Incorrect OTP",
+	CodeAccountExists:          "Account already exists",
+	CodeAccountBlocked:         "Account blocked",
+	CodeAccountInactive:        "Account inactive",
+	CodeAccountPending:         "Account pending",
+	CodeInvalidPhone:           "Invalid phone number",
+	CodeWeakPassword:           "Weak password",
+	CodeInvalidStatus:          "Invalid status",
+	CodeErasurePending:         "Account erasure already requested",
+	CodeErasureNotPending:      "No pending account erasure",
+	CodeAddressLimitReached:    "Address limit reached",
+	CodeAddressNotFound:        "Address not found",
+	CodeAddressNoPrimary:       "No primary address",
+	CodeInvalidLocation:        "Invalid location",
+	CodeLocationOutOfBounds:    "Location out of bounds",
+	CodeInvalidID:              "Invalid ID",
+	CodeInvalidGeoQuery:        "Invalid geo query",
+	CodeInvalidCursor:          "Invalid cursor",
+}
+
+// Title returns the human-readable title of code, falling back to the http status text
+func Title(code string, status int) string {
+	if t, ok := catalogue[code]; ok {
+		return t
+	}
+
+	return http.StatusText(status)
+}
+
+// Localized is implemented by errors whose message can be rendered in another language
+type Localized interface {
+	LocalizedMessage(lang string) string
+}
+
+// Coded is implemented by errors that carry an error code
+type Coded interface {
+	ErrorCode() string
+}
+
+// CodeOf returns the error code carried by err, or an empty string
+func CodeOf(err error) string {
+	if c, ok := err.(Coded); ok {
+		return c.ErrorCode()
+	}
+
+	return ""
+}
@@ -1,8 +1,11 @@
 package http_error
 
+import "github.com/iamrz1/ab-auth/i18n"
+
 // GenericHttpError represents Validation Error
 type GenericHttpError struct {
 	code    int
+	errCode string
 	message string
 }
 
@@ -14,10 +17,29 @@ func NewGenericError(code int, message string) GenericHttpError {
 	}
 }
 
+// NewCodedError returns a Generics error carrying a machine-readable error code
+func NewCodedError(code int, errCode, message string) GenericHttpError {
+	return GenericHttpError{
+		code:    code,
+		errCode: errCode,
+		message: message,
+	}
+}
+
 func (ge GenericHttpError) Error() string {
-	return ge.message
+	return ge.LocalizedMessage(i18n.English)
+}
+
+// LocalizedMessage returns the message translated into lang
+func (ge GenericHttpError) LocalizedMessage(lang string) string {
+	return i18n.T(lang, ge.message)
 }
 
 func (ge GenericHttpError) Code() int {
 	return ge.code
 }
+
+// ErrorCode returns the machine-readable error code, if any
+func (ge GenericHttpError) ErrorCode() string {
+	return ge.errCode
+}
@@ -16,3 +16,10 @@ func TestNewGenericError(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestNewCodedError(t *testing.T) {
+	err := NewCodedError(Code, "ACCOUNT_BLOCKED", Message)
+	if err.Code() != Code || err.ErrorCode() != "ACCOUNT_BLOCKED" || err.Error() != Message {
+		t.Fail()
+	}
+}
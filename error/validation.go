@@ -2,39 +2,91 @@ package http_error
 
 import (
 	"fmt"
+	"github.com/iamrz1/ab-auth/i18n"
+	"gopkg.in/validator.v2"
+	"sort"
 )
 
 // ValidationError represents Validation Error
 type ValidationError struct {
 	message string
 	err     error
+	errCode string
+	fields  []FieldError
+	args    []interface{}
 }
 
-// NewValidationError returns a validation error
+// FieldError describes why a single request field failed validation
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// NewValidationError returns a validation error. The error code is inherited from err
+// when it carries one, field errors are extracted when err comes from validator.v2.
 func NewValidationError(message string, err error) ValidationError {
+	errCode := CodeOf(err)
+	if errCode == "" {
+		errCode = CodeValidationFailed
+	}
+
 	return ValidationError{
 		message: message,
 		err:     err,
+		errCode: errCode,
+		fields:  fieldErrors(err),
 	}
 }
 
+// NewCodedValidationError returns a validation error with a specific error code
+func NewCodedValidationError(errCode, message string, err error) ValidationError {
+	ve := NewValidationError(message, err)
+	ve.errCode = errCode
+	return ve
+}
+
+// WithArgs returns a copy of the error whose message is formatted with args
+func (ve ValidationError) WithArgs(args ...interface{}) ValidationError {
+	ve.args = args
+	return ve
+}
+
 func (ve ValidationError) Error() string {
 	if ve.err != nil {
 		return fmt.Sprintf("%s", ve.err)
 	}
 
-	return ve.message
+	return i18n.T(i18n.English, ve.message, ve.args...)
 }
 
 func (ve ValidationError) ErrorMessage() string {
 	if ve.err != nil {
 		if ve.message != "" {
-			return fmt.Sprintf("%s:%v", ve.message, ve.err)
+			return fmt.Sprintf("%s:%v", i18n.T(i18n.English, ve.message, ve.args...), ve.err)
 		}
 		return ve.err.Error()
 	}
 
-	return ve.message
+	return i18n.T(i18n.English, ve.message, ve.args...)
+}
+
+// LocalizedMessage returns the message translated into lang. Wrapped errors are only
+// appended when there are no field errors, which describe the problem on their own.
+func (ve ValidationError) LocalizedMessage(lang string) string {
+	msg := i18n.T(lang, ve.message, ve.args...)
+	if ve.err == nil || (len(ve.fields) > 0 && msg != "") {
+		return msg
+	}
+
+	inner := ve.err.Error()
+	if l, ok := ve.err.(Localized); ok {
+		inner = l.LocalizedMessage(lang)
+	}
+	if msg == "" {
+		return inner
+	}
+
+	return fmt.Sprintf("%s:%s", msg, inner)
 }
 
 // GetMessage returns error message
@@ -46,3 +98,35 @@ func (ve ValidationError) GetMessage() string {
 func (ve ValidationError) GetError() error {
 	return ve.err
 }
+
+// ErrorCode returns the machine-readable error code
+func (ve ValidationError) ErrorCode() string {
+	return ve.errCode
+}
+
+// FieldErrors returns per-field validation errors, if any
+func (ve ValidationError) FieldErrors() []FieldError {
+	return ve.fields
+}
+
+func fieldErrors(err error) []FieldError {
+	errMap, ok := err.(validator.ErrorMap)
+	if !ok {
+		return nil
+	}
+
+	fields := make([]string, 0, len(errMap))
+	for f := range errMap {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+
+	res := make([]FieldError, 0)
+	for _, f := range fields {
+		for _, e := range errMap[f] {
+			res = append(res, FieldError{Field: f, Message: e.Error()})
+		}
+	}
+
+	return res
+}
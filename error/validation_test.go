@@ -2,6 +2,9 @@ package http_error
 
 import (
 	"fmt"
+	"github.com/iamrz1/ab-auth/i18n"
+	"gopkg.in/validator.v2"
+	"net/http"
 	"testing"
 )
 
@@ -20,3 +23,49 @@ func TestNewValidationError(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestNewValidationError_Codes(t *testing.T) {
+	err := NewValidationError(ValidationMessage, nil)
+	if err.ErrorCode() != CodeValidationFailed {
+		t.Fail()
+	}
+
+	err = NewValidationError("", NewCodedError(http.StatusBadRequest, CodeOTPExpired, "OTP expired"))
+	if err.ErrorCode() != CodeOTPExpired {
+		t.Fail()
+	}
+
+	err = NewCodedValidationError(CodeInvalidJSON, ValidationMessage, nil)
+	if err.ErrorCode() != CodeInvalidJSON {
+		t.Fail()
+	}
+}
+
+func TestNewValidationError_FieldErrors(t *testing.T) {
+	type req struct {
+		Username string `json:"username" validate:"nonzero"`
+		Password string `json:"password" validate:"nonzero"`
+	}
+
+	err := NewValidationError("Missing required field(s)", validator.WithPrintJSON(true).Validate(req{}))
+	fields := err.FieldErrors()
+	if len(fields) != 2 || fields[0].Field != "password" || fields[1].Field != "username" {
+		t.Fail()
+	}
+}
+
+func TestValidationError_LocalizedMessage(t *testing.T) {
+	err := NewValidationError("", NewCodedValidationError(CodeWeakPassword, i18n.MsgPasswordTooShort, nil))
+	if err.LocalizedMessage(i18n.English) != "Must be at least 8 characters long" {
+		t.Fail()
+	}
+
+	if err.LocalizedMessage(i18n.Bangla) != i18n.T(i18n.Bangla, i18n.MsgPasswordTooShort) {
+		t.Fail()
+	}
+
+	err = NewCodedValidationError(CodeAddressLimitReached, i18n.MsgAddressLimitReached, nil).WithArgs(5)
+	if err.Error() != "Maximum 5 addresses are allowed" {
+		t.Fail()
+	}
+}
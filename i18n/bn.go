@@ -0,0 +1,109 @@
+package i18n
+
+// bn is the Bangla bundle
+var bn = map[string]string{
+	MsgOK:               "ঠিক আছে",
+	MsgSuccessful:       "সফল হয়েছে",
+	MsgListFetched:      "তালিকা পাওয়া গেছে",
+	MsgLoggedIn:         "লগইন সফল হয়েছে",
+	MsgVerified:         "যাচাই সম্পন্ন হয়েছে",
+	MsgOTPSent:          "ওটিপি পাঠানো হয়েছে",
+	MsgPasswordSet:      "পাসওয়ার্ড সেট করা হয়েছে",
+	MsgPasswordUpdated:  "পাসওয়ার্ড হালনাগাদ করা হয়েছে",
+	MsgProfileUpdated:   "প্রোফাইল হালনাগাদ করা হয়েছে",
+	MsgTokenRefreshed:   "টোকেন রিফ্রেশ করা হয়েছে",
+	MsgTokenVerified:    "টোকেন যাচাই করা হয়েছে",
+	MsgLoggedOut:        "লগ আউট করা হয়েছে",
+	MsgAddressCreated:   "ঠিকানা যোগ করা হয়েছে",
+	MsgAddressUpdated:   "ঠিকানা হালনাগাদ করা হয়েছে",
+	MsgAddressRemoved:   "ঠিকানা মুছে ফেলা হয়েছে",
+	MsgErasureScheduled: "অ্যাকাউন্টটি মুছে ফেলার জন্য নির্ধারিত হয়েছে",
+	MsgErasureCancelled: "অ্যাকাউন্ট মুছে ফেলার অনুরোধ বাতিল করা হয়েছে",
+	MsgCustomerPurged:   "গ্রাহককে স্থায়ীভাবে মুছে ফেলা হয়েছে",
+	MsgMerchantPurged:   "মার্চেন্টকে স্থায়ীভাবে মুছে ফেলা হয়েছে",
+	MsgStatusUpdated:    "অ্যাকাউন্টের স্ট্যাটাস হালনাগাদ করা হয়েছে",
+	MsgCustomerRestored: "গ্রাহককে পুনরুদ্ধার করা হয়েছে",
+	MsgMerchantRestored: "মার্চেন্টকে পুনরুদ্ধার করা হয়েছে",
+
+	MsgSomethingWentWrong:      "কোনো একটি সমস্যা হয়েছে",
+	MsgNotReady:                "অনুরোধ গ্রহণের জন্য প্রস্তুত নয়",
+	MsgInvalidJSON:             "JSON সঠিক নয়",
+	MsgMissingRequiredFields:   "প্রয়োজনীয় তথ্য অনুপস্থিত",
+	MsgNothingToCreate:         "তৈরি করার মতো কিছু নেই",
+	MsgNothingToUpdate:         "হালনাগাদ করার মতো কিছু নেই",
+	MsgTryAgainShortly:         "কয়েক সেকেন্ড পর আবার চেষ্টা করুন",
+	MsgTryAgainLater:           "কিছুক্ষণ পর আবার চেষ্টা করুন",
+	MsgTryAgainTomorrow:        "২৪ ঘণ্টা পর আবার চেষ্টা করুন",
+	MsgCaptchaFailed:           "ক্যাপচা যাচাই ব্যর্থ হয়েছে",
+	MsgMissingAccessToken:      "অ্যাক্সেস টোকেন পাওয়া যায়নি",
+	MsgMissingRefreshToken:     "রিফ্রেশ টোকেন পাওয়া যায়নি",
+	MsgInvalidToken:            "টোকেনটি সঠিক নয়",
+	MsgTokenExpired:            "টোকেনের মেয়াদ শেষ হয়ে গেছে",
+	MsgTokenRevoked:            "টোকেন বাতিল করা হয়েছে, আবার লগ ইন করুন",
+	MsgTokenSessionMismatch:    "রিফ্রেশ টোকেনটি অন্য ব্যবহারকারীর",
+	MsgInertToken:              "টোকেনটি আর কার্যকর নয়",
+	MsgSessionExpired:          "সেশনের মেয়াদ শেষ হয়ে গেছে",
+	MsgMissingUsername:         "ব্যবহারকারীর নাম পাওয়া যায়নি",
+	MsgInvalidUser:             "ব্যবহারকারী সঠিক নয়",
+	MsgNotACustomer:            "আপনি গ্রাহক নন",
+	MsgNotAMerchant:            "আপনি মার্চেন্ট নন",
+	MsgForbidden:               "আপনার এটি করার অনুমতি নেই",
+	MsgCustomersOnly:           "শুধুমাত্র গ্রাহকদের জন্য",
+	MsgMerchantsOnly:           "শুধুমাত্র মার্চেন্টদের জন্য",
+	MsgIncorrectCredentials:    "ব্যবহারকারীর নাম অথবা পাসওয়ার্ড ভুল",
+	MsgIncorrectPassword:       "পাসওয়ার্ড ভুল",
+	MsgUserExists:              "এই ব্যবহারকারী আগে থেকেই নিবন্ধিত",
+	MsgInvalidPhone:            "ফোন নম্বরটি সঠিক নয়",
+	MsgInvalidGender:           "লিঙ্গ সঠিক নয়",
+	MsgInvalidStatus:           "স্ট্যাটাস সঠিক নয়",
+	MsgPasswordInvalidChar:     "পাসওয়ার্ডে অগ্রহণযোগ্য অক্ষর রয়েছে",
+	MsgPasswordSpecialChar:     "অন্তত একটি বিশেষ অক্ষর থাকতে হবে",
+	MsgPasswordTooShort:        "অন্তত ৮ অক্ষরের হতে হবে",
+	MsgAccountBlocked:          "অ্যাকাউন্টটি ব্লক করা হয়েছে",
+	MsgAccountInactive:         "অ্যাকাউন্টটি নিষ্ক্রিয়",
+	MsgAccountPending:          "অ্যাকাউন্টটি অনুমোদনের অপেক্ষায় আছে",
+	MsgNoDeletedCustomer:       "মুছে ফেলা কোনো গ্রাহক পাওয়া যায়নি",
+	MsgNoDeletedMerchant:       "মুছে ফেলা কোনো মার্চেন্ট পাওয়া যায়নি",
+	MsgErasureAlreadyRequested: "অ্যাকাউন্ট মুছে ফেলার অনুরোধ আগেই করা হয়েছে",
+	MsgNoPendingErasure:        "অ্যাকাউন্ট মুছে ফেলার কোনো অপেক্ষমাণ অনুরোধ নেই",
+	MsgOTPInProgress:           "একসাথে একাধিক ওটিপির অনুরোধ করা যাবে না",
+	MsgOTPRequestFailed:        "ওটিপির অনুরোধ ব্যর্থ হয়েছে",
+	MsgOTPVerificationFailed:   "ওটিপি যাচাই ব্যর্থ হয়েছে",
+	MsgOTPMatchFailed:          "ওটিপি মেলানো যায়নি",
+	MsgOTPIncorrect:            "ওটিপি ভুল",
+	MsgOTPExpired:              "ওটিপির মেয়াদ শেষ হয়ে গেছে",
+	MsgAddressLimitReached:     "সর্বোচ্চ %dটি ঠিকানা রাখা যাবে",
+	MsgInvalidAddressID:        "ঠিকানার আইডি সঠিক নয়",
+	MsgUnknownAddressID:        "অজানা ঠিকানার আইডি",
+	MsgAddressNotFound:         "ঠিকানা পাওয়া যায়নি",
+	MsgNoDefaultAddress:        "কোনো ডিফল্ট ঠিকানা নেই",
+	MsgMissingSearchQuery:      "অনুসন্ধানের জন্য কিছু লিখুন",
+	MsgInvalidLocationType:     "এলাকার ধরন সঠিক নয়",
+	MsgInvalidAddressLocation:  "নির্বাচিত এলাকাগুলো পরস্পরের সাথে মেলেনি",
+	MsgAddressOutOfBounds:      "ঠিকানার স্থানাঙ্ক সীমার বাইরে",
+	MsgLocationRequired:        "আবশ্যক",
+	MsgUnknownLocation:         "অজানা এলাকা",
+	MsgLocationParentMismatch:  "নির্বাচিত উপরের এলাকার অন্তর্ভুক্ত নয়",
+	MsgOutsideBangladesh:       "বাংলাদেশের বাইরে",
+	MsgTooFarFromDistrict:      "নির্বাচিত জেলা থেকে অনেক দূরে",
+	MsgInvalidSecret:           "সিক্রেট কী বা ক্লায়েন্ট সার্টিফিকেট সঠিক নয় বা পাওয়া যায়নি",
+	MsgEndpointNotAllowed:      "এই কলারের জন্য এই এন্ডপয়েন্ট অনুমোদিত নয়",
+	MsgCustomerNotFound:        "গ্রাহক পাওয়া যায়নি",
+	MsgMerchantNotFound:        "মার্চেন্ট পাওয়া যায়নি",
+	MsgInvalidGeoQuery:         "ভৌগোলিক অনুসন্ধান সঠিক নয়",
+	MsgRadiusOrPolygon:         "কেন্দ্র ও ব্যাসার্ধ অথবা একটি বহুভুজ দিন",
+	MsgRadiusTooLarge:          "ব্যাসার্ধ অনেক বড়",
+	MsgPolygonTooSmall:         "বহুভুজে অন্তত ৩টি বিন্দু প্রয়োজন",
+	MsgInvalidCoordinate:       "স্থানাঙ্ক সঠিক নয়",
+	MsgInvalidCursor:           "কার্সরটি সঠিক নয় অথবা অন্য তালিকার, প্রথম পৃষ্ঠা থেকে আবার শুরু করুন",
+
+	MsgOTPSignup:         "আপনার যাচাইকরণ কোড %s। কোডটি %d মিনিট পর্যন্ত কার্যকর থাকবে।",
+	MsgOTPForgotPassword: "আপনার পাসওয়ার্ড রিসেট কোড %s। কোডটি %d মিনিট পর্যন্ত কার্যকর থাকবে। কারও সাথে শেয়ার করবেন না।",
+
+	MsgZeroValue:      "খালি রাখা যাবে না",
+	MsgLessThanMin:    "সর্বনিম্ন সীমার চেয়ে কম",
+	MsgGreaterThanMax: "সর্বোচ্চ সীমার চেয়ে বেশি",
+	MsgInvalidLength:  "দৈর্ঘ্য সঠিক নয়",
+	MsgRegexpMismatch: "নির্ধারিত ফরম্যাটের সাথে মেলেনি",
+	MsgInvalidValue:   "মান সঠিক নয়",
+}
@@ -0,0 +1,109 @@
+package i18n
+
+// en is the English bundle, it doubles as the fallback for missing translations
+var en = map[string]string{
+	MsgOK:               "OK",
+	MsgSuccessful:       "Successful",
+	MsgListFetched:      "Success. Very nice!",
+	MsgLoggedIn:         "Logged in",
+	MsgVerified:         "Verified",
+	MsgOTPSent:          "OTP sent",
+	MsgPasswordSet:      "Password set",
+	MsgPasswordUpdated:  "Password updated",
+	MsgProfileUpdated:   "Profile updated",
+	MsgTokenRefreshed:   "Token refreshed",
+	MsgTokenVerified:    "Token verified",
+	MsgLoggedOut:        "Logged out",
+	MsgAddressCreated:   "Address created",
+	MsgAddressUpdated:   "Address updated",
+	MsgAddressRemoved:   "Address removed",
+	MsgErasureScheduled: "Account scheduled for deletion",
+	MsgErasureCancelled: "Account deletion cancelled",
+	MsgCustomerPurged:   "Purged customer successfully",
+	MsgMerchantPurged:   "Purged merchant successfully",
+	MsgStatusUpdated:    "Account status updated",
+	MsgCustomerRestored: "Restored customer successfully",
+	MsgMerchantRestored: "Restored merchant successfully",
+
+	MsgSomethingWentWrong:      "Something went wrong",
+	MsgNotReady:                "Not ready to serve requests",
+	MsgInvalidJSON:             "Invalid JSON",
+	MsgMissingRequiredFields:   "Missing required field(s)",
+	MsgNothingToCreate:         "Nothing to create",
+	MsgNothingToUpdate:         "Nothing to update",
+	MsgTryAgainShortly:         "Please try again in a few seconds",
+	MsgTryAgainLater:           "Please try again later",
+	MsgTryAgainTomorrow:        "Please try again in 24 hours",
+	MsgCaptchaFailed:           "Captcha verification failed",
+	MsgMissingAccessToken:      "Missing access token",
+	MsgMissingRefreshToken:     "Missing refresh token",
+	MsgInvalidToken:            "Invalid token",
+	MsgTokenExpired:            "Token expired",
+	MsgTokenRevoked:            "Token revoked, please log in again",
+	MsgTokenSessionMismatch:    "The refresh token belongs to another user",
+	MsgInertToken:              "Inert token",
+	MsgSessionExpired:          "Session expired",
+	MsgMissingUsername:         "Missing username",
+	MsgInvalidUser:             "Invalid user",
+	MsgNotACustomer:            "Not a customer",
+	MsgNotAMerchant:            "Not a merchant",
+	MsgForbidden:               "You are not allowed to do this",
+	MsgCustomersOnly:           "Restricted to customers",
+	MsgMerchantsOnly:           "Restricted to merchants",
+	MsgIncorrectCredentials:    "Incorrect username or password",
+	MsgIncorrectPassword:       "Incorrect password",
+	MsgUserExists:              "User already exists",
+	MsgInvalidPhone:            "Phone number is not valid",
+	MsgInvalidGender:           "Invalid gender",
+	MsgInvalidStatus:           "Invalid status",
+	MsgPasswordInvalidChar:     "Password contains invalid characters",
+	MsgPasswordSpecialChar:     "Must contain at least one special character",
+	MsgPasswordTooShort:        "Must be at least 8 characters long",
+	MsgAccountBlocked:          "Account is blocked",
+	MsgAccountInactive:         "Account is inactive",
+	MsgAccountPending:          "Account is pending approval",
+	MsgNoDeletedCustomer:       "No deleted customer found",
+	MsgNoDeletedMerchant:       "No deleted merchant found",
+	MsgErasureAlreadyRequested: "Account erasure already requested",
+	MsgNoPendingErasure:        "No pending account erasure",
+	MsgOTPInProgress:           "Can not request multiple OTPs at once",
+	MsgOTPRequestFailed:        "OTP request failed",
+	MsgOTPVerificationFailed:   "OTP verification failed",
+	MsgOTPMatchFailed:          "OTP match failed",
+	MsgOTPIncorrect:            "Incorrect OTP",
+	MsgOTPExpired:              "OTP expired",
+	MsgAddressLimitReached:     "Maximum %d addresses are allowed",
+	MsgInvalidAddressID:        "Invalid address ID",
+	MsgUnknownAddressID:        "Unknown address ID",
+	MsgAddressNotFound:         "Address not found",
+	MsgNoDefaultAddress:        "No default address",
+	MsgMissingSearchQuery:      "Search query is required",
+	MsgInvalidLocationType:     "Invalid location type",
+	MsgInvalidAddressLocation:  "Selected areas do not match",
+	MsgAddressOutOfBounds:      "Address coordinates are out of bounds",
+	MsgLocationRequired:        "is required",
+	MsgUnknownLocation:         "unknown area",
+	MsgLocationParentMismatch:  "does not belong to the selected parent area",
+	MsgOutsideBangladesh:       "is outside Bangladesh",
+	MsgTooFarFromDistrict:      "is too far from the selected district",
+	MsgInvalidSecret:           "Invalid or missing secret key or client certificate",
+	MsgEndpointNotAllowed:      "This caller is not allowed to use this endpoint",
+	MsgCustomerNotFound:        "Customer not found",
+	MsgMerchantNotFound:        "Merchant not found",
+	MsgInvalidGeoQuery:         "Invalid geo query",
+	MsgRadiusOrPolygon:         "give either a center with a radius or a polygon",
+	MsgRadiusTooLarge:          "radius is too large",
+	MsgPolygonTooSmall:         "a polygon needs at least 3 points",
+	MsgInvalidCoordinate:       "invalid coordinate",
+	MsgInvalidCursor:           "The cursor is invalid or belongs to another list, start over from the first page",
+
+	MsgOTPSignup:         "Your verification code is %s. It will expire in %d minutes.",
+	MsgOTPForgotPassword: "Your password reset code is %s. It will expire in %d minutes. Do not share it with anyone.",
+
+	MsgZeroValue:      "zero value",
+	MsgLessThanMin:    "less than min",
+	MsgGreaterThanMax: "greater than max",
+	MsgInvalidLength:  "invalid length",
+	MsgRegexpMismatch: "regular expression mismatch",
+	MsgInvalidValue:   "invalid value",
+}
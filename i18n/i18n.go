@@ -0,0 +1,111 @@
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Supported languages, as used in Accept-Language and Content-Language headers
+const (
+	English = "en"
+	Bangla  = "bn"
+
+	DefaultLanguage = English
+)
+
+var bundles = map[string]map[string]string{
+	English: en,
+	Bangla:  bn,
+}
+
+type ctxKey struct{}
+
+// WithLanguage returns a copy of ctx carrying lang
+func WithLanguage(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, lang)
+}
+
+// FromContext returns the language stored in ctx, or DefaultLanguage
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return DefaultLanguage
+	}
+
+	if lang, ok := ctx.Value(ctxKey{}).(string); ok && IsSupported(lang) {
+		return lang
+	}
+
+	return DefaultLanguage
+}
+
+// IsSupported reports whether there is a bundle for lang
+func IsSupported(lang string) bool {
+	_, ok := bundles[lang]
+	return ok
+}
+
+// T translates the message identified by id into lang, formatting it with args if any.
+// Missing translations fall back to English, unknown ids are returned as they are.
+func T(lang, id string, args ...interface{}) string {
+	msg, ok := bundles[lang][id]
+	if !ok {
+		msg, ok = bundles[DefaultLanguage][id]
+	}
+	if !ok {
+		msg = id
+	}
+
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+
+	return msg
+}
+
+// Negotiate picks the supported language the client prefers most from an Accept-Language header
+func Negotiate(acceptLanguage string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+
+	candidates := make([]candidate, 0)
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		q := 1.0
+		if i := strings.Index(part, ";"); i >= 0 {
+			param := strings.TrimSpace(part[i+1:])
+			part = strings.TrimSpace(part[:i])
+			if strings.HasPrefix(param, "q=") {
+				v, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+				if err != nil {
+					continue
+				}
+				q = v
+			}
+		}
+
+		// only the primary subtag matters, bn-BD and en-US are served by bn and en
+		lang := strings.ToLower(strings.SplitN(part, "-", 2)[0])
+		if q > 0 && IsSupported(lang) {
+			candidates = append(candidates, candidate{lang: lang, q: q})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return DefaultLanguage
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+
+	return candidates[0].lang
+}
@@ -0,0 +1,73 @@
+package i18n
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	cases := map[string]string{
+		"":                          English,
+		"bn":                        Bangla,
+		"bn-BD":                     Bangla,
+		"BN-bd,en;q=0.8":            Bangla,
+		"en-US,bn;q=0.9":            English,
+		"fr,bn;q=0.5,en;q=0.4":      Bangla,
+		"fr,de;q=0.5":               English,
+		"bn;q=0,en":                 English,
+		"en;q=0.2, bn;q=0.7, *;q=1": Bangla,
+	}
+
+	for header, want := range cases {
+		if got := Negotiate(header); got != want {
+			t.Errorf("Negotiate(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	if T(English, MsgInvalidJSON) != "Invalid JSON" {
+		t.Fail()
+	}
+
+	if T(Bangla, MsgInvalidJSON) != bn[MsgInvalidJSON] {
+		t.Fail()
+	}
+
+	if T(English, MsgAddressLimitReached, 5) != "Maximum 5 addresses are allowed" {
+		t.Fail()
+	}
+
+	// unknown ids pass through untouched
+	if T(Bangla, "not an id") != "not an id" {
+		t.Fail()
+	}
+}
+
+func TestBundlesAreComplete(t *testing.T) {
+	for id := range en {
+		if _, ok := bn[id]; !ok {
+			t.Errorf("missing bn translation for %q", id)
+		}
+	}
+
+	for id := range bn {
+		if _, ok := en[id]; !ok {
+			t.Errorf("bn translation for unknown id %q", id)
+		}
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if FromContext(context.Background()) != DefaultLanguage {
+		t.Fail()
+	}
+
+	if FromContext(WithLanguage(context.Background(), Bangla)) != Bangla {
+		t.Fail()
+	}
+
+	if FromContext(WithLanguage(context.Background(), "fr")) != DefaultLanguage {
+		t.Fail()
+	}
+}
@@ -0,0 +1,120 @@
+package i18n
+
+// Message ids. Ids are stable, the texts behind them live in the language bundles.
+
+// Success messages
+const (
+	MsgOK               = "ok"
+	MsgSuccessful       = "successful"
+	MsgListFetched      = "list_fetched"
+	MsgLoggedIn         = "logged_in"
+	MsgVerified         = "verified"
+	MsgOTPSent          = "otp_sent"
+	MsgPasswordSet      = "password_set"
+	MsgPasswordUpdated  = "password_updated"
+	MsgProfileUpdated   = "profile_updated"
+	MsgTokenRefreshed   = "token_refreshed"
+	MsgTokenVerified    = "token_verified"
+	MsgLoggedOut        = "logged_out"
+	MsgAddressCreated   = "address_created"
+	MsgAddressUpdated   = "address_updated"
+	MsgAddressRemoved   = "address_removed"
+	MsgErasureScheduled = "erasure_scheduled"
+	MsgErasureCancelled = "erasure_cancelled"
+	MsgCustomerPurged   = "customer_purged"
+	MsgMerchantPurged   = "merchant_purged"
+	MsgStatusUpdated    = "status_updated"
+	MsgCustomerRestored = "customer_restored"
+	MsgMerchantRestored = "merchant_restored"
+)
+
+// Error messages
+const (
+	MsgSomethingWentWrong      = "something_went_wrong"
+	MsgNotReady                = "not_ready"
+	MsgInvalidJSON             = "invalid_json"
+	MsgMissingRequiredFields   = "missing_required_fields"
+	MsgNothingToCreate         = "nothing_to_create"
+	MsgNothingToUpdate         = "nothing_to_update"
+	MsgTryAgainShortly         = "try_again_shortly"
+	MsgTryAgainLater           = "try_again_later"
+	MsgTryAgainTomorrow        = "try_again_tomorrow"
+	MsgCaptchaFailed           = "captcha_failed"
+	MsgMissingAccessToken      = "missing_access_token"
+	MsgMissingRefreshToken     = "missing_refresh_token"
+	MsgInvalidToken            = "invalid_token"
+	MsgTokenExpired            = "token_expired"
+	MsgTokenRevoked            = "token_revoked"
+	MsgTokenSessionMismatch    = "token_session_mismatch"
+	MsgInertToken              = "inert_token"
+	MsgSessionExpired          = "session_expired"
+	MsgMissingUsername         = "missing_username"
+	MsgInvalidUser             = "invalid_user"
+	MsgNotACustomer            = "not_a_customer"
+	MsgNotAMerchant            = "not_a_merchant"
+	MsgForbidden               = "forbidden"
+	MsgCustomersOnly           = "customers_only"
+	MsgMerchantsOnly           = "merchants_only"
+	MsgIncorrectCredentials    = "incorrect_credentials"
+	MsgIncorrectPassword       = "incorrect_password"
+	MsgUserExists              = "user_exists"
+	MsgInvalidPhone            = "invalid_phone"
+	MsgInvalidGender           = "invalid_gender"
+	MsgInvalidStatus           = "invalid_status"
+	MsgPasswordInvalidChar     = "password_invalid_char"
+	MsgPasswordSpecialChar     = "password_special_char"
+	MsgPasswordTooShort        = "password_too_short"
+	MsgAccountBlocked          = "account_blocked"
+	MsgAccountInactive         = "account_inactive"
+	MsgAccountPending          = "account_pending"
+	MsgNoDeletedCustomer       = "no_deleted_customer"
+	MsgNoDeletedMerchant       = "no_deleted_merchant"
+	MsgErasureAlreadyRequested = "erasure_already_requested"
+	MsgNoPendingErasure        = "no_pending_erasure"
+	MsgOTPInProgress           = "otp_in_progress"
+	MsgOTPRequestFailed        = "otp_request_failed"
+	MsgOTPVerificationFailed   = "otp_verification_failed"
+	MsgOTPMatchFailed          = "otp_match_failed"
+	MsgOTPIncorrect            = "otp_incorrect"
+	MsgOTPExpired              = "otp_expired"
+	MsgAddressLimitReached     = "address_limit_reached"
+	MsgInvalidAddressID        = "invalid_address_id"
+	MsgUnknownAddressID        = "unknown_address_id"
+	MsgAddressNotFound         = "address_not_found"
+	MsgNoDefaultAddress        = "no_default_address"
+	MsgMissingSearchQuery      = "missing_search_query"
+	MsgInvalidLocationType     = "invalid_location_type"
+	MsgInvalidAddressLocation  = "invalid_address_location"
+	MsgAddressOutOfBounds      = "address_out_of_bounds"
+	MsgLocationRequired        = "location_required"
+	MsgUnknownLocation         = "unknown_location"
+	MsgLocationParentMismatch  = "location_parent_mismatch"
+	MsgOutsideBangladesh       = "outside_bangladesh"
+	MsgTooFarFromDistrict      = "too_far_from_district"
+	MsgInvalidSecret           = "invalid_secret"
+	MsgEndpointNotAllowed      = "endpoint_not_allowed"
+	MsgCustomerNotFound        = "customer_not_found"
+	MsgMerchantNotFound        = "merchant_not_found"
+	MsgInvalidGeoQuery         = "invalid_geo_query"
+	MsgRadiusOrPolygon         = "radius_or_polygon"
+	MsgRadiusTooLarge          = "radius_too_large"
+	MsgPolygonTooSmall         = "polygon_too_small"
+	MsgInvalidCoordinate       = "invalid_coordinate"
+	MsgInvalidCursor           = "invalid_cursor"
+)
+
+// Notification templates, formatted with the OTP and its lifetime in minutes
+const (
+	MsgOTPSignup         = "otp_signup"
+	MsgOTPForgotPassword = "otp_forgot_password"
+)
+
+// Messages produced by validator.v2, used as ids as they are
+const (
+	MsgZeroValue      = "zero value"
+	MsgLessThanMin    = "less than min"
+	MsgGreaterThanMax = "greater than max"
+	MsgInvalidLength  = "invalid length"
+	MsgRegexpMismatch = "regular expression mismatch"
+	MsgInvalidValue   = "invalid value"
+)
@@ -0,0 +1,40 @@
+// Package cachetest runs the cache on an in-memory redis for tests, so code using the
+// redis client, the package level one included, works without a redis server.
+package cachetest
+
+import (
+	"github.com/alicebob/miniredis/v2"
+	infraCache "github.com/iamrz1/ab-auth/infra/cache"
+	"time"
+)
+
+// Cache is a cache connected to an in-memory redis
+type Cache struct {
+	*infraCache.Redis
+	srv *miniredis.Miniredis
+}
+
+// New starts an in-memory redis and connects a cache to it. The cache also becomes the
+// one infraCache.Client returns, like a cache of infraCache.NewCacheDB.
+func New() (*Cache, error) {
+	srv, err := miniredis.Run()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cache{
+		Redis: infraCache.NewCacheDB(srv.Addr(), nil),
+		srv:   srv,
+	}, nil
+}
+
+// FastForward moves the clock of the redis by d, expiring the keys whose ttl ran out
+func (c *Cache) FastForward(d time.Duration) {
+	c.srv.FastForward(d)
+}
+
+// Close disconnects the cache and stops the redis
+func (c *Cache) Close() {
+	c.Client.Close()
+	c.srv.Close()
+}
@@ -10,16 +10,33 @@ type Redis struct {
 
 var redisClient *redis.Client
 
-func NewCacheDB(url, pass string) *Redis {
+// NewCacheDB connects to the redis at url. password is asked for the current password
+// whenever a connection is opened, so a rotated password is picked up without a restart.
+// A nil password or an empty result means no password is set.
+func NewCacheDB(url string, password func() string) *Redis {
 	redisClient = redis.NewClient(&redis.Options{
-		Addr:     url,
-		Password: pass, // no password set
-		DB:       0,    // use default DB
+		Addr: url,
+		DB:   0, // use default DB
+		OnConnect: func(conn *redis.Conn) error {
+			if password == nil {
+				return nil
+			}
+			if pass := password(); pass != "" {
+				return conn.Auth(pass).Err()
+			}
+
+			return nil
+		},
 	})
 
 	return &Redis{Client: redisClient}
 }
 
+// PoolStats returns the connection pool stats of the client
+func (r *Redis) PoolStats() *redis.PoolStats {
+	return r.Client.PoolStats()
+}
+
 func Client() *redis.Client {
 	return redisClient
 }
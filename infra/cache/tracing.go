@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"context"
+	"github.com/go-redis/redis"
+	"github.com/iamrz1/ab-auth/tracing"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithContext returns the client of r bound to ctx. Its commands are traced as children
+// of the span in ctx.
+func (r *Redis) WithContext(ctx context.Context) *redis.Client {
+	return withTracing(ctx, r.Client)
+}
+
+// ClientWithContext is WithContext for the client Client returns
+func ClientWithContext(ctx context.Context) *redis.Client {
+	return withTracing(ctx, redisClient)
+}
+
+// withTracing wraps a copy of c, the process funcs of c itself stay untouched
+func withTracing(ctx context.Context, c *redis.Client) *redis.Client {
+	c = c.WithContext(ctx)
+	c.WrapProcess(func(process func(redis.Cmder) error) func(redis.Cmder) error {
+		return func(cmd redis.Cmder) error {
+			_, span := startSpan(ctx, cmd.Name())
+			defer span.End()
+
+			return endSpan(span, process(cmd))
+		}
+	})
+	c.WrapProcessPipeline(func(process func([]redis.Cmder) error) func([]redis.Cmder) error {
+		return func(cmds []redis.Cmder) error {
+			_, span := startSpan(ctx, "pipeline")
+			defer span.End()
+
+			return endSpan(span, process(cmds))
+		}
+	})
+
+	return c
+}
+
+func startSpan(ctx context.Context, operation string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "redis "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemRedis, semconv.DBOperationKey.String(operation)),
+	)
+}
+
+// endSpan marks span failed by err. A missing key is an answer, not a failure.
+func endSpan(span trace.Span, err error) error {
+	if err != nil && err != redis.Nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return err
+}
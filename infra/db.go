@@ -12,11 +12,14 @@ type DB interface {
 	Ping(ctx context.Context) error
 	Disconnect(ctx context.Context) error
 	EnsureIndices(ctx context.Context, collection string, inds []DbIndex) error
+	// DropIndices drops the indices named like inds
 	DropIndices(ctx context.Context, collection string, inds []DbIndex) error
 	Insert(ctx context.Context, collection string, doc interface{}) error
 	Update(ctx context.Context, collection string, filter, doc interface{}) (int64, error)
 	InsertMany(ctx context.Context, collection string, v []interface{}) error
 	List(ctx context.Context, collection string, filter interface{}, page, limit int64, v interface{}, sort ...interface{}) error
+	// ListPage lists the page q asks for of the documents matching filter into v
+	ListPage(ctx context.Context, collection string, filter interface{}, q PageQuery, v interface{}) (*PageInfo, error)
 	FindOne(ctx context.Context, collection string, filter interface{}, v interface{}, sort ...interface{}) error
 	PartialUpdateMany(ctx context.Context, collection string, filter DbQuery, data interface{}) error
 	PartialUpdateManyByQuery(ctx context.Context, collection string, filter DbQuery, query UnorderedDbQuery) error
@@ -28,6 +31,15 @@ type DB interface {
 	DeleteOne(ctx context.Context, collection string, filter interface{}) (int64, error)
 	Count(ctx context.Context, collection string, filter interface{}) (int64, error)
 	FindAndCount(ctx context.Context, collection string, filter interface{}) (int64, error)
+	// WithTransaction runs fn in a transaction, which is committed if fn returns nil and
+	// aborted otherwise. Only calls made with the ctx passed to fn take part in it.
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// PoolStats counts the connections of a connection pool
+type PoolStats struct {
+	Open  int64
+	InUse int64
 }
 
 // DbIndex holds database index
@@ -37,6 +49,9 @@ type DbIndex struct {
 	Unique *bool
 	Sparse *bool
 
+	// If PartialFilter is defined only documents matching it are indexed
+	PartialFilter interface{}
+
 	// If ExpireAfter is defined the server will periodically delete
 	// documents with indexed time.Time older than the provided delta.
 	ExpireAfter *time.Duration
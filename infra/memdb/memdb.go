@@ -0,0 +1,636 @@
+// Package memdb is an in-memory infra.DB for tests. It runs the subset of mongo queries
+// and updates the repos use on documents kept as bson, and enforces unique indices, so
+// services and handlers can be exercised without a mongo server.
+package memdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/iamrz1/ab-auth/infra"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrNotSupported is returned for queries and commands memdb does not implement, like
+// aggregations and geo queries
+var ErrNotSupported = errors.New("memdb: not supported")
+
+// DB keeps collections in memory. It is safe for concurrent use, but transactions are
+// not isolated, they only roll back.
+type DB struct {
+	mu          sync.Mutex
+	collections map[string]*collection
+}
+
+type collection struct {
+	docs    []bson.D
+	indices []infra.DbIndex
+}
+
+// New returns an empty DB
+func New() *DB {
+	return &DB{collections: map[string]*collection{}}
+}
+
+// coll returns collection name, creating it on first use like mongo does
+func (db *DB) coll(name string) *collection {
+	c, ok := db.collections[name]
+	if !ok {
+		c = &collection{}
+		db.collections[name] = c
+	}
+
+	return c
+}
+
+func (db *DB) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (db *DB) Disconnect(ctx context.Context) error {
+	return nil
+}
+
+// EnsureIndices adds inds to collection, replacing indices of the same name. Like mongo it
+// fails if the documents already break a unique index.
+func (db *DB) EnsureIndices(ctx context.Context, collection string, inds []infra.DbIndex) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	c := db.coll(collection)
+	indices := make([]infra.DbIndex, 0, len(c.indices)+len(inds))
+	for _, ind := range c.indices {
+		if !hasIndex(inds, indexName(ind)) {
+			indices = append(indices, ind)
+		}
+	}
+	indices = append(indices, inds...)
+
+	for i, doc := range c.docs {
+		if err := checkUnique(indices, c.docs[:i], doc); err != nil {
+			return err
+		}
+	}
+	c.indices = indices
+
+	return nil
+}
+
+// DropIndices drops the indices of collection named like inds
+func (db *DB) DropIndices(ctx context.Context, collection string, inds []infra.DbIndex) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	c := db.coll(collection)
+	kept := c.indices[:0]
+	for _, ind := range c.indices {
+		if !hasIndex(inds, indexName(ind)) {
+			kept = append(kept, ind)
+		}
+	}
+	c.indices = kept
+
+	return nil
+}
+
+func (db *DB) Insert(ctx context.Context, collection string, doc interface{}) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return db.insert(db.coll(collection), doc)
+}
+
+func (db *DB) InsertMany(ctx context.Context, collection string, docs []interface{}) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	c := db.coll(collection)
+	for _, doc := range docs {
+		if err := db.insert(c, doc); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (db *DB) insert(c *collection, v interface{}) error {
+	doc, err := toDoc(v)
+	if err != nil {
+		return err
+	}
+	if _, ok := lookup(doc, "_id"); !ok {
+		doc = append(bson.D{{Key: "_id", Value: primitive.NewObjectID()}}, doc...)
+	}
+
+	if err := checkUnique(c.indices, c.docs, doc); err != nil {
+		return err
+	}
+	c.docs = append(c.docs, doc)
+
+	return nil
+}
+
+// Update sets the fields of doc on the first document matching filter
+func (db *DB) Update(ctx context.Context, collection string, filter, doc interface{}) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return db.update(db.coll(collection), filter, bson.M{"$set": doc}, false, false)
+}
+
+func (db *DB) PartialUpdateMany(ctx context.Context, collection string, filter infra.DbQuery, data interface{}) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	_, err := db.update(db.coll(collection), filter, bson.M{"$set": data}, true, false)
+	return err
+}
+
+func (db *DB) PartialUpdateManyByQuery(ctx context.Context, collection string, filter infra.DbQuery, query infra.UnorderedDbQuery) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	_, err := db.update(db.coll(collection), filter, query, true, false)
+	return err
+}
+
+// update applies update, an update document or a replacement, to the first or all
+// documents matching filter, inserting one if none matches and upsert is set. It returns
+// the number of documents matched.
+func (db *DB) update(c *collection, filter, update interface{}, many, upsert bool) (int64, error) {
+	f, err := toDoc(filter)
+	if err != nil {
+		return 0, err
+	}
+	u, err := toDoc(update)
+	if err != nil {
+		return 0, err
+	}
+
+	var matched int64
+	for i, doc := range c.docs {
+		ok, err := match(doc, f)
+		if err != nil {
+			return matched, err
+		}
+		if !ok {
+			continue
+		}
+
+		updated, err := apply(doc, u, false)
+		if err != nil {
+			return matched, err
+		}
+		others := append(append([]bson.D{}, c.docs[:i]...), c.docs[i+1:]...)
+		if err := checkUnique(c.indices, others, updated); err != nil {
+			return matched, err
+		}
+		c.docs[i] = updated
+		matched++
+
+		if !many {
+			break
+		}
+	}
+	if matched > 0 || !upsert {
+		return matched, nil
+	}
+
+	// the new document starts from the equality conditions of the filter
+	seed := bson.D{}
+	for _, e := range f {
+		if !strings.HasPrefix(e.Key, "$") && !isOperatorDoc(e.Value) {
+			seed = append(seed, e)
+		}
+	}
+	doc, err := apply(seed, u, true)
+	if err != nil {
+		return 0, err
+	}
+
+	return 0, db.insert(c, doc)
+}
+
+// List finds the documents matching filter, skips the pages before page and decodes up
+// to limit of them into v, which points to a slice. A limit of 0 means no limit.
+func (db *DB) List(ctx context.Context, collection string, filter interface{}, page, limit int64, v interface{}, sort ...interface{}) error {
+	db.mu.Lock()
+	docs, err := db.find(db.coll(collection), filter, sort...)
+	db.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if limit > 0 {
+		skip := (page - 1) * limit
+		if skip < 0 {
+			return fmt.Errorf("memdb: negative skip %d", skip)
+		}
+		if skip > int64(len(docs)) {
+			skip = int64(len(docs))
+		}
+		docs = docs[skip:]
+		if int64(len(docs)) > limit {
+			docs = docs[:limit]
+		}
+	}
+
+	return decodeAll(docs, v)
+}
+
+func (db *DB) ListPage(ctx context.Context, collection string, filter interface{}, q infra.PageQuery, v interface{}) (*infra.PageInfo, error) {
+	plan, err := q.Plan(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	db.mu.Lock()
+	docs, err := db.find(db.coll(collection), plan.Filter, plan.Sort)
+	db.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if plan.Skip > int64(len(docs)) {
+		plan.Skip = int64(len(docs))
+	}
+	docs = docs[plan.Skip:]
+	if plan.Limit > 0 && int64(len(docs)) > plan.Limit {
+		docs = docs[:plan.Limit]
+	}
+
+	raws := make([]bson.Raw, 0, len(docs))
+	for _, doc := range docs {
+		raw, err := bson.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		raws = append(raws, raw)
+	}
+
+	raws, info, err := plan.Page(raws)
+	if err != nil {
+		return nil, err
+	}
+
+	return info, infra.DecodeAll(raws, v)
+}
+
+func (db *DB) FindOne(ctx context.Context, collection string, filter interface{}, v interface{}, sort ...interface{}) error {
+	db.mu.Lock()
+	docs, err := db.find(db.coll(collection), filter, sort...)
+	db.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if len(docs) == 0 {
+		return infra.ErrNotFound
+	}
+
+	return decode(docs[0], v)
+}
+
+// find returns the documents of c matching filter, sorted by the first of sorts if any
+func (db *DB) find(c *collection, filter interface{}, sorts ...interface{}) ([]bson.D, error) {
+	f, err := toDoc(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]bson.D, 0)
+	for _, doc := range c.docs {
+		ok, err := match(doc, f)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			docs = append(docs, doc)
+		}
+	}
+
+	if len(sorts) > 0 && sorts[0] != nil {
+		spec, err := toDoc(sorts[0])
+		if err != nil {
+			return nil, err
+		}
+		sortDocs(docs, spec)
+	}
+
+	return docs, nil
+}
+
+func (db *DB) Count(ctx context.Context, collection string, filter interface{}) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	docs, err := db.find(db.coll(collection), filter)
+	return int64(len(docs)), err
+}
+
+func (db *DB) FindAndCount(ctx context.Context, collection string, filter interface{}) (int64, error) {
+	return db.Count(ctx, collection, filter)
+}
+
+// BulkUpdate runs the insert, update, replace and delete models in order
+func (db *DB) BulkUpdate(ctx context.Context, collection string, models []mongo.WriteModel) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	c := db.coll(collection)
+	for _, m := range models {
+		var err error
+		switch m := m.(type) {
+		case *mongo.InsertOneModel:
+			err = db.insert(c, m.Document)
+		case *mongo.UpdateOneModel:
+			_, err = db.update(c, m.Filter, m.Update, false, m.Upsert != nil && *m.Upsert)
+		case *mongo.UpdateManyModel:
+			_, err = db.update(c, m.Filter, m.Update, true, m.Upsert != nil && *m.Upsert)
+		case *mongo.ReplaceOneModel:
+			_, err = db.update(c, m.Filter, m.Replacement, false, m.Upsert != nil && *m.Upsert)
+		case *mongo.DeleteOneModel:
+			_, err = db.delete(c, m.Filter, false)
+		case *mongo.DeleteManyModel:
+			_, err = db.delete(c, m.Filter, true)
+		default:
+			err = fmt.Errorf("%w: write model %T", ErrNotSupported, m)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (db *DB) Aggregate(ctx context.Context, collection string, q interface{}, v interface{}) error {
+	return fmt.Errorf("%w: aggregate", ErrNotSupported)
+}
+
+func (db *DB) AggregateWithDiskUse(ctx context.Context, collection string, q []infra.DbQuery, v interface{}) error {
+	return fmt.Errorf("%w: aggregate", ErrNotSupported)
+}
+
+// Distinct decodes the distinct values of field among the documents matching q into v
+func (db *DB) Distinct(ctx context.Context, collection, field string, q infra.DbQuery, v interface{}) error {
+	db.mu.Lock()
+	docs, err := db.find(db.coll(collection), q)
+	db.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	values := make([]interface{}, 0)
+	for _, doc := range docs {
+		value, ok := lookup(doc, field)
+		if !ok {
+			continue
+		}
+		seen := false
+		for _, prev := range values {
+			if equal(prev, value) {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			values = append(values, value)
+		}
+	}
+
+	data, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (db *DB) DeleteMany(ctx context.Context, collection string, filter interface{}) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	_, err := db.delete(db.coll(collection), filter, true)
+	return err
+}
+
+func (db *DB) DeleteOne(ctx context.Context, collection string, filter interface{}) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return db.delete(db.coll(collection), filter, false)
+}
+
+func (db *DB) delete(c *collection, filter interface{}, many bool) (int64, error) {
+	f, err := toDoc(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	var deleted int64
+	kept := make([]bson.D, 0, len(c.docs))
+	for _, doc := range c.docs {
+		if many || deleted == 0 {
+			ok, err := match(doc, f)
+			if err != nil {
+				return 0, err
+			}
+			if ok {
+				deleted++
+				continue
+			}
+		}
+		kept = append(kept, doc)
+	}
+	c.docs = kept
+
+	return deleted, nil
+}
+
+// WithTransaction runs fn and restores the documents as they were before if fn fails.
+// Writes of other goroutines made meanwhile are lost on rollback.
+func (db *DB) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	db.mu.Lock()
+	snapshot := make(map[string]*collection, len(db.collections))
+	for name, c := range db.collections {
+		snapshot[name] = &collection{
+			docs:    append([]bson.D{}, c.docs...),
+			indices: append([]infra.DbIndex{}, c.indices...),
+		}
+	}
+	db.mu.Unlock()
+
+	err := fn(ctx)
+	if err != nil {
+		db.mu.Lock()
+		db.collections = snapshot
+		db.mu.Unlock()
+	}
+
+	return err
+}
+
+// checkUnique tells if doc breaks the unique indices of a collection holding docs, the
+// one on _id included
+func checkUnique(indices []infra.DbIndex, docs []bson.D, doc bson.D) error {
+	unique := append([]infra.DbIndex{{Name: "_id_", Keys: []infra.DbIndexKey{{Key: "_id", Asc: 1}}}}, indices...)
+	for _, ind := range unique {
+		if ind.Name != "_id_" && (ind.Unique == nil || !*ind.Unique) {
+			continue
+		}
+		key, ok, err := indexKey(ind, doc)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		for _, other := range docs {
+			otherKey, ok, err := indexKey(ind, other)
+			if err != nil {
+				return err
+			}
+			if ok && equal(key, otherKey) {
+				return infra.ErrDuplicateKey
+			}
+		}
+	}
+
+	return nil
+}
+
+// indexKey returns the values of the keys of ind in doc, and false if ind does not
+// cover doc, as it is sparse or partial
+func indexKey(ind infra.DbIndex, doc bson.D) (primitive.A, bool, error) {
+	if ind.PartialFilter != nil {
+		f, err := toDoc(ind.PartialFilter)
+		if err != nil {
+			return nil, false, err
+		}
+		ok, err := match(doc, f)
+		if err != nil || !ok {
+			return nil, false, err
+		}
+	}
+
+	key := primitive.A{}
+	found := false
+	for _, k := range ind.Keys {
+		v, ok := lookup(doc, k.Key)
+		found = found || ok
+		key = append(key, v)
+	}
+	if ind.Sparse != nil && *ind.Sparse && !found {
+		return nil, false, nil
+	}
+
+	return key, true, nil
+}
+
+func indexName(ind infra.DbIndex) string {
+	if ind.Name != "" {
+		return ind.Name
+	}
+
+	parts := make([]string, 0, len(ind.Keys))
+	for _, k := range ind.Keys {
+		parts = append(parts, fmt.Sprintf("%s_%v", k.Key, k.Asc))
+	}
+	return strings.Join(parts, "_")
+}
+
+func hasIndex(inds []infra.DbIndex, name string) bool {
+	for _, ind := range inds {
+		if indexName(ind) == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// toDoc turns v, a struct, map or bson document, into a bson document the way the driver
+// would send it. A nil v is the empty document.
+func toDoc(v interface{}) (bson.D, error) {
+	if v == nil {
+		return bson.D{}, nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return bson.D{}, nil
+	}
+
+	raw, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	doc := bson.D{}
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+func decode(doc bson.D, v interface{}) error {
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	return bson.Unmarshal(raw, v)
+}
+
+// decodeAll decodes docs into the slice v points to, like mongo.Cursor.All
+func decodeAll(docs []bson.D, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("memdb: results need a pointer to a slice, got %T", v)
+	}
+
+	slice := rv.Elem()
+	elemType := slice.Type().Elem()
+	list := reflect.MakeSlice(slice.Type(), 0, len(docs))
+	for _, doc := range docs {
+		elem := reflect.New(elemType)
+		if elemType.Kind() == reflect.Ptr {
+			elem.Elem().Set(reflect.New(elemType.Elem()))
+			if err := decode(doc, elem.Elem().Interface()); err != nil {
+				return err
+			}
+		} else if err := decode(doc, elem.Interface()); err != nil {
+			return err
+		}
+		list = reflect.Append(list, elem.Elem())
+	}
+	slice.Set(list)
+
+	return nil
+}
+
+// sortDocs sorts docs by spec, like {"name": 1, "_id": -1}
+func sortDocs(docs []bson.D, spec bson.D) {
+	sort.SliceStable(docs, func(i, j int) bool {
+		for _, e := range spec {
+			a, _ := lookup(docs[i], e.Key)
+			b, _ := lookup(docs[j], e.Key)
+			c := compare(a, b)
+			if c == 0 {
+				continue
+			}
+			if n, ok := number(e.Value); ok && n < 0 {
+				return c > 0
+			}
+			return c < 0
+		}
+
+		return false
+	})
+}
@@ -0,0 +1,224 @@
+package memdb
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"github.com/iamrz1/ab-auth/infra"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"testing"
+	"time"
+)
+
+type item struct {
+	ID        string     `bson:"_id,omitempty"`
+	Name      string     `bson:"name,omitempty"`
+	Owner     string     `bson:"owner,omitempty"`
+	Rank      int        `bson:"rank,omitempty"`
+	IsPrimary *bool      `bson:"is_primary,omitempty"`
+	IsDeleted *bool      `bson:"is_deleted,omitempty"`
+	CreatedAt time.Time  `bson:"created_at,omitempty"`
+	Tags      []string   `bson:"tags,omitempty"`
+	Expiry    *time.Time `bson:"expiry,omitempty"`
+}
+
+func seed(t *testing.T, db *DB) {
+	base := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, name := range []string{"apple", "banana", "cherry", "date"} {
+		err := db.Insert(context.Background(), "items", &item{
+			Name:      name,
+			Owner:     []string{"alice", "bob"}[i%2],
+			Rank:      i + 1,
+			CreatedAt: base.Add(time.Hour * time.Duration(i)),
+			Tags:      []string{"fruit", name[:1]},
+		})
+		assert.NoError(t, err)
+	}
+}
+
+func names(list []*item) []string {
+	out := make([]string, 0, len(list))
+	for _, i := range list {
+		out = append(out, i.Name)
+	}
+	return out
+}
+
+func TestDB_Queries(t *testing.T) {
+	ctx := context.Background()
+	db := New()
+	seed(t, db)
+
+	cases := []struct {
+		filter interface{}
+		want   []string
+	}{
+		{&item{Owner: "alice"}, []string{"apple", "cherry"}},
+		{bson.M{"rank": bson.M{"$gt": 1, "$lte": 3}}, []string{"banana", "cherry"}},
+		{bson.M{"name": bson.M{"$in": bson.A{"date", "fig"}}}, []string{"date"}},
+		{bson.M{"name": bson.M{"$nin": bson.A{"date", "apple"}}}, []string{"banana", "cherry"}},
+		{bson.M{"is_deleted": bson.M{"$ne": true}}, []string{"apple", "banana", "cherry", "date"}},
+		{bson.M{"tags": "c"}, []string{"cherry"}},
+		{bson.M{"expiry": bson.M{"$exists": false}, "owner": "bob"}, []string{"banana", "date"}},
+		{bson.D{{Key: "name", Value: primitive.Regex{Pattern: "^B", Options: "i"}}}, []string{"banana"}},
+		{bson.M{"$or": bson.A{bson.M{"rank": 1}, bson.M{"name": "date"}}}, []string{"apple", "date"}},
+		{bson.M{"created_at": bson.M{"$gte": time.Date(2021, 1, 1, 2, 0, 0, 0, time.UTC)}}, []string{"cherry", "date"}},
+	}
+	for _, c := range cases {
+		list := make([]*item, 0)
+		assert.NoError(t, db.List(ctx, "items", c.filter, 1, 0, &list))
+		assert.Equal(t, c.want, names(list), "%v", c.filter)
+	}
+
+	list := make([]*item, 0)
+	assert.NoError(t, db.List(ctx, "items", nil, 2, 1, &list, bson.M{"rank": -1}))
+	assert.Equal(t, []string{"cherry"}, names(list), "the second page sorted by rank descending")
+	assert.NotEmpty(t, list[0].ID, "ids are generated like mongo does")
+
+	_, err := db.Count(ctx, "items", bson.M{"location": bson.M{"$nearSphere": bson.M{}}})
+	assert.True(t, errors.Is(err, ErrNotSupported))
+}
+
+func TestDB_Updates(t *testing.T) {
+	ctx := context.Background()
+	db := New()
+	seed(t, db)
+
+	matched, err := db.Update(ctx, "items", &item{Name: "apple"}, &item{Owner: "carol"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, matched)
+
+	err = db.PartialUpdateManyByQuery(ctx, "items", infra.DbQuery{{Key: "owner", Value: "bob"}}, infra.UnorderedDbQuery{
+		"$unset": bson.M{"tags": ""},
+		"$inc":   bson.M{"rank": 10},
+	})
+	assert.NoError(t, err)
+
+	err = db.BulkUpdate(ctx, "items", []mongo.WriteModel{
+		mongo.NewReplaceOneModel().SetFilter(bson.M{"name": "fig"}).SetReplacement(&item{Name: "fig", Rank: 5}).SetUpsert(true),
+		mongo.NewUpdateOneModel().SetFilter(bson.M{"name": "cherry"}).SetUpdate(bson.M{"$set": bson.M{"owner": "dave"}}),
+	})
+	assert.NoError(t, err)
+
+	got := &item{}
+	assert.NoError(t, db.FindOne(ctx, "items", bson.M{"name": "apple"}, got))
+	assert.Equal(t, "carol", got.Owner)
+	assert.Equal(t, 1, got.Rank, "fields that are not set are kept")
+
+	got = &item{}
+	assert.NoError(t, db.FindOne(ctx, "items", bson.M{"name": "banana"}, got))
+	assert.Equal(t, 12, got.Rank)
+	assert.Nil(t, got.Tags)
+
+	n, err := db.Count(ctx, "items", bson.M{"owner": "dave"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+
+	n, err = db.Count(ctx, "items", nil)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 5, n, "fig is upserted")
+
+	deleted, err := db.DeleteOne(ctx, "items", bson.M{"rank": bson.M{"$gt": 0}})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, deleted)
+	assert.NoError(t, db.DeleteMany(ctx, "items", bson.M{}))
+	n, _ = db.Count(ctx, "items", nil)
+	assert.EqualValues(t, 0, n)
+}
+
+func TestDB_UniqueIndices(t *testing.T) {
+	ctx := context.Background()
+	db := New()
+	yes, no := true, false
+
+	err := db.EnsureIndices(ctx, "items", []infra.DbIndex{
+		{Name: "name_unique", Keys: []infra.DbIndexKey{{Key: "name", Asc: 1}}, Unique: &yes},
+		{
+			Name:          "owner_primary_unique",
+			Keys:          []infra.DbIndexKey{{Key: "owner", Asc: 1}},
+			Unique:        &yes,
+			PartialFilter: bson.M{"is_primary": true, "is_deleted": false},
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, db.Insert(ctx, "items", &item{Name: "a", Owner: "alice", IsPrimary: &yes, IsDeleted: &no}))
+	assert.Equal(t, infra.ErrDuplicateKey, db.Insert(ctx, "items", &item{Name: "a"}))
+	assert.Equal(t, infra.ErrDuplicateKey, db.Insert(ctx, "items", &item{Name: "b", Owner: "alice", IsPrimary: &yes, IsDeleted: &no}))
+	assert.NoError(t, db.Insert(ctx, "items", &item{Name: "c", Owner: "alice", IsPrimary: &no, IsDeleted: &no}), "outside the partial filter")
+
+	_, err = db.Update(ctx, "items", bson.M{"name": "c"}, bson.M{"is_primary": true})
+	assert.Equal(t, infra.ErrDuplicateKey, err)
+
+	assert.NoError(t, db.DropIndices(ctx, "items", []infra.DbIndex{{Name: "name_unique"}}))
+	assert.NoError(t, db.Insert(ctx, "items", &item{Name: "a"}))
+}
+
+func TestDB_WithTransaction(t *testing.T) {
+	ctx := context.Background()
+	db := New()
+	seed(t, db)
+
+	err := db.WithTransaction(ctx, func(ctx context.Context) error {
+		assert.NoError(t, db.DeleteMany(ctx, "items", bson.M{"owner": "alice"}))
+		return errors.New("abort")
+	})
+	assert.Error(t, err)
+
+	n, _ := db.Count(ctx, "items", nil)
+	assert.EqualValues(t, 4, n, "the delete is rolled back")
+}
+
+func TestDB_ListPage(t *testing.T) {
+	ctx := context.Background()
+	db := New()
+	seed(t, db)
+
+	// owners tie, so _id orders the items of each, in the same direction
+	q := infra.PageQuery{SortField: "owner", Desc: true, Limit: 3}
+	var pages [][]string
+	for {
+		list := make([]*item, 0)
+		info, err := db.ListPage(ctx, "items", bson.M{"rank": bson.M{"$gt": 0}}, q, &list)
+		if !assert.NoError(t, err) {
+			return
+		}
+		pages = append(pages, names(list))
+		if info.NextCursor == "" {
+			q.Cursor = info.PrevCursor
+			break
+		}
+		q.Cursor = info.NextCursor
+	}
+	assert.Equal(t, [][]string{{"date", "banana", "cherry"}, {"apple"}}, pages)
+
+	list := make([]*item, 0)
+	info, err := db.ListPage(ctx, "items", nil, q, &list)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"date", "banana", "cherry"}, names(list), "the previous page")
+	assert.Empty(t, info.PrevCursor, "it is the first page")
+	assert.NotEmpty(t, info.NextCursor)
+
+	list = make([]*item, 0)
+	info, err = db.ListPage(ctx, "items", nil, infra.PageQuery{Skip: 1, Limit: 2}, &list)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"banana", "cherry"}, names(list))
+	assert.NotEmpty(t, info.PrevCursor)
+
+	list = make([]*item, 0)
+	_, err = db.ListPage(ctx, "items", nil, infra.PageQuery{Cursor: info.NextCursor, Limit: 2}, &list)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"date"}, names(list), "page/limit lists continue by cursor")
+
+	_, err = db.ListPage(ctx, "items", nil, infra.PageQuery{SortField: "name", Cursor: info.NextCursor}, &list)
+	assert.Equal(t, infra.ErrInvalidCursor, err, "the cursor is for another sort")
+	_, err = db.ListPage(ctx, "items", nil, infra.PageQuery{Cursor: "bm90IGEgY3Vyc29y"}, &list)
+	assert.Equal(t, infra.ErrInvalidCursor, err)
+
+	forged, _ := bson.Marshal(bson.M{"f": "_id", "v": nil, "i": bson.M{"$exists": true}})
+	_, err = db.ListPage(ctx, "items", nil, infra.PageQuery{Cursor: base64.RawURLEncoding.EncodeToString(forged)}, &list)
+	assert.Equal(t, infra.ErrInvalidCursor, err, "cursors can not smuggle operators into the filter")
+}
@@ -0,0 +1,450 @@
+package memdb
+
+import (
+	"bytes"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// match tells if doc matches filter. Documents decoded by toDoc hold primitive.D and
+// primitive.A for embedded documents and arrays.
+func match(doc bson.D, filter bson.D) (bool, error) {
+	for _, e := range filter {
+		var ok bool
+		var err error
+		switch e.Key {
+		case "$and", "$or", "$nor":
+			ok, err = matchLogical(doc, e.Key, e.Value)
+		default:
+			if strings.HasPrefix(e.Key, "$") {
+				return false, fmt.Errorf("%w: %s", ErrNotSupported, e.Key)
+			}
+			ok, err = matchField(doc, e.Key, e.Value)
+		}
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func matchLogical(doc bson.D, op string, v interface{}) (bool, error) {
+	clauses, ok := v.(primitive.A)
+	if !ok {
+		return false, fmt.Errorf("memdb: %s needs an array", op)
+	}
+
+	for _, clause := range clauses {
+		f, ok := clause.(primitive.D)
+		if !ok {
+			return false, fmt.Errorf("memdb: %s needs documents", op)
+		}
+		matched, err := match(doc, f)
+		if err != nil {
+			return false, err
+		}
+		switch {
+		case op == "$and" && !matched:
+			return false, nil
+		case op == "$or" && matched:
+			return true, nil
+		case op == "$nor" && matched:
+			return false, nil
+		}
+	}
+
+	return op != "$or", nil
+}
+
+// matchField tells if the value at path in doc meets cond, a value to equal, a regex or
+// a document of operators
+func matchField(doc bson.D, path string, cond interface{}) (bool, error) {
+	v, found := lookup(doc, path)
+
+	if re, ok := cond.(primitive.Regex); ok {
+		return matchRegex(v, re.Pattern, re.Options)
+	}
+	if !isOperatorDoc(cond) {
+		return found && matchEqual(v, cond) || !found && cond == nil, nil
+	}
+
+	ops := cond.(primitive.D)
+	for _, op := range ops {
+		var ok bool
+		var err error
+		switch op.Key {
+		case "$eq":
+			ok = found && matchEqual(v, op.Value) || !found && op.Value == nil
+		case "$ne":
+			ok = !(found && matchEqual(v, op.Value) || !found && op.Value == nil)
+		case "$gt", "$gte", "$lt", "$lte":
+			ok = found && matchRange(v, op.Key, op.Value)
+		case "$in", "$nin":
+			list, isList := op.Value.(primitive.A)
+			if !isList {
+				return false, fmt.Errorf("memdb: %s needs an array", op.Key)
+			}
+			in := false
+			for _, item := range list {
+				if found && matchEqual(v, item) || !found && item == nil {
+					in = true
+					break
+				}
+			}
+			ok = in == (op.Key == "$in")
+		case "$exists":
+			ok = found == truthy(op.Value)
+		case "$regex":
+			pattern, options := "", ""
+			switch re := op.Value.(type) {
+			case string:
+				pattern = re
+			case primitive.Regex:
+				pattern, options = re.Pattern, re.Options
+			}
+			for _, o := range ops {
+				if o.Key == "$options" {
+					options, _ = o.Value.(string)
+				}
+			}
+			ok, err = matchRegex(v, pattern, options)
+		case "$options":
+			ok = true
+		default:
+			return false, fmt.Errorf("%w: %s", ErrNotSupported, op.Key)
+		}
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// matchEqual tells if v equals want, or holds it when v is an array
+func matchEqual(v, want interface{}) bool {
+	if equal(v, want) {
+		return true
+	}
+	if list, ok := v.(primitive.A); ok {
+		for _, item := range list {
+			if equal(item, want) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func matchRange(v interface{}, op string, bound interface{}) bool {
+	// like mongo, values of different types are not compared
+	if typeOrder(v) != typeOrder(bound) {
+		return false
+	}
+
+	c := compare(v, bound)
+	switch op {
+	case "$gt":
+		return c > 0
+	case "$gte":
+		return c >= 0
+	case "$lt":
+		return c < 0
+	default:
+		return c <= 0
+	}
+}
+
+func matchRegex(v interface{}, pattern, options string) (bool, error) {
+	s, ok := v.(string)
+	if !ok {
+		return false, nil
+	}
+	if strings.Contains(options, "i") {
+		pattern = "(?i)" + pattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(s), nil
+}
+
+// isOperatorDoc tells if v is a document of query or update operators, like {"$gt": 1}
+func isOperatorDoc(v interface{}) bool {
+	d, ok := v.(primitive.D)
+	if !ok || len(d) == 0 {
+		return false
+	}
+
+	return strings.HasPrefix(d[0].Key, "$")
+}
+
+func truthy(v interface{}) bool {
+	if b, ok := v.(bool); ok {
+		return b
+	}
+	n, ok := number(v)
+	return ok && n != 0
+}
+
+// lookup returns the value at path in doc, a dotted path like "location.type" or
+// "items.0"
+func lookup(doc bson.D, path string) (interface{}, bool) {
+	var cur interface{} = primitive.D(doc)
+	for _, part := range strings.Split(path, ".") {
+		switch c := cur.(type) {
+		case primitive.D:
+			found := false
+			for _, e := range c {
+				if e.Key == part {
+					cur, found = e.Value, true
+					break
+				}
+			}
+			if !found {
+				return nil, false
+			}
+		case primitive.A:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, false
+			}
+			cur = c[i]
+		default:
+			return nil, false
+		}
+	}
+
+	return cur, true
+}
+
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+
+	return 0, false
+}
+
+// typeOrder ranks values by type the way mongo sorts mixed types
+func typeOrder(v interface{}) int {
+	switch v.(type) {
+	case nil, primitive.Null, primitive.Undefined:
+		return 1
+	case int32, int64, int, float64:
+		return 2
+	case string, primitive.Symbol:
+		return 3
+	case primitive.D:
+		return 4
+	case primitive.A:
+		return 5
+	case primitive.Binary:
+		return 6
+	case primitive.ObjectID:
+		return 7
+	case bool:
+		return 8
+	case primitive.DateTime:
+		return 9
+	case primitive.Timestamp:
+		return 10
+	case primitive.Regex:
+		return 11
+	}
+
+	return 12
+}
+
+// compare orders a and b, mixed types by typeOrder
+func compare(a, b interface{}) int {
+	if ta, tb := typeOrder(a), typeOrder(b); ta != tb {
+		return ta - tb
+	}
+
+	switch a := a.(type) {
+	case int32, int64, int, float64:
+		x, _ := number(a)
+		y, _ := number(b)
+		return compareFloat(x, y)
+	case string:
+		return strings.Compare(a, b.(string))
+	case primitive.ObjectID:
+		id := b.(primitive.ObjectID)
+		return bytes.Compare(a[:], id[:])
+	case bool:
+		switch {
+		case a == b.(bool):
+			return 0
+		case !a:
+			return -1
+		default:
+			return 1
+		}
+	case primitive.DateTime:
+		return compareFloat(float64(a), float64(b.(primitive.DateTime)))
+	case primitive.A:
+		other := b.(primitive.A)
+		for i := 0; i < len(a) && i < len(other); i++ {
+			if c := compare(a[i], other[i]); c != 0 {
+				return c
+			}
+		}
+		return len(a) - len(other)
+	}
+
+	if reflect.DeepEqual(a, b) {
+		return 0
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func compareFloat(x, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+
+	return 0
+}
+
+func equal(a, b interface{}) bool {
+	return typeOrder(a) == typeOrder(b) && compare(a, b) == 0
+}
+
+// apply returns a copy of doc with update applied. update is either a document of update
+// operators or a replacement, which keeps the _id of doc. $setOnInsert only applies when
+// inserting.
+func apply(doc bson.D, update bson.D, inserting bool) (bson.D, error) {
+	out, err := toDoc(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(update) == 0 || !strings.HasPrefix(update[0].Key, "$") {
+		replaced := bson.D{}
+		if id, ok := lookup(out, "_id"); ok {
+			replaced = append(replaced, bson.E{Key: "_id", Value: id})
+		}
+		for _, e := range update {
+			if e.Key != "_id" || len(replaced) == 0 {
+				replaced = append(replaced, e)
+			}
+		}
+		return replaced, nil
+	}
+
+	for _, op := range update {
+		fields, ok := op.Value.(primitive.D)
+		if !ok {
+			return nil, fmt.Errorf("memdb: %s needs a document", op.Key)
+		}
+		for _, f := range fields {
+			switch op.Key {
+			case "$set":
+				out = setPath(out, f.Key, f.Value)
+			case "$setOnInsert":
+				if inserting {
+					out = setPath(out, f.Key, f.Value)
+				}
+			case "$unset":
+				out = unsetPath(out, f.Key)
+			case "$inc":
+				cur, _ := lookup(out, f.Key)
+				x, _ := number(cur)
+				y, ok := number(f.Value)
+				if !ok {
+					return nil, fmt.Errorf("memdb: $inc needs a number for %s", f.Key)
+				}
+				out = setPath(out, f.Key, incResult(cur, f.Value, x+y))
+			default:
+				return nil, fmt.Errorf("%w: %s", ErrNotSupported, op.Key)
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// incResult keeps the integer type of the operands of $inc when both are integers
+func incResult(cur, by interface{}, sum float64) interface{} {
+	_, curFloat := cur.(float64)
+	_, byFloat := by.(float64)
+	if curFloat || byFloat {
+		return sum
+	}
+	if _, ok := cur.(int64); ok {
+		return int64(sum)
+	}
+	if _, ok := by.(int64); ok {
+		return int64(sum)
+	}
+
+	return int32(sum)
+}
+
+// setPath sets the value at path, creating the embedded documents on the way
+func setPath(doc bson.D, path string, v interface{}) bson.D {
+	key, rest := path, ""
+	if i := strings.Index(path, "."); i >= 0 {
+		key, rest = path[:i], path[i+1:]
+	}
+
+	for i, e := range doc {
+		if e.Key != key {
+			continue
+		}
+		if rest == "" {
+			doc[i].Value = v
+			return doc
+		}
+		sub, _ := e.Value.(primitive.D)
+		doc[i].Value = primitive.D(setPath(bson.D(sub), rest, v))
+		return doc
+	}
+
+	if rest == "" {
+		return append(doc, bson.E{Key: key, Value: v})
+	}
+	return append(doc, bson.E{Key: key, Value: primitive.D(setPath(bson.D{}, rest, v))})
+}
+
+func unsetPath(doc bson.D, path string) bson.D {
+	key, rest := path, ""
+	if i := strings.Index(path, "."); i >= 0 {
+		key, rest = path[:i], path[i+1:]
+	}
+
+	out := make(bson.D, 0, len(doc))
+	for _, e := range doc {
+		switch {
+		case e.Key != key:
+			out = append(out, e)
+		case rest != "":
+			if sub, ok := e.Value.(primitive.D); ok {
+				e.Value = primitive.D(unsetPath(bson.D(sub), rest))
+			}
+			out = append(out, e)
+		}
+	}
+
+	return out
+}
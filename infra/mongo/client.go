@@ -4,64 +4,206 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	rLog "github.com/iamrz1/rest-log"
-	"log"
+	"github.com/iamrz1/ab-auth/logger"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/iamrz1/ab-auth/infra"
 	"github.com/iamrz1/ab-auth/utils"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 )
 
+// errCodeIndexNotFound is the code of the error dropping an index that does not exist
+const errCodeIndexNotFound = 27
+
 // Mongo holds necessery fields and
 // mongo database session to connect
 type Mongo struct {
-	*mongo.Client
+	// open and inUse count pool connections across clients, the ones Reconnect replaced
+	// included. They come first to stay 64 bit aligned for atomic access.
+	open  int64
+	inUse int64
+	// commands traces the commands of every client
+	commands commandSpans
+	// mu guards client and database, which Reconnect swaps
+	mu       sync.RWMutex
+	client   *mongo.Client
 	database *mongo.Database
+	uri      string
 	name     string
-	lgr      rLog.Logger
+	timeout  time.Duration
+	username string
+	password string
+	lgr      logger.Logger
+
+	// transactions tells whether the server of client supports them, a standalone server
+	// does not. mu guards it along with client.
+	transactions bool
 }
 
 // New returns a new instance of mongodb using session s
 func New(ctx context.Context, uri, name string, timeout time.Duration, opts ...Option) (*Mongo, error) {
+	db := &Mongo{
+		uri:     uri,
+		name:    name,
+		timeout: timeout,
+	}
+	for _, opt := range opts {
+		opt.apply(db)
+	}
+
+	client, err := db.connect(ctx, db.username, db.password)
+	if err != nil {
+		return nil, err
+	}
+	db.client = client
+	db.database = client.Database(name)
+	db.transactions = supportsTransactions(ctx, client)
+
+	return db, nil
+}
+
+// supportsTransactions reports whether client is connected to a replica set or a sharded
+// cluster, the deployments with transactions. When that can not be told it assumes so,
+// transactions then fail with the error of the server.
+func supportsTransactions(ctx context.Context, client *mongo.Client) bool {
+	res := struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}{}
+	err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "isMaster", Value: 1}}).Decode(&res)
+	if err != nil {
+		logger.Warnln(ctx, "supportsTransactions", "could not tell the mongo deployment: "+err.Error())
+		return true
+	}
+
+	if res.SetName == "" && res.Msg != "isdbgrid" {
+		logger.Warnln(ctx, "supportsTransactions", "mongo is a standalone server, multi-document writes run without transactions")
+		return false
+	}
+
+	return true
+}
+
+func (d *Mongo) connect(ctx context.Context, username, password string) (*mongo.Client, error) {
 	minPoolSize := uint64(10)
 	maxPoolSize := uint64(100)
 	connectionOption := &options.ClientOptions{
-		SocketTimeout:          &timeout,
-		ConnectTimeout:         &timeout,
+		SocketTimeout:          &d.timeout,
+		ConnectTimeout:         &d.timeout,
 		MaxPoolSize:            &maxPoolSize,
 		MinPoolSize:            &minPoolSize,
-		ServerSelectionTimeout: &timeout,
+		ServerSelectionTimeout: &d.timeout,
 		RetryWrites:            utils.TrueP(),
 		ReadPreference:         readpref.Secondary(),
 		//ReplicaSet:             nil,
 		//Direct:                 nil,
 	}
-	log.Println("hitting mongo connect...")
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri), connectionOption)
+
+	uriOption := options.Client().ApplyURI(d.uri).SetPoolMonitor(&event.PoolMonitor{Event: d.countConnections}).
+		SetMonitor(d.commands.monitor())
+	if password != "" {
+		// credentials given apart from the uri keep its auth source and mechanism
+		auth := options.Credential{}
+		if uriOption.Auth != nil {
+			auth = *uriOption.Auth
+		}
+		if username != "" {
+			auth.Username = username
+		}
+		auth.Password = password
+		auth.PasswordSet = true
+		uriOption.SetAuth(auth)
+	}
+
+	logger.Debugln(ctx, "connect", "hitting mongo connect...")
+	client, err := mongo.Connect(ctx, uriOption, connectionOption)
 	if err != nil {
 		return nil, err
 	}
 
-	log.Println("pinging db...")
+	logger.Debugln(ctx, "connect", "pinging db...")
 	err = client.Ping(ctx, readpref.Primary())
 	if err != nil {
+		client.Disconnect(ctx)
 		return nil, err
 	}
-	log.Println("mongo connected...")
+	logger.Infoln(ctx, "connect", "mongo connected...")
 
-	db := &Mongo{
-		Client:   client,
-		database: client.Database(name),
-		name:     name,
+	return client, nil
+}
+
+// Reconnect connects with username and password, like after they were rotated, and
+// switches over to the new connections. The old ones are closed once the operations
+// using them are done, or after the timeout.
+func (d *Mongo) Reconnect(ctx context.Context, username, password string) error {
+	client, err := d.connect(ctx, username, password)
+	if err != nil {
+		return err
 	}
-	for _, opt := range opts {
-		opt.apply(db)
+
+	transactions := supportsTransactions(ctx, client)
+
+	d.mu.Lock()
+	old := d.client
+	d.client = client
+	d.database = client.Database(d.name)
+	d.transactions = transactions
+	d.username, d.password = username, password
+	d.mu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+		defer cancel()
+		if err := old.Disconnect(ctx); err != nil {
+			logger.Errorln(ctx, "Reconnect", "could not close the old mongo connections: "+err.Error())
+		}
+	}()
+
+	return nil
+}
+
+// countConnections keeps the counts of PoolStats up to date
+func (d *Mongo) countConnections(e *event.PoolEvent) {
+	switch e.Type {
+	case event.ConnectionCreated:
+		atomic.AddInt64(&d.open, 1)
+	case event.ConnectionClosed:
+		atomic.AddInt64(&d.open, -1)
+	case event.GetSucceeded:
+		atomic.AddInt64(&d.inUse, 1)
+	case event.ConnectionReturned:
+		atomic.AddInt64(&d.inUse, -1)
 	}
-	return db, nil
+}
+
+// PoolStats returns the open connections to mongo and how many of them are in use
+func (d *Mongo) PoolStats() infra.PoolStats {
+	return infra.PoolStats{
+		Open:  atomic.LoadInt64(&d.open),
+		InUse: atomic.LoadInt64(&d.inUse),
+	}
+}
+
+func (d *Mongo) db() *mongo.Database {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.database
+}
+
+func (d *Mongo) currentClient() *mongo.Client {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.client
 }
 
 // Option is mongo db option
@@ -76,25 +218,38 @@ func (f OptionFunc) apply(db *Mongo) {
 	f(db)
 }
 
+// SetCredentials sets the username and password to connect with, overriding those of the
+// uri. An empty username keeps the one of the uri.
+func SetCredentials(username, password string) Option {
+	return OptionFunc(func(db *Mongo) {
+		db.username = username
+		db.password = password
+	})
+}
+
 // SetLogger sets logger
-func SetLogger(lgr rLog.Logger) Option {
+func SetLogger(lgr logger.Logger) Option {
 	return OptionFunc(func(db *Mongo) {
 		db.lgr = lgr
 	})
 }
 
 func (d *Mongo) Ping(ctx context.Context) error {
-	return d.Client.Ping(ctx, readpref.Primary())
+	return d.currentClient().Ping(ctx, readpref.Primary())
 }
 
 func (d *Mongo) Close(ctx context.Context) error {
-	return d.Client.Disconnect(ctx)
+	return d.Disconnect(ctx)
+}
+
+func (d *Mongo) Disconnect(ctx context.Context) error {
+	return d.currentClient().Disconnect(ctx)
 }
 
 // EnsureIndices creates indices for collection col
 func (d *Mongo) EnsureIndices(ctx context.Context, collection string, inds []infra.DbIndex) error {
-	d.lgr.Info("EnsureIndices", "", fmt.Sprint("creating indices for", collection))
-	db := d.database
+	d.lgr.Infoln(ctx, "EnsureIndices", fmt.Sprint("creating indices for", collection))
+	db := d.db()
 	indexModels := []mongo.IndexModel{}
 	for _, ind := range inds {
 		keys := bson.D{}
@@ -111,6 +266,9 @@ func (d *Mongo) EnsureIndices(ctx context.Context, collection string, inds []inf
 		if ind.Name != "" {
 			opts.SetName(ind.Name)
 		}
+		if ind.PartialFilter != nil {
+			opts.SetPartialFilterExpression(ind.PartialFilter)
+		}
 		if ind.ExpireAfter != nil {
 			opts.SetExpireAfterSeconds(int32(ind.ExpireAfter.Seconds()))
 		}
@@ -126,19 +284,60 @@ func (d *Mongo) EnsureIndices(ctx context.Context, collection string, inds []inf
 	return nil
 }
 
-// DropIndices drops indices from collection col
-func (d *Mongo) DropIndices(ctx context.Context, collection string, inds []infra.DbIndex) error {
-	d.lgr.Info("DropIndices", "", fmt.Sprint("dropping indices from", collection))
-	if _, err := d.database.Collection(collection).Indexes().DropAll(ctx); err != nil {
+// WithTransaction runs fn in a transaction on a new session. Transactions need a replica set
+// and read from the primary, whatever the read preference of the client. On a standalone
+// server fn runs without one, its writes are made one by one, so callers order them to be
+// safe on their own.
+func (d *Mongo) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	d.mu.RLock()
+	client, transactions := d.client, d.transactions
+	d.mu.RUnlock()
+	if !transactions {
+		return fn(ctx)
+	}
+
+	session, err := client.StartSession()
+	if err != nil {
 		return err
 	}
+	defer session.EndSession(ctx)
+
+	txnOpts := options.Transaction().
+		SetReadPreference(readpref.Primary()).
+		SetReadConcern(readconcern.Snapshot()).
+		SetWriteConcern(writeconcern.New(writeconcern.WMajority()))
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	}, txnOpts)
+
+	return err
+}
+
+// DropIndices drops the indices of collection col named like inds. Indices that do not
+// exist are skipped.
+func (d *Mongo) DropIndices(ctx context.Context, collection string, inds []infra.DbIndex) error {
+	d.lgr.Infoln(ctx, "DropIndices", fmt.Sprint("dropping indices from", collection))
+	indexes := d.db().Collection(collection).Indexes()
+	for _, ind := range inds {
+		_, err := indexes.DropOne(ctx, ind.Name)
+		if cmdErr, ok := err.(mongo.CommandError); ok && cmdErr.Code == errCodeIndexNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 // Insert inserts doc into collection
 func (d *Mongo) Insert(ctx context.Context, collection string, doc interface{}) error {
-	d.lgr.Info("Insert", "", fmt.Sprint("insert into", collection))
-	if _, err := d.database.Collection(collection).InsertOne(ctx, doc); err != nil {
+	d.lgr.Infoln(ctx, "Insert", fmt.Sprint("insert into", collection))
+	if _, err := d.db().Collection(collection).InsertOne(ctx, doc); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return infra.ErrDuplicateKey
+		}
 		return err
 	}
 	return nil
@@ -146,11 +345,13 @@ func (d *Mongo) Insert(ctx context.Context, collection string, doc interface{})
 
 // Update updates existing doc in the collection
 func (d *Mongo) Update(ctx context.Context, collection string, filter, doc interface{}) (int64, error) {
-	d.lgr.Info("Update", "", fmt.Sprint("update in", collection))
+	d.lgr.Infoln(ctx, "Update", fmt.Sprint("update in", collection))
 	update := bson.M{"$set": doc}
-	res, err := d.database.Collection(collection).UpdateOne(ctx, filter, update)
+	res, err := d.db().Collection(collection).UpdateOne(ctx, filter, update)
 	if err != nil {
-
+		if mongo.IsDuplicateKeyError(err) {
+			return 0, infra.ErrDuplicateKey
+		}
 		return 0, err
 	}
 	return res.MatchedCount, nil
@@ -158,8 +359,8 @@ func (d *Mongo) Update(ctx context.Context, collection string, filter, doc inter
 
 // InsertMany inserts docs into collection
 func (d *Mongo) InsertMany(ctx context.Context, collection string, docs []interface{}) error {
-	d.lgr.Info("InsertMany", "", fmt.Sprint("insert many into", collection))
-	if _, err := d.database.Collection(collection).InsertMany(ctx, docs); err != nil {
+	d.lgr.Infoln(ctx, "InsertMany", fmt.Sprint("insert many into", collection))
+	if _, err := d.db().Collection(collection).InsertMany(ctx, docs); err != nil {
 		return err
 	}
 	return nil
@@ -167,12 +368,12 @@ func (d *Mongo) InsertMany(ctx context.Context, collection string, docs []interf
 
 // FindOne finds a doc by query
 func (d *Mongo) FindOne(ctx context.Context, collection string, q interface{}, v interface{}, sort ...interface{}) error {
-	d.lgr.Info("FindOne", "", fmt.Sprintf("find %v from %v", q, collection))
+	d.lgr.Infoln(ctx, "FindOne", fmt.Sprintf("find %v from %v", q, collection))
 	findOneOpts := options.FindOne()
 	if len(sort) > 0 {
 		findOneOpts = findOneOpts.SetSort(sort[0])
 	}
-	err := d.database.Collection(collection).FindOne(ctx, q, findOneOpts).Decode(v)
+	err := d.db().Collection(collection).FindOne(ctx, q, findOneOpts).Decode(v)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return infra.ErrNotFound
@@ -184,9 +385,9 @@ func (d *Mongo) FindOne(ctx context.Context, collection string, q interface{}, v
 
 // Count counts documents
 func (d *Mongo) Count(ctx context.Context, collection string, filter interface{}) (int64, error) {
-	d.lgr.Info("Count", "", fmt.Sprint("count", filter, "from", collection))
+	d.lgr.Infoln(ctx, "Count", fmt.Sprint("count", filter, "from", collection))
 
-	n, err := d.database.Collection(collection).CountDocuments(ctx, filter)
+	n, err := d.db().Collection(collection).CountDocuments(ctx, filter)
 	if err != nil {
 		return 0, err
 	}
@@ -195,9 +396,9 @@ func (d *Mongo) Count(ctx context.Context, collection string, filter interface{}
 
 // FindAndCount counts from found results
 func (d *Mongo) FindAndCount(ctx context.Context, collection string, filter interface{}) (int64, error) {
-	d.lgr.Info("FindAndCount", "", fmt.Sprint("count", filter, "from", collection))
+	d.lgr.Infoln(ctx, "FindAndCount", fmt.Sprint("count", filter, "from", collection))
 
-	cursor, err := d.database.Collection(collection).Find(ctx, filter)
+	cursor, err := d.db().Collection(collection).Find(ctx, filter)
 	if err != nil {
 		return 0, err
 	}
@@ -209,13 +410,13 @@ func (d *Mongo) FindAndCount(ctx context.Context, collection string, filter inte
 
 // List finds list of docs that matches query with skip and limit
 func (d *Mongo) List(ctx context.Context, collection string, filter interface{}, page, limit int64, v interface{}, sort ...interface{}) error {
-	d.lgr.Info("List", "", fmt.Sprint("list", filter, "from", collection))
+	d.lgr.Infoln(ctx, "List", fmt.Sprint("list", filter, "from", collection))
 	skip := (page - 1) * limit
 	findOpts := options.Find().SetSkip(skip).SetLimit(limit)
 	if len(sort) > 0 {
 		findOpts = findOpts.SetSort(sort[0])
 	}
-	cursor, err := d.database.Collection(collection).Find(ctx, filter, findOpts)
+	cursor, err := d.db().Collection(collection).Find(ctx, filter, findOpts)
 	if err != nil {
 		return err
 	}
@@ -228,10 +429,37 @@ func (d *Mongo) List(ctx context.Context, collection string, filter interface{},
 	return nil
 }
 
+// ListPage finds the page of docs q asks for, by the sort field of q instead of skipping
+// when q has a cursor
+func (d *Mongo) ListPage(ctx context.Context, collection string, filter interface{}, q infra.PageQuery, v interface{}) (*infra.PageInfo, error) {
+	d.lgr.Infoln(ctx, "ListPage", fmt.Sprint("list page", filter, "from", collection))
+	plan, err := q.Plan(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	findOpts := options.Find().SetSort(plan.Sort).SetSkip(plan.Skip).SetLimit(plan.Limit)
+	cursor, err := d.db().Collection(collection).Find(ctx, plan.Filter, findOpts)
+	if err != nil {
+		return nil, err
+	}
+	docs := make([]bson.Raw, 0)
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	docs, info, err := plan.Page(docs)
+	if err != nil {
+		return nil, err
+	}
+
+	return info, infra.DecodeAll(docs, v)
+}
+
 // Aggregate runs aggregation q on docs and store the result on v
 func (d *Mongo) Aggregate(ctx context.Context, collection string, q interface{}, v interface{}) error {
-	d.lgr.Info("Aggregate", "", fmt.Sprint("aggregate", q, "from", collection))
-	cursor, err := d.database.Collection(collection).Aggregate(ctx, q)
+	d.lgr.Infoln(ctx, "Aggregate", fmt.Sprint("aggregate", q, "from", collection))
+	cursor, err := d.db().Collection(collection).Aggregate(ctx, q)
 	if err != nil {
 		return err
 	}
@@ -242,9 +470,9 @@ func (d *Mongo) Aggregate(ctx context.Context, collection string, q interface{},
 }
 
 func (d *Mongo) AggregateWithDiskUse(ctx context.Context, collection string, q []infra.DbQuery, v interface{}) error {
-	d.lgr.Info("AggregateWithDiskUse", "", fmt.Sprint("aggregate", q, "from", collection))
+	d.lgr.Infoln(ctx, "AggregateWithDiskUse", fmt.Sprint("aggregate", q, "from", collection))
 	opt := options.Aggregate().SetAllowDiskUse(true)
-	cursor, err := d.database.Collection(collection).Aggregate(ctx, q, opt)
+	cursor, err := d.db().Collection(collection).Aggregate(ctx, q, opt)
 	if err != nil {
 		return err
 	}
@@ -255,8 +483,8 @@ func (d *Mongo) AggregateWithDiskUse(ctx context.Context, collection string, q [
 }
 
 func (d *Mongo) Distinct(ctx context.Context, collection, field string, q infra.DbQuery, v interface{}) error {
-	d.lgr.Info("Distinct", "", fmt.Sprint("aggregate", q, "from", collection))
-	interfaces, err := d.database.Collection(collection).Distinct(ctx, field, q)
+	d.lgr.Infoln(ctx, "Distinct", fmt.Sprint("aggregate", q, "from", collection))
+	interfaces, err := d.db().Collection(collection).Distinct(ctx, field, q)
 	if err != nil {
 		return err
 	}
@@ -268,7 +496,7 @@ func (d *Mongo) Distinct(ctx context.Context, collection, field string, q infra.
 }
 
 func (d *Mongo) PartialUpdateMany(ctx context.Context, collection string, filter infra.DbQuery, data interface{}) error {
-	_, err := d.database.Collection(collection).UpdateMany(ctx, filter, bson.M{"$set": data})
+	_, err := d.db().Collection(collection).UpdateMany(ctx, filter, bson.M{"$set": data})
 	if err != nil {
 		return err
 	}
@@ -276,7 +504,7 @@ func (d *Mongo) PartialUpdateMany(ctx context.Context, collection string, filter
 }
 
 func (d *Mongo) PartialUpdateManyByQuery(ctx context.Context, collection string, filter infra.DbQuery, query infra.UnorderedDbQuery) error {
-	_, err := d.database.Collection(collection).UpdateMany(ctx, filter, query)
+	_, err := d.db().Collection(collection).UpdateMany(ctx, filter, query)
 	if err != nil {
 		return err
 	}
@@ -284,17 +512,17 @@ func (d *Mongo) PartialUpdateManyByQuery(ctx context.Context, collection string,
 }
 
 func (d *Mongo) BulkUpdate(ctx context.Context, collection string, models []mongo.WriteModel) error {
-	_, err := d.database.Collection(collection).BulkWrite(ctx, models)
+	_, err := d.db().Collection(collection).BulkWrite(ctx, models)
 	return err
 }
 
 func (d *Mongo) DeleteMany(ctx context.Context, collection string, filter interface{}) error {
-	_, err := d.database.Collection(collection).DeleteMany(ctx, filter)
+	_, err := d.db().Collection(collection).DeleteMany(ctx, filter)
 	return err
 }
 
 func (d *Mongo) DeleteOne(ctx context.Context, collection string, filter interface{}) (int64, error) {
-	res, err := d.database.Collection(collection).DeleteOne(ctx, filter)
+	res, err := d.db().Collection(collection).DeleteOne(ctx, filter)
 	if err != nil {
 		return 0, err
 	}
@@ -0,0 +1,78 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"github.com/iamrz1/ab-auth/tracing"
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"sync"
+)
+
+// commandSpans traces every command sent to mongo as a client span, a child of the span
+// in the context of the operation. Commands are not recorded, filters hold user data.
+type commandSpans struct {
+	mu    sync.Mutex
+	spans map[int64]trace.Span
+}
+
+func (c *commandSpans) monitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started:   c.started,
+		Succeeded: c.succeeded,
+		Failed:    c.failed,
+	}
+}
+
+func (c *commandSpans) started(ctx context.Context, e *event.CommandStartedEvent) {
+	// most commands name their collection, like {"find": "customer", ...}
+	collection, _ := e.Command.Lookup(e.CommandName).StringValueOK()
+	name := e.CommandName + " " + e.DatabaseName
+	if collection != "" {
+		name += "." + collection
+	}
+
+	_, span := tracing.Tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemMongoDB,
+			semconv.DBNameKey.String(e.DatabaseName),
+			semconv.DBOperationKey.String(e.CommandName),
+			semconv.DBMongoDBCollectionKey.String(collection),
+			semconv.NetPeerNameKey.String(e.ConnectionID),
+		),
+	)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.spans == nil {
+		c.spans = make(map[int64]trace.Span)
+	}
+	c.spans[e.RequestID] = span
+}
+
+func (c *commandSpans) succeeded(_ context.Context, e *event.CommandSucceededEvent) {
+	if span := c.finished(e.RequestID); span != nil {
+		span.End()
+	}
+}
+
+func (c *commandSpans) failed(_ context.Context, e *event.CommandFailedEvent) {
+	if span := c.finished(e.RequestID); span != nil {
+		span.RecordError(errors.New(e.Failure))
+		span.SetStatus(codes.Error, e.Failure)
+		span.End()
+	}
+}
+
+func (c *commandSpans) finished(requestID int64) trace.Span {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	span := c.spans[requestID]
+	delete(c.spans, requestID)
+
+	return span
+}
@@ -0,0 +1,253 @@
+package infra
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"reflect"
+	"strings"
+)
+
+// ErrInvalidCursor is returned for cursors that are malformed or were made for another
+// sort order
+var ErrInvalidCursor = errors.New("infra: invalid cursor")
+
+const idField = "_id"
+
+// PageQuery asks for a page of a list sorted by SortField, _id by default, with _id
+// breaking ties so the order is total. The page starts after the document Cursor was made
+// from, or ends before it for a PageInfo.PrevCursor. Without a cursor Skip documents are
+// skipped, for the page/limit lists.
+//
+// Every document should have the sort field set, documents missing it are never reached
+// by cursors.
+type PageQuery struct {
+	SortField string
+	Desc      bool
+	Cursor    string
+	Skip      int64
+	Limit     int64
+}
+
+// PageInfo holds the cursors of the pages around a page, empty where there is none
+type PageInfo struct {
+	NextCursor string
+	PrevCursor string
+}
+
+// cursor is the position between two documents, encoded into the opaque cursor strings
+type cursor struct {
+	Field string      `bson:"f"`
+	Desc  bool        `bson:"d,omitempty"`
+	Value interface{} `bson:"v"`
+	ID    interface{} `bson:"i"`
+	Prev  bool        `bson:"p,omitempty"`
+}
+
+func (c *cursor) encode() (string, error) {
+	b, err := bson.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeCursor(s string) (*cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	c := &cursor{}
+	if err := bson.Unmarshal(b, c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	// the values end up in the filter, documents there could carry query operators
+	if !isScalar(c.Value) || !isScalar(c.ID) {
+		return nil, ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+func isScalar(v interface{}) bool {
+	switch v.(type) {
+	case nil, string, bool, int32, int64, float64, primitive.ObjectID, primitive.DateTime, primitive.Decimal128:
+		return true
+	}
+
+	return false
+}
+
+// PagePlan is how a DB runs a PageQuery: a find by Filter, sorted by Sort, skipping Skip
+// and limited to Limit documents, whose results Page turns into the page
+type PagePlan struct {
+	Filter bson.D
+	Sort   bson.D
+	Skip   int64
+	Limit  int64
+
+	q   PageQuery
+	cur *cursor
+}
+
+// Plan returns the plan of q over the documents matching filter
+func (q PageQuery) Plan(filter interface{}) (*PagePlan, error) {
+	if q.SortField == "" {
+		q.SortField = idField
+	}
+	if q.Limit < 0 || q.Skip < 0 {
+		return nil, fmt.Errorf("infra: negative limit %d or skip %d", q.Limit, q.Skip)
+	}
+
+	p := &PagePlan{q: q, Filter: bson.D{}, Skip: q.Skip}
+	if filter != nil {
+		p.Filter = bson.D{{Key: "$and", Value: bson.A{filter}}}
+	}
+
+	if q.Cursor != "" {
+		c, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if c.Field != q.SortField || c.Desc != q.Desc {
+			return nil, ErrInvalidCursor
+		}
+		p.cur, p.Skip = c, 0
+		p.Filter = bson.D{{Key: "$and", Value: append(bson.A{p.Filter}, c.filter())}}
+	}
+
+	// a previous page is read backwards from the cursor, and reversed by Page
+	desc := q.Desc != p.backward()
+	dir := 1
+	if desc {
+		dir = -1
+	}
+	p.Sort = bson.D{{Key: q.SortField, Value: dir}}
+	if q.SortField != idField {
+		p.Sort = append(p.Sort, bson.E{Key: idField, Value: dir})
+	}
+
+	// one more than asked for tells if there is a page after
+	if q.Limit > 0 {
+		p.Limit = q.Limit + 1
+	}
+
+	return p, nil
+}
+
+func (p *PagePlan) backward() bool {
+	return p.cur != nil && p.cur.Prev
+}
+
+// filter matches the documents past c, in the direction c reads
+func (c *cursor) filter() bson.D {
+	op := "$gt"
+	if c.Desc != c.Prev {
+		op = "$lt"
+	}
+
+	if c.Field == idField {
+		return bson.D{{Key: idField, Value: bson.D{{Key: op, Value: c.ID}}}}
+	}
+
+	return bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: c.Field, Value: bson.D{{Key: op, Value: c.Value}}}},
+		bson.D{{Key: c.Field, Value: c.Value}, {Key: idField, Value: bson.D{{Key: op, Value: c.ID}}}},
+	}}}
+}
+
+// Page trims docs, the results of the find of p, to the page and returns the cursors
+// around it
+func (p *PagePlan) Page(docs []bson.Raw) ([]bson.Raw, *PageInfo, error) {
+	more := p.q.Limit > 0 && int64(len(docs)) > p.q.Limit
+	if more {
+		docs = docs[:p.q.Limit]
+	}
+	if p.backward() {
+		for i, j := 0, len(docs)-1; i < j; i, j = i+1, j-1 {
+			docs[i], docs[j] = docs[j], docs[i]
+		}
+	}
+
+	info := &PageInfo{}
+	if len(docs) == 0 {
+		return docs, info, nil
+	}
+
+	// going forward, a cursor or a skip means the page has documents before it. Going
+	// backward, the cursor came from a document after it.
+	hasPrev, hasNext := p.cur != nil || p.Skip > 0, more
+	if p.backward() {
+		hasPrev, hasNext = more, true
+	}
+
+	var err error
+	if hasNext {
+		info.NextCursor, err = p.cursorAt(docs[len(docs)-1], false)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if hasPrev {
+		info.PrevCursor, err = p.cursorAt(docs[0], true)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return docs, info, nil
+}
+
+func (p *PagePlan) cursorAt(doc bson.Raw, prev bool) (string, error) {
+	c := &cursor{Field: p.q.SortField, Desc: p.q.Desc, Prev: prev}
+
+	id, err := doc.LookupErr(idField)
+	if err != nil {
+		return "", fmt.Errorf("infra: document without %s: %w", idField, err)
+	}
+	if err := id.Unmarshal(&c.ID); err != nil {
+		return "", err
+	}
+
+	if v, err := doc.LookupErr(strings.Split(p.q.SortField, ".")...); err == nil {
+		if err := v.Unmarshal(&c.Value); err != nil {
+			return "", err
+		}
+	}
+	if !isScalar(c.Value) {
+		return "", fmt.Errorf("infra: can not page by %s, a %s", p.q.SortField, reflect.TypeOf(c.Value))
+	}
+
+	return c.encode()
+}
+
+// DecodeAll decodes docs into the slice v points to, like mongo.Cursor.All
+func DecodeAll(docs []bson.Raw, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("infra: results need a pointer to a slice, got %T", v)
+	}
+
+	slice := rv.Elem()
+	elemType := slice.Type().Elem()
+	list := reflect.MakeSlice(slice.Type(), 0, len(docs))
+	for _, doc := range docs {
+		elem := reflect.New(elemType)
+		target := elem.Interface()
+		if elemType.Kind() == reflect.Ptr {
+			elem.Elem().Set(reflect.New(elemType.Elem()))
+			target = elem.Elem().Interface()
+		}
+		if err := bson.Unmarshal(doc, target); err != nil {
+			return err
+		}
+		list = reflect.Append(list, elem.Elem())
+	}
+	slice.Set(list)
+
+	return nil
+}
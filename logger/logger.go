@@ -1,5 +1,15 @@
+// Package logger is the structured logger of the app. Entries carry the request scoped
+// fields of their context and have secrets redacted before they are written.
 package logger
 
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
 type LogLevel string
 
 const (
@@ -10,17 +20,156 @@ const (
 	Fatal LogLevel = "Fatal"
 )
 
+// ParseLevel reads a level of the config, like debug or info
+func ParseLevel(s string) (LogLevel, error) {
+	for _, l := range []LogLevel{Debug, Info, Warn, Error, Fatal} {
+		if strings.EqualFold(s, string(l)) {
+			return l, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown log level %q", s)
+}
+
+// Logger writes leveled logs. Every entry carries the function it was logged in, the
+// fields of the scope of ctx, see WithFields, and the request id, trace id and route of
+// the request ctx belongs to. Sensitive fields and values are redacted.
 type Logger interface {
-	Infoln(fn, tid string, msg string)
-	Infof(fn, tid string, format string, args ...interface{})
-	Warnln(fn, tid string, msg string)
-	Errorln(fn, tid string, msg string)
-	Errorf(fn, tid string, format string, args ...interface{})
-	Print(level LogLevel, fn, tid string, msg string)
+	Debugln(ctx context.Context, fn string, msg string)
+	Debugf(ctx context.Context, fn string, format string, args ...interface{})
+	Infoln(ctx context.Context, fn string, msg string)
+	Infof(ctx context.Context, fn string, format string, args ...interface{})
+	Warnln(ctx context.Context, fn string, msg string)
+	Warnf(ctx context.Context, fn string, format string, args ...interface{})
+	Errorln(ctx context.Context, fn string, msg string)
+	Errorf(ctx context.Context, fn string, format string, args ...interface{})
+	Print(level LogLevel, ctx context.Context, fn string, msg string)
+}
+
+// Fields are request scoped key values logged with every entry of a context
+type Fields map[string]interface{}
+
+// scope holds the fields of a context and the contexts derived from it
+type scope struct {
+	mu     sync.RWMutex
+	fields Fields
+}
+
+type scopeKey struct{}
+
+// WithFields returns a copy of ctx with a new scope holding the fields of the scope of
+// ctx, if any, and fields
+func WithFields(ctx context.Context, fields Fields) context.Context {
+	s := &scope{fields: fieldsFrom(ctx)}
+	for k, v := range fields {
+		s.fields[k] = v
+	}
+
+	return context.WithValue(ctx, scopeKey{}, s)
+}
+
+// AddFields adds fields to the scope of ctx, so every context sharing the scope logs
+// them, like the access log entry of a request that learns the user in a later
+// middleware. It does nothing when ctx has no scope.
+func AddFields(ctx context.Context, fields Fields) {
+	s, ok := ctx.Value(scopeKey{}).(*scope)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, v := range fields {
+		s.fields[k] = v
+	}
+}
+
+// fieldsFrom returns a copy of the fields of the scope of ctx
+func fieldsFrom(ctx context.Context) Fields {
+	fields := Fields{}
+	if ctx == nil {
+		return fields
+	}
+	s, ok := ctx.Value(scopeKey{}).(*scope)
+	if !ok {
+		return fields
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for k, v := range s.fields {
+		fields[k] = v
+	}
+
+	return fields
 }
 
 func GetDefaultStructLogger(verbose bool) Logger {
-	logger := NewZeroLevelLogger(verbose)
-	logger.Infoln("GetDefaultStructLogger", "0000000000", "Running in verbose mode")
+	level := Info
+	if verbose {
+		level = Debug
+	}
+	logger := NewZeroLevelLogger(level)
+	logger.Debugln(context.Background(), "GetDefaultStructLogger", "Running in verbose mode")
 	return logger
 }
+
+// std backs the package level funcs, for code that is not handed a Logger
+var std = NewZeroLevelLogger(Info)
+
+// SetDefault makes l the logger of the package level funcs
+func SetDefault(l Logger) {
+	std = l
+}
+
+// Default returns the logger of the package level funcs
+func Default() Logger {
+	return std
+}
+
+func Debugln(ctx context.Context, fn string, msg string) {
+	std.Debugln(ctx, fn, msg)
+}
+
+func Debugf(ctx context.Context, fn string, format string, args ...interface{}) {
+	std.Debugf(ctx, fn, format, args...)
+}
+
+func Infoln(ctx context.Context, fn string, msg string) {
+	std.Infoln(ctx, fn, msg)
+}
+
+func Infof(ctx context.Context, fn string, format string, args ...interface{}) {
+	std.Infof(ctx, fn, format, args...)
+}
+
+func Warnln(ctx context.Context, fn string, msg string) {
+	std.Warnln(ctx, fn, msg)
+}
+
+func Warnf(ctx context.Context, fn string, format string, args ...interface{}) {
+	std.Warnf(ctx, fn, format, args...)
+}
+
+func Errorln(ctx context.Context, fn string, msg string) {
+	std.Errorln(ctx, fn, msg)
+}
+
+func Errorf(ctx context.Context, fn string, format string, args ...interface{}) {
+	std.Errorf(ctx, fn, format, args...)
+}
+
+// Writer returns a writer logging every write at level, as if logged in fn. It lets the
+// standard logger and libraries using it write through the default logger.
+func Writer(fn string, level LogLevel) io.Writer {
+	return writerFunc(func(p []byte) (int, error) {
+		std.Print(level, context.Background(), fn, strings.TrimSuffix(string(p), "\n"))
+		return len(p), nil
+	})
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces the values that must not reach the logs
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are parts of the names of fields, json keys and query parameters whose
+// values are redacted
+var sensitiveKeys = []string{"password", "passwd", "otp", "token", "secret", "authorization", "cookie", "hash"}
+
+var (
+	// jwtPattern matches a signed jwt, whatever it is labelled with
+	jwtPattern    = regexp.MustCompile(`eyJ[\w-]+\.[\w-]+\.[\w-]+`)
+	bearerPattern = regexp.MustCompile(`(?i)(bearer\s+)\S+`)
+	// pairPattern matches key value pairs like password=x, "otp": "x" or token: x
+	pairPattern = regexp.MustCompile(`(?i)("?[\w-]*(?:` + strings.Join(sensitiveKeys, "|") + `)[\w-]*"?\s*[:=]\s*)((?:bearer\s+)?(?:"[^"]*"|[^\s,&}]+))`)
+)
+
+// IsSensitive tells whether the value of the field key must be redacted
+func IsSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Redact masks the secrets found in msg, like jwts, bearer tokens and the values of
+// sensitive keys
+func Redact(msg string) string {
+	msg = jwtPattern.ReplaceAllString(msg, Redacted)
+	msg = pairPattern.ReplaceAllString(msg, "${1}"+Redacted)
+
+	return bearerPattern.ReplaceAllString(msg, "${1}"+Redacted)
+}
@@ -1,72 +1,119 @@
 package logger
 
 import (
+	"context"
+	"fmt"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
 	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"os"
 )
 
 type zeroLevelLogger struct {
-	lgr     zerolog.Logger
-	verbose bool
+	lgr zerolog.Logger
 }
 
-func NewZeroLevelLogger(verbose bool) Logger {
-	//if verbose{
-	//	zerolog.SetGlobalLevel(zerolog.DebugLevel)
-	//} else {
-	//	zerolog.SetGlobalLevel(zerolog.Disabled)
-	//}
+// NewZeroLevelLogger returns a logger writing json lines of level and above to stderr
+func NewZeroLevelLogger(level LogLevel) Logger {
+	return New(os.Stderr, level)
+}
 
-	sublogger := log.With().CallerWithSkipFrameCount(3).Stack().
+// New returns a logger writing json lines of level and above to w
+func New(w io.Writer, level LogLevel) Logger {
+	sublogger := zerolog.New(w).Level(getZeroLevel(level)).With().Timestamp().
 		Str("service", "auth").
 		Logger()
 
 	return &zeroLevelLogger{
-		lgr:     sublogger,
-		verbose: verbose,
+		lgr: sublogger,
 	}
 }
-func (l zeroLevelLogger) Infoln(fn, tid string, msg string) {
-	level := zerolog.Disabled
-	if l.verbose {
-		level = zerolog.InfoLevel
-	}
-	l.lgr.WithLevel(level).Str("function", fn).Str("tid", tid).Msg(msg)
+
+func (l zeroLevelLogger) Debugln(ctx context.Context, fn string, msg string) {
+	l.log(ctx, zerolog.DebugLevel, fn, msg)
 }
 
-func (l zeroLevelLogger) Infof(fn, tid string, format string, args ...interface{}) {
-	level := zerolog.Disabled
-	if l.verbose {
-		level = zerolog.InfoLevel
-	}
-	l.lgr.WithLevel(level).Str("function", fn).Str("tid", tid).Msgf(format, args...)
+func (l zeroLevelLogger) Debugf(ctx context.Context, fn string, format string, args ...interface{}) {
+	l.log(ctx, zerolog.DebugLevel, fn, fmt.Sprintf(format, args...))
 }
 
-func (l zeroLevelLogger) Warnln(fn, tid string, msg string) {
-	level := zerolog.Disabled
-	if l.verbose {
-		level = zerolog.WarnLevel
-	}
-	l.lgr.WithLevel(level).Str("function", fn).Str("tid", tid).Msg(msg)
+func (l zeroLevelLogger) Infoln(ctx context.Context, fn string, msg string) {
+	l.log(ctx, zerolog.InfoLevel, fn, msg)
+}
+
+func (l zeroLevelLogger) Infof(ctx context.Context, fn string, format string, args ...interface{}) {
+	l.log(ctx, zerolog.InfoLevel, fn, fmt.Sprintf(format, args...))
 }
 
-func (l zeroLevelLogger) Errorln(fn, tid string, msg string) {
-	level := zerolog.Disabled
-	if l.verbose {
-		level = zerolog.ErrorLevel
+func (l zeroLevelLogger) Warnln(ctx context.Context, fn string, msg string) {
+	l.log(ctx, zerolog.WarnLevel, fn, msg)
+}
+
+func (l zeroLevelLogger) Warnf(ctx context.Context, fn string, format string, args ...interface{}) {
+	l.log(ctx, zerolog.WarnLevel, fn, fmt.Sprintf(format, args...))
+}
+
+func (l zeroLevelLogger) Errorln(ctx context.Context, fn string, msg string) {
+	l.log(ctx, zerolog.ErrorLevel, fn, msg)
+}
+
+func (l zeroLevelLogger) Errorf(ctx context.Context, fn string, format string, args ...interface{}) {
+	l.log(ctx, zerolog.ErrorLevel, fn, fmt.Sprintf(format, args...))
+}
+
+func (l zeroLevelLogger) Print(level LogLevel, ctx context.Context, fn string, msg string) {
+	l.log(ctx, getZeroLevel(level), fn, msg)
+}
+
+func (l zeroLevelLogger) log(ctx context.Context, level zerolog.Level, fn string, msg string) {
+	e := l.lgr.WithLevel(level)
+	if e == nil {
+		return
 	}
-	l.lgr.WithLevel(level).Str("function", fn).Str("tid", tid).Msg(msg)
+
+	e.Str("function", fn).Fields(requestFields(ctx)).Msg(Redact(msg))
 }
-func (l zeroLevelLogger) Errorf(fn, tid string, format string, args ...interface{}) {
-	level := zerolog.Disabled
-	if l.verbose {
-		level = zerolog.ErrorLevel
+
+// requestFields returns the fields of the request ctx belongs to, redacted
+func requestFields(ctx context.Context) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if ctx == nil {
+		return fields
+	}
+
+	for k, v := range fieldsFrom(ctx) {
+		switch {
+		case IsSensitive(k):
+			v = Redacted
+		case isString(v):
+			v = Redact(fmt.Sprint(v))
+		}
+		fields[k] = v
 	}
-	l.lgr.WithLevel(level).Str("function", fn).Str("tid", tid).Msgf(format, args...)
+	if id := middleware.GetReqID(ctx); id != "" {
+		fields["request_id"] = id
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		fields["trace_id"] = sc.TraceID().String()
+	}
+	// the pattern grows while chi routes the request, it is complete in the handler and
+	// in the middlewares once the handler returned
+	if rctx := chi.RouteContext(ctx); rctx != nil && rctx.RoutePattern() != "" {
+		fields["route"] = rctx.RoutePattern()
+	}
+
+	return fields
 }
 
-func (l zeroLevelLogger) Print(level LogLevel, fn, tid string, msg string) {
-	l.lgr.Log().Str("level", getZeroLevel(level).String()).Str("function", fn).Str("tid", tid).Msg(msg)
+func isString(v interface{}) bool {
+	switch v.(type) {
+	case string, fmt.Stringer, error:
+		return true
+	}
+
+	return false
 }
 
 func getZeroLevel(level LogLevel) zerolog.Level {
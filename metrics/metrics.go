@@ -0,0 +1,96 @@
+// Package metrics collects prometheus metrics of the http api, the auth flows and the
+// database and cache connection pools, and serves them on /metrics.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+)
+
+const namespace = "ab_auth"
+
+// Results of an auth flow
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// Registry holds every metric of the app, next to the go runtime and process metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequestDuration observes requests by chi route pattern, so paths holding ids
+	// do not make a series each
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of http requests by method, route pattern and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	Logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Password logins by user type and result.",
+	}, []string{"user_type", "result"})
+
+	OTPSends = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "otp_sends_total",
+		Help:      "One time passwords sent by purpose.",
+	}, []string{"purpose"})
+
+	OTPMatches = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "otp_matches_total",
+		Help:      "One time password checks by purpose and result.",
+	}, []string{"purpose", "result"})
+
+	RateLimitRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limit_rejections_total",
+		Help:      "Requests rejected with 429 by error code.",
+	}, []string{"code"})
+
+	TokenRefreshes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "token_refreshes_total",
+		Help:      "Token refreshes by user type and result.",
+	}, []string{"user_type", "result"})
+
+	CaptchaFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "captcha_failures_total",
+		Help:      "Captcha verifications that failed or could not be made.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		HTTPRequestDuration,
+		Logins,
+		OTPSends,
+		OTPMatches,
+		RateLimitRejections,
+		TokenRefreshes,
+		CaptchaFailures,
+	)
+}
+
+// Result returns the result label of a flow that ended with err
+func Result(err error) string {
+	if err != nil {
+		return ResultFailure
+	}
+
+	return ResultSuccess
+}
+
+// Handler serves the metrics of Registry
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"github.com/go-chi/chi"
+	"github.com/go-redis/redis"
+	"github.com/iamrz1/ab-auth/infra"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type fakeMongoPool struct{}
+
+func (fakeMongoPool) PoolStats() infra.PoolStats {
+	return infra.PoolStats{Open: 7, InUse: 2}
+}
+
+type fakeRedisPool struct{}
+
+func (fakeRedisPool) PoolStats() *redis.PoolStats {
+	return &redis.PoolStats{Hits: 10, Misses: 1, TotalConns: 4, IdleConns: 3}
+}
+
+func scrape(t *testing.T) string {
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	b, err := ioutil.ReadAll(rec.Body)
+	assert.NoError(t, err)
+
+	return string(b)
+}
+
+func TestMiddleware_LabelsByRoutePattern(t *testing.T) {
+	sub := chi.NewRouter()
+	sub.Get("/customers/{username}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Mount("/api/v1", sub)
+
+	for _, path := range []string{"/api/v1/customers/alice", "/api/v1/customers/bob", "/nowhere"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	out := scrape(t)
+	assert.Contains(t, out, `ab_auth_http_request_duration_seconds_count{method="GET",route="/api/v1/customers/{username}",status="418"} 2`)
+	assert.Contains(t, out, `ab_auth_http_request_duration_seconds_count{method="GET",route="unmatched",status="404"} 1`)
+}
+
+func TestRegisterPools(t *testing.T) {
+	assert.NoError(t, RegisterPools(fakeMongoPool{}, fakeRedisPool{}))
+
+	out := scrape(t)
+	assert.Contains(t, out, "ab_auth_mongo_pool_open_connections 7")
+	assert.Contains(t, out, "ab_auth_mongo_pool_in_use_connections 2")
+	assert.Contains(t, out, "ab_auth_redis_pool_total_connections 4")
+	assert.Contains(t, out, "ab_auth_redis_pool_hits_total 10")
+}
+
+func TestResult(t *testing.T) {
+	assert.Equal(t, ResultSuccess, Result(nil))
+	assert.Equal(t, ResultFailure, Result(assert.AnError))
+}
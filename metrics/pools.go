@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"github.com/go-redis/redis"
+	"github.com/iamrz1/ab-auth/infra"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MongoPool is the connection pool of infraMongo.Mongo
+type MongoPool interface {
+	PoolStats() infra.PoolStats
+}
+
+// RedisPool is the connection pool of infraCache.Redis
+type RedisPool interface {
+	PoolStats() *redis.PoolStats
+}
+
+// RegisterPools exposes the connection pool stats of mongo and redis, read on every scrape
+func RegisterPools(mongo MongoPool, cache RedisPool) error {
+	gauge := func(subsystem, name, help string, fn func() float64) prometheus.Collector {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      name,
+			Help:      help,
+		}, fn)
+	}
+	counter := func(subsystem, name, help string, fn func() float64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      name,
+			Help:      help,
+		}, fn)
+	}
+
+	collectors := []prometheus.Collector{
+		gauge("mongo_pool", "open_connections", "Open connections to mongo.", func() float64 {
+			return float64(mongo.PoolStats().Open)
+		}),
+		gauge("mongo_pool", "in_use_connections", "Connections to mongo checked out of the pool.", func() float64 {
+			return float64(mongo.PoolStats().InUse)
+		}),
+		gauge("redis_pool", "total_connections", "Open connections to redis.", func() float64 {
+			return float64(cache.PoolStats().TotalConns)
+		}),
+		gauge("redis_pool", "idle_connections", "Idle connections to redis.", func() float64 {
+			return float64(cache.PoolStats().IdleConns)
+		}),
+		counter("redis_pool", "hits_total", "Times a free connection was found in the pool.", func() float64 {
+			return float64(cache.PoolStats().Hits)
+		}),
+		counter("redis_pool", "misses_total", "Times no free connection was found in the pool.", func() float64 {
+			return float64(cache.PoolStats().Misses)
+		}),
+		counter("redis_pool", "timeouts_total", "Times waiting for a connection timed out.", func() float64 {
+			return float64(cache.PoolStats().Timeouts)
+		}),
+		counter("redis_pool", "stale_connections_total", "Stale connections removed from the pool.", func() float64 {
+			return float64(cache.PoolStats().StaleConns)
+		}),
+	}
+
+	for _, c := range collectors {
+		err := Registry.Register(c)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
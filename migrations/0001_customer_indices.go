@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	"context"
+	"github.com/iamrz1/ab-auth/infra"
+	"github.com/iamrz1/ab-auth/utils"
+)
+
+// customerIndices keep usernames unique, so concurrent sign up verifications cannot
+// create the same customer twice, and let the erasure worker find due customers
+var customerIndices = []infra.DbIndex{
+	{Name: "username_unique", Keys: []infra.DbIndexKey{{Key: "username", Asc: 1}}, Unique: utils.BoolP(true)},
+	{Name: "erasure_scheduled_at", Keys: []infra.DbIndexKey{{Key: "erasure_scheduled_at", Asc: 1}}, Sparse: utils.BoolP(true)},
+}
+
+func init() {
+	Register(Migration{
+		Version: 1,
+		Name:    "customer_indices",
+		Up: func(ctx context.Context, env *Env) error {
+			return env.DB.EnsureIndices(ctx, env.Collections.CustomerCollection, customerIndices)
+		},
+		Down: func(ctx context.Context, env *Env) error {
+			return env.DB.DropIndices(ctx, env.Collections.CustomerCollection, customerIndices)
+		},
+	})
+}
@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"context"
+	"github.com/iamrz1/ab-auth/infra"
+	"github.com/iamrz1/ab-auth/utils"
+)
+
+// merchantIndices keep usernames unique, like those of customers
+var merchantIndices = []infra.DbIndex{
+	{Name: "username_unique", Keys: []infra.DbIndexKey{{Key: "username", Asc: 1}}, Unique: utils.BoolP(true)},
+}
+
+func init() {
+	Register(Migration{
+		Version: 2,
+		Name:    "merchant_indices",
+		Up: func(ctx context.Context, env *Env) error {
+			return env.DB.EnsureIndices(ctx, env.Collections.MerchantCollection, merchantIndices)
+		},
+		Down: func(ctx context.Context, env *Env) error {
+			return env.DB.DropIndices(ctx, env.Collections.MerchantCollection, merchantIndices)
+		},
+	})
+}
@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"context"
+	"github.com/iamrz1/ab-auth/infra"
+	"github.com/iamrz1/ab-auth/utils"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// addressIndices serve the addresses of a customer and location searches, and keep a
+// customer to one live primary address. The first two were created by serve before
+// migrations existed, so they keep their names.
+var addressIndices = []infra.DbIndex{
+	{Name: "location_2dsphere", Keys: []infra.DbIndexKey{{Key: "location", Asc: "2dsphere"}}},
+	{
+		Name:          "username_primary_unique",
+		Keys:          []infra.DbIndexKey{{Key: "username", Asc: 1}},
+		Unique:        utils.BoolP(true),
+		PartialFilter: bson.M{"is_primary": true, "is_deleted": false},
+	},
+	{Name: "username_is_deleted", Keys: []infra.DbIndexKey{{Key: "username", Asc: 1}, {Key: "is_deleted", Asc: 1}}},
+}
+
+func init() {
+	Register(Migration{
+		Version: 3,
+		Name:    "address_indices",
+		Up: func(ctx context.Context, env *Env) error {
+			return env.DB.EnsureIndices(ctx, env.Collections.AddressCollection, addressIndices)
+		},
+		Down: func(ctx context.Context, env *Env) error {
+			return env.DB.DropIndices(ctx, env.Collections.AddressCollection, addressIndices)
+		},
+	})
+}
@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"context"
+	"github.com/iamrz1/ab-auth/infra"
+)
+
+// bdLocationIndices back the keyset pages of BD location presets, which are sorted by name
+// within a parent and then by id
+var bdLocationIndices = []infra.DbIndex{
+	{Name: "parent_name_id", Keys: []infra.DbIndexKey{{Key: "parent", Asc: 1}, {Key: "name", Asc: 1}, {Key: "_id", Asc: 1}}},
+}
+
+func init() {
+	Register(Migration{
+		Version: 4,
+		Name:    "bd_location_indices",
+		Up: func(ctx context.Context, env *Env) error {
+			return env.DB.EnsureIndices(ctx, env.Collections.BDLocationCollection, bdLocationIndices)
+		},
+		Down: func(ctx context.Context, env *Env) error {
+			return env.DB.DropIndices(ctx, env.Collections.BDLocationCollection, bdLocationIndices)
+		},
+	})
+}
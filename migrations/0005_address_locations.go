@@ -0,0 +1,75 @@
+package migrations
+
+import (
+	"context"
+	"github.com/iamrz1/ab-auth/model"
+	"github.com/iamrz1/ab-auth/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// addressLocationBatchSize is how many addresses are read and updated at once
+const addressLocationBatchSize = 500
+
+func init() {
+	Register(Migration{
+		Version: 5,
+		Name:    "address_locations",
+		Up:      backfillAddressLocations,
+		// the locations mirror the coordinates and addresses saved since keep them in
+		// sync, so they are left in place
+		Down: func(ctx context.Context, env *Env) error {
+			return nil
+		},
+	})
+}
+
+// backfillAddressLocations sets the GeoJSON location of addresses stored before it existed,
+// deleted ones included, from their coordinates. Addresses with out of range coordinates
+// are skipped, as the 2dsphere index of migration 3 would reject them.
+func backfillAddressLocations(ctx context.Context, env *Env) error {
+	afterID := primitive.NilObjectID
+	for {
+		filter := bson.M{
+			"location":  bson.M{"$exists": false},
+			"latitude":  bson.M{"$exists": true},
+			"longitude": bson.M{"$exists": true},
+		}
+		if !afterID.IsZero() {
+			filter["_id"] = bson.M{"$gt": afterID}
+		}
+
+		list := make([]*model.Address, 0)
+		err := env.DB.List(ctx, env.Collections.AddressCollection, filter, 1, addressLocationBatchSize, &list, bson.M{"_id": 1})
+		if err != nil {
+			return err
+		}
+		if len(list) == 0 {
+			return nil
+		}
+
+		models := make([]mongo.WriteModel, 0, len(list))
+		for _, a := range list {
+			id, err := primitive.ObjectIDFromHex(a.ID)
+			if err != nil {
+				return err
+			}
+			afterID = id
+
+			if a.Latitude == 0 && a.Longitude == 0 || !utils.IsValidCoordinate(a.Latitude, a.Longitude) {
+				continue
+			}
+			models = append(models, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"_id": id}).
+				SetUpdate(bson.M{"$set": bson.M{"location": model.NewGeoPoint(a.Latitude, a.Longitude)}}))
+		}
+
+		if len(models) > 0 {
+			err = env.DB.BulkUpdate(ctx, env.Collections.AddressCollection, models)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package migrations
+
+import (
+	"context"
+	"github.com/iamrz1/ab-auth/config"
+	"github.com/iamrz1/ab-auth/infra/memdb"
+	"github.com/iamrz1/ab-auth/logger"
+	"github.com/iamrz1/ab-auth/model"
+	"github.com/iamrz1/ab-auth/utils"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"io/ioutil"
+	"testing"
+)
+
+func TestBackfillAddressLocations(t *testing.T) {
+	cfg := config.Defaults().Database
+	cfg.AddressCollection = "address"
+	db := memdb.New()
+	ctx := context.Background()
+
+	addresses := []*model.Address{
+		{Username: "01700000001", Latitude: 23.7461, Longitude: 90.3742, IsDeleted: utils.BoolP(false)},
+		{Username: "01700000002", Latitude: 22.3569, Longitude: 91.7832, IsDeleted: utils.BoolP(true)},
+		{Username: "01700000003", Latitude: 123.5, Longitude: 90.3742, IsDeleted: utils.BoolP(false)},
+		{Username: "01700000004", IsDeleted: utils.BoolP(false)},
+	}
+	for _, a := range addresses {
+		if err := db.Insert(ctx, cfg.AddressCollection, a); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := NewMigrator(db, cfg, logger.New(ioutil.Discard, logger.Error)).Up(ctx, 0)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	for _, c := range []struct {
+		username string
+		want     *model.GeoPoint
+	}{
+		{"01700000001", model.NewGeoPoint(23.7461, 90.3742)},
+		{"01700000002", model.NewGeoPoint(22.3569, 91.7832)},
+		{"01700000003", nil},
+		{"01700000004", nil},
+	} {
+		a := &model.Address{}
+		if assert.NoError(t, db.FindOne(ctx, cfg.AddressCollection, bson.M{"username": c.username}, a)) {
+			assert.Equal(t, c.want, a.Location, c.username)
+		}
+	}
+}
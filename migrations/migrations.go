@@ -0,0 +1,55 @@
+// Package migrations versions the mongo schema. Every migration registers itself from a
+// file named after its version, and a Migrator applies and reverts them in order while
+// holding a lock in the migration state collection.
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"github.com/iamrz1/ab-auth/config"
+	"github.com/iamrz1/ab-auth/infra"
+	"sort"
+)
+
+// Env is what migrations run against
+type Env struct {
+	DB          infra.DB
+	Collections config.DatabaseConfig
+}
+
+// Migration changes the schema from the previous version to Version with Up, and back
+// with Down. Both must be safe to run again after they failed half way.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(ctx context.Context, env *Env) error
+	Down    func(ctx context.Context, env *Env) error
+}
+
+var registry = map[int]Migration{}
+
+// Register adds m to the migrations the Migrator applies. It is meant to be called from
+// the init func of the file of m and panics if m is incomplete or its version is taken.
+func Register(m Migration) {
+	if m.Version <= 0 || m.Name == "" || m.Up == nil || m.Down == nil {
+		panic(fmt.Sprintf("migrations: incomplete migration %d %q", m.Version, m.Name))
+	}
+	if prev, ok := registry[m.Version]; ok {
+		panic(fmt.Sprintf("migrations: version %d of %q is taken by %q", m.Version, m.Name, prev.Name))
+	}
+
+	registry[m.Version] = m
+}
+
+// All returns the registered migrations, oldest first
+func All() []Migration {
+	list := make([]Migration, 0, len(registry))
+	for _, m := range registry {
+		list = append(list, m)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Version < list[j].Version
+	})
+
+	return list
+}
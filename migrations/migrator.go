@@ -0,0 +1,236 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/iamrz1/ab-auth/config"
+	"github.com/iamrz1/ab-auth/infra"
+	"github.com/iamrz1/ab-auth/logger"
+	"github.com/iamrz1/ab-auth/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"os"
+	"sort"
+	"time"
+)
+
+const (
+	// lockID is the id of the lock document in the migration state collection, next to
+	// the records keyed by version
+	lockID = "lock"
+	// LockTTL is how long a lock is held at most. A run that died keeps the lock until it
+	// expires, so it outlasts the longest index build.
+	LockTTL = time.Minute * 30
+)
+
+var (
+	ErrLocked         = errors.New("migrations: another run holds the lock")
+	ErrUnknownVersion = errors.New("migrations: applied version is not registered")
+)
+
+// Migrator applies and reverts migrations, and records which ones are applied
+type Migrator struct {
+	env        *Env
+	collection string
+	migrations []Migration
+	owner      string
+	lockTTL    time.Duration
+	lgr        logger.Logger
+}
+
+// NewMigrator returns a migrator of the registered migrations, keeping its state in the
+// migration collection of cfg
+func NewMigrator(db infra.DB, cfg config.DatabaseConfig, lgr logger.Logger) *Migrator {
+	host, _ := os.Hostname()
+
+	return &Migrator{
+		env:        &Env{DB: db, Collections: cfg},
+		collection: cfg.MigrationCollection,
+		migrations: All(),
+		owner:      fmt.Sprintf("%s:%d", host, os.Getpid()),
+		lockTTL:    LockTTL,
+		lgr:        lgr,
+	}
+}
+
+// Status returns every registered migration, oldest first, with the time it was applied
+// at, followed by applied migrations that are not registered
+func (m *Migrator) Status(ctx context.Context) ([]model.MigrationStatus, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]model.MigrationStatus, 0, len(m.migrations))
+	for _, mg := range m.migrations {
+		s := model.MigrationStatus{Version: mg.Version, Name: mg.Name}
+		if rec, ok := applied[mg.Version]; ok {
+			s.AppliedAt = &rec.AppliedAt
+			delete(applied, mg.Version)
+		}
+		list = append(list, s)
+	}
+
+	unknown := make([]model.MigrationStatus, 0, len(applied))
+	for _, rec := range applied {
+		at := rec.AppliedAt
+		unknown = append(unknown, model.MigrationStatus{Version: rec.Version, Name: rec.Name, AppliedAt: &at, Unknown: true})
+	}
+	sort.Slice(unknown, func(i, j int) bool {
+		return unknown[i].Version < unknown[j].Version
+	})
+
+	return append(list, unknown...), nil
+}
+
+// Pending returns the registered migrations that are not applied, oldest first
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make([]Migration, 0)
+	for _, mg := range m.migrations {
+		if _, ok := applied[mg.Version]; !ok {
+			pending = append(pending, mg)
+		}
+	}
+
+	return pending, nil
+}
+
+// Up applies the pending migrations up to version to, or all of them when to is 0, and
+// returns the ones it applied. It stops at the first one that fails.
+func (m *Migrator) Up(ctx context.Context, to int) ([]Migration, error) {
+	err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer m.unlock()
+
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make([]Migration, 0, len(pending))
+	for _, mg := range pending {
+		if to > 0 && mg.Version > to {
+			break
+		}
+
+		m.lgr.Infof(ctx, "Up", "applying migration %d %s", mg.Version, mg.Name)
+		err = mg.Up(ctx, m.env)
+		if err != nil {
+			return done, fmt.Errorf("migration %d %s: %w", mg.Version, mg.Name, err)
+		}
+
+		err = m.env.DB.Insert(ctx, m.collection, &model.MigrationRecord{Version: mg.Version, Name: mg.Name, AppliedAt: time.Now().UTC()})
+		if err != nil {
+			return done, err
+		}
+		done = append(done, mg)
+	}
+
+	return done, nil
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns the ones it
+// reverted. It stops at the first one that fails or that is not registered.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer m.unlock()
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]int, 0, len(applied))
+	for v := range applied {
+		versions = append(versions, v)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+
+	registered := make(map[int]Migration, len(m.migrations))
+	for _, mg := range m.migrations {
+		registered[mg.Version] = mg
+	}
+
+	done := make([]Migration, 0, steps)
+	for i := 0; i < steps && i < len(versions); i++ {
+		mg, ok := registered[versions[i]]
+		if !ok {
+			return done, fmt.Errorf("%w: %d %s", ErrUnknownVersion, versions[i], applied[versions[i]].Name)
+		}
+
+		m.lgr.Infof(ctx, "Down", "reverting migration %d %s", mg.Version, mg.Name)
+		err = mg.Down(ctx, m.env)
+		if err != nil {
+			return done, fmt.Errorf("migration %d %s: %w", mg.Version, mg.Name, err)
+		}
+
+		_, err = m.env.DB.DeleteOne(ctx, m.collection, bson.M{"_id": mg.Version})
+		if err != nil {
+			return done, err
+		}
+		done = append(done, mg)
+	}
+
+	return done, nil
+}
+
+// applied returns the records of the applied migrations by version
+func (m *Migrator) applied(ctx context.Context) (map[int]*model.MigrationRecord, error) {
+	records := make([]*model.MigrationRecord, 0)
+	err := m.env.DB.List(ctx, m.collection, bson.M{"_id": bson.M{"$ne": lockID}}, 1, 0, &records)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]*model.MigrationRecord, len(records))
+	for _, rec := range records {
+		applied[rec.Version] = rec
+	}
+
+	return applied, nil
+}
+
+// lock takes the lock of the migration state collection, so concurrent runs, like the
+// ones of several replicas deploying at once, do not apply a migration twice
+func (m *Migrator) lock(ctx context.Context) error {
+	now := time.Now().UTC()
+
+	// a lock left behind by a run that died is taken over once it expired
+	_, err := m.env.DB.DeleteOne(ctx, m.collection, bson.M{"_id": lockID, "expires_at": bson.M{"$lte": now}})
+	if err != nil {
+		return err
+	}
+
+	err = m.env.DB.Insert(ctx, m.collection, bson.M{
+		"_id":        lockID,
+		"owner":      m.owner,
+		"locked_at":  now,
+		"expires_at": now.Add(m.lockTTL),
+	})
+	if err == infra.ErrDuplicateKey {
+		return ErrLocked
+	}
+
+	return err
+}
+
+// unlock releases the lock if this migrator still holds it. It does not use the context
+// of the run, which may be done already.
+func (m *Migrator) unlock() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	_, err := m.env.DB.DeleteOne(ctx, m.collection, bson.M{"_id": lockID, "owner": m.owner})
+	if err != nil {
+		m.lgr.Errorln(ctx, "unlock", "could not release the migration lock: "+err.Error())
+	}
+}
@@ -0,0 +1,164 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"github.com/iamrz1/ab-auth/config"
+	"github.com/iamrz1/ab-auth/infra"
+	"github.com/iamrz1/ab-auth/logger"
+	"github.com/iamrz1/ab-auth/model"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+// stateDB keeps the migration state collection in memory, other calls panic
+type stateDB struct {
+	infra.DB
+	records map[int]*model.MigrationRecord
+	lock    bson.M
+}
+
+func newStateDB() *stateDB {
+	return &stateDB{records: map[int]*model.MigrationRecord{}}
+}
+
+func (db *stateDB) Insert(ctx context.Context, collection string, doc interface{}) error {
+	switch d := doc.(type) {
+	case *model.MigrationRecord:
+		db.records[d.Version] = d
+	case bson.M:
+		if db.lock != nil {
+			return infra.ErrDuplicateKey
+		}
+		db.lock = d
+	}
+	return nil
+}
+
+func (db *stateDB) DeleteOne(ctx context.Context, collection string, filter interface{}) (int64, error) {
+	f := filter.(bson.M)
+	if f["_id"] != lockID {
+		delete(db.records, f["_id"].(int))
+		return 1, nil
+	}
+	if db.lock == nil {
+		return 0, nil
+	}
+	if owner, ok := f["owner"]; ok && owner != db.lock["owner"] {
+		return 0, nil
+	}
+	if expired, ok := f["expires_at"]; ok && db.lock["expires_at"].(time.Time).After(expired.(bson.M)["$lte"].(time.Time)) {
+		return 0, nil
+	}
+	db.lock = nil
+	return 1, nil
+}
+
+func (db *stateDB) List(ctx context.Context, collection string, filter interface{}, page, limit int64, v interface{}, sort ...interface{}) error {
+	list := v.(*[]*model.MigrationRecord)
+	for _, rec := range db.records {
+		*list = append(*list, rec)
+	}
+	return nil
+}
+
+func newTestMigrator(db infra.DB, ran *[]string, list ...Migration) *Migrator {
+	for i := range list {
+		mg := list[i]
+		list[i].Up = func(context.Context, *Env) error {
+			*ran = append(*ran, "up "+mg.Name)
+			return nil
+		}
+		list[i].Down = func(context.Context, *Env) error {
+			*ran = append(*ran, "down "+mg.Name)
+			return nil
+		}
+	}
+
+	m := NewMigrator(db, config.DatabaseConfig{MigrationCollection: "schema_migration"}, logger.New(ioutil.Discard, logger.Error))
+	m.migrations = list
+	return m
+}
+
+func TestMigrator_UpDown(t *testing.T) {
+	ctx := context.Background()
+	db := newStateDB()
+	ran := []string{}
+	m := newTestMigrator(db, &ran, Migration{Version: 1, Name: "a"}, Migration{Version: 2, Name: "b"}, Migration{Version: 3, Name: "c"})
+
+	done, err := m.Up(ctx, 2)
+	assert.NoError(t, err)
+	assert.Len(t, done, 2)
+
+	done, err = m.Up(ctx, 0)
+	assert.NoError(t, err)
+	assert.Len(t, done, 1)
+	assert.Equal(t, []string{"up a", "up b", "up c"}, ran)
+	assert.Nil(t, db.lock, "the lock is released")
+
+	done, err = m.Down(ctx, 2)
+	assert.NoError(t, err)
+	assert.Len(t, done, 2)
+	assert.Equal(t, []string{"up a", "up b", "up c", "down c", "down b"}, ran)
+
+	list, err := m.Status(ctx)
+	assert.NoError(t, err)
+	assert.NotNil(t, list[0].AppliedAt)
+	assert.Nil(t, list[1].AppliedAt)
+	assert.Nil(t, list[2].AppliedAt)
+}
+
+func TestMigrator_Locked(t *testing.T) {
+	ctx := context.Background()
+	db := newStateDB()
+	ran := []string{}
+	m := newTestMigrator(db, &ran, Migration{Version: 1, Name: "a"})
+
+	db.lock = bson.M{"_id": lockID, "owner": "other", "expires_at": time.Now().Add(time.Minute)}
+	_, err := m.Up(ctx, 0)
+	assert.Equal(t, ErrLocked, err)
+	assert.Empty(t, ran)
+	assert.Equal(t, "other", db.lock["owner"], "the lock of another run is kept")
+
+	// the other run died and its lock expired
+	db.lock["expires_at"] = time.Now().Add(-time.Minute)
+	_, err = m.Up(ctx, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"up a"}, ran)
+}
+
+func TestMigrator_UnknownVersion(t *testing.T) {
+	ctx := context.Background()
+	db := newStateDB()
+	db.records[9] = &model.MigrationRecord{Version: 9, Name: "newer", AppliedAt: time.Now()}
+	ran := []string{}
+	m := newTestMigrator(db, &ran, Migration{Version: 1, Name: "a"})
+
+	list, err := m.Status(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	assert.True(t, list[1].Unknown)
+
+	_, err = m.Down(ctx, 1)
+	assert.True(t, errors.Is(err, ErrUnknownVersion))
+	assert.Empty(t, ran)
+}
+
+func TestRegistry(t *testing.T) {
+	prev := 0
+	for _, mg := range All() {
+		assert.Greater(t, mg.Version, prev, "versions are unique and sorted")
+		prev = mg.Version
+	}
+
+	assert.Panics(t, func() {
+		Register(Migration{Version: 1, Name: "taken", Up: noop, Down: noop})
+	})
+}
+
+func noop(context.Context, *Env) error {
+	return nil
+}
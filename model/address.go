@@ -3,6 +3,7 @@ package model
 import (
 	"encoding/json"
 	"github.com/iamrz1/ab-auth/utils"
+	"time"
 )
 
 type Address struct {
@@ -22,7 +23,9 @@ type Address struct {
 	IsPrimary       *bool   `json:"is_primary,omitempty" bson:"is_primary,omitempty"`
 	Longitude       float64 `json:"longitude,omitempty" bson:"longitude,omitempty"`
 	Latitude        float64 `json:"latitude,omitempty" bson:"latitude,omitempty"`
-	IsDeleted       *bool   `json:"is_deleted,omitempty" bson:"is_deleted,omitempty"`
+	// Location mirrors Longitude and Latitude as GeoJSON, for the 2dsphere index
+	Location  *GeoPoint `json:"location,omitempty" bson:"location,omitempty"`
+	IsDeleted *bool     `json:"is_deleted,omitempty" bson:"is_deleted,omitempty"`
 }
 
 type AddressCreateReq struct {
@@ -97,12 +100,60 @@ type BDLocation struct {
 	Name   string         `json:"name" bson:"name"`
 	NameBn string         `json:"name_bn" bson:"name_bn"`
 	Slug   string         `json:"slug" bson:"slug"`
-	Parent string         `json:"parent" json:"parent"`
+	Parent string         `json:"parent" bson:"parent"`
 	Type   BDLocationType `json:"type" bson:"type"`
+	// Latitude and Longitude hold the centroid, where known
+	Latitude  float64 `json:"latitude,omitempty" bson:"latitude,omitempty"`
+	Longitude float64 `json:"longitude,omitempty" bson:"longitude,omitempty"`
 }
 
 type BDLocationReq struct {
 	Parent string `json:"parent" bson:"parent"`
 	Page   int64  `json:"-" bson:"-"`
 	Limit  int64  `json:"-" bson:"-"`
+	Cursor string `json:"-" bson:"-"`
+}
+
+// IsValidBDLocationType reports whether t is one of the four BD location levels
+func IsValidBDLocationType(t BDLocationType) bool {
+	switch t {
+	case LocationTypeDivision, LocationTypeDistrict, LocationTypeSubDistrict, LocationTypeUnion:
+		return true
+	}
+
+	return false
+}
+
+// BDLocationDataset is a bundle of BD location presets, as read by the import-presets command
+type BDLocationDataset struct {
+	Version string `json:"version"`
+	// Sample marks a partial dataset for development and tests, it is never imported in
+	// production
+	Sample    bool          `json:"sample"`
+	Locations []*BDLocation `json:"locations"`
+}
+
+// BDLocationDatasetID identifies the BD location presets among versioned datasets
+const BDLocationDatasetID = "bd_location"
+
+// DatasetVersion records which version of a dataset was imported
+type DatasetVersion struct {
+	ID         string    `json:"id" bson:"_id"`
+	Version    string    `json:"version" bson:"version"`
+	Checksum   string    `json:"checksum" bson:"checksum"`
+	Count      int       `json:"count" bson:"count"`
+	ImportedAt time.Time `json:"imported_at" bson:"imported_at"`
+}
+
+type BDLocationSearchReq struct {
+	Query string
+	Type  BDLocationType
+	Limit int64
+}
+
+// BDLocationSearchResult is a matched location with its ancestors, nearest first
+// (union -> sub-district -> district -> division)
+type BDLocationSearchResult struct {
+	BDLocation
+	Ancestors []*BDLocation `json:"ancestors"`
 }
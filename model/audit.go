@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+const (
+	AuditActionSignup         = "signup"
+	AuditActionLogin          = "login"
+	AuditActionProfileUpdate  = "profile_update"
+	AuditActionPasswordUpdate = "password_update"
+	AuditActionPasswordReset  = "password_reset"
+	AuditActionAddressAdd     = "address_add"
+	AuditActionAddressUpdate  = "address_update"
+	AuditActionAddressRemove  = "address_remove"
+	AuditActionDataExport     = "data_export"
+	AuditActionErasureRequest = "erasure_request"
+	AuditActionErasureCancel  = "erasure_cancel"
+	AuditActionStatusChange   = "status_change"
+	AuditActionDelete         = "delete"
+	AuditActionRestore        = "restore"
+)
+
+// AuditEvent records an action taken on a user's account
+type AuditEvent struct {
+	ID          string    `json:"id,omitempty" bson:"_id,omitempty"`
+	Username    string    `json:"username,omitempty" bson:"username,omitempty"`
+	UserType    string    `json:"user_type,omitempty" bson:"user_type,omitempty"`
+	Action      string    `json:"action,omitempty" bson:"action,omitempty"`
+	Actor       string    `json:"actor,omitempty" bson:"actor,omitempty"`
+	Description string    `json:"description,omitempty" bson:"description,omitempty"`
+	Reason      string    `json:"reason,omitempty" bson:"reason,omitempty"`
+	CreatedAt   time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
+}
@@ -10,8 +10,9 @@ type ListOptions struct {
 
 type EmptyObject struct{}
 
+// Validate validates s using its validate tags, reporting fields by their json names
 func Validate(s interface{}) error {
-	return validator.Validate(s)
+	return validator.WithPrintJSON(true).Validate(s)
 }
 
 type LoginReq struct {
@@ -41,3 +42,11 @@ type UpdatePasswordReq struct {
 	CurrentPassword string `json:"current_password" validate:"nonzero"`
 	NewPassword     string `json:"new_password" validate:"nonzero"`
 }
+
+// AccountStatusUpdateReq moves an account to a new status
+type AccountStatusUpdateReq struct {
+	Username string `json:"-" validate:"nonzero"`
+	Status   string `json:"status" validate:"nonzero" example:"active/blocked/inactive/pending"`
+	Reason   string `json:"reason" validate:"nonzero"`
+	Actor    string `json:"-" validate:"nonzero"` // whoever made the change, never taken from the body
+}
@@ -19,10 +19,15 @@ type Customer struct {
 	BirthDate           time.Time `json:"-" bson:"birth_date,omitempty"`
 	BirthDateString     string    `json:"birth_date,omitempty" bson:"-"`
 	Status              string    `json:"status,omitempty" bson:"status,omitempty"`
+	StatusReason        string    `json:"status_reason,omitempty" bson:"status_reason,omitempty"`
+	StatusChangedBy     string    `json:"-" bson:"status_changed_by,omitempty"`
+	StatusChangedAt     time.Time `json:"status_changed_at,omitempty" bson:"status_changed_at,omitempty"`
 	IsVerified          *bool     `json:"is_verified,omitempty" bson:"is_verified,omitempty"`
 	ProfilePicURL       string    `json:"profile_pic_url,omitempty" bson:"profile_pic_url,omitempty"`
 	IsDeleted           *bool     `json:"is_deleted,omitempty" bson:"is_deleted,omitempty"`
 	LastResetAt         time.Time `json:"-" bson:"last_reset_at,omitempty"`
+	ErasureRequestedAt  time.Time `json:"erasure_requested_at,omitempty" bson:"erasure_requested_at,omitempty"`
+	ErasureScheduledAt  time.Time `json:"erasure_scheduled_at,omitempty" bson:"erasure_scheduled_at,omitempty"`
 	CreatedAt           time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
 	UpdatedAt           time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
 }
@@ -68,6 +73,7 @@ type CustomerSignupVerificationReq struct {
 type CustomerListReq struct {
 	Page   int64
 	Limit  int64
+	Cursor string
 	Sort   string
 	Order  string
 	Search string
@@ -97,3 +103,17 @@ type CustomerShort struct {
 	Gender   string `json:"gender,omitempty"`
 	Status   string `json:"status,omitempty"`
 }
+
+// CustomerDataExport bundles every piece of personal data kept for a customer
+type CustomerDataExport struct {
+	Profile      *Customer     `json:"profile"`
+	Addresses    []*Address    `json:"addresses"`
+	AuditHistory []*AuditEvent `json:"audit_history"`
+	ExportedAt   time.Time     `json:"exported_at"`
+}
+
+type CustomerErasureRes struct {
+	Username           string    `json:"username"`
+	ErasureRequestedAt time.Time `json:"erasure_requested_at"`
+	ErasureScheduledAt time.Time `json:"erasure_scheduled_at"`
+}
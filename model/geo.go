@@ -0,0 +1,29 @@
+package model
+
+// GeoJSONPoint and GeoJSONPolygon are the GeoJSON geometry types used in queries
+const (
+	GeoJSONPoint   = "Point"
+	GeoJSONPolygon = "Polygon"
+)
+
+// GeoPoint is a GeoJSON point. Coordinates are in [longitude, latitude] order.
+type GeoPoint struct {
+	Type        string    `json:"type" bson:"type" example:"Point"`
+	Coordinates []float64 `json:"coordinates" bson:"coordinates"`
+}
+
+// NewGeoPoint returns the GeoJSON point at lat, lng
+func NewGeoPoint(lat, lng float64) *GeoPoint {
+	return &GeoPoint{Type: GeoJSONPoint, Coordinates: []float64{lng, lat}}
+}
+
+// AddressGeoSearchReq finds addresses either within RadiusKm of Latitude, Longitude,
+// nearest first, or inside Polygon. Polygon points are [longitude, latitude] pairs,
+// the ring is closed if the last point differs from the first.
+type AddressGeoSearchReq struct {
+	Latitude  float64     `json:"latitude,omitempty" example:"23.8103"`
+	Longitude float64     `json:"longitude,omitempty" example:"90.4125"`
+	RadiusKm  float64     `json:"radius_km,omitempty" example:"5"`
+	Polygon   [][]float64 `json:"polygon,omitempty"`
+	Limit     int64       `json:"limit,omitempty" example:"100"`
+}
@@ -19,6 +19,9 @@ type Merchant struct {
 	BirthDate           time.Time `json:"-" bson:"birth_date,omitempty"`
 	BirthDateString     string    `json:"birth_date,omitempty" bson:"-"`
 	Status              string    `json:"status,omitempty" bson:"status,omitempty"`
+	StatusReason        string    `json:"status_reason,omitempty" bson:"status_reason,omitempty"`
+	StatusChangedBy     string    `json:"-" bson:"status_changed_by,omitempty"`
+	StatusChangedAt     time.Time `json:"status_changed_at,omitempty" bson:"status_changed_at,omitempty"`
 	IsVerified          *bool     `json:"is_verified,omitempty" bson:"is_verified,omitempty"`
 	ProfilePicURL       string    `json:"profile_pic_url,omitempty" bson:"profile_pic_url,omitempty"`
 	IsDeleted           *bool     `json:"is_deleted,omitempty" bson:"is_deleted,omitempty"`
@@ -68,6 +71,7 @@ type MerchantSignupVerificationReq struct {
 type MerchantListReq struct {
 	Page   int64
 	Limit  int64
+	Cursor string
 	Sort   string
 	Order  string
 	Search string
@@ -97,3 +101,12 @@ type MerchantShort struct {
 	Gender   string `json:"gender,omitempty"`
 	Status   string `json:"status,omitempty"`
 }
+
+// MerchantStatus tells other services whether a merchant can do business
+type MerchantStatus struct {
+	Username     string `json:"username"`
+	Status       string `json:"status"`
+	StatusReason string `json:"status_reason,omitempty"`
+	IsVerified   bool   `json:"is_verified"`
+	IsActive     bool   `json:"is_active"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// MigrationRecord marks a schema migration as applied
+type MigrationRecord struct {
+	Version   int       `json:"version" bson:"_id"`
+	Name      string    `json:"name" bson:"name"`
+	AppliedAt time.Time `json:"applied_at" bson:"applied_at"`
+}
+
+// MigrationStatus tells whether a schema migration is applied. Applied migrations the
+// binary does not know, like ones of a newer release, have Unknown set.
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	Unknown   bool       `json:"unknown,omitempty"`
+}
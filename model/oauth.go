@@ -0,0 +1,34 @@
+package model
+
+// Token type hints of an introspection request
+const (
+	TokenTypeHintAccess  = "access_token"
+	TokenTypeHintRefresh = "refresh_token"
+)
+
+// TokenIntrospection is an RFC 7662 introspection response. Only Active is set for
+// tokens that are invalid, expired or revoked.
+type TokenIntrospection struct {
+	Active    bool   `json:"active" example:"true"`
+	Sub       string `json:"sub,omitempty" example:"01712345678"`
+	Username  string `json:"username,omitempty" example:"01712345678"`
+	UserType  string `json:"user_type,omitempty" example:"customer"`
+	Role      string `json:"role,omitempty" example:"user"`
+	TokenType string `json:"token_type,omitempty" example:"access_token"`
+	Exp       int64  `json:"exp,omitempty" example:"1609459200"`
+	Iat       int64  `json:"iat,omitempty" example:"1609457400"`
+	// Jti identifies the session the token belongs to
+	Jti string `json:"jti,omitempty"`
+}
+
+// OAuthError is an RFC 6749 error response
+type OAuthError struct {
+	Error            string `json:"error" example:"invalid_client"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// LogoutReq optionally carries the refresh token of the session, which is revoked along
+// with the access token
+type LogoutReq struct {
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
@@ -21,6 +21,15 @@ type AddressListSuccessRes struct {
 	ListMeta  ListMeta        `json:"meta"`
 }
 
+// BDLocationSearchSuccessRes example
+type BDLocationSearchSuccessRes struct {
+	Success   bool                           `json:"success" example:"true"`
+	Status    string                         `json:"status" example:"OK"`
+	Message   string                         `json:"message" example:"success message"`
+	Timestamp string                         `json:"timestamp" example:"2006-01-02T15:04:05.000Z"`
+	Data      []model.BDLocationSearchResult `json:"data"`
+}
+
 // BDLocationListSuccessRes example
 type BDLocationListSuccessRes struct {
 	Success   bool               `json:"success" example:"true"`
@@ -28,5 +37,12 @@ type BDLocationListSuccessRes struct {
 	Message   string             `json:"message" example:"success message"`
 	Timestamp string             `json:"timestamp" example:"2006-01-02T15:04:05.000Z"`
 	Data      []model.BDLocation `json:"data"`
-	ListMeta  ListMeta           `json:"meta"`
+	ListMeta  BDLocationListMeta `json:"meta"`
+}
+
+// BDLocationListMeta is the list meta along with the version of the imported presets,
+// which clients can cache presets by
+type BDLocationListMeta struct {
+	ListMeta
+	DatasetVersion string `json:"dataset_version,omitempty" example:"2021.1"`
 }
@@ -1,23 +1,33 @@
 package response
 
-import "github.com/iamrz1/ab-auth/model"
+import (
+	rest_error "github.com/iamrz1/ab-auth/error"
+	"github.com/iamrz1/ab-auth/model"
+)
 
-// EmptyErrorRes example
+// EmptyErrorRes example. Errors lists the invalid fields of validation errors, meta
+// carries the cause of internal errors outside production.
 type EmptyErrorRes struct {
-	Success   bool              `json:"success" example:"false"`
-	Status    string            `json:"status" example:"Status string corresponding to the error"`
-	Message   string            `json:"message" example:"failure message"`
-	Timestamp string            `json:"timestamp" example:"2006-01-02T15:04:05.000Z"`
-	Data      model.EmptyObject `json:"data"`
+	Success   bool                    `json:"success" example:"false"`
+	Status    string                  `json:"status" example:"Status string corresponding to the error"`
+	Code      string                  `json:"code,omitempty" example:"ACCOUNT_BLOCKED"`
+	Message   string                  `json:"message" example:"failure message"`
+	Timestamp string                  `json:"timestamp" example:"2006-01-02T15:04:05.000Z"`
+	Data      model.EmptyObject       `json:"data"`
+	Errors    []rest_error.FieldError `json:"errors,omitempty"`
+	Meta      map[string]string       `json:"meta,omitempty"`
 }
 
-// EmptyListErrorRes example
+// EmptyListErrorRes example, the list counterpart of EmptyErrorRes
 type EmptyListErrorRes struct {
-	Success   bool                `json:"success" example:"false"`
-	Status    string              `json:"status" example:"Status string corresponding to the error"`
-	Message   string              `json:"message" example:"failure message"`
-	Timestamp string              `json:"timestamp" example:"2006-01-02T15:04:05.000Z"`
-	Data      []model.EmptyObject `json:"data"`
+	Success   bool                    `json:"success" example:"false"`
+	Status    string                  `json:"status" example:"Status string corresponding to the error"`
+	Code      string                  `json:"code,omitempty" example:"ACCOUNT_BLOCKED"`
+	Message   string                  `json:"message" example:"failure message"`
+	Timestamp string                  `json:"timestamp" example:"2006-01-02T15:04:05.000Z"`
+	Data      []model.EmptyObject     `json:"data"`
+	Errors    []rest_error.FieldError `json:"errors,omitempty"`
+	Meta      map[string]string       `json:"meta,omitempty"`
 }
 
 // EmptySuccessRes example
@@ -29,6 +39,22 @@ type EmptySuccessRes struct {
 	Data      model.EmptyObject `json:"data"`
 }
 
+// OTPSentSuccessRes example. Outside production meta carries the otp, so it can be
+// entered without an sms gateway.
+type OTPSentSuccessRes struct {
+	Success   bool              `json:"success" example:"true"`
+	Status    string            `json:"status" example:"Created"`
+	Message   string            `json:"message" example:"success message"`
+	Timestamp string            `json:"timestamp" example:"2006-01-02T15:04:05.000Z"`
+	Data      model.EmptyObject `json:"data"`
+	Meta      *OTPMeta          `json:"meta,omitempty"`
+}
+
+// OTPMeta is the meta of OTPSentSuccessRes
+type OTPMeta struct {
+	OTP string `json:"otp" example:"12345"`
+}
+
 type TokenSuccessRes struct {
 	Success   bool        `json:"success" example:"false"`
 	Status    string      `json:"status" example:"OK"`
@@ -37,11 +63,15 @@ type TokenSuccessRes struct {
 	Data      model.Token `json:"data"`
 }
 
+// ListMeta describes a page of a list. Page, pages and count are left out of the pages
+// asked for by cursor, which are not counted. The cursors are left out at either end.
 type ListMeta struct {
-	Page  int64 `json:"page"`
-	Pages int64 `json:"pages"`
-	Limit int64 `json:"Limit"`
-	Count int64 `json:"count"`
+	Page       *int64 `json:"page,omitempty"`
+	Pages      *int64 `json:"pages,omitempty"`
+	Limit      int64  `json:"Limit"`
+	Count      *int64 `json:"count,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJmIjoiX2lkIn0"`
+	PrevCursor string `json:"prev_cursor,omitempty" example:"eyJmIjoiX2lkIiwicCI6dHJ1ZX0"`
 }
 
 func GetListMeta(page, limit, count int64) *ListMeta {
@@ -51,9 +81,18 @@ func GetListMeta(page, limit, count int64) *ListMeta {
 	}
 
 	return &ListMeta{
-		Page:  page,
-		Pages: pages,
+		Page:  &page,
+		Pages: &pages,
 		Limit: limit,
-		Count: count,
+		Count: &count,
+	}
+}
+
+// GetCursorListMeta returns the meta of a page asked for by cursor
+func GetCursorListMeta(limit int64, nextCursor, prevCursor string) *ListMeta {
+	return &ListMeta{
+		Limit:      limit,
+		NextCursor: nextCursor,
+		PrevCursor: prevCursor,
 	}
 }
@@ -31,3 +31,21 @@ type CustomerResShort struct {
 	Timestamp string              `json:"timestamp" example:"2006-01-02T15:04:05.000Z"`
 	Data      model.CustomerShort `json:"data"`
 }
+
+// CustomerDataExportSuccessRes example
+type CustomerDataExportSuccessRes struct {
+	Success   bool                     `json:"success" example:"true"`
+	Status    string                   `json:"status" example:"OK"`
+	Message   string                   `json:"message" example:"success message"`
+	Timestamp string                   `json:"timestamp" example:"2006-01-02T15:04:05.000Z"`
+	Data      model.CustomerDataExport `json:"data"`
+}
+
+// CustomerErasureSuccessRes example
+type CustomerErasureSuccessRes struct {
+	Success   bool                     `json:"success" example:"true"`
+	Status    string                   `json:"status" example:"Accepted"`
+	Message   string                   `json:"message" example:"success message"`
+	Timestamp string                   `json:"timestamp" example:"2006-01-02T15:04:05.000Z"`
+	Data      model.CustomerErasureRes `json:"data"`
+}
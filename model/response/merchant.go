@@ -28,3 +28,12 @@ type MerchantResShort struct {
 	Timestamp string              `json:"timestamp" example:"2006-01-02T15:04:05.000Z"`
 	Data      model.MerchantShort `json:"data"`
 }
+
+// MerchantStatusSuccessRes example
+type MerchantStatusSuccessRes struct {
+	Success   bool                 `json:"success" example:"true"`
+	Status    string               `json:"status" example:"OK"`
+	Message   string               `json:"message" example:"success message"`
+	Timestamp string               `json:"timestamp" example:"2006-01-02T15:04:05.000Z"`
+	Data      model.MerchantStatus `json:"data"`
+}
@@ -4,11 +4,14 @@ import (
 	"context"
 	"fmt"
 	rest_error "github.com/iamrz1/ab-auth/error"
+	"github.com/iamrz1/ab-auth/i18n"
 	"github.com/iamrz1/ab-auth/infra"
+	"github.com/iamrz1/ab-auth/logger"
 	"github.com/iamrz1/ab-auth/model"
-	rLog "github.com/iamrz1/rest-log"
+	"github.com/iamrz1/ab-auth/utils"
 	"go.mongodb.org/mongo-driver/bson"
-	"log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"net/http"
 )
 
@@ -16,31 +19,33 @@ type AddressRepo struct {
 	DB           infra.DB
 	AddressTable string
 	BDGeoTable   string
-	Log          rLog.Logger
+	DatasetTable string
+	Log          logger.Logger
 }
 
-func NewAddressRepo(db infra.DB, addressTable, bdLocationTable string, log rLog.Logger) *AddressRepo {
+func NewAddressRepo(db infra.DB, addressTable, bdLocationTable, datasetTable string, log logger.Logger) *AddressRepo {
 	return &AddressRepo{
 		DB:           db,
 		Log:          log,
 		AddressTable: addressTable,
 		BDGeoTable:   bdLocationTable,
+		DatasetTable: datasetTable,
 	}
 }
 
 func (ar *AddressRepo) AddAddress(ctx context.Context, address *model.Address) error {
 	err := ar.DB.Insert(ctx, ar.AddressTable, address)
 	if err != nil {
-		ar.Log.Error("GetAddresses", "", fmt.Sprintf("insert err: %s", err.Error()))
+		ar.Log.Errorln(ctx, "GetAddresses", fmt.Sprintf("insert err: %s", err.Error()))
 		return err
 	}
 
 	return nil
 }
 
-func (ar *AddressRepo) GetAddress(ctx context.Context, filter interface{}) (*model.Address, error) {
+func (ar *AddressRepo) GetAddress(ctx context.Context, filter interface{}, opts ...ScopeOption) (*model.Address, error) {
 	res := &model.Address{}
-	err := ar.DB.FindOne(ctx, ar.AddressTable, filter, res)
+	err := ar.DB.FindOne(ctx, ar.AddressTable, applyScope(filter, opts...), res)
 	if err != nil {
 		return nil, err
 	}
@@ -48,17 +53,17 @@ func (ar *AddressRepo) GetAddress(ctx context.Context, filter interface{}) (*mod
 	return res, nil
 }
 
-func (ar *AddressRepo) GetAddressCount(ctx context.Context, filter interface{}) (int64, error) {
-	n, err := ar.DB.FindAndCount(ctx, ar.AddressTable, filter)
+func (ar *AddressRepo) GetAddressCount(ctx context.Context, filter interface{}, opts ...ScopeOption) (int64, error) {
+	n, err := ar.DB.Count(ctx, ar.AddressTable, applyScope(filter, opts...))
 	if err != nil {
-		ar.Log.Error("CountCustomer", "", err.Error())
+		ar.Log.Errorln(ctx, "GetAddressCount", err.Error())
 		return 0, err
 	}
 
 	return n, nil
 }
 
-func (ar *AddressRepo) GetAddresses(ctx context.Context, filter interface{}, listOptions *model.ListOptions) ([]*model.Address, error) {
+func (ar *AddressRepo) GetAddresses(ctx context.Context, filter interface{}, listOptions *model.ListOptions, opts ...ScopeOption) ([]*model.Address, error) {
 	res := make([]*model.Address, 0)
 	if listOptions == nil {
 		listOptions = &model.ListOptions{}
@@ -66,58 +71,207 @@ func (ar *AddressRepo) GetAddresses(ctx context.Context, filter interface{}, lis
 	if listOptions.Sort == nil {
 		listOptions.Sort = bson.M{"_id": -1}
 	}
-	err := ar.DB.List(ctx, ar.AddressTable, filter, listOptions.Page, listOptions.Limit, &res, listOptions.Sort)
+	err := ar.DB.List(ctx, ar.AddressTable, applyScope(filter, opts...), listOptions.Page, listOptions.Limit, &res, listOptions.Sort)
 	if err != nil {
-		ar.Log.Error("GetAddresses", "", err.Error())
+		ar.Log.Errorln(ctx, "GetAddresses", err.Error())
 		return nil, err
 	}
 
 	return res, nil
 }
 
-func (ar *AddressRepo) UpdateAddress(ctx context.Context, filter interface{}, doc *model.Address) (int64, error) {
+func (ar *AddressRepo) UpdateAddress(ctx context.Context, filter interface{}, doc *model.Address, opts ...ScopeOption) (int64, error) {
 	if (*doc) == (model.Address{}) {
-		log.Println("nothing to update")
-		return 0, rest_error.NewGenericError(http.StatusBadRequest, "Nothing to update")
+		logger.Warnln(ctx, "UpdateAddress", "nothing to update")
+		return 0, rest_error.NewCodedError(http.StatusBadRequest, rest_error.CodeNothingToUpdate, i18n.MsgNothingToUpdate)
 	}
-	matched, err := ar.DB.Update(ctx, ar.AddressTable, filter, doc)
+	matched, err := ar.DB.Update(ctx, ar.AddressTable, applyScope(filter, opts...), doc)
 	if err != nil {
-		ar.Log.Error("updateCustomerProfile", "", err.Error())
+		ar.Log.Errorln(ctx, "updateCustomerProfile", err.Error())
 		return 0, err
 	}
 
 	return matched, nil
 }
 
+// UnsetAddressFields removes fields from the address with id, which UpdateAddress can not
+// do since zero values are omitted from the update
+func (ar *AddressRepo) UnsetAddressFields(ctx context.Context, id primitive.ObjectID, fields ...string) error {
+	unset := bson.M{}
+	for _, f := range fields {
+		unset[f] = ""
+	}
+
+	filter := infra.DbQuery{{Key: "_id", Value: id}}
+	err := ar.DB.PartialUpdateManyByQuery(ctx, ar.AddressTable, filter, infra.UnorderedDbQuery{"$unset": unset})
+	if err != nil {
+		ar.Log.Errorln(ctx, "UnsetAddressFields", err.Error())
+		return err
+	}
+
+	return nil
+}
+
 func (ar *AddressRepo) PurgeAddress(ctx context.Context, filter interface{}) (int64, error) {
 	purged, err := ar.DB.DeleteOne(ctx, ar.AddressTable, filter)
 	if err != nil {
-		ar.Log.Error("PurgeOne", "", err.Error())
+		ar.Log.Errorln(ctx, "PurgeOne", err.Error())
 		return 0, err
 	}
 
 	return purged, nil
 }
 
-func (ar *AddressRepo) GetBdLocations(ctx context.Context, filter interface{}, listOptions *model.ListOptions) ([]*model.BDLocation, int64, error) {
+// InTransaction runs fn in a database transaction, repo calls made with the ctx given to
+// fn take part in it. A standalone mongo has no transactions, fn then runs its writes in
+// order, which the unique index on the primary address keeps safe.
+func (ar *AddressRepo) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return ar.DB.WithTransaction(ctx, fn)
+}
+
+// DemotePrimaryAddresses unsets the primary flag on every address of username
+func (ar *AddressRepo) DemotePrimaryAddresses(ctx context.Context, username string) error {
+	filter := infra.DbQuery{{Key: "username", Value: username}, {Key: "is_primary", Value: true}}
+	err := ar.DB.PartialUpdateMany(ctx, ar.AddressTable, filter, bson.M{"is_primary": false})
+	if err != nil {
+		ar.Log.Errorln(ctx, "DemotePrimaryAddresses", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// FindAddressesByLocation returns up to limit live addresses whose location matches the
+// geo query on the location field. The query goes in the filter as is, since $nearSphere
+// must not be nested in $and, and no sort is set so that $nearSphere keeps nearest first.
+func (ar *AddressRepo) FindAddressesByLocation(ctx context.Context, geoQuery bson.M, limit int64) ([]*model.Address, error) {
+	res := make([]*model.Address, 0)
+	filter := bson.M{
+		"location":   geoQuery,
+		"is_deleted": bson.M{"$ne": true},
+	}
+	err := ar.DB.List(ctx, ar.AddressTable, filter, 1, limit, &res)
+	if err != nil {
+		ar.Log.Errorln(ctx, "FindAddressesByLocation", err.Error())
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// CountBdLocations counts the BD location presets matching filter
+func (ar *AddressRepo) CountBdLocations(ctx context.Context, filter interface{}) (int64, error) {
+	n, err := ar.DB.Count(ctx, ar.BDGeoTable, filter)
+	if err != nil {
+		ar.Log.Errorln(ctx, "CountBdLocations", err.Error())
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// GetBdLocationsPage lists the page of BD location presets q asks for, along with the
+// cursors around it
+func (ar *AddressRepo) GetBdLocationsPage(ctx context.Context, filter interface{}, q infra.PageQuery) ([]*model.BDLocation, *infra.PageInfo, error) {
 	res := make([]*model.BDLocation, 0)
-	if listOptions == nil {
-		listOptions = &model.ListOptions{}
+	info, err := ar.DB.ListPage(ctx, ar.BDGeoTable, filter, q, &res)
+	if err != nil {
+		ar.Log.Errorln(ctx, "GetBdLocationsPage", err.Error())
+		return nil, nil, err
 	}
-	if listOptions.Sort == nil {
-		listOptions.Sort = bson.M{"_id": -1}
+
+	return res, info, nil
+}
+
+// GetAllBdLocations returns every BD location preset, for in-memory indexing
+func (ar *AddressRepo) GetAllBdLocations(ctx context.Context) ([]*model.BDLocation, error) {
+	res := make([]*model.BDLocation, 0)
+	err := ar.DB.List(ctx, ar.BDGeoTable, bson.M{}, 1, 0, &res)
+	if err != nil {
+		ar.Log.Errorln(ctx, "GetAllBdLocations", err.Error())
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// EnsureBdLocationIndices creates the indices BD location presets are looked up by
+func (ar *AddressRepo) EnsureBdLocationIndices(ctx context.Context) error {
+	return ar.DB.EnsureIndices(ctx, ar.BDGeoTable, []infra.DbIndex{
+		{Name: "slug_unique", Keys: []infra.DbIndexKey{{Key: "slug", Asc: 1}}, Unique: utils.BoolP(true)},
+		{Name: "parent_name", Keys: []infra.DbIndexKey{{Key: "parent", Asc: 1}, {Key: "name", Asc: 1}}},
+	})
+}
+
+// UpsertBdLocations inserts or replaces BD location presets by slug, in batches
+func (ar *AddressRepo) UpsertBdLocations(ctx context.Context, locations []*model.BDLocation) error {
+	const batchSize = 500
+
+	for start := 0; start < len(locations); start += batchSize {
+		end := start + batchSize
+		if end > len(locations) {
+			end = len(locations)
+		}
+
+		models := make([]mongo.WriteModel, 0, end-start)
+		for _, l := range locations[start:end] {
+			models = append(models, mongo.NewReplaceOneModel().
+				SetFilter(bson.M{"slug": l.Slug}).
+				SetReplacement(l).
+				SetUpsert(true))
+		}
+
+		err := ar.DB.BulkUpdate(ctx, ar.BDGeoTable, models)
+		if err != nil {
+			ar.Log.Errorln(ctx, "UpsertBdLocations", err.Error())
+			return err
+		}
 	}
-	err := ar.DB.List(ctx, ar.BDGeoTable, filter, listOptions.Page, listOptions.Limit, &res, listOptions.Sort)
+
+	return nil
+}
+
+// PruneBdLocations removes BD location presets whose slug is not in keep
+func (ar *AddressRepo) PruneBdLocations(ctx context.Context, keep []string) error {
+	err := ar.DB.DeleteMany(ctx, ar.BDGeoTable, bson.M{"slug": bson.M{"$nin": keep}})
 	if err != nil {
-		ar.Log.Error("GetAddresses", "", err.Error())
-		return nil, 0, err
+		ar.Log.Errorln(ctx, "PruneBdLocations", err.Error())
+		return err
 	}
 
-	n, err := ar.DB.FindAndCount(ctx, ar.BDGeoTable, filter)
+	return nil
+}
+
+// GetDatasetVersion returns the version record of dataset id
+func (ar *AddressRepo) GetDatasetVersion(ctx context.Context, id string) (*model.DatasetVersion, error) {
+	res := &model.DatasetVersion{}
+	err := ar.DB.FindOne(ctx, ar.DatasetTable, bson.M{"_id": id}, res)
 	if err != nil {
-		ar.Log.Error("CountCustomer", "", err.Error())
-		return nil, 0, err
+		return nil, err
 	}
 
-	return res, n, nil
+	return res, nil
+}
+
+// SetDatasetVersion stores the version record of a dataset
+func (ar *AddressRepo) SetDatasetVersion(ctx context.Context, v *model.DatasetVersion) error {
+	err := ar.DB.BulkUpdate(ctx, ar.DatasetTable, []mongo.WriteModel{
+		mongo.NewReplaceOneModel().SetFilter(bson.M{"_id": v.ID}).SetReplacement(v).SetUpsert(true),
+	})
+	if err != nil {
+		ar.Log.Errorln(ctx, "SetDatasetVersion", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (ar *AddressRepo) PurgeAddresses(ctx context.Context, filter interface{}) error {
+	err := ar.DB.DeleteMany(ctx, ar.AddressTable, filter)
+	if err != nil {
+		ar.Log.Errorln(ctx, "PurgeAddresses", err.Error())
+		return err
+	}
+
+	return nil
 }
@@ -0,0 +1,60 @@
+package repo
+
+import (
+	"context"
+	"github.com/iamrz1/ab-auth/infra"
+	"github.com/iamrz1/ab-auth/logger"
+	"github.com/iamrz1/ab-auth/model"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type AuditRepo struct {
+	DB    infra.DB
+	Table string
+	Log   logger.Logger
+}
+
+func NewAuditRepo(db infra.DB, table string, log logger.Logger) *AuditRepo {
+	return &AuditRepo{
+		DB:    db,
+		Table: table,
+		Log:   log,
+	}
+}
+
+func (adr *AuditRepo) AddEvent(ctx context.Context, event *model.AuditEvent) error {
+	err := adr.DB.Insert(ctx, adr.Table, event)
+	if err != nil {
+		adr.Log.Errorln(ctx, "AddEvent", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (adr *AuditRepo) ListEvents(ctx context.Context, filter interface{}, listOptions *model.ListOptions) ([]*model.AuditEvent, error) {
+	res := make([]*model.AuditEvent, 0)
+	if listOptions == nil {
+		listOptions = &model.ListOptions{}
+	}
+	if listOptions.Sort == nil {
+		listOptions.Sort = bson.M{"_id": -1}
+	}
+	err := adr.DB.List(ctx, adr.Table, filter, listOptions.Page, listOptions.Limit, &res, listOptions.Sort)
+	if err != nil {
+		adr.Log.Errorln(ctx, "ListEvents", err.Error())
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (adr *AuditRepo) PurgeEvents(ctx context.Context, filter interface{}) error {
+	err := adr.DB.DeleteMany(ctx, adr.Table, filter)
+	if err != nil {
+		adr.Log.Errorln(ctx, "PurgeEvents", err.Error())
+		return err
+	}
+
+	return nil
+}
@@ -1,23 +1,27 @@
 package repo
 
 import (
+	"context"
 	"fmt"
 	"github.com/go-redis/redis"
+	rest_error "github.com/iamrz1/ab-auth/error"
+	"github.com/iamrz1/ab-auth/i18n"
 	"github.com/iamrz1/ab-auth/infra"
 	infraCache "github.com/iamrz1/ab-auth/infra/cache"
+	"github.com/iamrz1/ab-auth/logger"
+	"github.com/iamrz1/ab-auth/metrics"
 	"github.com/iamrz1/ab-auth/utils"
-	rLog "github.com/iamrz1/rest-log"
-	"log"
+	"net/http"
 	"time"
 )
 
 type CommonRepo struct {
 	DB    infra.DB
 	Cache *infraCache.Redis
-	Log   rLog.Logger
+	Log   logger.Logger
 }
 
-func NewCommonRepo(db infra.DB, cache *infraCache.Redis, log rLog.Logger) *CommonRepo {
+func NewCommonRepo(db infra.DB, cache *infraCache.Redis, log logger.Logger) *CommonRepo {
 	return &CommonRepo{
 		DB:    db,
 		Cache: cache,
@@ -25,68 +29,72 @@ func NewCommonRepo(db infra.DB, cache *infraCache.Redis, log rLog.Logger) *Commo
 	}
 }
 
-func (cmr *CommonRepo) GetOTP(username, service string, limit, limitDuration, lockDuration int) (string, error) {
+func (cmr *CommonRepo) GetOTP(ctx context.Context, username, service string, limit, limitDuration, lockDuration int) (string, error) {
 	otp := utils.GetRandomDigits(5)
-	ok, err := cmr.LockKey(fmt.Sprintf("%s_%s_otp_gen", username, service), lockDuration)
+	ok, err := cmr.LockKey(ctx, fmt.Sprintf("%s_%s_otp_gen", username, service), lockDuration)
 	if err != nil || !ok {
-		return "", fmt.Errorf("%s", "Can not request multiple OTPs at once")
+		return "", rest_error.NewCodedError(http.StatusTooManyRequests, rest_error.CodeOTPRateLimited, i18n.MsgOTPInProgress)
 	}
 
-	if !cmr.EnsureUsageLimit(fmt.Sprintf("%s_%s_otp_gen_limit", username, service), limit, limitDuration) {
-		return "", fmt.Errorf("%s", "Please try again in 24 hours")
+	if !cmr.EnsureUsageLimit(ctx, fmt.Sprintf("%s_%s_otp_gen_limit", username, service), limit, limitDuration) {
+		return "", rest_error.NewCodedError(http.StatusTooManyRequests, rest_error.CodeOTPRateLimited, i18n.MsgTryAgainTomorrow)
 	}
 
 	return otp, nil
 }
 
-func (cmr *CommonRepo) SetOTP(username, service, otp string, durationSec int) error {
-	scmd := cmr.Cache.Client.Set(fmt.Sprintf("%s_%s_otp", username, service), otp, time.Second*time.Duration(durationSec))
+func (cmr *CommonRepo) SetOTP(ctx context.Context, username, service, otp string, durationSec int) error {
+	scmd := cmr.Cache.WithContext(ctx).Set(fmt.Sprintf("%s_%s_otp", username, service), otp, time.Second*time.Duration(durationSec))
 	if scmd.Err() != nil {
-		return fmt.Errorf("%s", "OTP request failed")
+		return rest_error.NewCodedError(http.StatusInternalServerError, rest_error.CodeInternal, i18n.MsgOTPRequestFailed)
 	}
 
 	return nil
 }
 
-func (cmr *CommonRepo) MatchOTP(username, service, otp string) error {
-	scmd := cmr.Cache.Client.Get(fmt.Sprintf("%s_%s_otp", username, service))
+func (cmr *CommonRepo) MatchOTP(ctx context.Context, username, service, otp string) error {
+	scmd := cmr.Cache.WithContext(ctx).Get(fmt.Sprintf("%s_%s_otp", username, service))
 	if scmd.Err() != nil {
-		return fmt.Errorf("%s", "OTP match failed")
+		metrics.OTPMatches.WithLabelValues(service, metrics.ResultFailure).Inc()
+		return rest_error.NewCodedValidationError(rest_error.CodeOTPExpired, i18n.MsgOTPMatchFailed, nil)
 	}
 
 	if scmd.Val() != otp {
-		return fmt.Errorf("%s", "Incorrect OTP")
+		metrics.OTPMatches.WithLabelValues(service, metrics.ResultFailure).Inc()
+		return rest_error.NewCodedValidationError(rest_error.CodeOTPIncorrect, i18n.MsgOTPIncorrect, nil)
 	}
 
+	metrics.OTPMatches.WithLabelValues(service, metrics.ResultSuccess).Inc()
 	return nil
 }
 
-func (cmr *CommonRepo) LockKey(key string, durationSec int) (bool, error) {
-	res := cmr.Cache.Client.SetNX(key, 1, time.Second*time.Duration(durationSec))
+func (cmr *CommonRepo) LockKey(ctx context.Context, key string, durationSec int) (bool, error) {
+	res := cmr.Cache.WithContext(ctx).SetNX(key, 1, time.Second*time.Duration(durationSec))
 	if res.Err() != nil {
-		log.Println(res.Err())
+		logger.Errorln(ctx, "LockKey", res.Err().Error())
 		return false, res.Err()
 	}
 
 	return res.Result()
 }
 
-func (cmr *CommonRepo) EnsureUsageLimit(key string, limit, durationSec int) bool {
+func (cmr *CommonRepo) EnsureUsageLimit(ctx context.Context, key string, limit, durationSec int) bool {
 	//pipe := cmr.Cache.Client.TxPipeline()
+	client := cmr.Cache.WithContext(ctx)
 	usedLimit := 0
-	scmd := cmr.Cache.Client.Get(key)
+	scmd := client.Get(key)
 	if scmd.Err() != nil {
 		if scmd.Err() != redis.Nil {
-			cmr.Log.Error("EnsureUsageLimit", "", scmd.Err().Error())
+			cmr.Log.Errorln(ctx, "EnsureUsageLimit", scmd.Err().Error())
 			return false
 		} else {
-			cmr.Cache.Client.Set(key, 1, time.Second*time.Duration(durationSec))
+			client.Set(key, 1, time.Second*time.Duration(durationSec))
 			return true
 		}
 	} else {
 		n, err := scmd.Int()
 		if err != nil {
-			cmr.Log.Error("EnsureUsageLimit", "", scmd.Err().Error())
+			cmr.Log.Errorln(ctx, "EnsureUsageLimit", scmd.Err().Error())
 			return false
 		}
 		usedLimit = n
@@ -96,7 +104,32 @@ func (cmr *CommonRepo) EnsureUsageLimit(key string, limit, durationSec int) bool
 		return false
 	}
 
-	cmr.Cache.Client.Incr(key)
+	client.Incr(key)
 
 	return true
 }
+
+// PurgeUserKeys deletes every cache key that belongs to username
+func (cmr *CommonRepo) PurgeUserKeys(ctx context.Context, username string) error {
+	client := cmr.Cache.WithContext(ctx)
+	var cursor uint64
+	for {
+		keys, next, err := client.Scan(cursor, fmt.Sprintf("%s_*", username), 100).Result()
+		if err != nil {
+			cmr.Log.Errorln(ctx, "PurgeUserKeys", err.Error())
+			return err
+		}
+
+		if len(keys) > 0 {
+			if err := client.Del(keys...).Err(); err != nil {
+				cmr.Log.Errorln(ctx, "PurgeUserKeys", err.Error())
+				return err
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
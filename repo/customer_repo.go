@@ -6,12 +6,13 @@ import (
 	"fmt"
 	"github.com/go-redis/redis"
 	rest_error "github.com/iamrz1/ab-auth/error"
+	"github.com/iamrz1/ab-auth/i18n"
 	"github.com/iamrz1/ab-auth/infra"
 	infraCache "github.com/iamrz1/ab-auth/infra/cache"
+	"github.com/iamrz1/ab-auth/logger"
+	"github.com/iamrz1/ab-auth/metrics"
 	"github.com/iamrz1/ab-auth/model"
-	rLog "github.com/iamrz1/rest-log"
 	"go.mongodb.org/mongo-driver/bson"
-	"log"
 	"net/http"
 	"time"
 )
@@ -20,10 +21,10 @@ type CustomerRepo struct {
 	DB    infra.DB
 	Cache *infraCache.Redis
 	Table string
-	Log   rLog.Logger
+	Log   logger.Logger
 }
 
-func NewCustomerRepo(db infra.DB, table string, cache *infraCache.Redis, log rLog.Logger) *CustomerRepo {
+func NewCustomerRepo(db infra.DB, table string, cache *infraCache.Redis, log logger.Logger) *CustomerRepo {
 	return &CustomerRepo{
 		DB:    db,
 		Cache: cache,
@@ -32,35 +33,37 @@ func NewCustomerRepo(db infra.DB, table string, cache *infraCache.Redis, log rLo
 	}
 }
 
-func (pr *CustomerRepo) HoldCustomerRegistrationInCache(otp string, doc *model.CustomerSignupReq) error {
+func (pr *CustomerRepo) HoldCustomerRegistrationInCache(ctx context.Context, otp string, doc *model.CustomerSignupReq) error {
 	if (*doc) == (model.CustomerSignupReq{}) {
-		return rest_error.NewGenericError(http.StatusBadRequest, "Nothing to create")
+		return rest_error.NewGenericError(http.StatusBadRequest, i18n.MsgNothingToCreate)
 	}
 
 	data, err := json.Marshal(doc)
 	if err != nil {
-		pr.Log.Error("HoldCustomerRegistrationInCache", "", err.Error())
+		pr.Log.Errorln(ctx, "HoldCustomerRegistrationInCache", err.Error())
 		return err
 	}
 
-	scmd := pr.Cache.Client.Set(fmt.Sprintf("%s_%s", doc.Username, otp), data, time.Minute*6)
+	scmd := pr.Cache.WithContext(ctx).Set(fmt.Sprintf("%s_%s", doc.Username, otp), data, time.Minute*6)
 	err = scmd.Err()
 	if err != nil {
-		pr.Log.Error("HoldCustomerRegistrationInCache", "", err.Error())
+		pr.Log.Errorln(ctx, "HoldCustomerRegistrationInCache", err.Error())
 		return err
 	}
 
 	return nil
 }
 
-func (pr *CustomerRepo) GetCustomerRegistrationFromCache(username, otp string) (*model.CustomerSignupReq, error) {
+func (pr *CustomerRepo) GetCustomerRegistrationFromCache(ctx context.Context, username, otp string) (*model.CustomerSignupReq, error) {
 	res := model.CustomerSignupReq{}
-	scmd := pr.Cache.Client.Get(fmt.Sprintf("%s_%s", username, otp))
+	scmd := pr.Cache.WithContext(ctx).Get(fmt.Sprintf("%s_%s", username, otp))
 	err := scmd.Err()
 	if err != nil {
-		pr.Log.Error("GetCustomerRegistrationFromCache", "", err.Error())
+		pr.Log.Errorln(ctx, "GetCustomerRegistrationFromCache", err.Error())
 		if err == redis.Nil {
-			return nil, fmt.Errorf("%s", "OTP expired")
+			// registrations are held under their otp, a wrong otp finds none
+			metrics.OTPMatches.WithLabelValues("signup", metrics.ResultFailure).Inc()
+			return nil, rest_error.NewCodedValidationError(rest_error.CodeOTPExpired, i18n.MsgOTPExpired, nil)
 		}
 		return nil, err
 	}
@@ -69,38 +72,39 @@ func (pr *CustomerRepo) GetCustomerRegistrationFromCache(username, otp string) (
 
 	err = json.Unmarshal(b, &res)
 	if err != nil {
-		pr.Log.Error("GetCustomerRegistrationFromCache", "", err.Error())
+		pr.Log.Errorln(ctx, "GetCustomerRegistrationFromCache", err.Error())
 		return nil, err
 	}
 
+	metrics.OTPMatches.WithLabelValues("signup", metrics.ResultSuccess).Inc()
 	return &res, nil
 }
 
 func (pr *CustomerRepo) CreateCustomer(ctx context.Context, doc *model.Customer) error {
 	if (*doc) == (model.Customer{}) {
-		return rest_error.NewGenericError(http.StatusBadRequest, "Nothing to create")
+		return rest_error.NewGenericError(http.StatusBadRequest, i18n.MsgNothingToCreate)
 	}
 	err := pr.DB.Insert(ctx, pr.Table, doc)
 	if err != nil {
-		pr.Log.Error("CreateCustomer", "", err.Error())
+		pr.Log.Errorln(ctx, "CreateCustomer", err.Error())
 		return err
 	}
 
 	return nil
 }
 
-func (pr *CustomerRepo) GetCustomer(ctx context.Context, selector interface{}) (*model.Customer, error) {
+func (pr *CustomerRepo) GetCustomer(ctx context.Context, selector interface{}, opts ...ScopeOption) (*model.Customer, error) {
 	res := model.Customer{}
-	err := pr.DB.FindOne(ctx, pr.Table, selector, &res)
+	err := pr.DB.FindOne(ctx, pr.Table, applyScope(selector, opts...), &res)
 	if err != nil {
-		pr.Log.Error("GetCustomer", "", err.Error())
+		pr.Log.Errorln(ctx, "GetCustomer", err.Error())
 		return nil, err
 	}
 
 	return &res, nil
 }
 
-func (pr *CustomerRepo) ListCustomers(ctx context.Context, selector interface{}, listOptions *model.ListOptions) ([]*model.Customer, error) {
+func (pr *CustomerRepo) ListCustomers(ctx context.Context, selector interface{}, listOptions *model.ListOptions, opts ...ScopeOption) ([]*model.Customer, error) {
 	res := make([]*model.Customer, 0)
 	if listOptions == nil {
 		listOptions = &model.ListOptions{}
@@ -108,43 +112,86 @@ func (pr *CustomerRepo) ListCustomers(ctx context.Context, selector interface{},
 	if listOptions.Sort == nil {
 		listOptions.Sort = bson.M{"_id": -1}
 	}
-	err := pr.DB.List(ctx, pr.Table, selector, listOptions.Page, listOptions.Limit, &res, listOptions.Sort)
+	err := pr.DB.List(ctx, pr.Table, applyScope(selector, opts...), listOptions.Page, listOptions.Limit, &res, listOptions.Sort)
 	if err != nil {
-		pr.Log.Error("ListCustomers", "", err.Error())
+		pr.Log.Errorln(ctx, "ListCustomers", err.Error())
 		return nil, err
 	}
 
 	return res, nil
 }
 
-func (pr *CustomerRepo) UpdateCustomer(ctx context.Context, filter, doc *model.Customer) (int64, error) {
+// ListCustomersPage lists the page of customers q asks for, along with the cursors
+// around it
+func (pr *CustomerRepo) ListCustomersPage(ctx context.Context, selector interface{}, q infra.PageQuery, opts ...ScopeOption) ([]*model.Customer, *infra.PageInfo, error) {
+	res := make([]*model.Customer, 0)
+	info, err := pr.DB.ListPage(ctx, pr.Table, applyScope(selector, opts...), q, &res)
+	if err != nil {
+		pr.Log.Errorln(ctx, "ListCustomersPage", err.Error())
+		return nil, nil, err
+	}
+
+	return res, info, nil
+}
+
+func (pr *CustomerRepo) UpdateCustomer(ctx context.Context, filter, doc *model.Customer, opts ...ScopeOption) (int64, error) {
 	if (*doc) == (model.Customer{}) {
-		log.Println("nothing to update")
-		return 0, rest_error.NewGenericError(http.StatusBadRequest, "Nothing to update")
+		logger.Warnln(ctx, "UpdateCustomer", "nothing to update")
+		return 0, rest_error.NewCodedError(http.StatusBadRequest, rest_error.CodeNothingToUpdate, i18n.MsgNothingToUpdate)
 	}
-	matched, err := pr.DB.Update(ctx, pr.Table, filter, doc)
+	matched, err := pr.DB.Update(ctx, pr.Table, applyScope(filter, opts...), doc)
 	if err != nil {
-		pr.Log.Error("updateCustomerProfile", "", err.Error())
+		pr.Log.Errorln(ctx, "updateCustomerProfile", err.Error())
 		return 0, err
 	}
 
 	return matched, nil
 }
 
-func (pr *CustomerRepo) CountCustomer(ctx context.Context, selector interface{}) (int64, error) {
-	n, err := pr.DB.FindAndCount(ctx, pr.Table, selector)
+// UnsetCustomerFields removes fields from a customer document, which UpdateCustomer
+// can not do since zero values are omitted from the update
+func (pr *CustomerRepo) UnsetCustomerFields(ctx context.Context, username string, fields ...string) error {
+	unset := bson.M{}
+	for _, f := range fields {
+		unset[f] = ""
+	}
+
+	filter := infra.DbQuery{{Key: "username", Value: username}}
+	err := pr.DB.PartialUpdateManyByQuery(ctx, pr.Table, filter, infra.UnorderedDbQuery{"$unset": unset})
+	if err != nil {
+		pr.Log.Errorln(ctx, "UnsetCustomerFields", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (pr *CustomerRepo) CountCustomer(ctx context.Context, selector interface{}, opts ...ScopeOption) (int64, error) {
+	n, err := pr.DB.Count(ctx, pr.Table, applyScope(selector, opts...))
 	if err != nil {
-		pr.Log.Error("CountCustomer", "", err.Error())
+		pr.Log.Errorln(ctx, "CountCustomer", err.Error())
 		return 0, err
 	}
 
 	return n, nil
 }
 
+// RestoreCustomer brings back a soft-deleted customer
+func (pr *CustomerRepo) RestoreCustomer(ctx context.Context, username string) (int64, error) {
+	filter := bson.M{"username": username, "is_deleted": true}
+	matched, err := pr.DB.Update(ctx, pr.Table, filter, bson.M{"is_deleted": false, "updated_at": time.Now().UTC()})
+	if err != nil {
+		pr.Log.Errorln(ctx, "RestoreCustomer", err.Error())
+		return 0, err
+	}
+
+	return matched, nil
+}
+
 func (pr *CustomerRepo) PurgeOne(ctx context.Context, filter interface{}) (int64, error) {
 	purged, err := pr.DB.DeleteOne(ctx, pr.Table, filter)
 	if err != nil {
-		pr.Log.Error("PurgeOne", "", err.Error())
+		pr.Log.Errorln(ctx, "PurgeOne", err.Error())
 		return 0, err
 	}
 
@@ -2,20 +2,17 @@ package repo
 
 import (
 	"context"
-	"github.com/iamrz1/ab-auth/config"
-	infraMongo "github.com/iamrz1/ab-auth/infra/mongo"
+	"github.com/iamrz1/ab-auth/infra/memdb"
 	"github.com/iamrz1/ab-auth/logger"
 	"github.com/iamrz1/ab-auth/model"
-	"github.com/joho/godotenv"
 	"github.com/stretchr/testify/assert"
-	"log"
+	"io/ioutil"
 	"testing"
 	"time"
 )
 
 const (
 	name = "User X"
-	slug = "repo-model-x"
 )
 
 var cr CustomerRepo
@@ -32,25 +29,10 @@ var doc = model.Customer{
 
 func init() {
 	ctx = context.Background()
-	godotenv.Load("../../.env")
-	err := config.LoadConfig()
-	if err != nil {
-		log.Println(err)
-		return
-	}
-	cfg := config.GetConfig()
-
-	gracefulTimeout := time.Second * time.Duration(cfg.GracefulTimeout)
-	db, err := infraMongo.New(ctx, cfg.DSN, cfg.Database, gracefulTimeout)
-	if err != nil {
-		log.Println(err)
-		return
-	}
-
 	cr = CustomerRepo{
-		DB:    db,
-		Table: cfg.CustomerTable,
-		Log:   logger.GetDefaultStructLogger(true),
+		DB:    memdb.New(),
+		Table: "customer",
+		Log:   logger.New(ioutil.Discard, logger.Error),
 	}
 }
 
@@ -69,7 +51,7 @@ func TestCustomerRepo_CreateCustomer(t *testing.T) {
 func TestCustomerRepo_GetCustomer(t *testing.T) {
 	t.Run("valid data", func(t *testing.T) {
 		filter := model.Customer{
-			Username: slug,
+			Username: doc.Username,
 		}
 		res, err := cr.GetCustomer(ctx, &filter)
 		assert.NoError(t, err, "failed to get object")
@@ -79,7 +61,7 @@ func TestCustomerRepo_GetCustomer(t *testing.T) {
 
 	t.Run("invalid data", func(t *testing.T) {
 		filter := model.Customer{
-			Username: slug + "i",
+			Username: doc.Username + "i",
 		}
 		_, err := cr.GetCustomer(ctx, &filter)
 		assert.Error(t, err, "expected not found err")
@@ -88,13 +70,9 @@ func TestCustomerRepo_GetCustomer(t *testing.T) {
 
 func TestCustomerRepo_ListCustomers(t *testing.T) {
 	t.Run("valid data", func(t *testing.T) {
-		filter := model.Customer{Username: slug}
+		filter := model.Customer{Username: doc.Username}
 		res, err := cr.ListCustomers(ctx, &filter, nil)
 		assert.NoError(t, err, "failed to get objects")
-		for _, r := range res {
-			log.Println(*r)
-		}
-		log.Println(res)
 		assert.EqualValues(t, 1, len(res))
 		assert.EqualValues(t, doc, *res[0])
 
@@ -102,7 +80,7 @@ func TestCustomerRepo_ListCustomers(t *testing.T) {
 
 	t.Run("invalid data", func(t *testing.T) {
 		filter := model.Customer{
-			Username: slug + "i",
+			Username: doc.Username + "i",
 		}
 		_, err := cr.GetCustomer(ctx, &filter)
 		assert.Error(t, err, "expected not found err")
@@ -112,7 +90,7 @@ func TestCustomerRepo_ListCustomers(t *testing.T) {
 func TestCustomerRepo_UpdateCustomer(t *testing.T) {
 	t.Run("valid data", func(t *testing.T) {
 		filter := model.Customer{
-			Username: slug,
+			Username: doc.Username,
 		}
 
 		update := model.Customer{RecoveryPhoneNumber: "101"}
@@ -127,7 +105,7 @@ func TestCustomerRepo_UpdateCustomer(t *testing.T) {
 
 	t.Run("invalid data", func(t *testing.T) {
 		filter := model.Customer{
-			Username: slug + "i",
+			Username: doc.Username + "i",
 		}
 
 		update := model.Customer{RecoveryPhoneNumber: "201"}
@@ -139,7 +117,7 @@ func TestCustomerRepo_UpdateCustomer(t *testing.T) {
 
 func TestCustomerRepo_CountCustomer(t *testing.T) {
 	t.Run("valid data", func(t *testing.T) {
-		filter := model.Customer{Username: slug}
+		filter := model.Customer{Username: doc.Username}
 		count, err := cr.CountCustomer(ctx, &filter)
 		assert.NoError(t, err, "failed to get object")
 		assert.EqualValues(t, 1, count)
@@ -147,7 +125,7 @@ func TestCustomerRepo_CountCustomer(t *testing.T) {
 
 	t.Run("invalid data", func(t *testing.T) {
 		filter := model.Customer{
-			Username: slug + "i",
+			Username: doc.Username + "i",
 		}
 		count, err := cr.CountCustomer(ctx, &filter)
 		assert.NoError(t, err, "failed to get object")
@@ -158,7 +136,7 @@ func TestCustomerRepo_CountCustomer(t *testing.T) {
 func TestCustomerRepo_PurgeOne(t *testing.T) {
 	t.Run("valid data", func(t *testing.T) {
 		filter := model.Customer{
-			Username: slug,
+			Username: doc.Username,
 		}
 		n, err := cr.PurgeOne(ctx, &filter)
 		assert.NoError(t, err, "failed to purge object")
@@ -169,7 +147,7 @@ func TestCustomerRepo_PurgeOne(t *testing.T) {
 
 	t.Run("invalid data", func(t *testing.T) {
 		filter := model.Customer{
-			Username: slug + "i",
+			Username: doc.Username + "i",
 		}
 		n, err := cr.PurgeOne(ctx, &filter)
 		assert.NoError(t, err, "expected not found err")
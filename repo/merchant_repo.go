@@ -6,12 +6,13 @@ import (
 	"fmt"
 	"github.com/go-redis/redis"
 	rest_error "github.com/iamrz1/ab-auth/error"
+	"github.com/iamrz1/ab-auth/i18n"
 	"github.com/iamrz1/ab-auth/infra"
 	infraCache "github.com/iamrz1/ab-auth/infra/cache"
+	"github.com/iamrz1/ab-auth/logger"
+	"github.com/iamrz1/ab-auth/metrics"
 	"github.com/iamrz1/ab-auth/model"
-	rLog "github.com/iamrz1/rest-log"
 	"go.mongodb.org/mongo-driver/bson"
-	"log"
 	"net/http"
 	"time"
 )
@@ -20,10 +21,10 @@ type MerchantRepo struct {
 	DB    infra.DB
 	Cache *infraCache.Redis
 	Table string
-	Log   rLog.Logger
+	Log   logger.Logger
 }
 
-func NewMerchantRepo(db infra.DB, table string, cache *infraCache.Redis, log rLog.Logger) *MerchantRepo {
+func NewMerchantRepo(db infra.DB, table string, cache *infraCache.Redis, log logger.Logger) *MerchantRepo {
 	return &MerchantRepo{
 		DB:    db,
 		Cache: cache,
@@ -32,35 +33,37 @@ func NewMerchantRepo(db infra.DB, table string, cache *infraCache.Redis, log rLo
 	}
 }
 
-func (pr *MerchantRepo) HoldMerchantRegistrationInCache(otp string, doc *model.MerchantSignupReq) error {
+func (pr *MerchantRepo) HoldMerchantRegistrationInCache(ctx context.Context, otp string, doc *model.MerchantSignupReq) error {
 	if (*doc) == (model.MerchantSignupReq{}) {
-		return rest_error.NewGenericError(http.StatusBadRequest, "Nothing to create")
+		return rest_error.NewGenericError(http.StatusBadRequest, i18n.MsgNothingToCreate)
 	}
 
 	data, err := json.Marshal(doc)
 	if err != nil {
-		pr.Log.Error("HoldMerchantRegistrationInCache", "", err.Error())
+		pr.Log.Errorln(ctx, "HoldMerchantRegistrationInCache", err.Error())
 		return err
 	}
 
-	scmd := pr.Cache.Client.Set(fmt.Sprintf("%s_%s", doc.Username, otp), data, time.Minute*6)
+	scmd := pr.Cache.WithContext(ctx).Set(fmt.Sprintf("%s_%s", doc.Username, otp), data, time.Minute*6)
 	err = scmd.Err()
 	if err != nil {
-		pr.Log.Error("HoldMerchantRegistrationInCache", "", err.Error())
+		pr.Log.Errorln(ctx, "HoldMerchantRegistrationInCache", err.Error())
 		return err
 	}
 
 	return nil
 }
 
-func (pr *MerchantRepo) GetMerchantRegistrationFromCache(username, otp string) (*model.MerchantSignupReq, error) {
+func (pr *MerchantRepo) GetMerchantRegistrationFromCache(ctx context.Context, username, otp string) (*model.MerchantSignupReq, error) {
 	res := model.MerchantSignupReq{}
-	scmd := pr.Cache.Client.Get(fmt.Sprintf("%s_%s", username, otp))
+	scmd := pr.Cache.WithContext(ctx).Get(fmt.Sprintf("%s_%s", username, otp))
 	err := scmd.Err()
 	if err != nil {
-		pr.Log.Error("GetMerchantRegistrationFromCache", "", err.Error())
+		pr.Log.Errorln(ctx, "GetMerchantRegistrationFromCache", err.Error())
 		if err == redis.Nil {
-			return nil, fmt.Errorf("%s", "OTP expired")
+			// registrations are held under their otp, a wrong otp finds none
+			metrics.OTPMatches.WithLabelValues("signup", metrics.ResultFailure).Inc()
+			return nil, rest_error.NewCodedValidationError(rest_error.CodeOTPExpired, i18n.MsgOTPExpired, nil)
 		}
 		return nil, err
 	}
@@ -69,38 +72,39 @@ func (pr *MerchantRepo) GetMerchantRegistrationFromCache(username, otp string) (
 
 	err = json.Unmarshal(b, &res)
 	if err != nil {
-		pr.Log.Error("GetMerchantRegistrationFromCache", "", err.Error())
+		pr.Log.Errorln(ctx, "GetMerchantRegistrationFromCache", err.Error())
 		return nil, err
 	}
 
+	metrics.OTPMatches.WithLabelValues("signup", metrics.ResultSuccess).Inc()
 	return &res, nil
 }
 
 func (pr *MerchantRepo) CreateMerchant(ctx context.Context, doc *model.Merchant) error {
 	if (*doc) == (model.Merchant{}) {
-		return rest_error.NewGenericError(http.StatusBadRequest, "Nothing to create")
+		return rest_error.NewGenericError(http.StatusBadRequest, i18n.MsgNothingToCreate)
 	}
 	err := pr.DB.Insert(ctx, pr.Table, doc)
 	if err != nil {
-		pr.Log.Error("CreateMerchant", "", err.Error())
+		pr.Log.Errorln(ctx, "CreateMerchant", err.Error())
 		return err
 	}
 
 	return nil
 }
 
-func (pr *MerchantRepo) GetMerchant(ctx context.Context, selector interface{}) (*model.Merchant, error) {
+func (pr *MerchantRepo) GetMerchant(ctx context.Context, selector interface{}, opts ...ScopeOption) (*model.Merchant, error) {
 	res := model.Merchant{}
-	err := pr.DB.FindOne(ctx, pr.Table, selector, &res)
+	err := pr.DB.FindOne(ctx, pr.Table, applyScope(selector, opts...), &res)
 	if err != nil {
-		pr.Log.Error("GetMerchant", "", err.Error())
+		pr.Log.Errorln(ctx, "GetMerchant", err.Error())
 		return nil, err
 	}
 
 	return &res, nil
 }
 
-func (pr *MerchantRepo) ListMerchants(ctx context.Context, selector interface{}, listOptions *model.ListOptions) ([]*model.Merchant, error) {
+func (pr *MerchantRepo) ListMerchants(ctx context.Context, selector interface{}, listOptions *model.ListOptions, opts ...ScopeOption) ([]*model.Merchant, error) {
 	res := make([]*model.Merchant, 0)
 	if listOptions == nil {
 		listOptions = &model.ListOptions{}
@@ -108,43 +112,68 @@ func (pr *MerchantRepo) ListMerchants(ctx context.Context, selector interface{},
 	if listOptions.Sort == nil {
 		listOptions.Sort = bson.M{"_id": -1}
 	}
-	err := pr.DB.List(ctx, pr.Table, selector, listOptions.Page, listOptions.Limit, &res, listOptions.Sort)
+	err := pr.DB.List(ctx, pr.Table, applyScope(selector, opts...), listOptions.Page, listOptions.Limit, &res, listOptions.Sort)
 	if err != nil {
-		pr.Log.Error("ListMerchants", "", err.Error())
+		pr.Log.Errorln(ctx, "ListMerchants", err.Error())
 		return nil, err
 	}
 
 	return res, nil
 }
 
-func (pr *MerchantRepo) UpdateMerchant(ctx context.Context, filter, doc *model.Merchant) (int64, error) {
+// ListMerchantsPage lists the page of merchants q asks for, along with the cursors
+// around it
+func (pr *MerchantRepo) ListMerchantsPage(ctx context.Context, selector interface{}, q infra.PageQuery, opts ...ScopeOption) ([]*model.Merchant, *infra.PageInfo, error) {
+	res := make([]*model.Merchant, 0)
+	info, err := pr.DB.ListPage(ctx, pr.Table, applyScope(selector, opts...), q, &res)
+	if err != nil {
+		pr.Log.Errorln(ctx, "ListMerchantsPage", err.Error())
+		return nil, nil, err
+	}
+
+	return res, info, nil
+}
+
+func (pr *MerchantRepo) UpdateMerchant(ctx context.Context, filter, doc *model.Merchant, opts ...ScopeOption) (int64, error) {
 	if (*doc) == (model.Merchant{}) {
-		log.Println("nothing to update")
-		return 0, rest_error.NewGenericError(http.StatusBadRequest, "Nothing to update")
+		logger.Warnln(ctx, "UpdateMerchant", "nothing to update")
+		return 0, rest_error.NewCodedError(http.StatusBadRequest, rest_error.CodeNothingToUpdate, i18n.MsgNothingToUpdate)
 	}
-	matched, err := pr.DB.Update(ctx, pr.Table, filter, doc)
+	matched, err := pr.DB.Update(ctx, pr.Table, applyScope(filter, opts...), doc)
 	if err != nil {
-		pr.Log.Error("updateMerchantProfile", "", err.Error())
+		pr.Log.Errorln(ctx, "updateMerchantProfile", err.Error())
 		return 0, err
 	}
 
 	return matched, nil
 }
 
-func (pr *MerchantRepo) CountMerchant(ctx context.Context, selector interface{}) (int64, error) {
-	n, err := pr.DB.FindAndCount(ctx, pr.Table, selector)
+func (pr *MerchantRepo) CountMerchant(ctx context.Context, selector interface{}, opts ...ScopeOption) (int64, error) {
+	n, err := pr.DB.Count(ctx, pr.Table, applyScope(selector, opts...))
 	if err != nil {
-		pr.Log.Error("CountMerchant", "", err.Error())
+		pr.Log.Errorln(ctx, "CountMerchant", err.Error())
 		return 0, err
 	}
 
 	return n, nil
 }
 
+// RestoreMerchant brings back a soft-deleted merchant
+func (pr *MerchantRepo) RestoreMerchant(ctx context.Context, username string) (int64, error) {
+	filter := bson.M{"username": username, "is_deleted": true}
+	matched, err := pr.DB.Update(ctx, pr.Table, filter, bson.M{"is_deleted": false, "updated_at": time.Now().UTC()})
+	if err != nil {
+		pr.Log.Errorln(ctx, "RestoreMerchant", err.Error())
+		return 0, err
+	}
+
+	return matched, nil
+}
+
 func (pr *MerchantRepo) PurgeOne(ctx context.Context, filter interface{}) (int64, error) {
 	purged, err := pr.DB.DeleteOne(ctx, pr.Table, filter)
 	if err != nil {
-		pr.Log.Error("PurgeOne", "", err.Error())
+		pr.Log.Errorln(ctx, "PurgeOne", err.Error())
 		return 0, err
 	}
 
@@ -0,0 +1,40 @@
+package repo
+
+import "go.mongodb.org/mongo-driver/bson"
+
+// ScopeOption changes the default scope repositories apply to their queries
+type ScopeOption func(*scope)
+
+type scope struct {
+	includeDeleted bool
+}
+
+// IncludeDeleted lifts the default scope so soft-deleted records are matched too.
+// Meant for admin paths such as restore and purge.
+func IncludeDeleted() ScopeOption {
+	return func(s *scope) {
+		s.includeDeleted = true
+	}
+}
+
+// applyScope wraps filter with the default scope, which excludes soft-deleted records
+func applyScope(filter interface{}, opts ...ScopeOption) interface{} {
+	s := &scope{}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.includeDeleted {
+		if filter == nil {
+			return bson.M{}
+		}
+		return filter
+	}
+
+	notDeleted := bson.M{"is_deleted": bson.M{"$ne": true}}
+	if filter == nil {
+		return notDeleted
+	}
+
+	return bson.M{"$and": bson.A{filter, notDeleted}}
+}
@@ -0,0 +1,94 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"github.com/iamrz1/ab-auth/logger"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileProvider reads every secret from a file named after it in a directory, like the
+// secret volumes of kubernetes or docker. Files are polled, as mounted secrets are swapped
+// through symlinks that file system events do not report reliably.
+type FileProvider struct {
+	dir      string
+	interval time.Duration
+}
+
+// NewFileProvider returns a provider reading secrets from dir, checking for changes every interval
+func NewFileProvider(dir string, interval time.Duration) *FileProvider {
+	return &FileProvider{dir: dir, interval: interval}
+}
+
+// Get returns the content of the file named after secret name, without a trailing newline
+func (p *FileProvider) Get(name string) (string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(p.dir, name))
+	if os.IsNotExist(err) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// Watch reports secret files that were added, changed or removed since the last check
+func (p *FileProvider) Watch(ctx context.Context, onChange func(name string)) {
+	seen, _ := p.snapshot()
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			current, err := p.snapshot()
+			if err != nil {
+				logger.Errorln(ctx, "Watch", "could not read secrets: "+err.Error())
+				continue
+			}
+			for name, content := range current {
+				if old, ok := seen[name]; !ok || !bytes.Equal(old, content) {
+					onChange(name)
+				}
+			}
+			for name := range seen {
+				if _, ok := current[name]; !ok {
+					onChange(name)
+				}
+			}
+			seen = current
+		}
+	}
+}
+
+// snapshot reads every secret file of the directory, skipping the hidden entries that
+// kubernetes keeps its versions in
+func (p *FileProvider) snapshot() (map[string][]byte, error) {
+	entries, err := ioutil.ReadDir(p.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") || e.IsDir() {
+			continue
+		}
+		b, err := ioutil.ReadFile(filepath.Join(p.dir, e.Name()))
+		if err != nil {
+			logger.Errorln(context.Background(), "snapshot", "could not read secret: "+err.Error())
+			continue
+		}
+		files[e.Name()] = b
+	}
+
+	return files, nil
+}
@@ -0,0 +1,92 @@
+// Package secrets reads signing keys and credentials from a mounted secrets directory or
+// env vars and follows their rotation, so they change without a restart.
+package secrets
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+)
+
+// Names of the secrets the app reads
+const (
+	JWTAccessKey  = "jwt_access_key"
+	JWTRefreshKey = "jwt_refresh_key"
+	OTPHashKey    = "otp_hash_key"
+	CaptchaKey    = "captcha_secret_key"
+	DBUsername    = "db_username"
+	DBPassword    = "db_password"
+	RedisPassword = "redis_password"
+)
+
+// EnvVars maps the secrets to the env vars EnvProvider reads them from
+var EnvVars = map[string]string{
+	JWTAccessKey:  "JWT_ACCESS_KEY",
+	JWTRefreshKey: "JWT_REFRESH_KEY",
+	OTPHashKey:    "OTP_HASH_KEY",
+	CaptchaKey:    "EVALY_API_SECRET_KEY",
+	DBUsername:    "DB_USERNAME",
+	DBPassword:    "DB_PASSWORD",
+	RedisPassword: "REDIS_PASSWORD",
+}
+
+// ErrNotFound is returned for secrets a provider does not have
+var ErrNotFound = errors.New("secrets: not found")
+
+// Provider hands out secrets by name
+type Provider interface {
+	// Get returns the current value of secret name, or ErrNotFound
+	Get(name string) (string, error)
+	// Watch calls onChange with the name of every secret that changes until ctx is done.
+	// Providers whose secrets can not change return at once.
+	Watch(ctx context.Context, onChange func(name string))
+}
+
+// EnvProvider reads secrets from the env vars listed in EnvVars. They are read once,
+// rotating them takes a restart.
+type EnvProvider struct{}
+
+// Get returns the env var of secret name, or ErrNotFound when it is unset or empty
+func (EnvProvider) Get(name string) (string, error) {
+	v := os.Getenv(EnvVars[name])
+	if EnvVars[name] == "" || v == "" {
+		return "", ErrNotFound
+	}
+
+	return v, nil
+}
+
+// Watch returns at once, env vars do not change
+func (EnvProvider) Watch(ctx context.Context, onChange func(name string)) {}
+
+// chain asks its providers in turn
+type chain []Provider
+
+// Chain returns a provider taking each secret from the first of providers that has it
+func Chain(providers ...Provider) Provider {
+	return chain(providers)
+}
+
+func (c chain) Get(name string) (string, error) {
+	for _, p := range c {
+		v, err := p.Get(name)
+		if err != ErrNotFound {
+			return v, err
+		}
+	}
+
+	return "", ErrNotFound
+}
+
+func (c chain) Watch(ctx context.Context, onChange func(name string)) {
+	var wg sync.WaitGroup
+	for _, p := range c {
+		wg.Add(1)
+		go func(p Provider) {
+			defer wg.Done()
+			p.Watch(ctx, onChange)
+		}(p)
+	}
+	wg.Wait()
+}
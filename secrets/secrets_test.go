@@ -0,0 +1,82 @@
+package secrets
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeSecret(t *testing.T, dir, name, value string) {
+	err := ioutil.WriteFile(filepath.Join(dir, name), []byte(value), 0600)
+	assert.NoError(t, err)
+}
+
+func TestFileProvider_Get(t *testing.T) {
+	dir := t.TempDir()
+	writeSecret(t, dir, JWTAccessKey, "access-key\n")
+
+	p := NewFileProvider(dir, time.Second)
+
+	v, err := p.Get(JWTAccessKey)
+	assert.NoError(t, err)
+	assert.Equal(t, "access-key", v, "the trailing newline is dropped")
+
+	_, err = p.Get(JWTRefreshKey)
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestChain_FallsBackToEnv(t *testing.T) {
+	dir := t.TempDir()
+	writeSecret(t, dir, JWTAccessKey, "from-file")
+	os.Setenv(EnvVars[JWTAccessKey], "from-env")
+	os.Setenv(EnvVars[OTPHashKey], "otp-from-env")
+	defer os.Unsetenv(EnvVars[JWTAccessKey])
+	defer os.Unsetenv(EnvVars[OTPHashKey])
+
+	p := Chain(NewFileProvider(dir, time.Second), EnvProvider{})
+
+	v, _ := p.Get(JWTAccessKey)
+	assert.Equal(t, "from-file", v)
+	v, _ = p.Get(OTPHashKey)
+	assert.Equal(t, "otp-from-env", v)
+	_, err := p.Get(RedisPassword)
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestStore_FollowsRotation(t *testing.T) {
+	dir := t.TempDir()
+	writeSecret(t, dir, DBPassword, "old")
+	writeSecret(t, dir, RedisPassword, "unchanged")
+
+	store := NewStore(NewFileProvider(dir, time.Millisecond*10))
+	assert.Equal(t, "old", store.Get(DBPassword))
+	assert.Equal(t, "unchanged", store.Get(RedisPassword))
+
+	rotated := make(chan string, 10)
+	store.OnChange(DBPassword, func(v string) { rotated <- v })
+	store.OnChange(RedisPassword, func(v string) { t.Error("redis_password did not change") })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go store.Watch(ctx)
+
+	time.Sleep(time.Millisecond * 30)
+	writeSecret(t, dir, DBPassword, "new")
+
+	select {
+	case v := <-rotated:
+		assert.Equal(t, "new", v)
+	case <-time.After(time.Second):
+		t.Fatal("rotation not noticed")
+	}
+	assert.Equal(t, "new", store.Get(DBPassword))
+
+	// a removed secret keeps its last value
+	assert.NoError(t, os.Remove(filepath.Join(dir, DBPassword)))
+	time.Sleep(time.Millisecond * 50)
+	assert.Equal(t, "new", store.Get(DBPassword))
+}
@@ -0,0 +1,83 @@
+package secrets
+
+import (
+	"context"
+	"github.com/iamrz1/ab-auth/logger"
+	"sync"
+)
+
+// Store keeps the secrets of a provider in memory and tells subscribers when one is rotated
+type Store struct {
+	provider Provider
+
+	mu     sync.RWMutex
+	values map[string]string
+	subs   map[string][]func(value string)
+}
+
+// NewStore returns a store reading secrets from provider
+func NewStore(provider Provider) *Store {
+	return &Store{
+		provider: provider,
+		values:   map[string]string{},
+		subs:     map[string][]func(value string){},
+	}
+}
+
+// Get returns the value of secret name, empty when it is not set
+func (s *Store) Get(name string) string {
+	s.mu.RLock()
+	v, ok := s.values[name]
+	s.mu.RUnlock()
+	if ok {
+		return v
+	}
+
+	v, err := s.provider.Get(name)
+	if err != nil && err != ErrNotFound {
+		logger.Errorf(context.Background(), "Get", "could not read secret %s: %v", name, err)
+	}
+
+	s.mu.Lock()
+	s.values[name] = v
+	s.mu.Unlock()
+
+	return v
+}
+
+// OnChange calls fn with the new value of secret name whenever it is rotated
+func (s *Store) OnChange(name string, fn func(value string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.subs[name] = append(s.subs[name], fn)
+}
+
+// Watch follows the rotation of secrets until ctx is done. A secret that disappears keeps
+// its last value, a half finished rotation must not leave the app without keys.
+func (s *Store) Watch(ctx context.Context) {
+	s.provider.Watch(ctx, s.reload)
+}
+
+func (s *Store) reload(name string) {
+	v, err := s.provider.Get(name)
+	if err != nil {
+		logger.Warnf(context.Background(), "reload", "keeping the last value of secret %s: %v", name, err)
+		return
+	}
+
+	s.mu.Lock()
+	old, known := s.values[name]
+	s.values[name] = v
+	subs := s.subs[name]
+	s.mu.Unlock()
+
+	if known && old == v {
+		return
+	}
+
+	logger.Infoln(context.Background(), "reload", "secret "+name+" rotated")
+	for _, fn := range subs {
+		fn(v)
+	}
+}
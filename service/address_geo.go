@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	rest_error "github.com/iamrz1/ab-auth/error"
+	"github.com/iamrz1/ab-auth/i18n"
+	"github.com/iamrz1/ab-auth/model"
+	"github.com/iamrz1/ab-auth/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"gopkg.in/validator.v2"
+)
+
+const (
+	addressGeoSearchDefaultLimit = 100
+	addressGeoSearchMaxLimit     = 1000
+	addressGeoSearchMaxRadiusKm  = 100
+)
+
+// addressGeoPoint returns the GeoJSON location of a, or nil if a has no coordinates
+func addressGeoPoint(a *model.Address) *model.GeoPoint {
+	if a.Latitude == 0 && a.Longitude == 0 {
+		return nil
+	}
+
+	return model.NewGeoPoint(a.Latitude, a.Longitude)
+}
+
+// buildAddressGeoQuery turns req into a query on the location field: $nearSphere for a
+// center and radius, $geoWithin for a polygon
+func buildAddressGeoQuery(req *model.AddressGeoSearchReq) (bson.M, error) {
+	problems := validator.ErrorMap{}
+	hasCircle := req.RadiusKm != 0 || req.Latitude != 0 || req.Longitude != 0
+	hasPolygon := len(req.Polygon) > 0
+
+	if hasCircle == hasPolygon {
+		problems["radius_km"] = validator.ErrorArray{errors.New(i18n.MsgRadiusOrPolygon)}
+		problems["polygon"] = validator.ErrorArray{errors.New(i18n.MsgRadiusOrPolygon)}
+		return nil, rest_error.NewCodedValidationError(rest_error.CodeInvalidGeoQuery, i18n.MsgInvalidGeoQuery, problems)
+	}
+
+	if hasCircle {
+		if !utils.IsValidCoordinate(req.Latitude, req.Longitude) {
+			problems["latitude"] = validator.ErrorArray{errors.New(i18n.MsgInvalidCoordinate)}
+			problems["longitude"] = validator.ErrorArray{errors.New(i18n.MsgInvalidCoordinate)}
+		}
+		switch {
+		case req.RadiusKm <= 0:
+			problems["radius_km"] = validator.ErrorArray{errors.New(i18n.MsgLessThanMin)}
+		case req.RadiusKm > addressGeoSearchMaxRadiusKm:
+			problems["radius_km"] = validator.ErrorArray{errors.New(i18n.MsgRadiusTooLarge)}
+		}
+		if len(problems) > 0 {
+			return nil, rest_error.NewCodedValidationError(rest_error.CodeInvalidGeoQuery, i18n.MsgInvalidGeoQuery, problems)
+		}
+
+		return bson.M{"$nearSphere": bson.M{
+			"$geometry":    model.NewGeoPoint(req.Latitude, req.Longitude),
+			"$maxDistance": req.RadiusKm * 1000,
+		}}, nil
+	}
+
+	ring := make([][]float64, 0, len(req.Polygon)+1)
+	for _, p := range req.Polygon {
+		if len(p) != 2 || !utils.IsValidCoordinate(p[1], p[0]) {
+			problems["polygon"] = validator.ErrorArray{errors.New(i18n.MsgInvalidCoordinate)}
+			return nil, rest_error.NewCodedValidationError(rest_error.CodeInvalidGeoQuery, i18n.MsgInvalidGeoQuery, problems)
+		}
+		ring = append(ring, p)
+	}
+
+	first, last := ring[0], ring[len(ring)-1]
+	if first[0] != last[0] || first[1] != last[1] {
+		ring = append(ring, first)
+	}
+
+	// a closed ring repeats its first point, so a triangle has four
+	if len(ring) < 4 {
+		problems["polygon"] = validator.ErrorArray{errors.New(i18n.MsgPolygonTooSmall)}
+		return nil, rest_error.NewCodedValidationError(rest_error.CodeInvalidGeoQuery, i18n.MsgInvalidGeoQuery, problems)
+	}
+
+	return bson.M{"$geoWithin": bson.M{
+		"$geometry": bson.M{"type": model.GeoJSONPolygon, "coordinates": [][][]float64{ring}},
+	}}, nil
+}
+
+// SearchAddressesByLocation finds live addresses within a radius, nearest first, or inside a polygon
+func (gs *customerService) SearchAddressesByLocation(ctx context.Context, req *model.AddressGeoSearchReq) ([]*model.Address, error) {
+	query, err := buildAddressGeoQuery(req)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = addressGeoSearchDefaultLimit
+	}
+	if limit > addressGeoSearchMaxLimit {
+		limit = addressGeoSearchMaxLimit
+	}
+
+	return gs.AddressRepo.FindAddressesByLocation(ctx, query, limit)
+}
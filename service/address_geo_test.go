@@ -0,0 +1,68 @@
+package service
+
+import (
+	rest_error "github.com/iamrz1/ab-auth/error"
+	"github.com/iamrz1/ab-auth/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"testing"
+)
+
+func TestAddressGeoPoint(t *testing.T) {
+	if p := addressGeoPoint(&model.Address{}); p != nil {
+		t.Errorf("expected no location without coordinates, got %+v", p)
+	}
+
+	p := addressGeoPoint(&model.Address{Latitude: 23.81, Longitude: 90.41})
+	if p == nil || p.Type != model.GeoJSONPoint || p.Coordinates[0] != 90.41 || p.Coordinates[1] != 23.81 {
+		t.Errorf("expected a [lng, lat] point, got %+v", p)
+	}
+}
+
+func TestBuildAddressGeoQuery_Radius(t *testing.T) {
+	q, err := buildAddressGeoQuery(&model.AddressGeoSearchReq{Latitude: 23.81, Longitude: 90.41, RadiusKm: 2.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	near, ok := q["$nearSphere"].(bson.M)
+	if !ok {
+		t.Fatalf("expected a $nearSphere query, got %v", q)
+	}
+	if near["$maxDistance"] != 2500.0 {
+		t.Errorf("expected the radius in meters, got %v", near["$maxDistance"])
+	}
+}
+
+func TestBuildAddressGeoQuery_ClosesPolygon(t *testing.T) {
+	q, err := buildAddressGeoQuery(&model.AddressGeoSearchReq{
+		Polygon: [][]float64{{90.3, 23.7}, {90.5, 23.7}, {90.5, 23.9}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	geometry := q["$geoWithin"].(bson.M)["$geometry"].(bson.M)
+	ring := geometry["coordinates"].([][][]float64)[0]
+	if len(ring) != 4 || ring[3][0] != 90.3 || ring[3][1] != 23.7 {
+		t.Errorf("expected the ring to be closed, got %v", ring)
+	}
+}
+
+func TestBuildAddressGeoQuery_Invalid(t *testing.T) {
+	cases := map[string]*model.AddressGeoSearchReq{
+		"empty":            {},
+		"both":             {Latitude: 23.81, Longitude: 90.41, RadiusKm: 1, Polygon: [][]float64{{90.3, 23.7}}},
+		"no radius":        {Latitude: 23.81, Longitude: 90.41},
+		"radius too large": {Latitude: 23.81, Longitude: 90.41, RadiusKm: addressGeoSearchMaxRadiusKm + 1},
+		"bad center":       {Latitude: 123.81, Longitude: 90.41, RadiusKm: 1},
+		"bad point":        {Polygon: [][]float64{{90.3}, {90.5, 23.7}, {90.5, 23.9}}},
+		"too few points":   {Polygon: [][]float64{{90.3, 23.7}, {90.5, 23.7}, {90.3, 23.7}}},
+	}
+
+	for name, req := range cases {
+		_, err := buildAddressGeoQuery(req)
+		if rest_error.CodeOf(err) != rest_error.CodeInvalidGeoQuery {
+			t.Errorf("%s: expected an invalid geo query error, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"errors"
+	rest_error "github.com/iamrz1/ab-auth/error"
+	"github.com/iamrz1/ab-auth/i18n"
+	"github.com/iamrz1/ab-auth/model"
+	"github.com/iamrz1/ab-auth/utils"
+	"gopkg.in/validator.v2"
+)
+
+// addressLevel is one level of the location chain of an address, from the division down
+type addressLevel struct {
+	field     string
+	nameField string
+	typ       model.BDLocationType
+	slug      *string
+	name      *string
+}
+
+func addressLevels(a *model.Address) []addressLevel {
+	return []addressLevel{
+		{field: "division_slug", nameField: "division", typ: model.LocationTypeDivision, slug: &a.DivisionSlug, name: &a.Division},
+		{field: "district_slug", nameField: "district", typ: model.LocationTypeDistrict, slug: &a.DistrictSlug, name: &a.District},
+		{field: "sub_district_slug", nameField: "sub_district", typ: model.LocationTypeSubDistrict, slug: &a.SubDistrictSlug, name: &a.SubDistrict},
+		{field: "union_slug", nameField: "union", typ: model.LocationTypeUnion, slug: &a.UnionSlug, name: &a.Union},
+	}
+}
+
+// clearedAddressFields returns the fields of the levels current has and merged cleared
+func clearedAddressFields(current, merged *model.Address) []string {
+	fields := make([]string, 0)
+	mergedLevels := addressLevels(merged)
+	for i, lvl := range addressLevels(current) {
+		if *lvl.slug != "" && *mergedLevels[i].slug == "" {
+			fields = append(fields, lvl.field, lvl.nameField)
+		}
+	}
+
+	return fields
+}
+
+// hasLocation reports whether a carries any location slug or coordinate
+func hasLocation(a *model.Address) bool {
+	return a.DivisionSlug != "" || a.DistrictSlug != "" || a.SubDistrictSlug != "" || a.UnionSlug != "" ||
+		a.Latitude != 0 || a.Longitude != 0
+}
+
+// mergeAddressLocation returns the location of current with the levels and coordinates set in update applied.
+// Once a level changes, the levels below it that update does not set are cleared, they are not under the new
+// area.
+func mergeAddressLocation(current, update *model.Address) *model.Address {
+	merged := &model.Address{}
+	copyAddressLocation(merged, current)
+
+	mergedLevels := addressLevels(merged)
+	changed := false
+	for i, lvl := range addressLevels(update) {
+		switch {
+		case *lvl.slug != "":
+			changed = changed || *lvl.slug != *mergedLevels[i].slug
+			*mergedLevels[i].slug = *lvl.slug
+		case changed:
+			*mergedLevels[i].slug, *mergedLevels[i].name = "", ""
+		}
+	}
+	if update.Latitude != 0 || update.Longitude != 0 {
+		merged.Latitude, merged.Longitude = update.Latitude, update.Longitude
+	}
+
+	return merged
+}
+
+// copyAddressLocation copies the location slugs, area names and coordinates of src into dst
+func copyAddressLocation(dst, src *model.Address) {
+	dstLevels := addressLevels(dst)
+	for i, lvl := range addressLevels(src) {
+		*dstLevels[i].slug = *lvl.slug
+		*dstLevels[i].name = *lvl.name
+	}
+	dst.Latitude, dst.Longitude = src.Latitude, src.Longitude
+}
+
+// resolveAddressLocation checks the location of a against the BD location presets and
+// fills in the area names from the slugs
+func (gs *customerService) resolveAddressLocation(ctx context.Context, a *model.Address) error {
+	err := gs.ensureBDIndex(ctx)
+	if err != nil {
+		return err
+	}
+
+	return validateAddressLocation(gs.bdIndex, a)
+}
+
+// validateAddressLocation checks that the slugs of a form a chain in idx, division and
+// district being mandatory, and that the coordinates, if any, lie in Bangladesh and near
+// the centroid of the district. Area names are overwritten with the preset names.
+func validateAddressLocation(idx *bdLocationIndex, a *model.Address) error {
+	problems := validator.ErrorMap{}
+	levels := addressLevels(a)
+
+	var parent, district *model.BDLocation
+	for i, lvl := range levels {
+		if *lvl.slug == "" {
+			// a union can only be picked under a sub-district
+			if i < 2 || (lvl.typ == model.LocationTypeSubDistrict && a.UnionSlug != "") {
+				problems[lvl.field] = validator.ErrorArray{errors.New(i18n.MsgLocationRequired)}
+			}
+			parent = nil
+			continue
+		}
+
+		l, ok := idx.get(*lvl.slug)
+		if !ok || l.Type != lvl.typ {
+			problems[lvl.field] = validator.ErrorArray{errors.New(i18n.MsgUnknownLocation)}
+			parent = nil
+			continue
+		}
+
+		if i > 0 && parent != nil && l.Parent != parent.Slug {
+			problems[lvl.field] = validator.ErrorArray{errors.New(i18n.MsgLocationParentMismatch)}
+		}
+
+		*lvl.name = l.Name
+		parent = l
+		if l.Type == model.LocationTypeDistrict {
+			district = l
+		}
+	}
+
+	if len(problems) > 0 {
+		return rest_error.NewCodedValidationError(rest_error.CodeInvalidLocation, i18n.MsgInvalidAddressLocation, problems)
+	}
+
+	if a.Latitude == 0 && a.Longitude == 0 {
+		return nil
+	}
+
+	reason := ""
+	switch {
+	case !utils.IsInBangladesh(a.Latitude, a.Longitude):
+		reason = i18n.MsgOutsideBangladesh
+	case district != nil && (district.Latitude != 0 || district.Longitude != 0) &&
+		utils.DistanceKm(a.Latitude, a.Longitude, district.Latitude, district.Longitude) > utils.MaxDistanceFromDistrictKm:
+		reason = i18n.MsgTooFarFromDistrict
+	}
+
+	if reason != "" {
+		problems["latitude"] = validator.ErrorArray{errors.New(reason)}
+		problems["longitude"] = validator.ErrorArray{errors.New(reason)}
+		return rest_error.NewCodedValidationError(rest_error.CodeLocationOutOfBounds, i18n.MsgAddressOutOfBounds, problems)
+	}
+
+	return nil
+}
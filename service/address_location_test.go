@@ -0,0 +1,137 @@
+package service
+
+import (
+	rest_error "github.com/iamrz1/ab-auth/error"
+	"github.com/iamrz1/ab-auth/model"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testAddressLocationIndex() *bdLocationIndex {
+	idx := &bdLocationIndex{}
+	idx.load([]*model.BDLocation{
+		{Name: "Dhaka", NameBn: "ঢাকা", Slug: "dhaka", Type: model.LocationTypeDivision},
+		{Name: "Dhaka", NameBn: "ঢাকা", Slug: "dhaka-dhaka", Parent: "dhaka", Type: model.LocationTypeDistrict, Latitude: 23.81, Longitude: 90.41},
+		{Name: "Gazipur", NameBn: "গাজীপুর", Slug: "dhaka-gazipur", Parent: "dhaka", Type: model.LocationTypeDistrict, Latitude: 24.00, Longitude: 90.42},
+		{Name: "Savar", NameBn: "সাভার", Slug: "dhaka-dhaka-savar", Parent: "dhaka-dhaka", Type: model.LocationTypeSubDistrict},
+		{Name: "Ashulia", NameBn: "আশুলিয়া", Slug: "dhaka-dhaka-savar-ashulia", Parent: "dhaka-dhaka-savar", Type: model.LocationTypeUnion},
+		{Name: "Sylhet", NameBn: "সিলেট", Slug: "sylhet", Type: model.LocationTypeDivision},
+		{Name: "Sylhet", NameBn: "সিলেট", Slug: "sylhet-sylhet", Parent: "sylhet", Type: model.LocationTypeDistrict, Latitude: 24.90, Longitude: 91.87},
+	}, time.Time{})
+
+	return idx
+}
+
+func TestValidateAddressLocation_FillsNames(t *testing.T) {
+	a := &model.Address{
+		DivisionSlug:    "dhaka",
+		DistrictSlug:    "dhaka-dhaka",
+		SubDistrictSlug: "dhaka-dhaka-savar",
+		UnionSlug:       "dhaka-dhaka-savar-ashulia",
+		District:        "whatever the client sent",
+		Latitude:        23.87,
+		Longitude:       90.32,
+	}
+
+	err := validateAddressLocation(testAddressLocationIndex(), a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a.Division != "Dhaka" || a.District != "Dhaka" || a.SubDistrict != "Savar" || a.Union != "Ashulia" {
+		t.Errorf("names not filled from slugs: %+v", a)
+	}
+}
+
+func TestValidateAddressLocation_Chain(t *testing.T) {
+	cases := map[string]*model.Address{
+		"district_slug":     {DivisionSlug: "sylhet", DistrictSlug: "dhaka-dhaka"},
+		"sub_district_slug": {DivisionSlug: "dhaka", DistrictSlug: "dhaka-dhaka", UnionSlug: "dhaka-dhaka-savar-ashulia"},
+		"union_slug":        {DivisionSlug: "dhaka", DistrictSlug: "dhaka-gazipur", SubDistrictSlug: "nowhere", UnionSlug: "dhaka-dhaka"},
+		"division_slug":     {DistrictSlug: "dhaka-dhaka"},
+	}
+
+	for field, a := range cases {
+		err := validateAddressLocation(testAddressLocationIndex(), a)
+		ve, ok := err.(rest_error.ValidationError)
+		if !ok || ve.ErrorCode() != rest_error.CodeInvalidLocation {
+			t.Errorf("%s: expected an invalid location error, got %v", field, err)
+			continue
+		}
+
+		found := false
+		for _, f := range ve.FieldErrors() {
+			found = found || f.Field == field
+		}
+		if !found {
+			t.Errorf("%s: expected a field error, got %v", field, ve.FieldErrors())
+		}
+	}
+}
+
+func TestValidateAddressLocation_Coordinates(t *testing.T) {
+	cases := []struct {
+		lat, lng float64
+		valid    bool
+	}{
+		{lat: 23.75, lng: 90.39, valid: true},
+		{lat: 0, lng: 0, valid: true},
+		{lat: 28.61, lng: 77.21},
+		// Sylhet is inside Bangladesh but far from Dhaka district
+		{lat: 24.90, lng: 91.87},
+	}
+
+	for _, c := range cases {
+		a := &model.Address{DivisionSlug: "dhaka", DistrictSlug: "dhaka-dhaka", Latitude: c.lat, Longitude: c.lng}
+		err := validateAddressLocation(testAddressLocationIndex(), a)
+		if c.valid && err != nil {
+			t.Errorf("(%v, %v): unexpected error %v", c.lat, c.lng, err)
+		}
+		if !c.valid && rest_error.CodeOf(err) != rest_error.CodeLocationOutOfBounds {
+			t.Errorf("(%v, %v): expected an out of bounds error, got %v", c.lat, c.lng, err)
+		}
+	}
+}
+
+func TestMergeAddressLocation(t *testing.T) {
+	current := &model.Address{DivisionSlug: "dhaka", DistrictSlug: "dhaka-dhaka", SubDistrictSlug: "dhaka-dhaka-savar", Latitude: 23.87, Longitude: 90.32}
+	merged := mergeAddressLocation(current, &model.Address{UnionSlug: "dhaka-dhaka-savar-ashulia"})
+
+	if merged.DistrictSlug != "dhaka-dhaka" || merged.UnionSlug != "dhaka-dhaka-savar-ashulia" || merged.Latitude != 23.87 {
+		t.Errorf("unexpected merge result %+v", merged)
+	}
+
+	if err := validateAddressLocation(testAddressLocationIndex(), merged); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMergeAddressLocation_ParentChange(t *testing.T) {
+	current := &model.Address{
+		DivisionSlug: "dhaka", Division: "Dhaka",
+		DistrictSlug: "dhaka-dhaka", District: "Dhaka",
+		SubDistrictSlug: "dhaka-dhaka-savar", SubDistrict: "Savar",
+		UnionSlug: "dhaka-dhaka-savar-ashulia", Union: "Ashulia",
+	}
+	merged := mergeAddressLocation(current, &model.Address{DistrictSlug: "dhaka-gazipur"})
+
+	if merged.DivisionSlug != "dhaka" || merged.DistrictSlug != "dhaka-gazipur" {
+		t.Errorf("unexpected merge result %+v", merged)
+	}
+	if merged.SubDistrictSlug != "" || merged.SubDistrict != "" || merged.UnionSlug != "" || merged.Union != "" {
+		t.Errorf("the areas under the old district are kept: %+v", merged)
+	}
+	if err := validateAddressLocation(testAddressLocationIndex(), merged); err != nil {
+		t.Error(err)
+	}
+	cleared := clearedAddressFields(current, merged)
+	if want := []string{"sub_district_slug", "sub_district", "union_slug", "union"}; !reflect.DeepEqual(cleared, want) {
+		t.Errorf("expected %v to be unset, got %v", want, cleared)
+	}
+
+	merged = mergeAddressLocation(current, &model.Address{DistrictSlug: "dhaka-dhaka", UnionSlug: "dhaka-dhaka-savar-ashulia"})
+	if merged.SubDistrictSlug != "dhaka-dhaka-savar" {
+		t.Errorf("an unchanged district keeps its sub-district: %+v", merged)
+	}
+}
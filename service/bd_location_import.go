@@ -0,0 +1,281 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/iamrz1/ab-auth/infra"
+	"github.com/iamrz1/ab-auth/model"
+	"github.com/iamrz1/ab-auth/utils"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// bdLocationCSVHeader is the column order expected in csv datasets, the centroid
+// columns may be left out
+var bdLocationCSVHeader = []string{"id", "name", "name_bn", "slug", "parent", "type", "latitude", "longitude"}
+
+const bdLocationCSVRequiredColumns = 6
+
+// BDLocationImportOptions tunes ImportBDLocations
+type BDLocationImportOptions struct {
+	// Prune removes presets that are not part of the dataset
+	Prune bool
+	// Force imports the dataset even if the same version and content was imported already
+	Force bool
+}
+
+// BDLocationImportRes summarises an import
+type BDLocationImportRes struct {
+	Version  string
+	Count    int
+	Skipped  bool
+	Checksum string
+}
+
+// LoadBDLocationDataset reads a json or csv dataset from path. Csv files carry no version,
+// so version must be given for them; for json it overrides the version in the file.
+func LoadBDLocationDataset(path, version string) (*model.BDLocationDataset, string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	ds := &model.BDLocationDataset{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(b, ds)
+	case ".csv":
+		ds.Locations, err = parseBDLocationCSV(bytes.NewReader(b))
+	default:
+		err = fmt.Errorf("unsupported dataset format %q, use .json or .csv", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	if version != "" {
+		ds.Version = version
+	}
+
+	sum := sha256.Sum256(b)
+
+	return ds, hex.EncodeToString(sum[:]), nil
+}
+
+func parseBDLocationCSV(r io.Reader) ([]*model.BDLocation, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 || !isBDLocationCSVHeader(records[0]) {
+		return nil, fmt.Errorf("csv header must be %s, latitude and longitude are optional", strings.Join(bdLocationCSVHeader, ","))
+	}
+
+	res := make([]*model.BDLocation, 0, len(records)-1)
+	for i, rec := range records[1:] {
+		id, err := strconv.Atoi(rec[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid id %q", i+2, rec[0])
+		}
+
+		l := &model.BDLocation{
+			ID:     int32(id),
+			Name:   rec[1],
+			NameBn: rec[2],
+			Slug:   rec[3],
+			Parent: rec[4],
+			Type:   model.BDLocationType(rec[5]),
+		}
+
+		if len(rec) > bdLocationCSVRequiredColumns && rec[6] != "" {
+			l.Latitude, err = strconv.ParseFloat(rec[6], 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid latitude %q", i+2, rec[6])
+			}
+			l.Longitude, err = strconv.ParseFloat(rec[7], 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid longitude %q", i+2, rec[7])
+			}
+		}
+
+		res = append(res, l)
+	}
+
+	return res, nil
+}
+
+func isBDLocationCSVHeader(header []string) bool {
+	if len(header) != bdLocationCSVRequiredColumns && len(header) != len(bdLocationCSVHeader) {
+		return false
+	}
+
+	for i, col := range header {
+		if strings.TrimSpace(col) != bdLocationCSVHeader[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ValidateBDLocationDataset checks that slugs are unique and that every location hangs
+// under a parent one level above it. All problems are reported at once.
+func ValidateBDLocationDataset(ds *model.BDLocationDataset) error {
+	problems := make([]string, 0)
+	if ds.Version == "" {
+		problems = append(problems, "dataset version is missing")
+	}
+	if len(ds.Locations) == 0 {
+		problems = append(problems, "dataset has no locations")
+	}
+
+	bySlug := make(map[string]*model.BDLocation, len(ds.Locations))
+	for i, l := range ds.Locations {
+		switch {
+		case l.Slug == "":
+			problems = append(problems, fmt.Sprintf("location #%d has no slug", i+1))
+		case bySlug[l.Slug] != nil:
+			problems = append(problems, fmt.Sprintf("%s: duplicate slug", l.Slug))
+		default:
+			bySlug[l.Slug] = l
+		}
+	}
+
+	for _, l := range ds.Locations {
+		if l.Slug == "" {
+			continue
+		}
+		if l.Name == "" || l.NameBn == "" {
+			problems = append(problems, fmt.Sprintf("%s: name and name_bn are required", l.Slug))
+		}
+		if (l.Latitude != 0 || l.Longitude != 0) && !utils.IsInBangladesh(l.Latitude, l.Longitude) {
+			problems = append(problems, fmt.Sprintf("%s: centroid is outside Bangladesh", l.Slug))
+		}
+		if !model.IsValidBDLocationType(l.Type) {
+			problems = append(problems, fmt.Sprintf("%s: invalid type %q", l.Slug, l.Type))
+			continue
+		}
+
+		want := parentLocationType(l.Type)
+		if want == "" {
+			if l.Parent != "" {
+				problems = append(problems, fmt.Sprintf("%s: a division can not have a parent", l.Slug))
+			}
+			continue
+		}
+
+		p, ok := bySlug[l.Parent]
+		switch {
+		case l.Parent == "":
+			problems = append(problems, fmt.Sprintf("%s: missing parent", l.Slug))
+		case !ok:
+			problems = append(problems, fmt.Sprintf("%s: unknown parent %q", l.Slug, l.Parent))
+		case p.Type != want:
+			problems = append(problems, fmt.Sprintf("%s: parent %q is a %s, expected a %s", l.Slug, l.Parent, p.Type, want))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid dataset:\n  %s", strings.Join(problems, "\n  "))
+	}
+
+	return nil
+}
+
+// ImportBDLocations validates ds and upserts it into the preset collection by slug, then
+// records its version. Importing the same dataset twice is a no-op unless forced. Sample
+// datasets are refused in production.
+func (gs *customerService) ImportBDLocations(ctx context.Context, ds *model.BDLocationDataset, checksum string, opts BDLocationImportOptions) (*BDLocationImportRes, error) {
+	err := ValidateBDLocationDataset(ds)
+	if err != nil {
+		return nil, err
+	}
+
+	if ds.Sample && gs.Config.Environment == utils.EnvProduction {
+		return nil, fmt.Errorf("dataset %s is a sample, import the full dataset in production", ds.Version)
+	}
+
+	res := &BDLocationImportRes{Version: ds.Version, Count: len(ds.Locations), Checksum: checksum}
+
+	current, err := gs.AddressRepo.GetDatasetVersion(ctx, model.BDLocationDatasetID)
+	if err != nil && err != infra.ErrNotFound {
+		return nil, err
+	}
+	if !opts.Force && current != nil && current.Version == ds.Version && current.Checksum == checksum {
+		res.Skipped = true
+		return res, nil
+	}
+
+	err = gs.AddressRepo.EnsureBdLocationIndices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = gs.AddressRepo.UpsertBdLocations(ctx, ds.Locations)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Prune {
+		keep := make([]string, 0, len(ds.Locations))
+		for _, l := range ds.Locations {
+			keep = append(keep, l.Slug)
+		}
+		err = gs.AddressRepo.PruneBdLocations(ctx, keep)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = gs.AddressRepo.SetDatasetVersion(ctx, &model.DatasetVersion{
+		ID:         model.BDLocationDatasetID,
+		Version:    ds.Version,
+		Checksum:   checksum,
+		Count:      len(ds.Locations),
+		ImportedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	gs.bdIndex.invalidate()
+
+	return res, nil
+}
+
+// GetBDLocationVersion returns the version of the imported BD location presets, or an
+// empty string when none was recorded
+func (gs *customerService) GetBDLocationVersion(ctx context.Context) (string, error) {
+	v, err := gs.AddressRepo.GetDatasetVersion(ctx, model.BDLocationDatasetID)
+	if err != nil {
+		if err == infra.ErrNotFound {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return v.Version, nil
+}
+
+// parentLocationType is the type a location of type t must hang under
+func parentLocationType(t model.BDLocationType) model.BDLocationType {
+	switch t {
+	case model.LocationTypeDistrict:
+		return model.LocationTypeDivision
+	case model.LocationTypeSubDistrict:
+		return model.LocationTypeDistrict
+	case model.LocationTypeUnion:
+		return model.LocationTypeSubDistrict
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"github.com/iamrz1/ab-auth/model"
+	"github.com/iamrz1/ab-auth/utils"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadBDLocationDataset_Bundled(t *testing.T) {
+	ds, checksum, err := LoadBDLocationDataset("../data/bd_locations.sample.json", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ds.Version == "" || checksum == "" {
+		t.Error("expected a version and a checksum")
+	}
+	if !ds.Sample {
+		t.Error("the bundled dataset is partial and must be marked as a sample")
+	}
+
+	if err := ValidateBDLocationDataset(ds); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLoadBDLocationDataset_CSV(t *testing.T) {
+	dir, err := ioutil.TempDir("", "presets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "presets.csv")
+	data := "id,name,name_bn,slug,parent,type\n" +
+		"1,Dhaka,ঢাকা,dhaka,,division\n" +
+		"2,Gazipur,গাজীপুর,dhaka-gazipur,dhaka,district\n"
+	if err := ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ds, _, err := LoadBDLocationDataset(path, "2021.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ds.Version != "2021.2" || len(ds.Locations) != 2 || ds.Locations[1].Parent != "dhaka" {
+		t.Errorf("unexpected dataset %+v", ds)
+	}
+
+	if err := ValidateBDLocationDataset(ds); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestValidateBDLocationDataset(t *testing.T) {
+	ds := &model.BDLocationDataset{
+		Version: "1",
+		Locations: []*model.BDLocation{
+			{Name: "Dhaka", NameBn: "ঢাকা", Slug: "dhaka", Type: model.LocationTypeDivision},
+			{Name: "Dhaka", NameBn: "ঢাকা", Slug: "dhaka", Type: model.LocationTypeDivision},
+			{Name: "Savar", NameBn: "সাভার", Slug: "savar", Parent: "dhaka", Type: model.LocationTypeSubDistrict},
+			{Name: "Nowhere", NameBn: "কোথাও না", Slug: "nowhere", Parent: "missing", Type: model.LocationTypeDistrict},
+			{Name: "Odd", NameBn: "অদ্ভুত", Slug: "odd", Type: "village"},
+		},
+	}
+
+	err := ValidateBDLocationDataset(ds)
+	if err == nil {
+		t.Fatal("expected validation to fail")
+	}
+
+	for _, want := range []string{"dhaka: duplicate slug", `savar: parent "dhaka" is a division`, `nowhere: unknown parent "missing"`, `odd: invalid type "village"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %q", want, err.Error())
+		}
+	}
+}
+
+func TestImportBDLocations_SampleInProduction(t *testing.T) {
+	svc, _ := testServices(t)
+	cs := svc.CustomerService
+	ds, checksum, err := LoadBDLocationDataset("../data/bd_locations.sample.json", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cs.Config.Environment = utils.EnvProduction
+	if _, err := cs.ImportBDLocations(context.Background(), ds, checksum, BDLocationImportOptions{}); err == nil {
+		t.Error("expected the sample dataset to be refused in production")
+	}
+
+	cs.Config.Environment = utils.EnvDevelopment
+	res, err := cs.ImportBDLocations(context.Background(), ds, checksum, BDLocationImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Version != "sample" {
+		t.Errorf("expected the sample to be recorded as version sample, got %q", res.Version)
+	}
+}
@@ -0,0 +1,324 @@
+package service
+
+import (
+	"context"
+	"github.com/iamrz1/ab-auth/infra"
+	"github.com/iamrz1/ab-auth/model"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	bdLocationIndexTTL   = time.Hour
+	bdSearchDefaultLimit = 10
+	bdSearchMaxLimit     = 50
+
+	// bdDatasetCheckInterval is how often the index compares its dataset with the imported
+	// one, so an import by another process shows up within it
+	bdDatasetCheckInterval = time.Minute
+)
+
+// match kinds, lower is better
+const (
+	matchExact = iota
+	matchPrefix
+	matchWordPrefix
+	matchContains
+	matchFuzzy
+)
+
+// bdLocationIndex keeps the BD location presets in memory. There are only a few thousand
+// of them and they rarely change, which makes fuzzy matching in process cheap.
+type bdLocationIndex struct {
+	mu        sync.RWMutex
+	locations []*model.BDLocation
+	bySlug    map[string]*model.BDLocation
+	// importedAt is when the dataset the index was loaded from was imported, zero when no
+	// import was recorded
+	importedAt time.Time
+	loadedAt   time.Time
+	checkedAt  time.Time
+}
+
+// fresh reports whether the index can be used without looking at the imported dataset.
+// An empty index never is, the presets may not have been imported yet.
+func (idx *bdLocationIndex) fresh() bool {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return len(idx.locations) > 0 && time.Since(idx.loadedAt) <= bdLocationIndexTTL &&
+		time.Since(idx.checkedAt) <= bdDatasetCheckInterval
+}
+
+// keep reports whether the index can still be used when the imported dataset is the one
+// of importedAt, and if so marks it checked
+func (idx *bdLocationIndex) keep(importedAt time.Time) bool {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if len(idx.locations) == 0 || time.Since(idx.loadedAt) > bdLocationIndexTTL || !idx.importedAt.Equal(importedAt) {
+		return false
+	}
+	idx.checkedAt = time.Now()
+
+	return true
+}
+
+// load replaces the index with locations, from the dataset imported at importedAt
+func (idx *bdLocationIndex) load(locations []*model.BDLocation, importedAt time.Time) {
+	bySlug := make(map[string]*model.BDLocation, len(locations))
+	for _, l := range locations {
+		bySlug[l.Slug] = l
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.locations = locations
+	idx.bySlug = bySlug
+	idx.importedAt = importedAt
+	idx.loadedAt = time.Now()
+	idx.checkedAt = idx.loadedAt
+}
+
+// invalidate makes the next use reload the index
+func (idx *bdLocationIndex) invalidate() {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.loadedAt = time.Time{}
+}
+
+// get returns the location with slug, if any
+func (idx *bdLocationIndex) get(slug string) (*model.BDLocation, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	l, ok := idx.bySlug[slug]
+	return l, ok
+}
+
+// ancestors walks up the parent slugs of l, nearest first
+func (idx *bdLocationIndex) ancestors(l *model.BDLocation) []*model.BDLocation {
+	res := make([]*model.BDLocation, 0)
+	seen := map[string]bool{l.Slug: true}
+	for parent := l.Parent; parent != "" && !seen[parent]; {
+		p, ok := idx.bySlug[parent]
+		if !ok {
+			break
+		}
+		res = append(res, p)
+		seen[parent] = true
+		parent = p.Parent
+	}
+
+	return res
+}
+
+// search ranks locations by how well their English or Bangla name matches q
+func (idx *bdLocationIndex) search(q string, t model.BDLocationType, limit int) []*model.BDLocationSearchResult {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	q = normalizeName(q)
+	if q == "" {
+		return []*model.BDLocationSearchResult{}
+	}
+
+	type hit struct {
+		loc   *model.BDLocation
+		score int
+	}
+
+	hits := make([]hit, 0)
+	for _, l := range idx.locations {
+		if t != "" && l.Type != t {
+			continue
+		}
+
+		score, ok := nameScore(normalizeName(l.Name), q)
+		if s, okBn := nameScore(normalizeName(l.NameBn), q); okBn && (!ok || s < score) {
+			score, ok = s, true
+		}
+		if ok {
+			hits = append(hits, hit{loc: l, score: score})
+		}
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].score != hits[j].score {
+			return hits[i].score < hits[j].score
+		}
+		if ri, rj := locationTypeRank(hits[i].loc.Type), locationTypeRank(hits[j].loc.Type); ri != rj {
+			return ri < rj
+		}
+		return hits[i].loc.Name < hits[j].loc.Name
+	})
+
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	res := make([]*model.BDLocationSearchResult, 0, len(hits))
+	for _, h := range hits {
+		res = append(res, &model.BDLocationSearchResult{
+			BDLocation: *h.loc,
+			Ancestors:  idx.ancestors(h.loc),
+		})
+	}
+
+	return res
+}
+
+// SearchBDLocations does prefix and fuzzy search on BD location names in English and Bangla
+func (gs *customerService) SearchBDLocations(ctx context.Context, req *model.BDLocationSearchReq) ([]*model.BDLocationSearchResult, error) {
+	err := gs.ensureBDIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = bdSearchDefaultLimit
+	}
+	if limit > bdSearchMaxLimit {
+		limit = bdSearchMaxLimit
+	}
+
+	return gs.bdIndex.search(req.Query, req.Type, limit), nil
+}
+
+// ensureBDIndex (re)loads the BD location index from the preset collection when it is
+// stale or another dataset was imported since it was loaded
+func (gs *customerService) ensureBDIndex(ctx context.Context) error {
+	if gs.bdIndex.fresh() {
+		return nil
+	}
+
+	importedAt := time.Time{}
+	v, err := gs.AddressRepo.GetDatasetVersion(ctx, model.BDLocationDatasetID)
+	switch {
+	case err == nil:
+		importedAt = v.ImportedAt
+	case err != infra.ErrNotFound:
+		return err
+	}
+	if gs.bdIndex.keep(importedAt) {
+		return nil
+	}
+
+	locations, err := gs.AddressRepo.GetAllBdLocations(ctx)
+	if err != nil {
+		return err
+	}
+	gs.bdIndex.load(locations, importedAt)
+
+	return nil
+}
+
+// nameScore tells how well name matches q. The score is the match kind, fuzzy matches
+// add their edit distance on top.
+func nameScore(name, q string) (int, bool) {
+	switch {
+	case name == "":
+		return 0, false
+	case name == q:
+		return matchExact, true
+	case strings.HasPrefix(name, q):
+		return matchPrefix, true
+	}
+
+	words := strings.Fields(name)
+	for _, w := range words {
+		if strings.HasPrefix(w, q) {
+			return matchWordPrefix, true
+		}
+	}
+
+	if strings.Contains(name, q) {
+		return matchContains, true
+	}
+
+	qr := []rune(q)
+	if len(qr) < 3 {
+		return 0, false
+	}
+
+	// a typo costs one edit, longer queries may have two
+	allowed := 1
+	if len(qr) > 5 {
+		allowed = 2
+	}
+
+	best := allowed + 1
+	for _, w := range append(words, name) {
+		wr := []rune(w)
+		if len(wr) > len(qr) {
+			wr = wr[:len(qr)]
+		}
+		if d := editDistance(wr, qr); d < best {
+			best = d
+		}
+	}
+	if best > allowed {
+		return 0, false
+	}
+
+	return matchFuzzy + best, true
+}
+
+// normalizeName lowercases s and replaces punctuation with single spaces
+func normalizeName(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Mc, r))
+	}), " ")
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
+
+func minInt(n int, rest ...int) int {
+	for _, v := range rest {
+		if v < n {
+			n = v
+		}
+	}
+
+	return n
+}
+
+func locationTypeRank(t model.BDLocationType) int {
+	switch t {
+	case model.LocationTypeDivision:
+		return 0
+	case model.LocationTypeDistrict:
+		return 1
+	case model.LocationTypeSubDistrict:
+		return 2
+	default:
+		return 3
+	}
+}
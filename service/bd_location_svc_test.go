@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"github.com/iamrz1/ab-auth/infra/memdb"
+	"github.com/iamrz1/ab-auth/logger"
+	"github.com/iamrz1/ab-auth/model"
+	"github.com/iamrz1/ab-auth/repo"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func testBDLocationIndex() *bdLocationIndex {
+	idx := &bdLocationIndex{}
+	idx.load([]*model.BDLocation{
+		{Name: "Dhaka", NameBn: "ঢাকা", Slug: "dhaka", Type: model.LocationTypeDivision},
+		{Name: "Dhaka", NameBn: "ঢাকা", Slug: "dhaka-district", Parent: "dhaka", Type: model.LocationTypeDistrict},
+		{Name: "Savar", NameBn: "সাভার", Slug: "savar", Parent: "dhaka-district", Type: model.LocationTypeSubDistrict},
+		{Name: "Ashulia", NameBn: "আশুলিয়া", Slug: "ashulia", Parent: "savar", Type: model.LocationTypeUnion},
+		{Name: "Chattogram", NameBn: "চট্টগ্রাম", Slug: "chattogram", Type: model.LocationTypeDivision},
+		{Name: "Cox's Bazar", NameBn: "কক্সবাজার", Slug: "coxs-bazar", Parent: "chattogram", Type: model.LocationTypeDistrict},
+	}, time.Time{})
+
+	return idx
+}
+
+func TestBDLocationIndex_Search(t *testing.T) {
+	idx := testBDLocationIndex()
+
+	cases := []struct {
+		q     string
+		first string
+	}{
+		{q: "dha", first: "dhaka"},
+		{q: "ASHU", first: "ashulia"},
+		{q: "bazar", first: "coxs-bazar"},
+		{q: "সাভা", first: "savar"},
+		{q: "chatogram", first: "chattogram"},
+		{q: "savr", first: "savar"},
+	}
+
+	for _, c := range cases {
+		res := idx.search(c.q, "", 10)
+		if len(res) == 0 || res[0].Slug != c.first {
+			t.Errorf("search(%q): expected %s first, got %v", c.q, c.first, res)
+		}
+	}
+
+	if res := idx.search("xyzw", "", 10); len(res) != 0 {
+		t.Errorf("expected no match, got %d", len(res))
+	}
+}
+
+func TestBDLocationIndex_SearchType(t *testing.T) {
+	res := testBDLocationIndex().search("dhaka", model.LocationTypeDistrict, 10)
+	if len(res) != 1 || res[0].Slug != "dhaka-district" {
+		t.Fail()
+	}
+}
+
+func TestBDLocationIndex_Ancestors(t *testing.T) {
+	res := testBDLocationIndex().search("ashulia", "", 1)
+	if len(res) != 1 {
+		t.Fatal("expected a match")
+	}
+
+	chain := []string{"savar", "dhaka-district", "dhaka"}
+	if len(res[0].Ancestors) != len(chain) {
+		t.Fatalf("expected %d ancestors, got %d", len(chain), len(res[0].Ancestors))
+	}
+	for i, slug := range chain {
+		if res[0].Ancestors[i].Slug != slug {
+			t.Errorf("ancestor %d: expected %s, got %s", i, slug, res[0].Ancestors[i].Slug)
+		}
+	}
+}
+
+func TestEnsureBDIndex_Reloads(t *testing.T) {
+	ctx := context.Background()
+	db := memdb.New()
+	addressRepo := repo.NewAddressRepo(db, "address", "bd_location", "dataset_version", logger.New(ioutil.Discard, logger.Error))
+	gs := &customerService{AddressRepo: addressRepo, bdIndex: &bdLocationIndex{}}
+
+	if err := gs.ensureBDIndex(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if gs.bdIndex.fresh() {
+		t.Error("an empty index is kept")
+	}
+
+	if err := db.Insert(ctx, "bd_location", &model.BDLocation{ID: 1, Name: "Dhaka", Slug: "dhaka", Type: model.LocationTypeDivision}); err != nil {
+		t.Fatal(err)
+	}
+	if err := gs.ensureBDIndex(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := gs.bdIndex.get("dhaka"); !ok {
+		t.Error("the presets are not loaded once they are imported")
+	}
+
+	// another process imports a dataset, the index picks it up at the next check
+	if err := db.Insert(ctx, "bd_location", &model.BDLocation{ID: 2, Name: "Sylhet", Slug: "sylhet", Type: model.LocationTypeDivision}); err != nil {
+		t.Fatal(err)
+	}
+	if err := addressRepo.SetDatasetVersion(ctx, &model.DatasetVersion{ID: model.BDLocationDatasetID, Version: "2", ImportedAt: time.Now().UTC()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := gs.ensureBDIndex(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := gs.bdIndex.get("sylhet"); ok {
+		t.Error("the index is reloaded before the dataset is checked")
+	}
+
+	gs.bdIndex.checkedAt = time.Now().Add(-bdDatasetCheckInterval - time.Second)
+	if err := gs.ensureBDIndex(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := gs.bdIndex.get("sylhet"); !ok {
+		t.Error("the index is not reloaded for the new dataset")
+	}
+	if !gs.bdIndex.fresh() {
+		t.Error("the reloaded index is not kept")
+	}
+}
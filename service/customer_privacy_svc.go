@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	rest_error "github.com/iamrz1/ab-auth/error"
+	"github.com/iamrz1/ab-auth/i18n"
+	"github.com/iamrz1/ab-auth/infra"
+	"github.com/iamrz1/ab-auth/model"
+	"github.com/iamrz1/ab-auth/repo"
+	"github.com/iamrz1/ab-auth/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"time"
+)
+
+// ExportCustomerData bundles the profile, addresses and audit history of a customer
+func (gs *customerService) ExportCustomerData(ctx context.Context, username string) (*model.CustomerDataExport, error) {
+	c, err := gs.CustomerRepo.GetCustomer(ctx, &model.Customer{Username: username})
+	if err != nil {
+		if err == infra.ErrNotFound {
+			return nil, rest_error.NewCodedValidationError(rest_error.CodeNotFound, "", infra.ErrNotFound)
+		}
+		return nil, err
+	}
+
+	// removed addresses are still kept, so they belong in the export
+	addresses, err := gs.AddressRepo.GetAddresses(ctx, bson.M{"username": username}, nil, repo.IncludeDeleted())
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := gs.AuditRepo.ListEvents(ctx, bson.M{"username": username}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	gs.recordAudit(ctx, username, model.AuditActionDataExport, "")
+
+	return &model.CustomerDataExport{
+		Profile:      c.ToResponse(),
+		Addresses:    addresses,
+		AuditHistory: history,
+		ExportedAt:   time.Now().UTC(),
+	}, nil
+}
+
+// RequestErasure schedules the customer's account to be purged once the grace period ends
+func (gs *customerService) RequestErasure(ctx context.Context, username string) (*model.CustomerErasureRes, error) {
+	filter := &model.Customer{Username: username}
+	c, err := gs.CustomerRepo.GetCustomer(ctx, filter)
+	if err != nil {
+		if err == infra.ErrNotFound {
+			return nil, rest_error.NewCodedValidationError(rest_error.CodeNotFound, "", infra.ErrNotFound)
+		}
+		return nil, err
+	}
+
+	if !c.ErasureScheduledAt.IsZero() {
+		return nil, rest_error.NewCodedValidationError(rest_error.CodeErasurePending, i18n.MsgErasureAlreadyRequested, nil)
+	}
+
+	now := time.Now().UTC()
+	updateDoc := &model.Customer{
+		ErasureRequestedAt: now,
+		ErasureScheduledAt: now.Add(time.Hour * 24 * time.Duration(gs.Config.Account.ErasureGraceDays)),
+		UpdatedAt:          now,
+	}
+
+	_, err = gs.CustomerRepo.UpdateCustomer(ctx, filter, updateDoc)
+	if err != nil {
+		gs.Log.Errorln(ctx, "RequestErasure", err.Error())
+		return nil, err
+	}
+
+	gs.recordAudit(ctx, username, model.AuditActionErasureRequest, updateDoc.ErasureScheduledAt.Format(utils.ISOLayout))
+
+	return &model.CustomerErasureRes{
+		Username:           username,
+		ErasureRequestedAt: updateDoc.ErasureRequestedAt,
+		ErasureScheduledAt: updateDoc.ErasureScheduledAt,
+	}, nil
+}
+
+// CancelErasure cancels a pending account erasure request
+func (gs *customerService) CancelErasure(ctx context.Context, username string) error {
+	c, err := gs.CustomerRepo.GetCustomer(ctx, &model.Customer{Username: username})
+	if err != nil {
+		if err == infra.ErrNotFound {
+			return rest_error.NewCodedValidationError(rest_error.CodeNotFound, "", infra.ErrNotFound)
+		}
+		return err
+	}
+
+	if c.ErasureScheduledAt.IsZero() {
+		return rest_error.NewCodedValidationError(rest_error.CodeErasureNotPending, i18n.MsgNoPendingErasure, nil)
+	}
+
+	err = gs.CustomerRepo.UnsetCustomerFields(ctx, username, "erasure_requested_at", "erasure_scheduled_at")
+	if err != nil {
+		return err
+	}
+
+	gs.recordAudit(ctx, username, model.AuditActionErasureCancel, "")
+
+	return nil
+}
+
+// PurgeDueErasures purges every customer whose erasure grace period has ended,
+// and returns the number of customers purged
+func (gs *customerService) PurgeDueErasures(ctx context.Context) (int, error) {
+	filter := bson.M{"erasure_scheduled_at": bson.M{"$lte": time.Now().UTC()}}
+	customers, err := gs.CustomerRepo.ListCustomers(ctx, filter, &model.ListOptions{Page: 1, Limit: 100}, repo.IncludeDeleted())
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, c := range customers {
+		err = gs.purgeCustomerData(ctx, c.Username)
+		if err != nil {
+			gs.Log.Errorln(ctx, "PurgeDueErasures", err.Error())
+			continue
+		}
+		purged++
+	}
+
+	return purged, nil
+}
+
+// RunErasureWorker runs PurgeDueErasures every interval until ctx is done
+func (gs *customerService) RunErasureWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := gs.PurgeDueErasures(ctx)
+			if err != nil {
+				gs.Log.Errorln(ctx, "RunErasureWorker", err.Error())
+				continue
+			}
+			if n > 0 {
+				gs.Log.Infoln(ctx, "RunErasureWorker", fmt.Sprintf("purged %d customer(s)", n))
+			}
+		}
+	}
+}
+
+// purgeCustomerData hard-deletes the customer along with their addresses, audit history and cache keys
+func (gs *customerService) purgeCustomerData(ctx context.Context, username string) error {
+	filter := bson.M{"username": username}
+
+	err := gs.AddressRepo.PurgeAddresses(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	err = gs.AuditRepo.PurgeEvents(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	err = gs.CommonRepo.PurgeUserKeys(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	_, err = gs.CustomerRepo.PurgeOne(ctx, filter)
+	if err != nil {
+		gs.Log.Errorln(ctx, "purgeCustomerData", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// recordAudit stores an audit event for username, failures are logged and otherwise ignored
+func (gs *customerService) recordAudit(ctx context.Context, username, action, description string) {
+	gs.saveAudit(ctx, &model.AuditEvent{
+		Username:    username,
+		Action:      action,
+		Actor:       username,
+		Description: description,
+	})
+}
+
+// saveAudit stores event as is, filling in the user type and time
+func (gs *customerService) saveAudit(ctx context.Context, event *model.AuditEvent) {
+	event.UserType = utils.UserTypeCustomer
+	event.CreatedAt = time.Now().UTC()
+
+	err := gs.AuditRepo.AddEvent(ctx, event)
+	if err != nil {
+		gs.Log.Errorln(ctx, "saveAudit", err.Error())
+	}
+}
@@ -5,45 +5,49 @@ import (
 	"fmt"
 	"github.com/iamrz1/ab-auth/config"
 	rest_error "github.com/iamrz1/ab-auth/error"
+	"github.com/iamrz1/ab-auth/i18n"
 	"github.com/iamrz1/ab-auth/infra"
+	"github.com/iamrz1/ab-auth/logger"
 	"github.com/iamrz1/ab-auth/model"
 	"github.com/iamrz1/ab-auth/repo"
 	"github.com/iamrz1/ab-auth/utils"
-	rLog "github.com/iamrz1/rest-log"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"log"
 	"net/http"
 	"strings"
 	"time"
 )
 
 type customerService struct {
-	CommonRepo   *repo.CommonRepo
-	CustomerRepo *repo.CustomerRepo
-	AddressRepo  *repo.AddressRepo
-	Log          rLog.Logger
+	CommonRepo   CommonRepo
+	CustomerRepo CustomerRepo
+	AddressRepo  AddressRepo
+	AuditRepo    AuditRepo
+	Log          logger.Logger
 	Config       *config.AppConfig
+	bdIndex      *bdLocationIndex
 }
 
-func NewCustomerService(cfg *config.AppConfig, cm *repo.CommonRepo, cs *repo.CustomerRepo, ar *repo.AddressRepo, logger rLog.Logger) *customerService {
+func NewCustomerService(cfg *config.AppConfig, cm CommonRepo, cs CustomerRepo, ar AddressRepo, adr AuditRepo, logger logger.Logger) *customerService {
 	return &customerService{
 		CommonRepo:   cm,
 		CustomerRepo: cs,
 		AddressRepo:  ar,
+		AuditRepo:    adr,
 		Log:          logger,
 		Config:       cfg,
+		bdIndex:      &bdLocationIndex{},
 	}
 }
 
 func (gs *customerService) CreateCustomer(ctx context.Context, req *model.CustomerSignupReq) (string, error) {
 	err := utils.ValidatePassword(req.Password)
 	if err != nil {
-		return "", rest_error.NewValidationError("", err)
+		return "", rest_error.NewCodedValidationError(rest_error.CodeWeakPassword, "", err)
 	}
 
 	if !utils.IsValidPhoneNumber(req.Username) {
-		return "", rest_error.NewValidationError("Phone number is not valid", nil)
+		return "", rest_error.NewCodedValidationError(rest_error.CodeInvalidPhone, i18n.MsgInvalidPhone, nil)
 	}
 
 	_, err = gs.GetCustomer(ctx, &model.Customer{Username: req.Username})
@@ -52,44 +56,62 @@ func (gs *customerService) CreateCustomer(ctx context.Context, req *model.Custom
 			return "", err
 		}
 	} else {
-		return "", rest_error.NewValidationError("User already exists", err)
+		return "", rest_error.NewCodedValidationError(rest_error.CodeAccountExists, i18n.MsgUserExists, nil)
 	}
 
-	otp, err := gs.CommonRepo.GetOTP(req.Username, "signup", 5, 24*60*60, 10)
+	otp, err := gs.CommonRepo.GetOTP(ctx, req.Username, "signup", 5, 24*60*60, 10)
 	if err != nil {
-		return "", rest_error.NewGenericError(http.StatusTooManyRequests, err.Error())
+		return "", err
 	}
-	// todo: send otp
 
-	err = gs.CustomerRepo.HoldCustomerRegistrationInCache(otp, req)
+	err = gs.CustomerRepo.HoldCustomerRegistrationInCache(ctx, otp, req)
 	if err != nil {
-		gs.Log.Error("CreateCustomer", "", err.Error())
+		gs.Log.Errorln(ctx, "CreateCustomer", err.Error())
 		return "", err
 	}
 
+	sendOTP(ctx, gs.Log, req.Username, i18n.MsgOTPSignup, otp, time.Minute*6)
+
 	return otp, nil
 }
 
 func (gs *customerService) VerifyCustomerSignUp(ctx context.Context, req *model.CustomerSignupVerificationReq) error {
 	if !utils.IsValidPhoneNumber(req.Username) {
-		return rest_error.NewValidationError("Phone number is not valid", nil)
+		return rest_error.NewCodedValidationError(rest_error.CodeInvalidPhone, i18n.MsgInvalidPhone, nil)
 	}
 
-	ok, err := gs.CommonRepo.LockKey(fmt.Sprintf("%s_%s_otp_match", req.Username, "signup"), 5)
+	ok, err := gs.CommonRepo.LockKey(ctx, fmt.Sprintf("%s_%s_otp_match", req.Username, "signup"), 5)
 	if err != nil || !ok {
-		return fmt.Errorf("%s", "Please try again in a few seconds")
+		return rest_error.NewCodedError(http.StatusConflict, rest_error.CodeConcurrentRequest, i18n.MsgTryAgainShortly)
 	}
 
-	if !gs.CommonRepo.EnsureUsageLimit(fmt.Sprintf("%s_%s_otp_gen_limit", req.Username, "signup"), 5, 5*60) {
-		return rest_error.NewGenericError(http.StatusTooManyRequests, "OTP verification failed")
+	if !gs.CommonRepo.EnsureUsageLimit(ctx, fmt.Sprintf("%s_%s_otp_gen_limit", req.Username, "signup"), 5, 5*60) {
+		return rest_error.NewCodedError(http.StatusTooManyRequests, rest_error.CodeOTPRateLimited, i18n.MsgOTPVerificationFailed)
 	}
 
-	customerData, err := gs.CustomerRepo.GetCustomerRegistrationFromCache(req.Username, req.OTP)
+	customerData, err := gs.CustomerRepo.GetCustomerRegistrationFromCache(ctx, req.Username, req.OTP)
 	if err != nil {
-		gs.Log.Error("VerifyCustomerSignUp", "", err.Error())
+		gs.Log.Errorln(ctx, "VerifyCustomerSignUp", err.Error())
 		return rest_error.NewValidationError("", err)
 	}
 
+	// a soft-deleted customer may register the same number again, the new account starts
+	// fresh and whatever was kept for the deleted one is purged
+	existing, err := gs.CustomerRepo.GetCustomer(ctx, &model.Customer{Username: req.Username}, repo.IncludeDeleted())
+	if err != nil {
+		if err != infra.ErrNotFound {
+			return err
+		}
+	} else {
+		if existing.IsDeleted == nil || !*existing.IsDeleted {
+			return rest_error.NewCodedValidationError(rest_error.CodeAccountExists, i18n.MsgUserExists, nil)
+		}
+		err = gs.purgeCustomerData(ctx, existing.Username)
+		if err != nil {
+			return err
+		}
+	}
+
 	c := &model.Customer{
 		Username:    customerData.Username,
 		FullName:    customerData.FullName,
@@ -103,65 +125,91 @@ func (gs *customerService) VerifyCustomerSignUp(ctx context.Context, req *model.
 	}
 
 	err = gs.CustomerRepo.CreateCustomer(ctx, c)
+	// a concurrent verification of the same sign up created it first
+	if err == infra.ErrDuplicateKey {
+		return rest_error.NewCodedValidationError(rest_error.CodeAccountExists, i18n.MsgUserExists, nil)
+	}
 	if err != nil {
-		gs.Log.Error("VerifyCustomerSignUp", "", err.Error())
+		gs.Log.Errorln(ctx, "VerifyCustomerSignUp", err.Error())
 		return err
 	}
 
+	gs.recordAudit(ctx, c.Username, model.AuditActionSignup, "")
+
 	return nil
 }
 
 func (gs *customerService) Login(ctx context.Context, req *model.LoginReq) (*model.Token, error) {
-	incorrectMsg := "Incorrect username or password"
+	incorrectMsg := i18n.MsgIncorrectCredentials
 
-	ok, err := gs.CommonRepo.LockKey(fmt.Sprintf("%s_%s_password_match", req.Username, "login"), 5)
+	ok, err := gs.CommonRepo.LockKey(ctx, fmt.Sprintf("%s_%s_password_match", req.Username, "login"), 5)
 	if err != nil || !ok {
-		return nil, fmt.Errorf("%s", "Please try again in a few seconds")
+		return nil, rest_error.NewCodedError(http.StatusConflict, rest_error.CodeConcurrentRequest, i18n.MsgTryAgainShortly)
 	}
 
-	if !gs.CommonRepo.EnsureUsageLimit(fmt.Sprintf("%s_%s_password_match_limit", req.Username, "login"), 5, 5*60) {
+	if !gs.CommonRepo.EnsureUsageLimit(ctx, fmt.Sprintf("%s_%s_password_match_limit", req.Username, "login"), 5, 5*60) {
 		// max 5 try in 5 minutes
-		return nil, rest_error.NewGenericError(http.StatusTooManyRequests, "Please try again later")
+		return nil, rest_error.NewCodedError(http.StatusTooManyRequests, rest_error.CodeRateLimited, i18n.MsgTryAgainLater)
 	}
 
 	g, err := gs.CustomerRepo.GetCustomer(ctx, model.Customer{Username: req.Username})
 	if err != nil {
-		gs.Log.Error("login", "", err.Error())
-		return nil, rest_error.NewValidationError(incorrectMsg, nil)
+		gs.Log.Errorln(ctx, "login", err.Error())
+		return nil, rest_error.NewCodedValidationError(rest_error.CodeAuthInvalidCredentials, incorrectMsg, nil)
 	}
 
 	if !utils.VerifyPassword(req.Password, g.Password) {
-		gs.Log.Error("login", "", "password mismatch")
-		return nil, rest_error.NewValidationError(incorrectMsg, nil)
+		gs.Log.Errorln(ctx, "login", "password mismatch")
+		return nil, rest_error.NewCodedValidationError(rest_error.CodeAuthInvalidCredentials, incorrectMsg, nil)
+	}
+
+	err = utils.ValidateAccountStatus(g.Status, false)
+	if err != nil {
+		return nil, err
 	}
 
-	utils.SetLastResetAt(g.Username, g.LastResetAt.Unix())
+	utils.SetLastResetAt(ctx, g.Username, g.LastResetAt.Unix())
 
 	access, refresh := utils.GenerateTokens(g.Username, "", "customer")
 
+	gs.recordAudit(ctx, g.Username, model.AuditActionLogin, "")
+
 	return &model.Token{AccessToken: access, RefreshToken: refresh}, nil
 }
 
 func (gs *customerService) GetShortProfile(ctx context.Context, req *model.Token) (*model.CustomerShort, error) {
-	claims, err := utils.VerifyToken(req.AccessToken, false)
+	claims, err := utils.VerifyFreshToken(ctx, req.AccessToken, false)
 	if err != nil {
-		gs.Log.Error("GetShortProfile", "", err.Error())
-		return nil, rest_error.NewGenericError(http.StatusUnauthorized, err.Error())
+		gs.Log.Errorln(ctx, "GetShortProfile", err.Error())
+		return nil, err
 	}
 
 	g, err := gs.CustomerRepo.GetCustomer(ctx, model.Customer{Username: claims.Username})
 	if err != nil {
-		gs.Log.Error("GetShortProfile", "", err.Error())
-		return nil, rest_error.NewGenericError(http.StatusUnauthorized, "Invalid user")
+		gs.Log.Errorln(ctx, "GetShortProfile", err.Error())
+		return nil, rest_error.NewGenericError(http.StatusUnauthorized, i18n.MsgInvalidUser)
 	}
 
-	if claims.IssuedAt < g.LastResetAt.Unix() {
-		log.Println("claims.IssuedAt:", claims.IssuedAt, "g.BirthDate.Unix():", g.LastResetAt.Unix())
-		return nil, rest_error.NewGenericError(http.StatusUnauthorized, "Inert token")
+	err = utils.ValidateAccountStatus(g.Status, false)
+	if err != nil {
+		return nil, err
 	}
 
 	if claims.UserType != "customer" {
-		return nil, rest_error.NewGenericError(http.StatusUnauthorized, "Restricted to customers")
+		return nil, rest_error.NewCodedError(http.StatusUnauthorized, rest_error.CodeAuthForbidden, i18n.MsgCustomersOnly)
+	}
+
+	return g.ToShortResponse(), nil
+}
+
+// GetShortProfileByUsername returns the short profile of customer username, for other services
+func (gs *customerService) GetShortProfileByUsername(ctx context.Context, username string) (*model.CustomerShort, error) {
+	g, err := gs.CustomerRepo.GetCustomer(ctx, model.Customer{Username: username})
+	if err != nil {
+		if err == infra.ErrNotFound {
+			return nil, rest_error.NewCodedError(http.StatusNotFound, rest_error.CodeNotFound, i18n.MsgCustomerNotFound)
+		}
+		return nil, err
 	}
 
 	return g.ToShortResponse(), nil
@@ -176,36 +224,37 @@ func (gs *customerService) GetCustomer(ctx context.Context, req *model.Customer)
 	return g.ToResponse(), nil
 }
 
-func (gs *customerService) ListCustomers(ctx context.Context, req *model.CustomerListReq) ([]*model.Customer, int64, error) {
+// ListCustomers lists the page of customers req asks for, newest first. The count is only
+// taken for page/limit requests, as counting is what makes large lists slow.
+func (gs *customerService) ListCustomers(ctx context.Context, req *model.CustomerListReq) ([]*model.Customer, int64, *infra.PageInfo, error) {
 	selector := &bson.D{}
 
 	if req.Search != "" {
 		selector = utils.AppendSearchPattern(selector, "string_field", req.Search, true)
 	}
 
-	opts := &model.ListOptions{
-		Page:  req.Page,
-		Limit: req.Limit,
-		Sort:  nil,
+	q := pageQuery(req.Page, req.Limit, req.Cursor, "_id", true)
+	Customers, info, err := gs.CustomerRepo.ListCustomersPage(ctx, selector, q)
+	if err != nil {
+		gs.Log.Errorln(ctx, "ListCustomers", err.Error())
+		return nil, 0, nil, pageError(err)
 	}
 
-	Customers, err := gs.CustomerRepo.ListCustomers(ctx, selector, opts)
-	if err != nil {
-		gs.Log.Error("ListCustomers", "", err.Error())
-		return nil, 0, err
+	for i, g := range Customers {
+		Customers[i] = g.ToResponse()
 	}
 
-	for _, g := range Customers {
-		g = g.ToResponse()
+	if req.Cursor != "" {
+		return Customers, 0, info, nil
 	}
 
 	count, err := gs.CustomerRepo.CountCustomer(ctx, selector)
 	if err != nil {
-		gs.Log.Error("CountCustomer", "", err.Error())
-		return nil, 0, err
+		gs.Log.Errorln(ctx, "CountCustomer", err.Error())
+		return nil, 0, nil, err
 	}
 
-	return Customers, count, nil
+	return Customers, count, info, nil
 }
 
 func (gs *customerService) UpdateCustomer(ctx context.Context, req *model.CustomerProfileUpdateReq) (*model.Customer, error) {
@@ -213,13 +262,13 @@ func (gs *customerService) UpdateCustomer(ctx context.Context, req *model.Custom
 	_, err := gs.CustomerRepo.GetCustomer(ctx, filter)
 	if err != nil {
 		if err == infra.ErrNotFound {
-			return nil, rest_error.NewValidationError("", infra.ErrNotFound)
+			return nil, rest_error.NewCodedValidationError(rest_error.CodeNotFound, "", infra.ErrNotFound)
 		}
 		return nil, err
 	}
 	gender := strings.ToLower(strings.TrimSpace(req.Gender))
 	if gender != "" && !utils.IsGenderValid(gender) {
-		return nil, rest_error.NewValidationError("Invalid gender", nil)
+		return nil, rest_error.NewValidationError(i18n.MsgInvalidGender, nil)
 	}
 
 	updateDoc := &model.Customer{
@@ -235,10 +284,12 @@ func (gs *customerService) UpdateCustomer(ctx context.Context, req *model.Custom
 
 	_, err = gs.CustomerRepo.UpdateCustomer(ctx, filter, updateDoc)
 	if err != nil {
-		gs.Log.Error("updateCustomerProfile", "", err.Error())
+		gs.Log.Errorln(ctx, "updateCustomerProfile", err.Error())
 		return nil, err
 	}
 
+	gs.recordAudit(ctx, req.Username, model.AuditActionProfileUpdate, "")
+
 	g, err := gs.CustomerRepo.GetCustomer(ctx, filter)
 	if err != nil {
 		return nil, err
@@ -252,24 +303,24 @@ func (gs *customerService) UpdatePassword(ctx context.Context, req *model.Update
 	c, err := gs.CustomerRepo.GetCustomer(ctx, filter)
 	if err != nil {
 		if err == infra.ErrNotFound {
-			return nil, rest_error.NewValidationError("", infra.ErrNotFound)
+			return nil, rest_error.NewCodedValidationError(rest_error.CodeNotFound, "", infra.ErrNotFound)
 		}
 		return nil, err
 	}
 
-	ok, err := gs.CommonRepo.LockKey(fmt.Sprintf("%s_%s_password_match", req.Username, "update"), 5)
+	ok, err := gs.CommonRepo.LockKey(ctx, fmt.Sprintf("%s_%s_password_match", req.Username, "update"), 5)
 	if err != nil || !ok {
-		return nil, fmt.Errorf("%s", "Please try again in a few seconds")
+		return nil, rest_error.NewCodedError(http.StatusConflict, rest_error.CodeConcurrentRequest, i18n.MsgTryAgainShortly)
 	}
 
-	if !gs.CommonRepo.EnsureUsageLimit(fmt.Sprintf("%s_%s_password_match_limit", req.Username, "update"), 5, 5*60) {
+	if !gs.CommonRepo.EnsureUsageLimit(ctx, fmt.Sprintf("%s_%s_password_match_limit", req.Username, "update"), 5, 5*60) {
 		// max 5 try in 5 minutes
-		return nil, rest_error.NewGenericError(http.StatusTooManyRequests, "Please try again later")
+		return nil, rest_error.NewCodedError(http.StatusTooManyRequests, rest_error.CodeRateLimited, i18n.MsgTryAgainLater)
 	}
 
 	if !utils.VerifyPassword(req.CurrentPassword, c.Password) {
-		gs.Log.Error("updatePassword", "", "password mismatch")
-		return nil, rest_error.NewValidationError("Incorrect password", nil)
+		gs.Log.Errorln(ctx, "updatePassword", "password mismatch")
+		return nil, rest_error.NewCodedValidationError(rest_error.CodeAuthInvalidCredentials, i18n.MsgIncorrectPassword, nil)
 	}
 
 	updateDoc := &model.Customer{
@@ -279,11 +330,13 @@ func (gs *customerService) UpdatePassword(ctx context.Context, req *model.Update
 
 	_, err = gs.CustomerRepo.UpdateCustomer(ctx, filter, updateDoc)
 	if err != nil {
-		gs.Log.Error("updateCustomerProfile", "", err.Error())
+		gs.Log.Errorln(ctx, "updateCustomerProfile", err.Error())
 		return nil, err
 	}
 
-	utils.SetLastResetAt(req.Username, updateDoc.LastResetAt.Unix())
+	utils.SetLastResetAt(ctx, req.Username, updateDoc.LastResetAt.Unix())
+
+	gs.recordAudit(ctx, req.Username, model.AuditActionPasswordUpdate, "")
 
 	return c.ToResponse(), nil
 }
@@ -293,45 +346,142 @@ func (gs *customerService) DeleteCustomer(ctx context.Context, delete *model.Cus
 	_, err := gs.CustomerRepo.GetCustomer(ctx, filter)
 	if err != nil {
 		if err == infra.ErrNotFound {
-			return nil, rest_error.NewValidationError("", infra.ErrNotFound)
+			return nil, rest_error.NewCodedValidationError(rest_error.CodeNotFound, "", infra.ErrNotFound)
+		}
+		return nil, err
+	}
+
+	updateDoc := &model.Customer{
+		IsDeleted:   utils.BoolP(true),
+		LastResetAt: time.Now().UTC(),
+		UpdatedAt:   time.Now().UTC(),
+	}
+
+	_, err = gs.CustomerRepo.UpdateCustomer(ctx, filter, updateDoc)
+	if err != nil {
+		gs.Log.Errorln(ctx, "updateCustomerProfile", err.Error())
+		return nil, err
+	}
+
+	// revoke every session of the deleted customer
+	utils.SetLastResetAt(ctx, delete.Username, updateDoc.LastResetAt.Unix())
+	gs.recordAudit(ctx, delete.Username, model.AuditActionDelete, "")
+
+	g, err := gs.CustomerRepo.GetCustomer(ctx, filter, repo.IncludeDeleted())
+	if err != nil {
+		if err == infra.ErrNotFound {
+			return nil, rest_error.NewCodedValidationError(rest_error.CodeNotFound, "", infra.ErrNotFound)
+		}
+		return nil, err
+	}
+
+	return g.ToResponse(), nil
+}
+
+// UpdateCustomerStatus moves a customer to a new status, recording why and by whom.
+// Blocking or deactivating an account revokes all of its sessions right away.
+func (gs *customerService) UpdateCustomerStatus(ctx context.Context, req *model.AccountStatusUpdateReq) (*model.Customer, error) {
+	if !utils.IsValidAccountStatus(req.Status) {
+		return nil, rest_error.NewCodedValidationError(rest_error.CodeInvalidStatus, i18n.MsgInvalidStatus, nil)
+	}
+
+	filter := &model.Customer{Username: req.Username}
+	c, err := gs.CustomerRepo.GetCustomer(ctx, filter)
+	if err != nil {
+		if err == infra.ErrNotFound {
+			return nil, rest_error.NewCodedValidationError(rest_error.CodeNotFound, "", infra.ErrNotFound)
 		}
 		return nil, err
 	}
 
+	if c.Status == req.Status {
+		return c.ToResponse(), nil
+	}
+
+	now := time.Now().UTC()
 	updateDoc := &model.Customer{
-		IsDeleted: utils.BoolP(true),
+		Status:          req.Status,
+		StatusReason:    req.Reason,
+		StatusChangedBy: req.Actor,
+		StatusChangedAt: now,
+		UpdatedAt:       now,
+	}
+
+	revoke := req.Status == utils.StatusBlocked || req.Status == utils.StatusInactive
+	if revoke {
+		updateDoc.LastResetAt = now
 	}
 
 	_, err = gs.CustomerRepo.UpdateCustomer(ctx, filter, updateDoc)
 	if err != nil {
-		gs.Log.Error("updateCustomerProfile", "", err.Error())
+		gs.Log.Errorln(ctx, "UpdateCustomerStatus", err.Error())
 		return nil, err
 	}
 
+	if revoke {
+		utils.SetLastResetAt(ctx, req.Username, now.Unix())
+	}
+
+	gs.saveAudit(ctx, &model.AuditEvent{
+		Username:    req.Username,
+		Action:      model.AuditActionStatusChange,
+		Actor:       req.Actor,
+		Description: fmt.Sprintf("%s -> %s", c.Status, req.Status),
+		Reason:      req.Reason,
+	})
+
 	g, err := gs.CustomerRepo.GetCustomer(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.ToResponse(), nil
+}
+
+// RestoreCustomer undoes a soft delete
+func (gs *customerService) RestoreCustomer(ctx context.Context, req *model.CustomerDeleteReq) (*model.Customer, error) {
+	filter := &model.Customer{Username: req.Username}
+	c, err := gs.CustomerRepo.GetCustomer(ctx, filter, repo.IncludeDeleted())
 	if err != nil {
 		if err == infra.ErrNotFound {
-			return nil, rest_error.NewValidationError("", infra.ErrNotFound)
+			return nil, rest_error.NewCodedValidationError(rest_error.CodeNotFound, "", infra.ErrNotFound)
 		}
 		return nil, err
 	}
+	if c.IsDeleted == nil || !*c.IsDeleted {
+		return nil, rest_error.NewCodedValidationError(rest_error.CodeNotFound, i18n.MsgNoDeletedCustomer, nil)
+	}
+
+	n, err := gs.CustomerRepo.RestoreCustomer(ctx, req.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	if n == 0 {
+		return nil, rest_error.NewCodedValidationError(rest_error.CodeNotFound, i18n.MsgNoDeletedCustomer, nil)
+	}
+	gs.recordAudit(ctx, req.Username, model.AuditActionRestore, "")
+
+	g, err := gs.CustomerRepo.GetCustomer(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
 
 	return g.ToResponse(), nil
 }
 
 func (gs *customerService) PurgeCustomer(ctx context.Context, delete *model.CustomerDeleteReq) (*model.Customer, error) {
 	filter := model.Customer{Username: delete.Username}
-	g, err := gs.CustomerRepo.GetCustomer(ctx, filter)
+	g, err := gs.CustomerRepo.GetCustomer(ctx, filter, repo.IncludeDeleted())
 	if err != nil {
 		if err == infra.ErrNotFound {
-			return nil, rest_error.NewValidationError("", infra.ErrNotFound)
+			return nil, rest_error.NewCodedValidationError(rest_error.CodeNotFound, "", infra.ErrNotFound)
 		}
 		return nil, err
 	}
 
-	_, err = gs.CustomerRepo.PurgeOne(ctx, filter)
+	err = gs.purgeCustomerData(ctx, g.Username)
 	if err != nil {
-		gs.Log.Error("PurgeOne", "", err.Error())
 		return nil, err
 	}
 
@@ -340,7 +490,7 @@ func (gs *customerService) PurgeCustomer(ctx context.Context, delete *model.Cust
 
 func (gs *customerService) ForgotPassword(ctx context.Context, req *model.ForgotPasswordReq) (string, error) {
 	if !utils.IsValidPhoneNumber(req.Username) {
-		return "", rest_error.NewValidationError("Phone number is not valid", nil)
+		return "", rest_error.NewCodedValidationError(rest_error.CodeInvalidPhone, i18n.MsgInvalidPhone, nil)
 	}
 
 	_, err := gs.GetCustomer(ctx, &model.Customer{Username: req.Username})
@@ -351,36 +501,36 @@ func (gs *customerService) ForgotPassword(ctx context.Context, req *model.Forgot
 		return "", nil // lets just pretend that the user exists and throw off random api calls
 	}
 
-	otp, err := gs.CommonRepo.GetOTP(req.Username, "forgot", 2, 12*60*60, 10)
+	otp, err := gs.CommonRepo.GetOTP(ctx, req.Username, "forgot", 2, 12*60*60, 10)
 	if err != nil {
-		return "", rest_error.NewGenericError(http.StatusTooManyRequests, err.Error())
+		return "", err
 	}
 
-	err = gs.CommonRepo.SetOTP(req.Username, "forgot", otp, 5*60)
+	err = gs.CommonRepo.SetOTP(ctx, req.Username, "forgot", otp, 5*60)
 	if err != nil {
 		return "", rest_error.NewValidationError("", err)
 	}
-	// todo: send otp
+	sendOTP(ctx, gs.Log, req.Username, i18n.MsgOTPForgotPassword, otp, time.Minute*5)
 
 	return otp, nil
 }
 
 func (gs *customerService) SetPassword(ctx context.Context, req *model.SetPasswordReq) error {
 	if !utils.IsValidPhoneNumber(req.Username) {
-		return rest_error.NewValidationError("Phone number is not valid", nil)
+		return rest_error.NewCodedValidationError(rest_error.CodeInvalidPhone, i18n.MsgInvalidPhone, nil)
 	}
 
-	ok, err := gs.CommonRepo.LockKey(fmt.Sprintf("%s_%s_otp_match", req.Username, "forgot"), 5)
+	ok, err := gs.CommonRepo.LockKey(ctx, fmt.Sprintf("%s_%s_otp_match", req.Username, "forgot"), 5)
 	if err != nil || !ok {
-		return fmt.Errorf("%s", "Please try again in a few seconds")
+		return rest_error.NewCodedError(http.StatusConflict, rest_error.CodeConcurrentRequest, i18n.MsgTryAgainShortly)
 	}
 
-	if !gs.CommonRepo.EnsureUsageLimit(fmt.Sprintf("%s_%s_otp_match_limit", req.Username, "forgot"), 5, 5*60) {
+	if !gs.CommonRepo.EnsureUsageLimit(ctx, fmt.Sprintf("%s_%s_otp_match_limit", req.Username, "forgot"), 5, 5*60) {
 		// max 5 try in 5 minutes
-		return rest_error.NewGenericError(http.StatusTooManyRequests, "OTP verification failed")
+		return rest_error.NewCodedError(http.StatusTooManyRequests, rest_error.CodeOTPRateLimited, i18n.MsgOTPVerificationFailed)
 	}
 
-	err = gs.CommonRepo.MatchOTP(req.Username, "forgot", req.OTP)
+	err = gs.CommonRepo.MatchOTP(ctx, req.Username, "forgot", req.OTP)
 	if err != nil {
 		return rest_error.NewValidationError("", err)
 	}
@@ -394,31 +544,33 @@ func (gs *customerService) SetPassword(ctx context.Context, req *model.SetPasswo
 
 	_, err = gs.CustomerRepo.UpdateCustomer(ctx, filter, updateDoc)
 	if err != nil {
-		gs.Log.Error("updateCustomerProfile", "", err.Error())
+		gs.Log.Errorln(ctx, "updateCustomerProfile", err.Error())
 		return err
 	}
 
-	utils.SetLastResetAt(req.Username, updateDoc.LastResetAt.Unix())
+	utils.SetLastResetAt(ctx, req.Username, updateDoc.LastResetAt.Unix())
+
+	gs.recordAudit(ctx, req.Username, model.AuditActionPasswordReset, "")
 
 	return nil
 }
 
 func (gs *customerService) ChangePassword(ctx context.Context, req *model.SetPasswordReq) error {
 	if !utils.IsValidPhoneNumber(req.Username) {
-		return rest_error.NewValidationError("Phone number is not valid", nil)
+		return rest_error.NewCodedValidationError(rest_error.CodeInvalidPhone, i18n.MsgInvalidPhone, nil)
 	}
 
-	ok, err := gs.CommonRepo.LockKey(fmt.Sprintf("%s_%s_otp_match", req.Username, "forgot"), 5)
+	ok, err := gs.CommonRepo.LockKey(ctx, fmt.Sprintf("%s_%s_otp_match", req.Username, "forgot"), 5)
 	if err != nil || !ok {
-		return fmt.Errorf("%s", "Please try again in a few seconds")
+		return rest_error.NewCodedError(http.StatusConflict, rest_error.CodeConcurrentRequest, i18n.MsgTryAgainShortly)
 	}
 
-	if !gs.CommonRepo.EnsureUsageLimit(fmt.Sprintf("%s_%s_otp_match_limit", req.Username, "forgot"), 5, 5*60) {
+	if !gs.CommonRepo.EnsureUsageLimit(ctx, fmt.Sprintf("%s_%s_otp_match_limit", req.Username, "forgot"), 5, 5*60) {
 		// max 5 try in 5 minutes
-		return rest_error.NewGenericError(http.StatusTooManyRequests, "Please try again later")
+		return rest_error.NewCodedError(http.StatusTooManyRequests, rest_error.CodeRateLimited, i18n.MsgTryAgainLater)
 	}
 
-	err = gs.CommonRepo.MatchOTP(req.Username, "forgot", req.OTP)
+	err = gs.CommonRepo.MatchOTP(ctx, req.Username, "forgot", req.OTP)
 	if err != nil {
 		return rest_error.NewValidationError("", err)
 	}
@@ -432,11 +584,11 @@ func (gs *customerService) ChangePassword(ctx context.Context, req *model.SetPas
 
 	_, err = gs.CustomerRepo.UpdateCustomer(ctx, filter, updateDoc)
 	if err != nil {
-		gs.Log.Error("updateCustomerProfile", "", err.Error())
+		gs.Log.Errorln(ctx, "updateCustomerProfile", err.Error())
 		return err
 	}
 
-	utils.SetLastResetAt(req.Username, updateDoc.LastResetAt.Unix())
+	utils.SetLastResetAt(ctx, req.Username, updateDoc.LastResetAt.Unix())
 
 	return nil
 }
@@ -449,20 +601,29 @@ func (gs *customerService) AddAddress(ctx context.Context, req *model.Address) (
 		return nil, err
 	}
 	if n >= utils.MaxAddressAllowed {
-		return nil, rest_error.NewValidationError(fmt.Sprintf("Maximum %d addresses are allowed", utils.MaxAddressAllowed), nil)
+		return nil, rest_error.NewCodedValidationError(rest_error.CodeAddressLimitReached, i18n.MsgAddressLimitReached, nil).WithArgs(utils.MaxAddressAllowed)
 	}
 
-	if n == 0 {
-		req.IsPrimary = utils.BoolP(true)
-	} else {
-		req.IsPrimary = utils.BoolP(false)
+	err = gs.resolveAddressLocation(ctx, req)
+	if err != nil {
+		return nil, err
 	}
+	req.Location = addressGeoPoint(req)
 
+	// the first address becomes primary. If another request added one meanwhile, the
+	// primary index rejects this one and it is added as a secondary address instead.
+	req.IsPrimary = utils.BoolP(n == 0)
 	err = gs.AddressRepo.AddAddress(ctx, req)
+	if err == infra.ErrDuplicateKey && *req.IsPrimary {
+		req.IsPrimary = utils.BoolP(false)
+		err = gs.AddressRepo.AddAddress(ctx, req)
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	gs.recordAudit(ctx, req.Username, model.AuditActionAddressAdd, "")
+
 	list, err := gs.AddressRepo.GetAddresses(ctx, filter, nil)
 	if err != nil {
 		return nil, err
@@ -474,19 +635,50 @@ func (gs *customerService) AddAddress(ctx context.Context, req *model.Address) (
 func (gs *customerService) UpdateAddress(ctx context.Context, req *model.Address) ([]*model.Address, error) {
 	objID, err := primitive.ObjectIDFromHex(req.ID)
 	if err != nil {
-		return nil, rest_error.NewValidationError("Invalid address ID", nil)
+		return nil, rest_error.NewCodedValidationError(rest_error.CodeInvalidID, i18n.MsgInvalidAddressID, nil)
 	}
 	req.ID = ""
 	filter := bson.M{"_id": objID, "username": req.Username}
+
+	cleared := make([]string, 0)
+	if hasLocation(req) {
+		current, err := gs.AddressRepo.GetAddress(ctx, filter)
+		if err != nil {
+			if err == infra.ErrNotFound {
+				return nil, rest_error.NewCodedValidationError(rest_error.CodeAddressNotFound, i18n.MsgAddressNotFound, nil)
+			}
+			return nil, err
+		}
+
+		// the changed levels are checked together with the ones already stored
+		merged := mergeAddressLocation(current, req)
+		err = gs.resolveAddressLocation(ctx, merged)
+		if err != nil {
+			return nil, err
+		}
+		copyAddressLocation(req, merged)
+		req.Location = addressGeoPoint(req)
+		cleared = clearedAddressFields(current, merged)
+	}
+
 	n, err := gs.AddressRepo.UpdateAddress(ctx, filter, req)
 	if err != nil {
 		return nil, err
 	}
 
 	if n == 0 {
-		return nil, rest_error.NewValidationError("Nothing to update", nil)
+		return nil, rest_error.NewCodedValidationError(rest_error.CodeNothingToUpdate, i18n.MsgNothingToUpdate, nil)
 	}
 
+	if len(cleared) > 0 {
+		err = gs.AddressRepo.UnsetAddressFields(ctx, objID, cleared...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	gs.recordAudit(ctx, req.Username, model.AuditActionAddressUpdate, objID.Hex())
+
 	getFilter := model.Address{Username: req.Username, IsDeleted: utils.BoolP(false)}
 	list, err := gs.AddressRepo.GetAddresses(ctx, getFilter, nil)
 	if err != nil {
@@ -499,19 +691,47 @@ func (gs *customerService) UpdateAddress(ctx context.Context, req *model.Address
 func (gs *customerService) RemoveAddress(ctx context.Context, req *model.Address) ([]*model.Address, error) {
 	objID, err := primitive.ObjectIDFromHex(req.ID)
 	if err != nil {
-		return nil, rest_error.NewValidationError("Invalid address ID", nil)
+		return nil, rest_error.NewCodedValidationError(rest_error.CodeInvalidID, i18n.MsgInvalidAddressID, nil)
 	}
-	filter := bson.E{Key: "_id", Value: objID}
+	filter := bson.M{"_id": objID, "username": req.Username}
+
+	err = gs.AddressRepo.InTransaction(ctx, func(ctx context.Context) error {
+		current, err := gs.AddressRepo.GetAddress(ctx, filter)
+		if err != nil {
+			if err == infra.ErrNotFound {
+				return rest_error.NewCodedValidationError(rest_error.CodeAddressNotFound, i18n.MsgAddressNotFound, nil)
+			}
+			return err
+		}
 
-	doc := &model.Address{IsDeleted: utils.BoolP(true)}
-	n, err := gs.AddressRepo.UpdateAddress(ctx, filter, doc)
+		doc := &model.Address{IsDeleted: utils.BoolP(true), IsPrimary: utils.BoolP(false)}
+		_, err = gs.AddressRepo.UpdateAddress(ctx, filter, doc)
+		if err != nil {
+			return err
+		}
+
+		if current.IsPrimary == nil || !*current.IsPrimary {
+			return nil
+		}
+
+		// the most recently added of the remaining addresses takes over as primary
+		rest, err := gs.AddressRepo.GetAddresses(ctx, model.Address{Username: req.Username, IsDeleted: utils.BoolP(false)}, &model.ListOptions{Page: 1, Limit: 1})
+		if err != nil || len(rest) == 0 {
+			return err
+		}
+
+		nextID, err := primitive.ObjectIDFromHex(rest[0].ID)
+		if err != nil {
+			return err
+		}
+		_, err = gs.AddressRepo.UpdateAddress(ctx, bson.M{"_id": nextID}, &model.Address{IsPrimary: utils.BoolP(true)})
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if n == 0 {
-		return nil, rest_error.NewValidationError("Address not found", nil)
-	}
+	gs.recordAudit(ctx, req.Username, model.AuditActionAddressRemove, objID.Hex())
 
 	getFilter := model.Address{Username: req.Username, IsDeleted: utils.BoolP(false)}
 	list, err := gs.AddressRepo.GetAddresses(ctx, getFilter, nil)
@@ -537,7 +757,7 @@ func (gs *customerService) GetPrimaryAddress(ctx context.Context, username strin
 	address, err := gs.AddressRepo.GetAddress(ctx, getFilter)
 	if err != nil {
 		if err == infra.ErrNotFound {
-			return nil, rest_error.NewGenericError(http.StatusExpectationFailed, "No default address")
+			return nil, rest_error.NewCodedError(http.StatusExpectationFailed, rest_error.CodeAddressNoPrimary, i18n.MsgNoDefaultAddress)
 		}
 		return nil, err
 	}
@@ -545,78 +765,66 @@ func (gs *customerService) GetPrimaryAddress(ctx context.Context, username strin
 	return address, nil
 }
 
+// SetPrimaryAddress makes the address req.ID the primary address of req.Username. The old
+// primary is demoted in the same transaction, so there is never zero or two primaries.
 func (gs *customerService) SetPrimaryAddress(ctx context.Context, req *model.Address) ([]*model.Address, error) {
-	getFilter := model.Address{Username: req.Username, IsDeleted: utils.BoolP(false)}
-	list, err := gs.AddressRepo.GetAddresses(ctx, getFilter, nil)
+	objID, err := primitive.ObjectIDFromHex(req.ID)
 	if err != nil {
-		return nil, err
+		return nil, rest_error.NewCodedValidationError(rest_error.CodeInvalidID, i18n.MsgInvalidAddressID, nil)
 	}
+	filter := bson.M{"_id": objID, "username": req.Username}
 
-	found := false
-	isAlreadyPrimary := false
-	oldPrimaryID := ""
-	for _, address := range list {
-		if address.ID == req.ID {
-			found = true
-			if address.IsPrimary != nil && (*(address).IsPrimary) {
-				isAlreadyPrimary = true
-			}
-			address.IsPrimary = utils.BoolP(true)
-		} else {
-			if address.IsPrimary != nil && (*(address).IsPrimary) {
-				oldPrimaryID = address.ID
-				address.IsPrimary = utils.BoolP(false)
+	err = gs.AddressRepo.InTransaction(ctx, func(ctx context.Context) error {
+		address, err := gs.AddressRepo.GetAddress(ctx, filter)
+		if err != nil {
+			if err == infra.ErrNotFound {
+				return rest_error.NewCodedValidationError(rest_error.CodeAddressNotFound, i18n.MsgUnknownAddressID, nil)
 			}
+			return err
 		}
-	}
 
-	if !found {
-		return nil, rest_error.NewValidationError("Unknown address ID", nil)
-	}
-
-	if isAlreadyPrimary {
-		return list, nil
-	}
+		if address.IsPrimary != nil && *address.IsPrimary {
+			return nil
+		}
 
-	log.Println("incoming primary:", req.ID)
-	log.Println("current primary:", oldPrimaryID)
+		// demote first, the primary index allows one primary at any time
+		err = gs.AddressRepo.DemotePrimaryAddresses(ctx, req.Username)
+		if err != nil {
+			return err
+		}
 
-	objID, err := primitive.ObjectIDFromHex(req.ID)
-	if err != nil {
-		return nil, rest_error.NewValidationError("Invalid address ID", nil)
-	}
-	filter := bson.M{"_id": objID}
-	var count int64
-	count, err = gs.AddressRepo.UpdateAddress(ctx, filter, &model.Address{IsPrimary: utils.BoolP(true)})
+		_, err = gs.AddressRepo.UpdateAddress(ctx, filter, &model.Address{IsPrimary: utils.BoolP(true)})
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	log.Println("cont1:", count)
-
-	if oldPrimaryID != "" {
-		objID, err = primitive.ObjectIDFromHex(oldPrimaryID)
-		filter = bson.M{"_id": objID}
-		count, err = gs.AddressRepo.UpdateAddress(ctx, filter, &model.Address{IsPrimary: utils.BoolP(false)})
-		if err != nil {
-			return nil, err
-		}
-		log.Println("cont1:", count)
+	getFilter := model.Address{Username: req.Username, IsDeleted: utils.BoolP(false)}
+	list, err := gs.AddressRepo.GetAddresses(ctx, getFilter, nil)
+	if err != nil {
+		return nil, err
 	}
 
 	return list, nil
 }
 
-func (gs *customerService) GetBDLocation(ctx context.Context, req *model.BDLocationReq) ([]*model.BDLocation, int64, error) {
-	listOption := model.ListOptions{
-		Page:  req.Page,
-		Limit: req.Limit,
-		Sort:  bson.M{"name": -1},
+// GetBDLocation lists the page of BD location presets req asks for. The count is only
+// taken for page/limit requests.
+func (gs *customerService) GetBDLocation(ctx context.Context, req *model.BDLocationReq) ([]*model.BDLocation, int64, *infra.PageInfo, error) {
+	q := pageQuery(req.Page, req.Limit, req.Cursor, "name", true)
+	list, info, err := gs.AddressRepo.GetBdLocationsPage(ctx, req, q)
+	if err != nil {
+		return nil, 0, nil, pageError(err)
 	}
-	list, count, err := gs.AddressRepo.GetBdLocations(ctx, req, &listOption)
+	if req.Cursor != "" {
+		return list, 0, info, nil
+	}
+
+	count, err := gs.AddressRepo.CountBdLocations(ctx, req)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, nil, err
 	}
 
-	return list, count, nil
+	return list, count, info, nil
 }
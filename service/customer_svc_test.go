@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"github.com/iamrz1/ab-auth/config"
+	rest_error "github.com/iamrz1/ab-auth/error"
+	"github.com/iamrz1/ab-auth/infra"
+	"github.com/iamrz1/ab-auth/infra/cache/cachetest"
+	"github.com/iamrz1/ab-auth/infra/memdb"
+	"github.com/iamrz1/ab-auth/logger"
+	"github.com/iamrz1/ab-auth/migrations"
+	"github.com/iamrz1/ab-auth/model"
+	"github.com/iamrz1/ab-auth/utils"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"io/ioutil"
+	"testing"
+)
+
+// testServices sets the services up on an in-memory db and cache, with the migrations
+// applied
+func testServices(t *testing.T) (*Config, infra.DB) {
+	cfg := config.Defaults()
+	cfg.Environment = utils.EnvDevelopment
+	cfg.Database.CustomerCollection = "customer"
+	cfg.Database.MerchantCollection = "merchant"
+	cfg.Database.AddressCollection = "address"
+
+	lgr := logger.New(ioutil.Discard, logger.Error)
+	db := memdb.New()
+	_, err := migrations.NewMigrator(db, cfg.Database, lgr).Up(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err := cachetest.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(cache.Close)
+
+	return SetupServiceConfig(cfg, db, cache.Redis, lgr), db
+}
+
+// errorCode returns the code of the validation error err, if it is one
+func errorCode(err error) string {
+	if ve, ok := err.(rest_error.ValidationError); ok {
+		return ve.ErrorCode()
+	}
+
+	return ""
+}
+
+func TestRestoreCustomer(t *testing.T) {
+	svc, db := testServices(t)
+	cs := svc.CustomerService
+	ctx := context.Background()
+
+	err := db.Insert(ctx, "customer", &model.Customer{Username: "01700000001", Status: utils.StatusActive, IsDeleted: utils.BoolP(false)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = cs.RestoreCustomer(ctx, &model.CustomerDeleteReq{Username: "01700000001"})
+	assert.Equal(t, rest_error.CodeNotFound, errorCode(err), "a live customer is not restored")
+
+	_, err = cs.DeleteCustomer(ctx, &model.CustomerDeleteReq{Username: "01700000001"})
+	assert.NoError(t, err)
+	_, err = cs.CustomerRepo.GetCustomer(ctx, &model.Customer{Username: "01700000001"})
+	assert.Equal(t, infra.ErrNotFound, err)
+
+	res, err := cs.RestoreCustomer(ctx, &model.CustomerDeleteReq{Username: "01700000001"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "01700000001", res.Username)
+	}
+	_, err = cs.CustomerRepo.GetCustomer(ctx, &model.Customer{Username: "01700000001"})
+	assert.NoError(t, err)
+
+	events := make([]*model.AuditEvent, 0)
+	err = db.List(ctx, cs.Config.Database.AuditCollection, bson.M{"username": "01700000001"}, 1, 0, &events, bson.M{"_id": 1})
+	if assert.NoError(t, err) && assert.Len(t, events, 2) {
+		assert.Equal(t, model.AuditActionDelete, events[0].Action)
+		assert.Equal(t, model.AuditActionRestore, events[1].Action)
+	}
+
+	_, err = cs.RestoreCustomer(ctx, &model.CustomerDeleteReq{Username: "01700000002"})
+	assert.Equal(t, rest_error.CodeNotFound, errorCode(err))
+}
+
+func TestUpdateAddress_ClearsAreasOfOldDistrict(t *testing.T) {
+	svc, db := testServices(t)
+	cs := svc.CustomerService
+	ctx := context.Background()
+
+	for _, l := range []*model.BDLocation{
+		{ID: 1, Name: "Dhaka", Slug: "dhaka", Type: model.LocationTypeDivision},
+		{ID: 2, Name: "Dhaka", Slug: "dhaka-dhaka", Parent: "dhaka", Type: model.LocationTypeDistrict},
+		{ID: 3, Name: "Gazipur", Slug: "dhaka-gazipur", Parent: "dhaka", Type: model.LocationTypeDistrict},
+		{ID: 4, Name: "Savar", Slug: "dhaka-dhaka-savar", Parent: "dhaka-dhaka", Type: model.LocationTypeSubDistrict},
+	} {
+		if err := db.Insert(ctx, "address_preset", l); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list, err := cs.AddAddress(ctx, &model.Address{
+		Username:        "01700000001",
+		FullName:        "Test Customer",
+		DivisionSlug:    "dhaka",
+		DistrictSlug:    "dhaka-dhaka",
+		SubDistrictSlug: "dhaka-dhaka-savar",
+		IsDeleted:       utils.BoolP(false),
+	})
+	if !assert.NoError(t, err) || !assert.Len(t, list, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, "Savar", list[0].SubDistrict)
+
+	list, err = cs.UpdateAddress(ctx, &model.Address{ID: list[0].ID, Username: "01700000001", DistrictSlug: "dhaka-gazipur"})
+	if !assert.NoError(t, err) || !assert.Len(t, list, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, "Gazipur", list[0].District)
+	assert.Empty(t, list[0].SubDistrictSlug)
+	assert.Empty(t, list[0].SubDistrict)
+
+	stored := bson.M{}
+	assert.NoError(t, db.FindOne(ctx, "address", bson.M{"username": "01700000001"}, &stored))
+	assert.NotContains(t, stored, "sub_district_slug")
+	assert.NotContains(t, stored, "sub_district")
+}
+
+func TestValidatePassword(t *testing.T) {
+	err := utils.ValidatePassword("Evaly2020!")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = utils.ValidatePassword("evaly2020!")
+	if err != nil {
+		t.Log(err)
+	}
+
+	err = utils.ValidatePassword("Evaly2020!বাংলা")
+	if err != nil {
+		t.Log(err)
+	}
+
+}
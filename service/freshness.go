@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"github.com/iamrz1/ab-auth/infra"
+	"github.com/iamrz1/ab-auth/model"
+	"github.com/iamrz1/ab-auth/utils"
+)
+
+// newLastResetLoader reads the last reset of a user from Mongo for utils.GetLastResetAt.
+// Customers and merchants share the reset key in Redis, so the later of the two wins.
+func newLastResetLoader(customers CustomerRepo, merchants MerchantRepo) utils.LastResetLoader {
+	return func(ctx context.Context, username string) (int64, bool, error) {
+		var lastResetAt int64
+		found := false
+
+		c, err := customers.GetCustomer(ctx, model.Customer{Username: username})
+		if err != nil && err != infra.ErrNotFound {
+			return 0, false, err
+		}
+		if c != nil {
+			lastResetAt, found = c.LastResetAt.Unix(), true
+		}
+
+		m, err := merchants.GetMerchant(ctx, model.Merchant{Username: username})
+		if err != nil && err != infra.ErrNotFound {
+			return 0, false, err
+		}
+		if m != nil && (!found || m.LastResetAt.Unix() > lastResetAt) {
+			lastResetAt, found = m.LastResetAt.Unix(), true
+		}
+
+		return lastResetAt, found, nil
+	}
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"github.com/iamrz1/ab-auth/model"
+	"github.com/iamrz1/ab-auth/utils"
+)
+
+// IntrospectToken tells whether token is an active access or refresh token, running the
+// same checks as the authentication middleware. hint picks the type to try first, the
+// other type is tried next as RFC 7662 asks.
+func IntrospectToken(ctx context.Context, token, hint string) *model.TokenIntrospection {
+	types := []string{model.TokenTypeHintAccess, model.TokenTypeHintRefresh}
+	if hint == model.TokenTypeHintRefresh {
+		types = []string{model.TokenTypeHintRefresh, model.TokenTypeHintAccess}
+	}
+
+	for _, t := range types {
+		c, err := utils.VerifyFreshToken(ctx, token, t == model.TokenTypeHintRefresh)
+		if err != nil {
+			continue
+		}
+
+		return &model.TokenIntrospection{
+			Active:    true,
+			Sub:       c.Username,
+			Username:  c.Username,
+			UserType:  c.UserType,
+			Role:      c.Role,
+			TokenType: t,
+			Exp:       c.ExpiresAt,
+			Iat:       c.IssuedAt,
+			Jti:       c.Id,
+		}
+	}
+
+	return &model.TokenIntrospection{Active: false}
+}
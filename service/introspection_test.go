@@ -0,0 +1,18 @@
+package service
+
+import (
+	"context"
+	"github.com/iamrz1/ab-auth/model"
+	"testing"
+)
+
+func TestIntrospectToken_Inactive(t *testing.T) {
+	for _, token := range []string{"", "not-a-jwt", "eyJhbGciOiJIUzI1NiJ9.e30.invalid-signature"} {
+		for _, hint := range []string{"", model.TokenTypeHintAccess, model.TokenTypeHintRefresh} {
+			res := IntrospectToken(context.Background(), token, hint)
+			if res.Active || res.Sub != "" {
+				t.Errorf("%q (%s): expected an inactive token without claims, got %+v", token, hint, res)
+			}
+		}
+	}
+}
@@ -5,30 +5,32 @@ import (
 	"fmt"
 	"github.com/iamrz1/ab-auth/config"
 	rest_error "github.com/iamrz1/ab-auth/error"
+	"github.com/iamrz1/ab-auth/i18n"
 	"github.com/iamrz1/ab-auth/infra"
+	"github.com/iamrz1/ab-auth/logger"
 	"github.com/iamrz1/ab-auth/model"
 	"github.com/iamrz1/ab-auth/repo"
 	"github.com/iamrz1/ab-auth/utils"
-	rLog "github.com/iamrz1/rest-log"
 	"go.mongodb.org/mongo-driver/bson"
-	"log"
 	"net/http"
 	"strings"
 	"time"
 )
 
 type merchantService struct {
-	CommonRepo   *repo.CommonRepo
-	MerchantRepo *repo.MerchantRepo
-	AddressRepo  *repo.AddressRepo
-	Log          rLog.Logger
+	CommonRepo   CommonRepo
+	MerchantRepo MerchantRepo
+	AddressRepo  AddressRepo
+	AuditRepo    AuditRepo
+	Log          logger.Logger
 	Config       *config.AppConfig
 }
 
-func NewMerchantService(cfg *config.AppConfig, cm *repo.CommonRepo, cs *repo.MerchantRepo, logger rLog.Logger) *merchantService {
+func NewMerchantService(cfg *config.AppConfig, cm CommonRepo, cs MerchantRepo, adr AuditRepo, logger logger.Logger) *merchantService {
 	return &merchantService{
 		CommonRepo:   cm,
 		MerchantRepo: cs,
+		AuditRepo:    adr,
 		Log:          logger,
 		Config:       cfg,
 	}
@@ -37,11 +39,11 @@ func NewMerchantService(cfg *config.AppConfig, cm *repo.CommonRepo, cs *repo.Mer
 func (gs *merchantService) CreateMerchant(ctx context.Context, req *model.MerchantSignupReq) (string, error) {
 	err := utils.ValidatePassword(req.Password)
 	if err != nil {
-		return "", rest_error.NewValidationError("", err)
+		return "", rest_error.NewCodedValidationError(rest_error.CodeWeakPassword, "", err)
 	}
 
 	if !utils.IsValidPhoneNumber(req.Username) {
-		return "", rest_error.NewValidationError("Phone number is not valid", nil)
+		return "", rest_error.NewCodedValidationError(rest_error.CodeInvalidPhone, i18n.MsgInvalidPhone, nil)
 	}
 
 	_, err = gs.GetMerchant(ctx, &model.Merchant{Username: req.Username})
@@ -50,44 +52,62 @@ func (gs *merchantService) CreateMerchant(ctx context.Context, req *model.Mercha
 			return "", err
 		}
 	} else {
-		return "", rest_error.NewValidationError("User already exists", err)
+		return "", rest_error.NewCodedValidationError(rest_error.CodeAccountExists, i18n.MsgUserExists, nil)
 	}
 
-	otp, err := gs.CommonRepo.GetOTP(req.Username, "signup", 5, 24*60*60, 10)
+	otp, err := gs.CommonRepo.GetOTP(ctx, req.Username, "signup", 5, 24*60*60, 10)
 	if err != nil {
-		return "", rest_error.NewGenericError(http.StatusTooManyRequests, err.Error())
+		return "", err
 	}
-	// todo: send otp
 
-	err = gs.MerchantRepo.HoldMerchantRegistrationInCache(otp, req)
+	err = gs.MerchantRepo.HoldMerchantRegistrationInCache(ctx, otp, req)
 	if err != nil {
-		gs.Log.Error("CreateMerchant", "", err.Error())
+		gs.Log.Errorln(ctx, "CreateMerchant", err.Error())
 		return "", err
 	}
 
+	sendOTP(ctx, gs.Log, req.Username, i18n.MsgOTPSignup, otp, time.Minute*6)
+
 	return otp, nil
 }
 
 func (gs *merchantService) VerifyMerchantSignUp(ctx context.Context, req *model.MerchantSignupVerificationReq) error {
 	if !utils.IsValidPhoneNumber(req.Username) {
-		return rest_error.NewValidationError("Phone number is not valid", nil)
+		return rest_error.NewCodedValidationError(rest_error.CodeInvalidPhone, i18n.MsgInvalidPhone, nil)
 	}
 
-	ok, err := gs.CommonRepo.LockKey(fmt.Sprintf("%s_%s_otp_match", req.Username, "signup"), 5)
+	ok, err := gs.CommonRepo.LockKey(ctx, fmt.Sprintf("%s_%s_otp_match", req.Username, "signup"), 5)
 	if err != nil || !ok {
-		return fmt.Errorf("%s", "Please try again in a few seconds")
+		return rest_error.NewCodedError(http.StatusConflict, rest_error.CodeConcurrentRequest, i18n.MsgTryAgainShortly)
 	}
 
-	if !gs.CommonRepo.EnsureUsageLimit(fmt.Sprintf("%s_%s_otp_gen_limit", req.Username, "signup"), 5, 5*60) {
-		return rest_error.NewGenericError(http.StatusTooManyRequests, "OTP verification failed")
+	if !gs.CommonRepo.EnsureUsageLimit(ctx, fmt.Sprintf("%s_%s_otp_gen_limit", req.Username, "signup"), 5, 5*60) {
+		return rest_error.NewCodedError(http.StatusTooManyRequests, rest_error.CodeOTPRateLimited, i18n.MsgOTPVerificationFailed)
 	}
 
-	merchantData, err := gs.MerchantRepo.GetMerchantRegistrationFromCache(req.Username, req.OTP)
+	merchantData, err := gs.MerchantRepo.GetMerchantRegistrationFromCache(ctx, req.Username, req.OTP)
 	if err != nil {
-		gs.Log.Error("VerifyMerchantSignUp", "", err.Error())
+		gs.Log.Errorln(ctx, "VerifyMerchantSignUp", err.Error())
 		return rest_error.NewValidationError("", err)
 	}
 
+	// a soft-deleted merchant may register the same number again, the new account starts
+	// fresh and whatever was kept for the deleted one is purged
+	existing, err := gs.MerchantRepo.GetMerchant(ctx, &model.Merchant{Username: req.Username}, repo.IncludeDeleted())
+	if err != nil {
+		if err != infra.ErrNotFound {
+			return err
+		}
+	} else {
+		if existing.IsDeleted == nil || !*existing.IsDeleted {
+			return rest_error.NewCodedValidationError(rest_error.CodeAccountExists, i18n.MsgUserExists, nil)
+		}
+		err = gs.purgeMerchantData(ctx, existing.Username)
+		if err != nil {
+			return err
+		}
+	}
+
 	c := &model.Merchant{
 		Username:    merchantData.Username,
 		FullName:    merchantData.FullName,
@@ -101,8 +121,12 @@ func (gs *merchantService) VerifyMerchantSignUp(ctx context.Context, req *model.
 	}
 
 	err = gs.MerchantRepo.CreateMerchant(ctx, c)
+	// a concurrent verification of the same sign up created it first
+	if err == infra.ErrDuplicateKey {
+		return rest_error.NewCodedValidationError(rest_error.CodeAccountExists, i18n.MsgUserExists, nil)
+	}
 	if err != nil {
-		gs.Log.Error("VerifyMerchantSignUp", "", err.Error())
+		gs.Log.Errorln(ctx, "VerifyMerchantSignUp", err.Error())
 		return err
 	}
 
@@ -110,30 +134,36 @@ func (gs *merchantService) VerifyMerchantSignUp(ctx context.Context, req *model.
 }
 
 func (gs *merchantService) Login(ctx context.Context, req *model.LoginReq) (*model.Token, error) {
-	incorrectMsg := "Incorrect username or password"
+	incorrectMsg := i18n.MsgIncorrectCredentials
 
-	ok, err := gs.CommonRepo.LockKey(fmt.Sprintf("%s_%s_password_match", req.Username, "login"), 5)
+	ok, err := gs.CommonRepo.LockKey(ctx, fmt.Sprintf("%s_%s_password_match", req.Username, "login"), 5)
 	if err != nil || !ok {
-		return nil, fmt.Errorf("%s", "Please try again in a few seconds")
+		return nil, rest_error.NewCodedError(http.StatusConflict, rest_error.CodeConcurrentRequest, i18n.MsgTryAgainShortly)
 	}
 
-	if !gs.CommonRepo.EnsureUsageLimit(fmt.Sprintf("%s_%s_password_match_limit", req.Username, "login"), 5, 5*60) {
+	if !gs.CommonRepo.EnsureUsageLimit(ctx, fmt.Sprintf("%s_%s_password_match_limit", req.Username, "login"), 5, 5*60) {
 		// max 5 try in 5 minutes
-		return nil, rest_error.NewGenericError(http.StatusTooManyRequests, "Please try again later")
+		return nil, rest_error.NewCodedError(http.StatusTooManyRequests, rest_error.CodeRateLimited, i18n.MsgTryAgainLater)
 	}
 
 	g, err := gs.MerchantRepo.GetMerchant(ctx, model.Merchant{Username: req.Username})
 	if err != nil {
-		gs.Log.Error("login", "", err.Error())
-		return nil, rest_error.NewValidationError(incorrectMsg, nil)
+		gs.Log.Errorln(ctx, "login", err.Error())
+		return nil, rest_error.NewCodedValidationError(rest_error.CodeAuthInvalidCredentials, incorrectMsg, nil)
 	}
 
 	if !utils.VerifyPassword(req.Password, g.Password) {
-		gs.Log.Error("login", "", "password mismatch")
-		return nil, rest_error.NewValidationError(incorrectMsg, nil)
+		gs.Log.Errorln(ctx, "login", "password mismatch")
+		return nil, rest_error.NewCodedValidationError(rest_error.CodeAuthInvalidCredentials, incorrectMsg, nil)
+	}
+
+	// pending merchants may log in to complete onboarding
+	err = utils.ValidateAccountStatus(g.Status, true)
+	if err != nil {
+		return nil, err
 	}
 
-	utils.SetLastResetAt(g.Username, g.LastResetAt.Unix())
+	utils.SetLastResetAt(ctx, g.Username, g.LastResetAt.Unix())
 
 	access, refresh := utils.GenerateTokens(g.Username, "", "merchant")
 
@@ -141,25 +171,25 @@ func (gs *merchantService) Login(ctx context.Context, req *model.LoginReq) (*mod
 }
 
 func (gs *merchantService) GetShortProfile(ctx context.Context, req *model.Token) (*model.MerchantShort, error) {
-	claims, err := utils.VerifyToken(req.AccessToken, false)
+	claims, err := utils.VerifyFreshToken(ctx, req.AccessToken, false)
 	if err != nil {
-		gs.Log.Error("GetShortProfile", "", err.Error())
-		return nil, rest_error.NewGenericError(http.StatusUnauthorized, err.Error())
+		gs.Log.Errorln(ctx, "GetShortProfile", err.Error())
+		return nil, err
 	}
 
 	g, err := gs.MerchantRepo.GetMerchant(ctx, model.Merchant{Username: claims.Username})
 	if err != nil {
-		gs.Log.Error("GetShortProfile", "", err.Error())
-		return nil, rest_error.NewGenericError(http.StatusUnauthorized, "Invalid user")
+		gs.Log.Errorln(ctx, "GetShortProfile", err.Error())
+		return nil, rest_error.NewGenericError(http.StatusUnauthorized, i18n.MsgInvalidUser)
 	}
 
-	if claims.IssuedAt < g.LastResetAt.Unix() {
-		log.Println("claims.IssuedAt:", claims.IssuedAt, "g.BirthDate.Unix():", g.LastResetAt.Unix())
-		return nil, rest_error.NewGenericError(http.StatusUnauthorized, "Inert token")
+	err = utils.ValidateAccountStatus(g.Status, true)
+	if err != nil {
+		return nil, err
 	}
 
 	if claims.UserType != "merchant" {
-		return nil, rest_error.NewGenericError(http.StatusUnauthorized, "Restricted to merchants")
+		return nil, rest_error.NewCodedError(http.StatusUnauthorized, rest_error.CodeAuthForbidden, i18n.MsgMerchantsOnly)
 	}
 
 	return g.ToShortResponse(), nil
@@ -174,36 +204,56 @@ func (gs *merchantService) GetMerchant(ctx context.Context, req *model.Merchant)
 	return g.ToResponse(), nil
 }
 
-func (gs *merchantService) ListMerchants(ctx context.Context, req *model.MerchantListReq) ([]*model.Merchant, int64, error) {
+// GetMerchantStatus returns the account status of merchant username
+func (gs *merchantService) GetMerchantStatus(ctx context.Context, username string) (*model.MerchantStatus, error) {
+	m, err := gs.MerchantRepo.GetMerchant(ctx, model.Merchant{Username: username})
+	if err != nil {
+		if err == infra.ErrNotFound {
+			return nil, rest_error.NewCodedError(http.StatusNotFound, rest_error.CodeNotFound, i18n.MsgMerchantNotFound)
+		}
+		return nil, err
+	}
+
+	return &model.MerchantStatus{
+		Username:     m.Username,
+		Status:       m.Status,
+		StatusReason: m.StatusReason,
+		IsVerified:   m.IsVerified != nil && *m.IsVerified,
+		IsActive:     m.Status == utils.StatusActive,
+	}, nil
+}
+
+// ListMerchants lists the page of merchants req asks for, newest first. The count is only
+// taken for page/limit requests, as counting is what makes large lists slow.
+func (gs *merchantService) ListMerchants(ctx context.Context, req *model.MerchantListReq) ([]*model.Merchant, int64, *infra.PageInfo, error) {
 	selector := &bson.D{}
 
 	if req.Search != "" {
 		selector = utils.AppendSearchPattern(selector, "string_field", req.Search, true)
 	}
 
-	opts := &model.ListOptions{
-		Page:  req.Page,
-		Limit: req.Limit,
-		Sort:  nil,
+	q := pageQuery(req.Page, req.Limit, req.Cursor, "_id", true)
+	Merchants, info, err := gs.MerchantRepo.ListMerchantsPage(ctx, selector, q)
+	if err != nil {
+		gs.Log.Errorln(ctx, "ListMerchants", err.Error())
+		return nil, 0, nil, pageError(err)
 	}
 
-	Merchants, err := gs.MerchantRepo.ListMerchants(ctx, selector, opts)
-	if err != nil {
-		gs.Log.Error("ListMerchants", "", err.Error())
-		return nil, 0, err
+	for i, g := range Merchants {
+		Merchants[i] = g.ToResponse()
 	}
 
-	for _, g := range Merchants {
-		g = g.ToResponse()
+	if req.Cursor != "" {
+		return Merchants, 0, info, nil
 	}
 
 	count, err := gs.MerchantRepo.CountMerchant(ctx, selector)
 	if err != nil {
-		gs.Log.Error("CountMerchant", "", err.Error())
-		return nil, 0, err
+		gs.Log.Errorln(ctx, "CountMerchant", err.Error())
+		return nil, 0, nil, err
 	}
 
-	return Merchants, count, nil
+	return Merchants, count, info, nil
 }
 
 func (gs *merchantService) UpdateMerchant(ctx context.Context, req *model.MerchantProfileUpdateReq) (*model.Merchant, error) {
@@ -211,13 +261,13 @@ func (gs *merchantService) UpdateMerchant(ctx context.Context, req *model.Mercha
 	_, err := gs.MerchantRepo.GetMerchant(ctx, filter)
 	if err != nil {
 		if err == infra.ErrNotFound {
-			return nil, rest_error.NewValidationError("", infra.ErrNotFound)
+			return nil, rest_error.NewCodedValidationError(rest_error.CodeNotFound, "", infra.ErrNotFound)
 		}
 		return nil, err
 	}
 	gender := strings.ToLower(strings.TrimSpace(req.Gender))
 	if gender != "" && !utils.IsGenderValid(gender) {
-		return nil, rest_error.NewValidationError("Invalid gender", nil)
+		return nil, rest_error.NewValidationError(i18n.MsgInvalidGender, nil)
 	}
 
 	updateDoc := &model.Merchant{
@@ -233,7 +283,7 @@ func (gs *merchantService) UpdateMerchant(ctx context.Context, req *model.Mercha
 
 	_, err = gs.MerchantRepo.UpdateMerchant(ctx, filter, updateDoc)
 	if err != nil {
-		gs.Log.Error("updateMerchantProfile", "", err.Error())
+		gs.Log.Errorln(ctx, "updateMerchantProfile", err.Error())
 		return nil, err
 	}
 
@@ -250,24 +300,24 @@ func (gs *merchantService) UpdatePassword(ctx context.Context, req *model.Update
 	c, err := gs.MerchantRepo.GetMerchant(ctx, filter)
 	if err != nil {
 		if err == infra.ErrNotFound {
-			return nil, rest_error.NewValidationError("", infra.ErrNotFound)
+			return nil, rest_error.NewCodedValidationError(rest_error.CodeNotFound, "", infra.ErrNotFound)
 		}
 		return nil, err
 	}
 
-	ok, err := gs.CommonRepo.LockKey(fmt.Sprintf("%s_%s_password_match", req.Username, "update"), 5)
+	ok, err := gs.CommonRepo.LockKey(ctx, fmt.Sprintf("%s_%s_password_match", req.Username, "update"), 5)
 	if err != nil || !ok {
-		return nil, fmt.Errorf("%s", "Please try again in a few seconds")
+		return nil, rest_error.NewCodedError(http.StatusConflict, rest_error.CodeConcurrentRequest, i18n.MsgTryAgainShortly)
 	}
 
-	if !gs.CommonRepo.EnsureUsageLimit(fmt.Sprintf("%s_%s_password_match_limit", req.Username, "update"), 5, 5*60) {
+	if !gs.CommonRepo.EnsureUsageLimit(ctx, fmt.Sprintf("%s_%s_password_match_limit", req.Username, "update"), 5, 5*60) {
 		// max 5 try in 5 minutes
-		return nil, rest_error.NewGenericError(http.StatusTooManyRequests, "Please try again later")
+		return nil, rest_error.NewCodedError(http.StatusTooManyRequests, rest_error.CodeRateLimited, i18n.MsgTryAgainLater)
 	}
 
 	if !utils.VerifyPassword(req.CurrentPassword, c.Password) {
-		gs.Log.Error("updatePassword", "", "password mismatch")
-		return nil, rest_error.NewValidationError("Incorrect password", nil)
+		gs.Log.Errorln(ctx, "updatePassword", "password mismatch")
+		return nil, rest_error.NewCodedValidationError(rest_error.CodeAuthInvalidCredentials, i18n.MsgIncorrectPassword, nil)
 	}
 
 	updateDoc := &model.Merchant{
@@ -277,11 +327,11 @@ func (gs *merchantService) UpdatePassword(ctx context.Context, req *model.Update
 
 	_, err = gs.MerchantRepo.UpdateMerchant(ctx, filter, updateDoc)
 	if err != nil {
-		gs.Log.Error("updateMerchantProfile", "", err.Error())
+		gs.Log.Errorln(ctx, "updateMerchantProfile", err.Error())
 		return nil, err
 	}
 
-	utils.SetLastResetAt(req.Username, updateDoc.LastResetAt.Unix())
+	utils.SetLastResetAt(ctx, req.Username, updateDoc.LastResetAt.Unix())
 
 	return c.ToResponse(), nil
 }
@@ -291,54 +341,178 @@ func (gs *merchantService) DeleteMerchant(ctx context.Context, delete *model.Mer
 	_, err := gs.MerchantRepo.GetMerchant(ctx, filter)
 	if err != nil {
 		if err == infra.ErrNotFound {
-			return nil, rest_error.NewValidationError("", infra.ErrNotFound)
+			return nil, rest_error.NewCodedValidationError(rest_error.CodeNotFound, "", infra.ErrNotFound)
+		}
+		return nil, err
+	}
+
+	updateDoc := &model.Merchant{
+		IsDeleted:   utils.BoolP(true),
+		LastResetAt: time.Now().UTC(),
+		UpdatedAt:   time.Now().UTC(),
+	}
+
+	_, err = gs.MerchantRepo.UpdateMerchant(ctx, filter, updateDoc)
+	if err != nil {
+		gs.Log.Errorln(ctx, "updateMerchantProfile", err.Error())
+		return nil, err
+	}
+
+	// revoke every session of the deleted merchant
+	utils.SetLastResetAt(ctx, delete.Username, updateDoc.LastResetAt.Unix())
+	gs.saveAudit(ctx, &model.AuditEvent{Username: delete.Username, Action: model.AuditActionDelete, Actor: delete.Username})
+
+	g, err := gs.MerchantRepo.GetMerchant(ctx, filter, repo.IncludeDeleted())
+	if err != nil {
+		if err == infra.ErrNotFound {
+			return nil, rest_error.NewCodedValidationError(rest_error.CodeNotFound, "", infra.ErrNotFound)
+		}
+		return nil, err
+	}
+
+	return g.ToResponse(), nil
+}
+
+// UpdateMerchantStatus moves a merchant to a new status, recording why and by whom.
+// Blocking or deactivating an account revokes all of its sessions right away.
+func (gs *merchantService) UpdateMerchantStatus(ctx context.Context, req *model.AccountStatusUpdateReq) (*model.Merchant, error) {
+	if !utils.IsValidAccountStatus(req.Status) {
+		return nil, rest_error.NewCodedValidationError(rest_error.CodeInvalidStatus, i18n.MsgInvalidStatus, nil)
+	}
+
+	filter := &model.Merchant{Username: req.Username}
+	c, err := gs.MerchantRepo.GetMerchant(ctx, filter)
+	if err != nil {
+		if err == infra.ErrNotFound {
+			return nil, rest_error.NewCodedValidationError(rest_error.CodeNotFound, "", infra.ErrNotFound)
 		}
 		return nil, err
 	}
 
+	if c.Status == req.Status {
+		return c.ToResponse(), nil
+	}
+
+	now := time.Now().UTC()
 	updateDoc := &model.Merchant{
-		IsDeleted: utils.BoolP(true),
+		Status:          req.Status,
+		StatusReason:    req.Reason,
+		StatusChangedBy: req.Actor,
+		StatusChangedAt: now,
+		UpdatedAt:       now,
+	}
+
+	revoke := req.Status == utils.StatusBlocked || req.Status == utils.StatusInactive
+	if revoke {
+		updateDoc.LastResetAt = now
 	}
 
 	_, err = gs.MerchantRepo.UpdateMerchant(ctx, filter, updateDoc)
 	if err != nil {
-		gs.Log.Error("updateMerchantProfile", "", err.Error())
+		gs.Log.Errorln(ctx, "UpdateMerchantStatus", err.Error())
 		return nil, err
 	}
 
+	if revoke {
+		utils.SetLastResetAt(ctx, req.Username, now.Unix())
+	}
+
+	gs.saveAudit(ctx, &model.AuditEvent{
+		Username:    req.Username,
+		Action:      model.AuditActionStatusChange,
+		Actor:       req.Actor,
+		Description: fmt.Sprintf("%s -> %s", c.Status, req.Status),
+		Reason:      req.Reason,
+	})
+
 	g, err := gs.MerchantRepo.GetMerchant(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.ToResponse(), nil
+}
+
+// saveAudit stores event as is, filling in the user type and time
+func (gs *merchantService) saveAudit(ctx context.Context, event *model.AuditEvent) {
+	event.UserType = utils.UserTypeMerchant
+	event.CreatedAt = time.Now().UTC()
+
+	err := gs.AuditRepo.AddEvent(ctx, event)
+	if err != nil {
+		gs.Log.Errorln(ctx, "saveAudit", err.Error())
+	}
+}
+
+// RestoreMerchant undoes a soft delete
+func (gs *merchantService) RestoreMerchant(ctx context.Context, req *model.MerchantDeleteReq) (*model.Merchant, error) {
+	filter := &model.Merchant{Username: req.Username}
+	c, err := gs.MerchantRepo.GetMerchant(ctx, filter, repo.IncludeDeleted())
 	if err != nil {
 		if err == infra.ErrNotFound {
-			return nil, rest_error.NewValidationError("", infra.ErrNotFound)
+			return nil, rest_error.NewCodedValidationError(rest_error.CodeNotFound, "", infra.ErrNotFound)
 		}
 		return nil, err
 	}
+	if c.IsDeleted == nil || !*c.IsDeleted {
+		return nil, rest_error.NewCodedValidationError(rest_error.CodeNotFound, i18n.MsgNoDeletedMerchant, nil)
+	}
+
+	n, err := gs.MerchantRepo.RestoreMerchant(ctx, req.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	if n == 0 {
+		return nil, rest_error.NewCodedValidationError(rest_error.CodeNotFound, i18n.MsgNoDeletedMerchant, nil)
+	}
+	gs.saveAudit(ctx, &model.AuditEvent{Username: req.Username, Action: model.AuditActionRestore, Actor: req.Username})
+
+	g, err := gs.MerchantRepo.GetMerchant(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
 
 	return g.ToResponse(), nil
 }
 
 func (gs *merchantService) PurgeMerchant(ctx context.Context, delete *model.MerchantDeleteReq) (*model.Merchant, error) {
 	filter := model.Merchant{Username: delete.Username}
-	g, err := gs.MerchantRepo.GetMerchant(ctx, filter)
+	g, err := gs.MerchantRepo.GetMerchant(ctx, filter, repo.IncludeDeleted())
 	if err != nil {
 		if err == infra.ErrNotFound {
-			return nil, rest_error.NewValidationError("", infra.ErrNotFound)
+			return nil, rest_error.NewCodedValidationError(rest_error.CodeNotFound, "", infra.ErrNotFound)
 		}
 		return nil, err
 	}
 
-	_, err = gs.MerchantRepo.PurgeOne(ctx, filter)
+	err = gs.purgeMerchantData(ctx, g.Username)
 	if err != nil {
-		gs.Log.Error("PurgeOne", "", err.Error())
 		return nil, err
 	}
 
 	return g.ToResponse(), nil
 }
 
+// purgeMerchantData hard-deletes the merchant along with their cache keys
+func (gs *merchantService) purgeMerchantData(ctx context.Context, username string) error {
+	err := gs.CommonRepo.PurgeUserKeys(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	_, err = gs.MerchantRepo.PurgeOne(ctx, bson.M{"username": username})
+	if err != nil {
+		gs.Log.Errorln(ctx, "purgeMerchantData", err.Error())
+		return err
+	}
+
+	return nil
+}
+
 func (gs *merchantService) ForgotPassword(ctx context.Context, req *model.ForgotPasswordReq) (string, error) {
 	if !utils.IsValidPhoneNumber(req.Username) {
-		return "", rest_error.NewValidationError("Phone number is not valid", nil)
+		return "", rest_error.NewCodedValidationError(rest_error.CodeInvalidPhone, i18n.MsgInvalidPhone, nil)
 	}
 
 	_, err := gs.GetMerchant(ctx, &model.Merchant{Username: req.Username})
@@ -349,36 +523,36 @@ func (gs *merchantService) ForgotPassword(ctx context.Context, req *model.Forgot
 		return "", nil // lets just pretend that the user exists and throw off random api calls
 	}
 
-	otp, err := gs.CommonRepo.GetOTP(req.Username, "forgot", 2, 12*60*60, 10)
+	otp, err := gs.CommonRepo.GetOTP(ctx, req.Username, "forgot", 2, 12*60*60, 10)
 	if err != nil {
-		return "", rest_error.NewGenericError(http.StatusTooManyRequests, err.Error())
+		return "", err
 	}
 
-	err = gs.CommonRepo.SetOTP(req.Username, "forgot", otp, 5*60)
+	err = gs.CommonRepo.SetOTP(ctx, req.Username, "forgot", otp, 5*60)
 	if err != nil {
 		return "", rest_error.NewValidationError("", err)
 	}
-	// todo: send otp
+	sendOTP(ctx, gs.Log, req.Username, i18n.MsgOTPForgotPassword, otp, time.Minute*5)
 
 	return otp, nil
 }
 
 func (gs *merchantService) SetPassword(ctx context.Context, req *model.SetPasswordReq) error {
 	if !utils.IsValidPhoneNumber(req.Username) {
-		return rest_error.NewValidationError("Phone number is not valid", nil)
+		return rest_error.NewCodedValidationError(rest_error.CodeInvalidPhone, i18n.MsgInvalidPhone, nil)
 	}
 
-	ok, err := gs.CommonRepo.LockKey(fmt.Sprintf("%s_%s_otp_match", req.Username, "forgot"), 5)
+	ok, err := gs.CommonRepo.LockKey(ctx, fmt.Sprintf("%s_%s_otp_match", req.Username, "forgot"), 5)
 	if err != nil || !ok {
-		return fmt.Errorf("%s", "Please try again in a few seconds")
+		return rest_error.NewCodedError(http.StatusConflict, rest_error.CodeConcurrentRequest, i18n.MsgTryAgainShortly)
 	}
 
-	if !gs.CommonRepo.EnsureUsageLimit(fmt.Sprintf("%s_%s_otp_match_limit", req.Username, "forgot"), 5, 5*60) {
+	if !gs.CommonRepo.EnsureUsageLimit(ctx, fmt.Sprintf("%s_%s_otp_match_limit", req.Username, "forgot"), 5, 5*60) {
 		// max 5 try in 5 minutes
-		return rest_error.NewGenericError(http.StatusTooManyRequests, "OTP verification failed")
+		return rest_error.NewCodedError(http.StatusTooManyRequests, rest_error.CodeOTPRateLimited, i18n.MsgOTPVerificationFailed)
 	}
 
-	err = gs.CommonRepo.MatchOTP(req.Username, "forgot", req.OTP)
+	err = gs.CommonRepo.MatchOTP(ctx, req.Username, "forgot", req.OTP)
 	if err != nil {
 		return rest_error.NewValidationError("", err)
 	}
@@ -392,31 +566,31 @@ func (gs *merchantService) SetPassword(ctx context.Context, req *model.SetPasswo
 
 	_, err = gs.MerchantRepo.UpdateMerchant(ctx, filter, updateDoc)
 	if err != nil {
-		gs.Log.Error("updateMerchantProfile", "", err.Error())
+		gs.Log.Errorln(ctx, "updateMerchantProfile", err.Error())
 		return err
 	}
 
-	utils.SetLastResetAt(req.Username, updateDoc.LastResetAt.Unix())
+	utils.SetLastResetAt(ctx, req.Username, updateDoc.LastResetAt.Unix())
 
 	return nil
 }
 
 func (gs *merchantService) ChangePassword(ctx context.Context, req *model.SetPasswordReq) error {
 	if !utils.IsValidPhoneNumber(req.Username) {
-		return rest_error.NewValidationError("Phone number is not valid", nil)
+		return rest_error.NewCodedValidationError(rest_error.CodeInvalidPhone, i18n.MsgInvalidPhone, nil)
 	}
 
-	ok, err := gs.CommonRepo.LockKey(fmt.Sprintf("%s_%s_otp_match", req.Username, "forgot"), 5)
+	ok, err := gs.CommonRepo.LockKey(ctx, fmt.Sprintf("%s_%s_otp_match", req.Username, "forgot"), 5)
 	if err != nil || !ok {
-		return fmt.Errorf("%s", "Please try again in a few seconds")
+		return rest_error.NewCodedError(http.StatusConflict, rest_error.CodeConcurrentRequest, i18n.MsgTryAgainShortly)
 	}
 
-	if !gs.CommonRepo.EnsureUsageLimit(fmt.Sprintf("%s_%s_otp_match_limit", req.Username, "forgot"), 5, 5*60) {
+	if !gs.CommonRepo.EnsureUsageLimit(ctx, fmt.Sprintf("%s_%s_otp_match_limit", req.Username, "forgot"), 5, 5*60) {
 		// max 5 try in 5 minutes
-		return rest_error.NewGenericError(http.StatusTooManyRequests, "Please try again later")
+		return rest_error.NewCodedError(http.StatusTooManyRequests, rest_error.CodeRateLimited, i18n.MsgTryAgainLater)
 	}
 
-	err = gs.CommonRepo.MatchOTP(req.Username, "forgot", req.OTP)
+	err = gs.CommonRepo.MatchOTP(ctx, req.Username, "forgot", req.OTP)
 	if err != nil {
 		return rest_error.NewValidationError("", err)
 	}
@@ -430,11 +604,11 @@ func (gs *merchantService) ChangePassword(ctx context.Context, req *model.SetPas
 
 	_, err = gs.MerchantRepo.UpdateMerchant(ctx, filter, updateDoc)
 	if err != nil {
-		gs.Log.Error("updateMerchantProfile", "", err.Error())
+		gs.Log.Errorln(ctx, "updateMerchantProfile", err.Error())
 		return err
 	}
 
-	utils.SetLastResetAt(req.Username, updateDoc.LastResetAt.Unix())
+	utils.SetLastResetAt(ctx, req.Username, updateDoc.LastResetAt.Unix())
 
 	return nil
 }
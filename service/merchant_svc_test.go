@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"github.com/iamrz1/ab-auth/model"
+	"github.com/iamrz1/ab-auth/utils"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"testing"
+)
+
+func TestUpdateMerchantStatus(t *testing.T) {
+	svc, db := testServices(t)
+	ms := svc.MerchantService
+	ctx := context.Background()
+
+	err := db.Insert(ctx, "merchant", &model.Merchant{Username: "01700000001", Status: utils.StatusActive, IsDeleted: utils.BoolP(false)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := ms.UpdateMerchantStatus(ctx, &model.AccountStatusUpdateReq{
+		Username: "01700000001",
+		Status:   utils.StatusBlocked,
+		Reason:   "fraud",
+		Actor:    "backoffice",
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, utils.StatusBlocked, res.Status)
+
+	lastResetAt, err := utils.GetLastResetAt(ctx, "01700000001")
+	assert.NoError(t, err)
+	assert.NotZero(t, lastResetAt, "blocking revokes the sessions")
+
+	events, err := ms.AuditRepo.ListEvents(ctx, bson.M{"username": "01700000001"}, nil)
+	if assert.NoError(t, err) && assert.Len(t, events, 1) {
+		assert.Equal(t, model.AuditActionStatusChange, events[0].Action)
+		assert.Equal(t, utils.UserTypeMerchant, events[0].UserType)
+		assert.Equal(t, "backoffice", events[0].Actor)
+		assert.Equal(t, "active -> blocked", events[0].Description)
+		assert.Equal(t, "fraud", events[0].Reason)
+	}
+}
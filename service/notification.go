@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/iamrz1/ab-auth/i18n"
+	"github.com/iamrz1/ab-auth/logger"
+	"github.com/iamrz1/ab-auth/metrics"
+	"time"
+)
+
+// sendOTP renders the OTP notification template in the language negotiated for the request
+// and hands it over for delivery
+func sendOTP(ctx context.Context, lgr logger.Logger, username, templateID, otp string, ttl time.Duration) {
+	lang := i18n.FromContext(ctx)
+	msg := i18n.T(lang, templateID, otp, int(ttl.Minutes()))
+	metrics.OTPSends.WithLabelValues(templateID).Inc()
+
+	// todo: deliver msg over sms. Until then the handlers return the otp to the client
+	// outside production, the message itself never goes to stdout or into the logs.
+	lgr.Debugln(ctx, "sendOTP", fmt.Sprintf("rendered %s in %s, %d characters", templateID, lang, len([]rune(msg))))
+	lgr.Infoln(ctx, "sendOTP", fmt.Sprintf("%s sent to %s", templateID, username))
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"errors"
+	rest_error "github.com/iamrz1/ab-auth/error"
+	"github.com/iamrz1/ab-auth/i18n"
+	"github.com/iamrz1/ab-auth/infra"
+)
+
+// pageQuery returns the query of a list request, sorted by sortField. A cursor takes the
+// place of page, which stays for the clients of page/limit lists.
+func pageQuery(page, limit int64, cursor, sortField string, desc bool) infra.PageQuery {
+	q := infra.PageQuery{SortField: sortField, Desc: desc, Cursor: cursor, Limit: limit}
+	if cursor == "" && page > 1 {
+		q.Skip = (page - 1) * limit
+	}
+
+	return q
+}
+
+// pageError turns the error of a malformed or foreign cursor into a bad request
+func pageError(err error) error {
+	if errors.Is(err, infra.ErrInvalidCursor) {
+		return rest_error.NewCodedValidationError(rest_error.CodeInvalidCursor, i18n.MsgInvalidCursor, nil)
+	}
+
+	return err
+}
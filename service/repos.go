@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"github.com/iamrz1/ab-auth/infra"
+	"github.com/iamrz1/ab-auth/model"
+	"github.com/iamrz1/ab-auth/repo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// The services depend on these instead of the repos of package repo, so tests can hand
+// them fakes. The repos of package repo implement them on top of infra.DB and redis.
+
+// CommonRepo keeps the one time passwords, locks and usage limits of users
+type CommonRepo interface {
+	GetOTP(ctx context.Context, username, service string, limit, limitDuration, lockDuration int) (string, error)
+	SetOTP(ctx context.Context, username, service, otp string, durationSec int) error
+	MatchOTP(ctx context.Context, username, service, otp string) error
+	LockKey(ctx context.Context, key string, durationSec int) (bool, error)
+	EnsureUsageLimit(ctx context.Context, key string, limit, durationSec int) bool
+	PurgeUserKeys(ctx context.Context, username string) error
+}
+
+// CustomerRepo stores customers and the sign ups waiting for their otp
+type CustomerRepo interface {
+	HoldCustomerRegistrationInCache(ctx context.Context, otp string, doc *model.CustomerSignupReq) error
+	GetCustomerRegistrationFromCache(ctx context.Context, username, otp string) (*model.CustomerSignupReq, error)
+	CreateCustomer(ctx context.Context, doc *model.Customer) error
+	GetCustomer(ctx context.Context, selector interface{}, opts ...repo.ScopeOption) (*model.Customer, error)
+	ListCustomers(ctx context.Context, selector interface{}, listOptions *model.ListOptions, opts ...repo.ScopeOption) ([]*model.Customer, error)
+	ListCustomersPage(ctx context.Context, selector interface{}, q infra.PageQuery, opts ...repo.ScopeOption) ([]*model.Customer, *infra.PageInfo, error)
+	UpdateCustomer(ctx context.Context, filter, doc *model.Customer, opts ...repo.ScopeOption) (int64, error)
+	UnsetCustomerFields(ctx context.Context, username string, fields ...string) error
+	CountCustomer(ctx context.Context, selector interface{}, opts ...repo.ScopeOption) (int64, error)
+	RestoreCustomer(ctx context.Context, username string) (int64, error)
+	PurgeOne(ctx context.Context, filter interface{}) (int64, error)
+}
+
+// MerchantRepo stores merchants and the sign ups waiting for their otp
+type MerchantRepo interface {
+	HoldMerchantRegistrationInCache(ctx context.Context, otp string, doc *model.MerchantSignupReq) error
+	GetMerchantRegistrationFromCache(ctx context.Context, username, otp string) (*model.MerchantSignupReq, error)
+	CreateMerchant(ctx context.Context, doc *model.Merchant) error
+	GetMerchant(ctx context.Context, selector interface{}, opts ...repo.ScopeOption) (*model.Merchant, error)
+	ListMerchants(ctx context.Context, selector interface{}, listOptions *model.ListOptions, opts ...repo.ScopeOption) ([]*model.Merchant, error)
+	ListMerchantsPage(ctx context.Context, selector interface{}, q infra.PageQuery, opts ...repo.ScopeOption) ([]*model.Merchant, *infra.PageInfo, error)
+	UpdateMerchant(ctx context.Context, filter, doc *model.Merchant, opts ...repo.ScopeOption) (int64, error)
+	CountMerchant(ctx context.Context, selector interface{}, opts ...repo.ScopeOption) (int64, error)
+	RestoreMerchant(ctx context.Context, username string) (int64, error)
+	PurgeOne(ctx context.Context, filter interface{}) (int64, error)
+}
+
+// AddressRepo stores the addresses of customers and the BD location presets they are
+// picked from
+type AddressRepo interface {
+	AddAddress(ctx context.Context, address *model.Address) error
+	GetAddress(ctx context.Context, filter interface{}, opts ...repo.ScopeOption) (*model.Address, error)
+	GetAddressCount(ctx context.Context, filter interface{}, opts ...repo.ScopeOption) (int64, error)
+	GetAddresses(ctx context.Context, filter interface{}, listOptions *model.ListOptions, opts ...repo.ScopeOption) ([]*model.Address, error)
+	UpdateAddress(ctx context.Context, filter interface{}, doc *model.Address, opts ...repo.ScopeOption) (int64, error)
+	UnsetAddressFields(ctx context.Context, id primitive.ObjectID, fields ...string) error
+	PurgeAddress(ctx context.Context, filter interface{}) (int64, error)
+	PurgeAddresses(ctx context.Context, filter interface{}) error
+	InTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+	DemotePrimaryAddresses(ctx context.Context, username string) error
+	FindAddressesByLocation(ctx context.Context, geoQuery bson.M, limit int64) ([]*model.Address, error)
+	CountBdLocations(ctx context.Context, filter interface{}) (int64, error)
+	GetBdLocationsPage(ctx context.Context, filter interface{}, q infra.PageQuery) ([]*model.BDLocation, *infra.PageInfo, error)
+	GetAllBdLocations(ctx context.Context) ([]*model.BDLocation, error)
+	EnsureBdLocationIndices(ctx context.Context) error
+	UpsertBdLocations(ctx context.Context, locations []*model.BDLocation) error
+	PruneBdLocations(ctx context.Context, keep []string) error
+	GetDatasetVersion(ctx context.Context, id string) (*model.DatasetVersion, error)
+	SetDatasetVersion(ctx context.Context, v *model.DatasetVersion) error
+}
+
+// AuditRepo stores the audit trail of accounts
+type AuditRepo interface {
+	AddEvent(ctx context.Context, event *model.AuditEvent) error
+	ListEvents(ctx context.Context, filter interface{}, listOptions *model.ListOptions) ([]*model.AuditEvent, error)
+	PurgeEvents(ctx context.Context, filter interface{}) error
+}
@@ -4,8 +4,9 @@ import (
 	"github.com/iamrz1/ab-auth/config"
 	"github.com/iamrz1/ab-auth/infra"
 	infraCache "github.com/iamrz1/ab-auth/infra/cache"
+	"github.com/iamrz1/ab-auth/logger"
 	"github.com/iamrz1/ab-auth/repo"
-	rLog "github.com/iamrz1/rest-log"
+	"github.com/iamrz1/ab-auth/utils"
 )
 
 // Config holds application configurations
@@ -19,13 +20,17 @@ func getServiceConfig(cs *customerService, ms *merchantService) *Config {
 	return &Config{CustomerService: cs, MerchantService: ms}
 }
 
-func SetupServiceConfig(cfg *config.AppConfig, db infra.DB, cache *infraCache.Redis, rLogger rLog.Logger) *Config {
-	customerRepo := repo.NewCustomerRepo(db, cfg.CustomerTable, cache, rLogger)
-	merchantRepo := repo.NewMerchantRepo(db, cfg.MerchantTable, cache, rLogger)
-	addressRepo := repo.NewAddressRepo(db, cfg.AddressTable, "address_preset", rLogger)
-	commonRepo := repo.NewCommonRepo(db, cache, rLogger)
-	cs := NewCustomerService(cfg, commonRepo, customerRepo, addressRepo, rLogger)
-	ms := NewMerchantService(cfg, commonRepo, merchantRepo, rLogger)
+func SetupServiceConfig(cfg *config.AppConfig, db infra.DB, cache *infraCache.Redis, lgr logger.Logger) *Config {
+	utils.Configure(cfg)
+
+	customerRepo := repo.NewCustomerRepo(db, cfg.Database.CustomerCollection, cache, lgr)
+	merchantRepo := repo.NewMerchantRepo(db, cfg.Database.MerchantCollection, cache, lgr)
+	addressRepo := repo.NewAddressRepo(db, cfg.Database.AddressCollection, cfg.Database.BDLocationCollection, cfg.Database.DatasetCollection, lgr)
+	auditRepo := repo.NewAuditRepo(db, cfg.Database.AuditCollection, lgr)
+	commonRepo := repo.NewCommonRepo(db, cache, lgr)
+	cs := NewCustomerService(cfg, commonRepo, customerRepo, addressRepo, auditRepo, lgr)
+	ms := NewMerchantService(cfg, commonRepo, merchantRepo, auditRepo, lgr)
+	utils.SetLastResetLoader(newLastResetLoader(customerRepo, merchantRepo))
 
 	return getServiceConfig(cs, ms)
 }
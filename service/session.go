@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	rest_error "github.com/iamrz1/ab-auth/error"
+	"github.com/iamrz1/ab-auth/i18n"
+	"github.com/iamrz1/ab-auth/utils"
+	"net/http"
+)
+
+// Logout revokes accessToken until it expires, and refreshToken too if given. The refresh
+// token must belong to the same user.
+func Logout(ctx context.Context, accessToken, refreshToken string) error {
+	access, err := utils.VerifyToken(accessToken, false)
+	if err != nil {
+		return utils.TokenVerificationError(err)
+	}
+
+	if refreshToken != "" {
+		refresh, err := utils.VerifyToken(refreshToken, true)
+		if err != nil {
+			return utils.TokenVerificationError(err)
+		}
+		if refresh.Username != access.Username {
+			return rest_error.NewCodedError(http.StatusBadRequest, rest_error.CodeAuthTokenInvalid, i18n.MsgTokenSessionMismatch)
+		}
+
+		err = utils.RevokeToken(ctx, utils.TokenID(refresh, refreshToken), refresh.ExpiresAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return utils.RevokeToken(ctx, utils.TokenID(access, accessToken), access.ExpiresAt)
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	rest_error "github.com/iamrz1/ab-auth/error"
+	"github.com/iamrz1/ab-auth/utils"
+	"testing"
+)
+
+func TestLogout_RefreshTokenOfAnotherUser(t *testing.T) {
+	access, _ := utils.GenerateTokens("01700000001", "", utils.UserTypeCustomer)
+	_, refresh := utils.GenerateTokens("01700000002", "", utils.UserTypeCustomer)
+
+	err := Logout(context.Background(), access, refresh)
+	if rest_error.CodeOf(err) != rest_error.CodeAuthTokenInvalid {
+		t.Errorf("expected the refresh token to be refused, got %v", err)
+	}
+}
+
+func TestGenerateTokens_UniqueJTI(t *testing.T) {
+	a1, r1 := utils.GenerateTokens("01700000001", "", utils.UserTypeCustomer)
+	a2, _ := utils.GenerateTokens("01700000001", "", utils.UserTypeCustomer)
+
+	ids := map[string]bool{}
+	for _, tc := range []struct {
+		token     string
+		isRefresh bool
+	}{{a1, false}, {r1, true}, {a2, false}} {
+		c, err := utils.VerifyToken(tc.token, tc.isRefresh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Id == "" || ids[c.Id] {
+			t.Errorf("expected a unique jti, got %q", c.Id)
+		}
+		ids[c.Id] = true
+	}
+}
@@ -0,0 +1,69 @@
+package test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+type bdAreaListRes struct {
+	Code string `json:"code"`
+	Data []struct {
+		Name string `json:"name"`
+	} `json:"data"`
+	Meta struct {
+		Page       *int64 `json:"page"`
+		Count      *int64 `json:"count"`
+		NextCursor string `json:"next_cursor"`
+		PrevCursor string `json:"prev_cursor"`
+	} `json:"meta"`
+}
+
+func (res bdAreaListRes) names() []string {
+	out := make([]string, 0, len(res.Data))
+	for _, l := range res.Data {
+		out = append(out, l.Name)
+	}
+	return out
+}
+
+func TestRouter_BDAreaPages(t *testing.T) {
+	srv := testServer(t)
+	list := func(query string) (int, bdAreaListRes) {
+		res := bdAreaListRes{}
+		code := do(t, srv, http.MethodGet, "/api/v1/public/bd-area?"+query, "", nil, &res)
+		return code, res
+	}
+
+	code, first := list("limit=2")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"Sylhet", "Rajshahi"}, first.names())
+	if assert.NotNil(t, first.Meta.Count) {
+		assert.EqualValues(t, 5, *first.Meta.Count, "page/limit lists are counted")
+	}
+	assert.Empty(t, first.Meta.PrevCursor)
+
+	code, second := list("limit=2&cursor=" + url.QueryEscape(first.Meta.NextCursor))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"Khulna", "Dhaka"}, second.names())
+	assert.Nil(t, second.Meta.Count, "pages by cursor are not counted")
+	assert.Nil(t, second.Meta.Page)
+
+	code, res := list("page=2&limit=2")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, second.names(), res.names(), "both ways of paging agree")
+
+	code, last := list("limit=2&cursor=" + url.QueryEscape(second.Meta.NextCursor))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"Chattogram"}, last.names())
+	assert.Empty(t, last.Meta.NextCursor)
+
+	code, res = list("limit=2&cursor=" + url.QueryEscape(second.Meta.PrevCursor))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, first.names(), res.names())
+
+	code, res = list("limit=2&cursor=bm90LWEtY3Vyc29y")
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "INVALID_CURSOR", res.Code)
+}
@@ -0,0 +1,392 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/go-openapi/spec"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// specFile is the swagger spec generated from the annotations of the handlers
+const specFile = "../docs/swagger.json"
+
+// unspecified are the routes of the router that are not part of the api, so the spec
+// does not have them
+var unspecified = []string{"/healthz", "/readyz", "/doc/"}
+
+var (
+	loadSpecOnce sync.Once
+	loadedSpec   *spec.Swagger
+	loadSpecErr  error
+)
+
+func loadSpec() (*spec.Swagger, error) {
+	loadSpecOnce.Do(func() {
+		b, err := ioutil.ReadFile(specFile)
+		if err != nil {
+			loadSpecErr = err
+			return
+		}
+		loadedSpec = &spec.Swagger{}
+		loadSpecErr = json.Unmarshal(b, loadedSpec)
+	})
+
+	return loadedSpec, loadSpecErr
+}
+
+// contract is a transport that checks the requests it sends and every response it gets
+// against the swagger spec, failing the test on drift between the handlers and the docs.
+// Bodies must not have properties the spec does not document, so a field added to a
+// handler without updating its annotations is caught too.
+type contract struct {
+	t    *testing.T
+	spec *spec.Swagger
+	next http.RoundTripper
+}
+
+func newContract(t *testing.T, next http.RoundTripper) *contract {
+	sw, err := loadSpec()
+	if err != nil {
+		t.Fatalf("loading %s: %v", specFile, err)
+	}
+
+	return &contract{t: t, spec: sw, next: next}
+}
+
+func (c *contract) RoundTrip(req *http.Request) (*http.Response, error) {
+	for _, p := range unspecified {
+		if req.URL.Path == p || strings.HasSuffix(p, "/") && strings.HasPrefix(req.URL.Path, p) {
+			return c.next.RoundTrip(req)
+		}
+	}
+
+	route := req.Method + " " + req.URL.Path
+	op, err := c.operation(req.Method, req.URL.Path)
+	if err != nil {
+		c.t.Errorf("%s: %v", route, err)
+		return c.next.RoundTrip(req)
+	}
+
+	var reqBody []byte
+	if req.Body != nil {
+		reqBody, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+	}
+	reqErrs := c.checkRequest(op, req, reqBody)
+
+	resp, err := c.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// tests send requests the spec rules out on purpose, to see them rejected, so only
+	// the requests the handler accepts must match it
+	if resp.StatusCode < http.StatusBadRequest {
+		for _, e := range reqErrs {
+			c.t.Errorf("%s: request: %s", route, e)
+		}
+	}
+
+	resBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(resBody))
+	for _, e := range c.checkResponse(op, resp, resBody) {
+		c.t.Errorf("%s: response %d: %s", route, resp.StatusCode, e)
+	}
+
+	return resp, nil
+}
+
+// operation finds the operation of the spec serving method on path. When several path
+// templates match, the one with the most literal segments wins, as chi routes them.
+func (c *contract) operation(method, path string) (*spec.Operation, error) {
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	best, bestScore := (*spec.PathItem)(nil), -1
+	for tmpl, item := range c.spec.Paths.Paths {
+		score, ok := matchTemplate(strings.Split(strings.Trim(tmpl, "/"), "/"), segs)
+		if !ok || score <= bestScore || operationOf(item, method) == nil {
+			continue
+		}
+		item := item
+		best, bestScore = &item, score
+	}
+	if best == nil {
+		return nil, fmt.Errorf("the spec has no such operation")
+	}
+
+	return operationOf(*best, method), nil
+}
+
+func matchTemplate(tmpl, segs []string) (int, bool) {
+	if len(tmpl) != len(segs) {
+		return 0, false
+	}
+
+	literal := 0
+	for i, s := range tmpl {
+		switch {
+		case strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}"):
+		case s == segs[i]:
+			literal++
+		default:
+			return 0, false
+		}
+	}
+
+	return literal, true
+}
+
+func operationOf(item spec.PathItem, method string) *spec.Operation {
+	switch method {
+	case http.MethodGet:
+		return item.Get
+	case http.MethodPost:
+		return item.Post
+	case http.MethodPut:
+		return item.Put
+	case http.MethodPatch:
+		return item.Patch
+	case http.MethodDelete:
+		return item.Delete
+	}
+
+	return nil
+}
+
+func (c *contract) checkRequest(op *spec.Operation, req *http.Request, body []byte) []string {
+	var errs []string
+	query := req.URL.Query()
+	documented := map[string]bool{}
+	hasBody := len(bytes.TrimSpace(body)) > 0 && string(bytes.TrimSpace(body)) != "null"
+
+	for _, p := range op.Parameters {
+		switch p.In {
+		case "header":
+			if p.Required && req.Header.Get(p.Name) == "" {
+				errs = append(errs, fmt.Sprintf("missing required header %s", p.Name))
+			}
+		case "query":
+			documented[p.Name] = true
+			if p.Required && query.Get(p.Name) == "" {
+				errs = append(errs, fmt.Sprintf("missing required query parameter %s", p.Name))
+			}
+		case "body":
+			if !hasBody {
+				if p.Required {
+					errs = append(errs, "missing required body")
+				}
+				continue
+			}
+			errs = append(errs, c.checkJSON(p.Schema, body)...)
+			hasBody = false
+		}
+	}
+
+	for name := range query {
+		if !documented[name] {
+			errs = append(errs, fmt.Sprintf("undocumented query parameter %s", name))
+		}
+	}
+	if hasBody && req.Header.Get("Content-Type") != "application/x-www-form-urlencoded" {
+		errs = append(errs, "the operation documents no body")
+	}
+
+	return errs
+}
+
+func (c *contract) checkResponse(op *spec.Operation, resp *http.Response, body []byte) []string {
+	if op.Responses == nil {
+		return []string{"the operation documents no responses"}
+	}
+
+	res, ok := op.Responses.StatusCodeResponses[resp.StatusCode]
+	if !ok {
+		if op.Responses.Default == nil {
+			return []string{"undocumented status code"}
+		}
+		res = *op.Responses.Default
+	}
+	if res.Schema == nil {
+		return nil
+	}
+
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		return []string{fmt.Sprintf("content type %q is not json", ct)}
+	}
+
+	return c.checkJSON(res.Schema, body)
+}
+
+func (c *contract) checkJSON(s *spec.Schema, body []byte) []string {
+	var v interface{}
+	d := json.NewDecoder(bytes.NewReader(body))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return []string{fmt.Sprintf("invalid json: %v", err)}
+	}
+
+	var errs []string
+	c.validate(s, v, "$", &errs)
+	return errs
+}
+
+// validate checks v against s, the subset of json schema that swag generates
+func (c *contract) validate(s *spec.Schema, v interface{}, at string, errs *[]string) {
+	s, err := c.resolve(s)
+	if err != nil {
+		*errs = append(*errs, fmt.Sprintf("%s: %v", at, err))
+		return
+	}
+	if v == nil {
+		*errs = append(*errs, fmt.Sprintf("%s: is null", at))
+		return
+	}
+
+	if len(s.Enum) > 0 && !inEnum(s.Enum, v) {
+		*errs = append(*errs, fmt.Sprintf("%s: %v is not one of %v", at, v, s.Enum))
+	}
+
+	typ := ""
+	if len(s.Type) > 0 {
+		typ = s.Type[0]
+	} else if len(s.Properties) > 0 {
+		typ = "object"
+	}
+
+	switch typ {
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			*errs = append(*errs, fmt.Sprintf("%s: %s is not an object", at, kind(v)))
+			return
+		}
+		c.validateObject(s, obj, at, errs)
+	case "array":
+		list, ok := v.([]interface{})
+		if !ok {
+			*errs = append(*errs, fmt.Sprintf("%s: %s is not an array", at, kind(v)))
+			return
+		}
+		if s.Items == nil || s.Items.Schema == nil {
+			return
+		}
+		for i, item := range list {
+			c.validate(s.Items.Schema, item, at+"["+strconv.Itoa(i)+"]", errs)
+		}
+	case "string":
+		if _, ok := v.(string); !ok {
+			*errs = append(*errs, fmt.Sprintf("%s: %s is not a string", at, kind(v)))
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			*errs = append(*errs, fmt.Sprintf("%s: %s is not a boolean", at, kind(v)))
+		}
+	case "integer", "number":
+		n, ok := v.(json.Number)
+		if !ok {
+			*errs = append(*errs, fmt.Sprintf("%s: %s is not a number", at, kind(v)))
+			return
+		}
+		if f, err := n.Float64(); typ == "integer" && (err != nil || f != math.Trunc(f)) {
+			*errs = append(*errs, fmt.Sprintf("%s: %s is not an integer", at, n))
+		}
+	}
+}
+
+// validateObject checks the properties of obj. Null stands for a missing property, as
+// encoding/json writes nil pointers, maps and slices. An object schema without
+// properties, like the one of interface{}, allows any.
+func (c *contract) validateObject(s *spec.Schema, obj map[string]interface{}, at string, errs *[]string) {
+	for _, name := range s.Required {
+		if obj[name] == nil {
+			*errs = append(*errs, fmt.Sprintf("%s: missing required property %s", at, name))
+		}
+	}
+
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		prop, ok := s.Properties[name]
+		switch {
+		case ok:
+			if obj[name] != nil {
+				c.validate(&prop, obj[name], at+"."+name, errs)
+			}
+		case s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil:
+			c.validate(s.AdditionalProperties.Schema, obj[name], at+"."+name, errs)
+		case s.AdditionalProperties != nil && !s.AdditionalProperties.Allows:
+			*errs = append(*errs, fmt.Sprintf("%s: undocumented property %s", at, name))
+		case s.AdditionalProperties == nil && len(s.Properties) > 0:
+			*errs = append(*errs, fmt.Sprintf("%s: undocumented property %s", at, name))
+		}
+	}
+}
+
+// resolve follows the $ref of s, and the single allOf swag wraps a described $ref in
+func (c *contract) resolve(s *spec.Schema) (*spec.Schema, error) {
+	for i := 0; ; i++ {
+		if i > 32 {
+			return nil, fmt.Errorf("too many references")
+		}
+		if len(s.AllOf) == 1 && len(s.Type) == 0 && len(s.Properties) == 0 {
+			s = &s.AllOf[0]
+			continue
+		}
+
+		ref := s.Ref.String()
+		if ref == "" {
+			return s, nil
+		}
+		def, ok := c.spec.Definitions[strings.TrimPrefix(ref, "#/definitions/")]
+		if !ok {
+			return nil, fmt.Errorf("unresolved reference %s", ref)
+		}
+		s = &def
+	}
+}
+
+func inEnum(enum []interface{}, v interface{}) bool {
+	for _, e := range enum {
+		if fmt.Sprint(e) == fmt.Sprint(v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func kind(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	}
+
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,306 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/iamrz1/ab-auth/api"
+	"github.com/iamrz1/ab-auth/api/health"
+	"github.com/iamrz1/ab-auth/config"
+	"github.com/iamrz1/ab-auth/infra"
+	"github.com/iamrz1/ab-auth/infra/cache/cachetest"
+	"github.com/iamrz1/ab-auth/infra/memdb"
+	"github.com/iamrz1/ab-auth/logger"
+	"github.com/iamrz1/ab-auth/migrations"
+	"github.com/iamrz1/ab-auth/model"
+	"github.com/iamrz1/ab-auth/service"
+	"github.com/iamrz1/ab-auth/utils"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// bdLocations are the BD location presets of testServer, a few divisions and a chain of
+// areas under Dhaka
+var bdLocations = []*model.BDLocation{
+	{ID: 1, Name: "Dhaka", NameBn: "ঢাকা", Slug: "dhaka", Type: model.LocationTypeDivision},
+	{ID: 2, Name: "Dhaka", NameBn: "ঢাকা", Slug: "dhaka-district", Parent: "dhaka", Type: model.LocationTypeDistrict, Latitude: 23.8103, Longitude: 90.4125},
+	{ID: 3, Name: "Dhanmondi", NameBn: "ধানমন্ডি", Slug: "dhanmondi", Parent: "dhaka-district", Type: model.LocationTypeSubDistrict},
+	{ID: 4, Name: "Chattogram", NameBn: "চট্টগ্রাম", Slug: "chattogram", Type: model.LocationTypeDivision},
+	{ID: 5, Name: "Khulna", NameBn: "খুলনা", Slug: "khulna", Type: model.LocationTypeDivision},
+	{ID: 6, Name: "Sylhet", NameBn: "সিলেট", Slug: "sylhet", Type: model.LocationTypeDivision},
+	{ID: 7, Name: "Rajshahi", NameBn: "রাজশাহী", Slug: "rajshahi", Type: model.LocationTypeDivision},
+}
+
+// backofficeSecret is the secret of the internal caller of testServer, allowed on every
+// internal endpoint
+const backofficeSecret = "backoffice-secret"
+
+// testServer serves the router of SetupRouter on an in-memory db and cache, with the
+// migrations applied. The requests of its client are checked against the swagger spec.
+func testServer(t *testing.T) *httptest.Server {
+	srv, _ := testServerDB(t)
+	return srv
+}
+
+// testServerDB is testServer along with its db, for tests that set up records the api
+// can not make
+func testServerDB(t *testing.T) (*httptest.Server, infra.DB) {
+	cfg := config.Defaults()
+	cfg.Environment = utils.EnvDevelopment
+	cfg.Database.CustomerCollection = "customer"
+	cfg.Database.MerchantCollection = "merchant"
+	cfg.Database.AddressCollection = "address"
+	cfg.Internal.Callers = config.NewInternalCallerSet([]config.InternalCaller{
+		{Name: "backoffice", Secrets: []string{backofficeSecret}, Endpoints: []string{"*"}},
+	})
+
+	lgr := logger.New(ioutil.Discard, logger.Error)
+	db := memdb.New()
+	_, err := migrations.NewMigrator(db, cfg.Database, lgr).Up(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range bdLocations {
+		if err := db.Insert(context.Background(), cfg.Database.BDLocationCollection, l); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cache, err := cachetest.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svc := service.SetupServiceConfig(cfg, db, cache.Redis, lgr)
+	router, err := api.SetupRouter(cfg, svc, lgr, health.NewChecker())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(router)
+	srv.Client().Transport = newContract(t, srv.Client().Transport)
+	t.Cleanup(func() {
+		srv.Close()
+		cache.Close()
+	})
+
+	return srv, db
+}
+
+type testRes struct {
+	Code int
+	Body struct {
+		Code string                 `json:"code"`
+		Data map[string]interface{} `json:"data"`
+		Meta map[string]string      `json:"meta"`
+	}
+}
+
+func call(t *testing.T, srv *httptest.Server, method, path, token string, body interface{}) testRes {
+	res := testRes{}
+	res.Code = do(t, srv, method, path, token, body, &res.Body)
+
+	return res
+}
+
+// callInternal is call for the internal apis, as the caller with secret
+func callInternal(t *testing.T, srv *httptest.Server, method, path, secret string, body interface{}) testRes {
+	header := http.Header{}
+	if secret != "" {
+		header.Set(utils.KeyForSecretKey, secret)
+	}
+
+	res := testRes{}
+	res.Code = send(t, srv, method, path, header, body, &res.Body)
+
+	return res
+}
+
+// do sends body as json and decodes the response into out, returning the status code
+func do(t *testing.T, srv *httptest.Server, method, path, token string, body, out interface{}) int {
+	header := http.Header{}
+	if token != "" {
+		header.Set(utils.AuthorizationKey, "Bearer "+token)
+	}
+
+	return send(t, srv, method, path, header, body, out)
+}
+
+func send(t *testing.T, srv *httptest.Server, method, path string, header http.Header, body, out interface{}) int {
+	b, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(method, srv.URL+path, bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header = header
+
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(out), path)
+
+	return resp.StatusCode
+}
+
+func signUpCustomer(t *testing.T, srv *httptest.Server, username, password string) {
+	res := call(t, srv, http.MethodPost, "/api/v1/public/customers/signup", "", map[string]string{
+		"username":      username,
+		"full_name":     "Test Customer",
+		"password":      password,
+		"captcha_id":    "test",
+		"captcha_value": utils.DefaultCaptchaValue,
+	})
+	if !assert.Equal(t, http.StatusCreated, res.Code) {
+		t.FailNow()
+	}
+
+	res = call(t, srv, http.MethodPost, "/api/v1/public/customers/verify-signup", "", map[string]string{
+		"username": username,
+		"otp":      res.Body.Meta["otp"],
+	})
+	if !assert.Equal(t, http.StatusOK, res.Code) {
+		t.FailNow()
+	}
+}
+
+func TestRouter_CustomerSession(t *testing.T) {
+	srv := testServer(t)
+	username, password := "01700000001", "secret#pass1"
+	signUpCustomer(t, srv, username, password)
+
+	res := call(t, srv, http.MethodPost, "/api/v1/public/customers/signup", "", map[string]string{
+		"username":      username,
+		"full_name":     "Test Customer",
+		"password":      password,
+		"captcha_id":    "test",
+		"captcha_value": utils.DefaultCaptchaValue,
+	})
+	assert.Equal(t, http.StatusBadRequest, res.Code, "the number is taken")
+
+	res = call(t, srv, http.MethodPost, "/api/v1/public/customers/login", "", map[string]string{
+		"username": username,
+		"password": password,
+	})
+	if !assert.Equal(t, http.StatusOK, res.Code) {
+		t.FailNow()
+	}
+	access, _ := res.Body.Data["access_token"].(string)
+	refresh, _ := res.Body.Data["refresh_token"].(string)
+
+	res = call(t, srv, http.MethodGet, "/api/v1/private/customers/profile", access, nil)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, username, res.Body.Data["username"])
+
+	res = call(t, srv, http.MethodPatch, "/api/v1/private/customers/profile", access, map[string]string{"full_name": "Renamed Customer"})
+	assert.Equal(t, http.StatusOK, res.Code)
+	res = call(t, srv, http.MethodGet, "/api/v1/private/customers/profile", access, nil)
+	assert.Equal(t, "Renamed Customer", res.Body.Data["full_name"])
+
+	res = call(t, srv, http.MethodPost, "/api/v1/private/logout", access, map[string]string{"refresh_token": refresh})
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	res = call(t, srv, http.MethodGet, "/api/v1/private/customers/profile", access, nil)
+	assert.Equal(t, http.StatusUnauthorized, res.Code, "the access token is revoked")
+}
+
+func TestRouter_PrivateNeedsToken(t *testing.T) {
+	srv := testServer(t)
+
+	res := call(t, srv, http.MethodGet, "/api/v1/private/customers/profile", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+
+	res = call(t, srv, http.MethodGet, "/readyz", "", nil)
+	assert.Equal(t, http.StatusOK, res.Code)
+}
+
+func TestRouter_CustomerStatus(t *testing.T) {
+	srv := testServer(t)
+	username, password := "01700000004", "secret#pass4"
+	signUpCustomer(t, srv, username, password)
+
+	res := call(t, srv, http.MethodPost, "/api/v1/public/customers/login", "", map[string]string{
+		"username": username,
+		"password": password,
+	})
+	if !assert.Equal(t, http.StatusOK, res.Code) {
+		t.FailNow()
+	}
+	refresh, _ := res.Body.Data["refresh_token"].(string)
+
+	path := "/api/v1/internal/customers/" + username + "/status"
+	res = callInternal(t, srv, http.MethodPut, path, "", map[string]string{"status": utils.StatusBlocked, "reason": "fraud"})
+	assert.Equal(t, http.StatusUnauthorized, res.Code, "only internal callers change statuses")
+	res = callInternal(t, srv, http.MethodPut, path, backofficeSecret, map[string]string{"status": "gone", "reason": "fraud"})
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+	assert.Equal(t, "INVALID_STATUS", res.Body.Code)
+
+	res = callInternal(t, srv, http.MethodPut, path, backofficeSecret, map[string]string{"status": utils.StatusBlocked, "reason": "fraud"})
+	if !assert.Equal(t, http.StatusOK, res.Code) {
+		t.FailNow()
+	}
+	assert.Equal(t, utils.StatusBlocked, res.Body.Data["status"])
+
+	res = call(t, srv, http.MethodGet, "/api/v1/private/customers/refresh-token", refresh, nil)
+	assert.Equal(t, http.StatusForbidden, res.Code)
+	assert.Equal(t, "ACCOUNT_BLOCKED", res.Body.Code)
+
+	res = callInternal(t, srv, http.MethodPut, path, backofficeSecret, map[string]string{"status": utils.StatusActive, "reason": "cleared"})
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, utils.StatusActive, res.Body.Data["status"])
+}
+
+func TestRouter_RestoreCustomer(t *testing.T) {
+	srv, db := testServerDB(t)
+	username, password := "01700000005", "secret#pass5"
+	signUpCustomer(t, srv, username, password)
+
+	path := "/api/v1/internal/customers/" + username + "/restore"
+	res := callInternal(t, srv, http.MethodPost, path, backofficeSecret, nil)
+	assert.Equal(t, http.StatusBadRequest, res.Code, "the customer is not deleted")
+	assert.Equal(t, "NOT_FOUND", res.Body.Code)
+
+	_, err := db.Update(context.Background(), "customer", bson.M{"username": username}, bson.M{"is_deleted": true})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	res = callInternal(t, srv, http.MethodPost, path, "", nil)
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+	res = callInternal(t, srv, http.MethodPost, path, backofficeSecret, nil)
+	if !assert.Equal(t, http.StatusOK, res.Code) {
+		t.FailNow()
+	}
+	assert.Equal(t, username, res.Body.Data["username"])
+
+	res = callInternal(t, srv, http.MethodPost, "/api/v1/internal/customers/01700000006/restore", backofficeSecret, nil)
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+	assert.Equal(t, "NOT_FOUND", res.Body.Code)
+
+	res = call(t, srv, http.MethodPost, "/api/v1/public/customers/login", "", map[string]string{
+		"username": username,
+		"password": password,
+	})
+	assert.Equal(t, http.StatusOK, res.Code, "the restored customer can log in")
+}
+
+func TestRouter_MetricsNotPublic(t *testing.T) {
+	srv := testServer(t)
+
+	// metrics are served on their own port, the api router does not have them
+	resp, err := http.Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
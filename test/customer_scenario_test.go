@@ -0,0 +1,112 @@
+package test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+type addressListRes struct {
+	Code string                   `json:"code"`
+	Data []map[string]interface{} `json:"data"`
+}
+
+// TestContract_CustomerScenario replays the life of a customer from sign up to address
+// book. The contract of testServer checks every request and response against the spec.
+func TestContract_CustomerScenario(t *testing.T) {
+	srv := testServer(t)
+	username, password := "01700000002", "secret#pass2"
+	signUpCustomer(t, srv, username, password)
+
+	res := call(t, srv, http.MethodPost, "/api/v1/public/customers/login", "", map[string]string{
+		"username": username,
+		"password": password,
+	})
+	if !assert.Equal(t, http.StatusOK, res.Code) {
+		t.FailNow()
+	}
+	refresh, _ := res.Body.Data["refresh_token"].(string)
+
+	res = call(t, srv, http.MethodGet, "/api/v1/private/customers/refresh-token", refresh, nil)
+	if !assert.Equal(t, http.StatusOK, res.Code) {
+		t.FailNow()
+	}
+	access, _ := res.Body.Data["access_token"].(string)
+
+	res = call(t, srv, http.MethodGet, "/api/v1/private/customers/profile", access, nil)
+	assert.Equal(t, http.StatusOK, res.Code)
+	res = call(t, srv, http.MethodGet, "/api/v1/private/customers/verify-token", access, nil)
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	res = call(t, srv, http.MethodPost, "/api/v1/public/customers/signup", "", map[string]string{"username": "01700000003"})
+	assert.Equal(t, http.StatusBadRequest, res.Code, "errors are documented too")
+
+	list := addressListRes{}
+	code := do(t, srv, http.MethodPost, "/api/v1/private/customers/address", access, map[string]interface{}{
+		"phone_number":  username,
+		"full_name":     "Test Customer",
+		"division":      "Dhaka",
+		"division_slug": "dhaka",
+		"district_slug": "dhaka-district",
+		"address":       "House 1, Road 2",
+		"latitude":      23.7461,
+		"longitude":     90.3742,
+	}, &list)
+	if !assert.Equal(t, http.StatusCreated, code) || !assert.Len(t, list.Data, 1) {
+		t.FailNow()
+	}
+	first, _ := list.Data[0]["id"].(string)
+
+	list = addressListRes{}
+	code = do(t, srv, http.MethodPost, "/api/v1/private/customers/address", access, map[string]interface{}{
+		"phone_number":      username,
+		"full_name":         "Test Customer",
+		"division":          "Dhaka",
+		"division_slug":     "dhaka",
+		"district_slug":     "dhaka-district",
+		"sub_district_slug": "dhanmondi",
+	}, &list)
+	if !assert.Equal(t, http.StatusCreated, code) || !assert.Len(t, list.Data, 2) {
+		t.FailNow()
+	}
+	second := ""
+	for _, a := range list.Data {
+		if id, _ := a["id"].(string); id != first {
+			second = id
+		}
+	}
+
+	list = addressListRes{}
+	code = do(t, srv, http.MethodPatch, "/api/v1/private/customers/address/"+second, access, map[string]interface{}{
+		"address": "House 3, Road 4",
+	}, &list)
+	assert.Equal(t, http.StatusOK, code)
+
+	list = addressListRes{}
+	code = do(t, srv, http.MethodPost, "/api/v1/private/customers/address/primary/"+second, access, nil, &list)
+	assert.Equal(t, http.StatusOK, code)
+
+	res = call(t, srv, http.MethodGet, "/api/v1/private/customers/address/primary", access, nil)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, second, res.Body.Data["id"])
+
+	list = addressListRes{}
+	code = do(t, srv, http.MethodDelete, "/api/v1/private/customers/address/"+first, access, nil, &list)
+	assert.Equal(t, http.StatusOK, code)
+
+	list = addressListRes{}
+	code = do(t, srv, http.MethodGet, "/api/v1/private/customers/address/all", access, nil, &list)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, list.Data, 1)
+
+	list = addressListRes{}
+	code = do(t, srv, http.MethodPost, "/api/v1/private/customers/address", access, map[string]interface{}{
+		"phone_number":  username,
+		"full_name":     "Test Customer",
+		"division":      "Dhaka",
+		"division_slug": "dhaka",
+		"district_slug": "nowhere",
+	}, &list)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "INVALID_LOCATION", list.Code)
+}
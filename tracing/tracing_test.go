@@ -0,0 +1,78 @@
+package tracing
+
+import (
+	"context"
+	"github.com/go-chi/chi"
+	"github.com/iamrz1/ab-auth/config"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func record(t *testing.T) *tracetest.SpanRecorder {
+	_, err := Setup(context.Background(), config.TracingConfig{Exporter: ExporterNone, SamplePercent: 100}, "test")
+	assert.NoError(t, err)
+
+	sr := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+
+	return sr
+}
+
+func TestMiddleware_ContinuesInboundTrace(t *testing.T) {
+	sr := record(t)
+
+	var traceID string
+	sub := chi.NewRouter()
+	sub.Get("/customers/{username}", func(w http.ResponseWriter, r *http.Request) {
+		traceID = trace.SpanContextFromContext(r.Context()).TraceID().String()
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Mount("/api/v1", sub)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/customers/alice", nil)
+	req.Header.Set("traceparent", traceParent)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID)
+
+	spans := sr.Ended()
+	assert.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "GET /api/v1/customers/{username}", span.Name())
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+	assert.Contains(t, span.Attributes(), semconv.HTTPStatusCodeKey.Int(http.StatusInternalServerError))
+	assert.Equal(t, codes.Error, span.Status().Code)
+}
+
+func TestMiddleware_StartsTraceWithoutHeader(t *testing.T) {
+	sr := record(t)
+
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Get("/ping", func(w http.ResponseWriter, r *http.Request) {})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nowhere", nil))
+
+	spans := sr.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "HTTP GET", spans[0].Name(), "unmatched requests keep the generic name")
+	assert.False(t, spans[0].Parent().IsValid())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code, "a 404 is not a failure of the server")
+}
+
+func TestSetup_UnknownExporter(t *testing.T) {
+	_, err := Setup(context.Background(), config.TracingConfig{Exporter: "zipkin"}, "test")
+	assert.Error(t, err)
+}
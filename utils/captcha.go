@@ -2,43 +2,52 @@ package utils
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	rest_error "github.com/iamrz1/ab-auth/error"
+	"github.com/iamrz1/ab-auth/i18n"
+	"github.com/iamrz1/ab-auth/logger"
+	"github.com/iamrz1/ab-auth/metrics"
 	"net/http"
-	"os"
 	"time"
 )
 
 // VerifyCaptcha verifies captcha value for an id
-func VerifyCaptcha(id, value string) (bool, error) {
-	sendError := fmt.Errorf("%s", "Captcha veirification failed")
+func VerifyCaptcha(id, value string) (ok bool, err error) {
+	defer func() {
+		if !ok {
+			metrics.CaptchaFailures.Inc()
+		}
+	}()
+
+	sendError := rest_error.NewCodedValidationError(rest_error.CodeCaptchaFailed, i18n.MsgCaptchaFailed, nil)
 
 	client := http.Client{Timeout: time.Minute * 2}
 
-	captchaBaseUrl := os.Getenv("CAPTCHA_BASE_URL")
+	captchaBaseUrl := appConfig.Captcha.BaseURL
 
 	b, err := json.Marshal(&map[string]string{"id": id, "value": value})
 
 	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/api/v1/verify-captcha", captchaBaseUrl), bytes.NewReader(b))
 	if err != nil {
-		log.Println(err)
+		logger.Errorln(context.Background(), "VerifyCaptcha", err.Error())
 		return false, sendError
 	}
 
-	req.Header.Set("Secret-Key", os.Getenv("EVALY_API_SECRET_KEY"))
+	req.Header.Set("Secret-Key", captchaSecret())
 
-	log.Println("sending captcha verification query:")
+	logger.Debugln(context.Background(), "VerifyCaptcha", "sending captcha verification query")
 	// send the request
 	resp, err := client.Do(req)
 	if err != nil {
-		log.Println(err)
+		logger.Errorln(context.Background(), "VerifyCaptcha", err.Error())
 		return false, sendError
 	}
-	log.Println("query sent: reading resp: status:", resp.StatusCode)
+	logger.Debugf(context.Background(), "VerifyCaptcha", "query sent: reading resp: status: %d", resp.StatusCode)
 
 	if resp.StatusCode != http.StatusOK {
-		log.Println(err)
+		logger.Errorln(context.Background(), "VerifyCaptcha", err.Error())
 		return false, sendError
 	}
 
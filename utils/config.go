@@ -0,0 +1,11 @@
+package utils
+
+import "github.com/iamrz1/ab-auth/config"
+
+// appConfig is read by the helpers of this package, it holds the defaults until Configure is called
+var appConfig = config.Defaults()
+
+// Configure hands the loaded config to the helpers of this package
+func Configure(cfg *config.AppConfig) {
+	appConfig = cfg
+}
@@ -4,6 +4,7 @@ import "time"
 
 const (
 	EnvProduction                = "prod"
+	EnvDevelopment               = "dev"
 	StatusActive                 = "active"
 	StatusBlocked                = "blocked"
 	StatusInactive               = "inactive"
@@ -25,13 +26,30 @@ const (
 	RefreshTokenExpirationPeriod = time.Hour * 24 * 7
 	KeyForSecretKey              = "Secret-Key"
 	RealUserIpKey                = "X-Original-Forwarded-For"
-	UsernameKey                  = "username"
 	DefaultHashingIteration      = 1500
 	TryAgainMessage              = "Please try again later"
 	MaxAddressAllowed            = 5
 	PasswordPattern              = "^([a-zA-z0-9!@#%*_=+/-]*)$"
-	accessTokenKey               = "jdskhhiuewfhosfkaskfhajksfeiwhfuiowehfiwejdkfewudhuiewhjfdiu"
-	refreshTokenKey              = "fdshfjdshfjhdsjlfhuoashfuherifherhfuqheruifhiquwhfukwjnfjiwhl"
 	DefaultCaptchaValue          = "11111"
 	LastResetEventAtKey          = "last_reset_at"
+	ErasureJobInterval           = time.Hour
+	RevokedTokenKeyPrefix        = "revoked_token_"
+	// RevocationCacheSize and RevocationCacheTTL bound the in-process cache of denylist
+	// lookups. Revocations are announced through RevokedTokenChannel and evict the tokens
+	// found not revoked, the TTL only covers lost messages.
+	RevocationCacheSize = 10000
+	RevocationCacheTTL  = time.Second * 10
+	RevokedTokenChannel = "revoked_token_events"
+	// dev keys stand in for signing keys missing outside production, never use them elsewhere
+	devAccessTokenKey  = "jdskhhiuewfhosfkaskfhajksfeiwhfuiowehfiwejdkfewudhuiewhjfdiu"
+	devRefreshTokenKey = "fdshfjdshfjhdsjlfhuoashfuherifherhfuqheruifhiquwhfukwjnfjiwhl"
+	devOTPHashKey      = "uJ9eZCNwg9NxJ2rmY6nN"
+	// minSigningKeyLength keeps guessable signing keys out of production
+	minSigningKeyLength = 32
+	// LastResetCacheSize and LastResetCacheTTL bound the in-process cache of last resets.
+	// Entries are evicted through LastResetChannel, the TTL only covers lost messages.
+	LastResetCacheSize   = 10000
+	LastResetCacheTTL    = time.Minute
+	LastResetChannel     = "last_reset_at_events"
+	LastResetLoadTimeout = time.Second * 5
 )
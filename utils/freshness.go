@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redis"
+	infraCache "github.com/iamrz1/ab-auth/infra/cache"
+	"github.com/iamrz1/ab-auth/logger"
+)
+
+// LastResetLoader reads the last reset of username from the database. found is false when
+// username has no account.
+type LastResetLoader func(ctx context.Context, username string) (lastResetAt int64, found bool, err error)
+
+var (
+	// lastResetCache holds last reset times per username, sparing a Redis round trip per request
+	lastResetCache  = infraCache.NewLRU(LastResetCacheSize)
+	lastResetLoader LastResetLoader
+)
+
+// SetLastResetLoader registers the database fallback used when Redis does not know the
+// last reset of a user, e.g. after it was flushed
+func SetLastResetLoader(l LastResetLoader) {
+	lastResetLoader = l
+}
+
+// IsTokenFresh reports whether a token issued at issueTime was issued after the last reset
+// of username. It fails closed, a token is taken as stale when the last reset is unknown.
+func IsTokenFresh(ctx context.Context, username string, issueTime int64) bool {
+	lastResetAt, err := GetLastResetAt(ctx, username)
+	if err != nil {
+		return false
+	}
+
+	return lastResetAt <= issueTime
+}
+
+// GetLastResetAt returns the last reset of username from the in-process cache, then Redis,
+// then the database. Values read from the database are written back to Redis.
+func GetLastResetAt(ctx context.Context, username string) (int64, error) {
+	if v, ok := lastResetCache.Get(username); ok {
+		return v.(int64), nil
+	}
+
+	lastResetAt, err := infraCache.ClientWithContext(ctx).Get(lastResetKey(username)).Int64()
+	if err == nil {
+		lastResetCache.Set(username, lastResetAt, LastResetCacheTTL)
+		return lastResetAt, nil
+	}
+	if err != redis.Nil {
+		logger.Errorln(ctx, "GetLastResetAt", err.Error())
+	}
+	if lastResetLoader == nil {
+		return 0, err
+	}
+
+	loadCtx, cancel := context.WithTimeout(ctx, LastResetLoadTimeout)
+	defer cancel()
+
+	lastResetAt, found, lErr := lastResetLoader(loadCtx, username)
+	if lErr != nil {
+		logger.Errorln(ctx, "GetLastResetAt", lErr.Error())
+		return 0, lErr
+	}
+	if !found {
+		return 0, fmt.Errorf("no last reset known for %s", username)
+	}
+
+	if err == redis.Nil {
+		// SetNX leaves a reset that was set while the database was read in place
+		if wErr := infraCache.ClientWithContext(ctx).SetNX(lastResetKey(username), lastResetAt, 0).Err(); wErr != nil {
+			logger.Errorln(ctx, "GetLastResetAt", wErr.Error())
+		}
+	}
+	lastResetCache.Set(username, lastResetAt, LastResetCacheTTL)
+
+	return lastResetAt, nil
+}
+
+// SetLastResetAt records a reset of username, making tokens issued before in stale, and
+// tells the other instances to drop their cached value
+func SetLastResetAt(ctx context.Context, username string, in int64) {
+	client := infraCache.ClientWithContext(ctx)
+	err := client.Set(lastResetKey(username), in, 0).Err()
+	if err != nil {
+		logger.Errorln(ctx, "SetLastResetAt", err.Error())
+	}
+	lastResetCache.Set(username, in, LastResetCacheTTL)
+
+	err = client.Publish(LastResetChannel, username).Err()
+	if err != nil {
+		logger.Errorln(ctx, "SetLastResetAt", err.Error())
+	}
+}
+
+// WatchLastResets evicts cached last resets announced by SetLastResetAt on any instance
+// until ctx is done. Messages missed while disconnected are covered by LastResetCacheTTL.
+func WatchLastResets(ctx context.Context) {
+	pubsub := infraCache.Client().Subscribe(LastResetChannel)
+	defer pubsub.Close()
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			lastResetCache.Delete(msg.Payload)
+		}
+	}
+}
+
+func lastResetKey(username string) string {
+	return fmt.Sprintf("%s_%s", username, LastResetEventAtKey)
+}
@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	infraCache "github.com/iamrz1/ab-auth/infra/cache"
+	"github.com/iamrz1/ab-auth/infra/cache/cachetest"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// stubLoader is a LastResetLoader over values, counting its calls. before runs ahead of
+// each load, like writes racing with it.
+type stubLoader struct {
+	values map[string]int64
+	err    error
+	before func()
+	calls  int
+}
+
+func (l *stubLoader) load(ctx context.Context, username string) (int64, bool, error) {
+	l.calls++
+	if l.before != nil {
+		l.before()
+	}
+	if l.err != nil {
+		return 0, false, l.err
+	}
+
+	v, ok := l.values[username]
+	return v, ok, nil
+}
+
+// useFreshness runs the last resets on an in-memory redis with loader as the database,
+// starting from an empty in-process cache
+func useFreshness(t *testing.T, loader *stubLoader) *cachetest.Cache {
+	cache, err := cachetest.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldLoader := lastResetLoader
+	SetLastResetLoader(loader.load)
+	lastResetCache = infraCache.NewLRU(LastResetCacheSize)
+	t.Cleanup(func() {
+		SetLastResetLoader(oldLoader)
+		lastResetCache = infraCache.NewLRU(LastResetCacheSize)
+		cache.Close()
+	})
+
+	return cache
+}
+
+func TestGetLastResetAt_CacheHit(t *testing.T) {
+	loader := &stubLoader{}
+	cache := useFreshness(t, loader)
+	ctx := context.Background()
+
+	assert.NoError(t, cache.Client.Set(lastResetKey("01700000001"), 100, 0).Err())
+	v, err := GetLastResetAt(ctx, "01700000001")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 100, v)
+
+	// redis is only read again once the in-process value expires or is evicted
+	assert.NoError(t, cache.Client.Set(lastResetKey("01700000001"), 200, 0).Err())
+	v, err = GetLastResetAt(ctx, "01700000001")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 100, v)
+	assert.Equal(t, 0, loader.calls, "the database is not read on a hit")
+}
+
+func TestGetLastResetAt_LoaderFallback(t *testing.T) {
+	loader := &stubLoader{values: map[string]int64{"01700000001": 300}}
+	cache := useFreshness(t, loader)
+	ctx := context.Background()
+
+	v, err := GetLastResetAt(ctx, "01700000001")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 300, v)
+	assert.Equal(t, 1, loader.calls)
+
+	stored, err := cache.Client.Get(lastResetKey("01700000001")).Int64()
+	assert.NoError(t, err, "the value read from the database warms redis")
+	assert.EqualValues(t, 300, stored)
+
+	_, err = GetLastResetAt(ctx, "01700000001")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, loader.calls, "the loaded value is cached in process")
+
+	_, err = GetLastResetAt(ctx, "01700000002")
+	assert.Error(t, err, "users without an account have no last reset")
+	assert.False(t, IsTokenFresh(ctx, "01700000002", time.Now().Unix()))
+}
+
+func TestGetLastResetAt_LoaderError(t *testing.T) {
+	loader := &stubLoader{err: errors.New("mongo is down")}
+	useFreshness(t, loader)
+
+	_, err := GetLastResetAt(context.Background(), "01700000001")
+	assert.Error(t, err)
+	assert.False(t, IsTokenFresh(context.Background(), "01700000001", time.Now().Unix()), "freshness fails closed")
+}
+
+func TestGetLastResetAt_KeepsNewerReset(t *testing.T) {
+	loader := &stubLoader{values: map[string]int64{"01700000001": 300}}
+	cache := useFreshness(t, loader)
+
+	// a reset is recorded while the database is read, rewarming must not undo it
+	loader.before = func() {
+		cache.Client.Set(lastResetKey("01700000001"), 500, 0)
+	}
+	_, err := GetLastResetAt(context.Background(), "01700000001")
+	assert.NoError(t, err)
+
+	stored, err := cache.Client.Get(lastResetKey("01700000001")).Int64()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 500, stored)
+}
+
+func TestWatchLastResets_Evicts(t *testing.T) {
+	cache := useFreshness(t, &stubLoader{})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		WatchLastResets(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	SetLastResetAt(ctx, "01700000001", 100)
+	v, err := GetLastResetAt(ctx, "01700000001")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 100, v)
+
+	// another instance records a reset, its message evicts the value cached here
+	assert.NoError(t, cache.Client.Set(lastResetKey("01700000001"), 200, 0).Err())
+	assert.Eventually(t, func() bool {
+		cache.Client.Publish(LastResetChannel, "01700000001")
+		v, err := GetLastResetAt(ctx, "01700000001")
+		return err == nil && v == 200
+	}, time.Second, 10*time.Millisecond)
+}
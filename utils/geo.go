@@ -0,0 +1,38 @@
+package utils
+
+import "math"
+
+// Bounding box of Bangladesh, with a little slack around the borders
+const (
+	BDMinLatitude  = 20.5
+	BDMaxLatitude  = 26.7
+	BDMinLongitude = 88.0
+	BDMaxLongitude = 92.7
+
+	// MaxDistanceFromDistrictKm is how far an address may lie from the centroid of its district
+	MaxDistanceFromDistrictKm = 100
+
+	earthRadiusKm = 6371.0
+)
+
+// IsInBangladesh reports whether the coordinate falls within the bounding box of Bangladesh
+func IsInBangladesh(lat, lng float64) bool {
+	return lat >= BDMinLatitude && lat <= BDMaxLatitude && lng >= BDMinLongitude && lng <= BDMaxLongitude
+}
+
+// DistanceKm returns the great-circle distance between two coordinates
+func DistanceKm(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := func(d float64) float64 { return d * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+// IsValidCoordinate reports whether lat and lng are within the ranges of latitude and longitude
+func IsValidCoordinate(lat, lng float64) bool {
+	return lat >= -90 && lat <= 90 && lng >= -180 && lng <= 180
+}
@@ -1,12 +1,14 @@
 package utils
 
 import (
+	"context"
 	"fmt"
 	"github.com/dgrijalva/jwt-go"
-	infraCache "github.com/iamrz1/ab-auth/infra/cache"
-	"log"
-	"os"
-	"strconv"
+	"github.com/google/uuid"
+	rest_error "github.com/iamrz1/ab-auth/error"
+	"github.com/iamrz1/ab-auth/i18n"
+	"net/http"
+	"strings"
 	"time"
 )
 
@@ -18,34 +20,16 @@ type claims struct {
 }
 
 func GenerateTokens(username, role, usertype string) (string, string) {
-	t := time.Now()
-	accessTokenValidityStr := os.Getenv("ACCESS_TOKEN_EXPIRATION_MINUTES")
-	//refreshTokenValidityStr := os.Getenv("REFRESH_TOKEN_VALIDITY")
-	accessTokenValidity, err := strconv.Atoi(accessTokenValidityStr)
-	if err != nil {
-		log.Println("ACCESS_TOKEN_EXPIRATION_MINUTES variable is not found in env")
-		//return token, err
-		accessTokenValidity = 30
-	}
-
-	refreshTokenValidityStr := os.Getenv("REFRESH_TOKEN_EXPIRATION_MINUTES")
-	//refreshTokenValidityStr := os.Getenv("REFRESH_TOKEN_VALIDITY")
-	refreshTokenValidity, err := strconv.Atoi(refreshTokenValidityStr)
-	if err != nil {
-		log.Println("REFRESH_TOKEN_EXPIRATION_MINUTES variable is not found in env")
-		//return token, err
-		refreshTokenValidity = 7 * 24 * 60
-	}
-
 	now := time.Now().UTC()
-	accessExpTime := now.Add(time.Minute * time.Duration(accessTokenValidity))
-	refreshExpTime := now.Add(time.Minute * time.Duration(refreshTokenValidity))
+	accessExpTime := now.Add(time.Minute * time.Duration(appConfig.Auth.AccessTokenTTLMinutes))
+	refreshExpTime := now.Add(time.Minute * time.Duration(appConfig.Auth.RefreshTokenTTLMinutes))
 	// Create the JWT accessClaims, which includes the username and expiry time
 	accessClaims := &claims{
 		Username: username,
 		Role:     role,
 		UserType: usertype,
 		StandardClaims: jwt.StandardClaims{
+			Id:        uuid.New().String(),
 			IssuedAt:  now.Unix(),
 			ExpiresAt: accessExpTime.Unix(),
 		},
@@ -54,6 +38,7 @@ func GenerateTokens(username, role, usertype string) (string, string) {
 	refreshClaims := &claims{
 		Username: username,
 		StandardClaims: jwt.StandardClaims{
+			Id:        uuid.New().String(),
 			IssuedAt:  now.Unix(),
 			ExpiresAt: refreshExpTime.Unix(),
 		},
@@ -63,56 +48,82 @@ func GenerateTokens(username, role, usertype string) (string, string) {
 	jwtAccessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
 	jwtRefreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
 	// Create the JWT string
-	accessTokenString, _ := jwtAccessToken.SignedString([]byte(accessTokenKey))
-	refreshTokenString, _ := jwtRefreshToken.SignedString([]byte(refreshTokenKey))
-
-	log.Printf("CreateTokens method took: %s \n", time.Now().Sub(t).String())
+	accessTokenString, _ := jwtAccessToken.SignedString(accessTokenKeys.signingKey())
+	refreshTokenString, _ := jwtRefreshToken.SignedString(refreshTokenKeys.signingKey())
 
 	return accessTokenString, refreshTokenString
 
 }
 
+// VerifyToken parses token and checks its signature and expiry. Tokens signed with the key
+// replaced by the last rotation are still accepted.
 func VerifyToken(token string, isRefresh bool) (*claims, error) {
-	thisClaims := &claims{}
-	secretKey := accessTokenKey
+	ring := accessTokenKeys
 	if isRefresh {
-		secretKey = refreshTokenKey
+		ring = refreshTokenKeys
 	}
 
-	// Parse the JWT string and store the result in `claims`.
-	// Note that we are passing the key in this method as well. This method will return an error
-	// if the token is invalid (if it has expired according to the expiry time we set on sign in),
-	// or if the signature does not match
-	tkn, err := jwt.ParseWithClaims(token, thisClaims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(secretKey), nil
-	})
-	if err != nil {
-		return nil, err
+	var err error
+	for _, key := range ring.verificationKeys() {
+		thisClaims := &claims{}
+		// Parse the JWT string and store the result in `claims`.
+		// This method will return an error if the token is invalid (if it has expired
+		// according to the expiry time we set on sign in), or if the signature does not match
+		var tkn *jwt.Token
+		tkn, err = jwt.ParseWithClaims(token, thisClaims, func(token *jwt.Token) (interface{}, error) {
+			return key, nil
+		})
+		if ve, ok := err.(*jwt.ValidationError); ok && ve.Errors&jwt.ValidationErrorSignatureInvalid != 0 {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !tkn.Valid {
+			return nil, fmt.Errorf("%s", i18n.MsgInvalidToken)
+		}
+
+		return thisClaims, nil
 	}
-	if !tkn.Valid {
-		return nil, fmt.Errorf("%s", "Invalid token")
+
+	return nil, err
+}
+
+// TokenVerificationError converts an error returned by VerifyToken into a 401 carrying
+// AUTH_TOKEN_EXPIRED for expired tokens and AUTH_TOKEN_INVALID otherwise
+func TokenVerificationError(err error) error {
+	if ve, ok := err.(*jwt.ValidationError); ok && ve.Errors&jwt.ValidationErrorExpired != 0 {
+		return rest_error.NewCodedError(http.StatusUnauthorized, rest_error.CodeAuthTokenExpired, i18n.MsgTokenExpired)
 	}
 
-	return thisClaims, nil
+	return rest_error.NewCodedError(http.StatusUnauthorized, rest_error.CodeAuthTokenInvalid, i18n.MsgInvalidToken)
 }
 
-func GetLastResetAt(username string) (int64, error) {
-	scmd := infraCache.Client().Get(fmt.Sprintf("%s_%s", username, LastResetEventAtKey))
-	err := scmd.Err()
+// VerifyFreshToken verifies token like VerifyToken, then checks that it was issued after
+// the last password reset or status change of its user and was not revoked by a logout.
+// Errors are coded 401s.
+func VerifyFreshToken(ctx context.Context, token string, isRefresh bool) (*claims, error) {
+	c, err := VerifyToken(token, isRefresh)
 	if err != nil {
-		log.Println(err)
-		return 0, err
+		return nil, TokenVerificationError(err)
 	}
 
-	lastResetAt, err := scmd.Int64()
-	if err != nil {
-		log.Println("Unsupported value as last_reset_at")
-		return 0, fmt.Errorf("%s", "Unsupported value as last_reset_at")
+	if !IsTokenFresh(ctx, c.Username, c.IssuedAt) {
+		return nil, rest_error.NewCodedError(http.StatusUnauthorized, rest_error.CodeAuthSessionExpired, i18n.MsgSessionExpired)
 	}
 
-	return lastResetAt, nil
+	if IsTokenRevoked(ctx, TokenID(c, token)) {
+		return nil, rest_error.NewCodedError(http.StatusUnauthorized, rest_error.CodeAuthTokenRevoked, i18n.MsgTokenRevoked)
+	}
+
+	return c, nil
 }
 
-func SetLastResetAt(username string, in int64) {
-	infraCache.Client().Set(fmt.Sprintf("%s_%s", username, LastResetEventAtKey), in, 0)
+// StripBearer removes the Bearer scheme from an authorization header value
+func StripBearer(token string) string {
+	if strings.HasPrefix(token, "Bearer ") {
+		return strings.TrimPrefix(token, "Bearer ")
+	}
+
+	return strings.TrimPrefix(token, "bearer ")
 }
@@ -3,12 +3,19 @@ package utils
 import (
 	"encoding/json"
 	rest_error "github.com/iamrz1/ab-auth/error"
-	"log"
+	"github.com/iamrz1/ab-auth/i18n"
+	"github.com/iamrz1/ab-auth/logger"
+	"github.com/iamrz1/ab-auth/metrics"
 	"net/http"
-	"os"
+	"strings"
 	"time"
 )
 
+const (
+	ProblemJSONContentType = "application/problem+json"
+	problemTypePrefix      = "urn:ab-auth:error:"
+)
+
 type Meta struct {
 	Count    int64 `json:"count"`
 	PageSize int64 `json:"page_size"`
@@ -17,6 +24,7 @@ type Meta struct {
 // Response serializer util
 type Response struct {
 	Status    string      `json:"status,omitempty"`
+	Code      string      `json:"code,omitempty"`
 	Message   string      `json:"message,omitempty"`
 	Success   bool        `json:"success"`
 	Meta      interface{} `json:"meta,omitempty"`
@@ -25,52 +33,65 @@ type Response struct {
 	Timestamp string      `json:"timestamp,omitempty"`
 }
 
-func ServeJSONObject(w http.ResponseWriter, code int, message string, data interface{}, meta interface{}, success bool) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-	w.Header().Add("Access-Control-Allow-Origin", "*")
+// Problem is an RFC 7807 problem details document
+type Problem struct {
+	Type     string                  `json:"type"`
+	Title    string                  `json:"title"`
+	Status   int                     `json:"status"`
+	Detail   string                  `json:"detail,omitempty"`
+	Instance string                  `json:"instance,omitempty"`
+	Code     string                  `json:"code"`
+	Errors   []rest_error.FieldError `json:"errors,omitempty"`
+}
 
-	var resp interface{}
-	type EmptyObject struct{}
+type EmptyObject struct{}
+
+func ServeJSONObject(w http.ResponseWriter, code int, message string, data interface{}, meta interface{}, success bool) error {
 	if data == nil {
 		data = EmptyObject{}
 	}
 
-	resp = &Response{
-		Status:    http.StatusText(code),
-		Message:   message,
-		Success:   success,
-		Data:      data,
-		Meta:      meta,
-		Timestamp: time.Now().Format(ISOLayout),
-	}
-
-	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		return err
-	}
-
-	return nil
+	return serveResponse(w, code, &Response{
+		Message: message,
+		Success: success,
+		Data:    data,
+		Meta:    meta,
+	})
 }
 
-func ServeJSONList(w http.ResponseWriter, code int, message string, list interface{}, meta interface{}, success bool) error {
+// ServeOAuthJSON writes body as is, without the response envelope, as the OAuth RFCs
+// define the top level of their responses. Token data must not be cached.
+func ServeOAuthJSON(w http.ResponseWriter, code int, body interface{}) error {
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
 	w.WriteHeader(code)
-	w.Header().Add("Access-Control-Allow-Origin", "*")
 
-	var resp interface{}
-	type EmptyObject struct{}
+	return json.NewEncoder(w).Encode(body)
+}
+
+func ServeJSONList(w http.ResponseWriter, code int, message string, list interface{}, meta interface{}, success bool) error {
 	if list == nil {
 		list = []EmptyObject{}
 	}
 
-	resp = &Response{
-		Status:    http.StatusText(code),
-		Message:   message,
-		Success:   success,
-		Data:      list,
-		Meta:      meta,
-		Timestamp: time.Now().Format(ISOLayout),
-	}
+	return serveResponse(w, code, &Response{
+		Message: message,
+		Success: success,
+		Data:    list,
+		Meta:    meta,
+	})
+}
+
+func serveResponse(w http.ResponseWriter, code int, resp *Response) error {
+	resp.Message = i18n.T(ResponseLanguage(w), resp.Message)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Header().Add("Access-Control-Allow-Origin", "*")
+
+	resp.Status = http.StatusText(code)
+	resp.Timestamp = time.Now().Format(ISOLayout)
 
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
 		return err
@@ -79,42 +100,130 @@ func ServeJSONList(w http.ResponseWriter, code int, message string, list interfa
 	return nil
 }
 
-func HandleObjectError(w http.ResponseWriter, err error) {
-	errMeta := map[string]string{}
-	if os.Getenv("ENV") != "prod" {
-		errMeta["error"] = err.Error()
-	}
+func HandleObjectError(w http.ResponseWriter, r *http.Request, err error) {
+	handleError(w, r, err, false)
+}
+
+func HandleListError(w http.ResponseWriter, r *http.Request, err error) {
+	logger.Errorln(r.Context(), "HandleListError", "service error: "+err.Error())
+	handleError(w, r, err, true)
+}
+
+// handleError writes err as a json response, or as problem+json when the client asks for it
+func handleError(w http.ResponseWriter, r *http.Request, err error, isList bool) {
+	var errMeta map[string]string
+	var fields []rest_error.FieldError
+	lang := ResponseLanguage(w)
+	status := http.StatusInternalServerError
+	errCode := rest_error.CodeInternal
+	message := i18n.T(lang, i18n.MsgSomethingWentWrong)
 
 	switch v := err.(type) {
 	case rest_error.ValidationError:
-		ServeJSONObject(w, http.StatusBadRequest, v.ErrorMessage(), nil, nil, false)
-		return
+		status = http.StatusBadRequest
+		errCode = v.ErrorCode()
+		message = v.LocalizedMessage(lang)
+		fields = localizeFieldErrors(lang, v.FieldErrors())
 	case rest_error.GenericHttpError:
-		ServeJSONObject(w, v.Code(), v.Error(), nil, nil, false)
-		return
+		status = v.Code()
+		message = v.LocalizedMessage(lang)
+		errCode = v.ErrorCode()
+		if errCode == "" {
+			errCode = codeForStatus(status)
+		}
 	default:
-		ServeJSONObject(w, http.StatusInternalServerError, "Something went wrong", nil, errMeta, false)
+		if appConfig.Environment != EnvProduction {
+			errMeta = map[string]string{"error": err.Error()}
+		}
+	}
+
+	if status == http.StatusTooManyRequests {
+		metrics.RateLimitRejections.WithLabelValues(errCode).Inc()
+	}
+
+	if wantsProblem(r) {
+		serveProblem(w, r, status, errCode, message, fields)
 		return
 	}
+
+	var data interface{} = EmptyObject{}
+	if isList {
+		data = []EmptyObject{}
+	}
+
+	resp := &Response{
+		Code:    errCode,
+		Message: message,
+		Success: false,
+		Data:    data,
+	}
+	if errMeta != nil {
+		resp.Meta = errMeta
+	}
+	if len(fields) > 0 {
+		resp.Errors = fields
+	}
+
+	serveResponse(w, status, resp)
+}
+
+// ResponseLanguage returns the language the response is written in, as negotiated by
+// the language middleware through the Content-Language header
+func ResponseLanguage(w http.ResponseWriter) string {
+	if lang := w.Header().Get("Content-Language"); i18n.IsSupported(lang) {
+		return lang
+	}
+
+	return i18n.DefaultLanguage
 }
 
-func HandleListError(w http.ResponseWriter, err error) {
-	log.Println("service error: ", err)
+func localizeFieldErrors(lang string, fields []rest_error.FieldError) []rest_error.FieldError {
+	res := make([]rest_error.FieldError, 0, len(fields))
+	for _, f := range fields {
+		res = append(res, rest_error.FieldError{Field: f.Field, Message: i18n.T(lang, f.Message)})
+	}
+
+	return res
+}
 
-	errMeta := map[string]string{}
-	if os.Getenv("ENV") != "prod" {
-		errMeta["error"] = err.Error()
+func serveProblem(w http.ResponseWriter, r *http.Request, status int, errCode, detail string, fields []rest_error.FieldError) {
+	w.Header().Set("Content-Type", ProblemJSONContentType)
+	w.WriteHeader(status)
+
+	p := &Problem{
+		Type:     problemTypePrefix + strings.ToLower(errCode),
+		Title:    rest_error.Title(errCode, status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+		Code:     errCode,
+		Errors:   fields,
 	}
 
-	switch v := err.(type) {
-	case rest_error.ValidationError:
-		ServeJSONList(w, http.StatusBadRequest, v.ErrorMessage(), nil, nil, false)
-		return
-	case rest_error.GenericHttpError:
-		ServeJSONList(w, v.Code(), v.Error(), nil, nil, false)
-		return
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		logger.Errorln(r.Context(), "serveProblem", err.Error())
+	}
+}
+
+// wantsProblem reports whether the client negotiated problem+json error responses
+func wantsProblem(r *http.Request) bool {
+	return r != nil && strings.Contains(r.Header.Get("Accept"), ProblemJSONContentType)
+}
+
+// codeForStatus picks a generic error code for errors that were created without one
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return rest_error.CodeValidationFailed
+	case http.StatusUnauthorized:
+		return rest_error.CodeAuthTokenInvalid
+	case http.StatusForbidden:
+		return rest_error.CodeAuthForbidden
+	case http.StatusNotFound:
+		return rest_error.CodeNotFound
+	case http.StatusTooManyRequests:
+		return rest_error.CodeRateLimited
 	default:
-		ServeJSONList(w, http.StatusInternalServerError, "Something went wrong", nil, errMeta, false)
-		return
+		return rest_error.CodeInternal
 	}
 }
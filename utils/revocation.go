@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	infraCache "github.com/iamrz1/ab-auth/infra/cache"
+	"github.com/iamrz1/ab-auth/logger"
+	"time"
+)
+
+// revocationCache remembers denylist lookups, sparing a Redis round trip per request
+var revocationCache = infraCache.NewLRU(RevocationCacheSize)
+
+// TokenID identifies a token on the denylist by its jti. Tokens issued before jti was
+// added are identified by their hash.
+func TokenID(c *claims, token string) string {
+	if c.Id != "" {
+		return c.Id
+	}
+
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// RevokeToken puts the token with id on the denylist until exp, when it expires anyway, and
+// announces it to the other instances
+func RevokeToken(ctx context.Context, id string, exp int64) error {
+	ttl := time.Until(time.Unix(exp, 0))
+	if ttl <= 0 {
+		return nil
+	}
+
+	client := infraCache.ClientWithContext(ctx)
+	err := client.Set(RevokedTokenKeyPrefix+id, 1, ttl).Err()
+	if err != nil {
+		return err
+	}
+	revocationCache.Set(id, true, ttl)
+
+	err = client.Publish(RevokedTokenChannel, id).Err()
+	if err != nil {
+		logger.Errorln(ctx, "RevokeToken", err.Error())
+	}
+
+	return nil
+}
+
+// IsTokenRevoked reports whether the token with id is on the denylist. Like IsTokenFresh
+// it fails closed, a token is taken as revoked when Redis can not be asked.
+func IsTokenRevoked(ctx context.Context, id string) bool {
+	if v, ok := revocationCache.Get(id); ok {
+		return v.(bool)
+	}
+
+	n, err := infraCache.ClientWithContext(ctx).Exists(RevokedTokenKeyPrefix + id).Result()
+	if err != nil {
+		logger.Errorln(ctx, "IsTokenRevoked", err.Error())
+		return true
+	}
+
+	revoked := n > 0
+	revocationCache.Set(id, revoked, RevocationCacheTTL)
+
+	return revoked
+}
+
+// WatchRevocations evicts cached lookups of the tokens revoked by RevokeToken on any
+// instance until ctx is done. Messages missed while disconnected are covered by
+// RevocationCacheTTL.
+func WatchRevocations(ctx context.Context) {
+	pubsub := infraCache.Client().Subscribe(RevokedTokenChannel)
+	defer pubsub.Close()
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			revocationCache.Delete(msg.Payload)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"context"
+	infraCache "github.com/iamrz1/ab-auth/infra/cache"
+	"github.com/iamrz1/ab-auth/infra/cache/cachetest"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestWatchRevocations_Evicts(t *testing.T) {
+	cache, err := cachetest.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	revocationCache = infraCache.NewLRU(RevocationCacheSize)
+	t.Cleanup(func() {
+		revocationCache = infraCache.NewLRU(RevocationCacheSize)
+		cache.Close()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		WatchRevocations(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	assert.False(t, IsTokenRevoked(ctx, "jti-1"))
+
+	// another instance handles the logout, its message evicts the lookup cached here
+	assert.NoError(t, cache.Client.Set(RevokedTokenKeyPrefix+"jti-1", 1, time.Minute).Err())
+	assert.False(t, IsTokenRevoked(ctx, "jti-1"), "the lookup is cached until evicted")
+	assert.Eventually(t, func() bool {
+		cache.Client.Publish(RevokedTokenChannel, "jti-1")
+		return IsTokenRevoked(ctx, "jti-1")
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,182 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/iamrz1/ab-auth/logger"
+	"github.com/iamrz1/ab-auth/secrets"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// keyRing holds a signing key and the key it replaced, which still verifies what it
+// signed until the next rotation
+type keyRing struct {
+	mu       sync.RWMutex
+	current  []byte
+	previous []byte
+}
+
+func newKeyRing(key string) *keyRing {
+	return &keyRing{current: []byte(key)}
+}
+
+// set makes key the only key. It is how the key a process starts with is taken, so the
+// key it replaces, like a dev key, verifies nothing.
+func (k *keyRing) set(key string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.current, k.previous = []byte(key), nil
+}
+
+// rotate makes key the current key, empty keys are ignored
+func (k *keyRing) rotate(key string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if key == "" || key == string(k.current) {
+		return
+	}
+	k.previous, k.current = k.current, []byte(key)
+}
+
+// signingKey returns the key to sign with
+func (k *keyRing) signingKey() []byte {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return k.current
+}
+
+// verificationKeys returns the keys to verify with, the current one first
+func (k *keyRing) verificationKeys() [][]byte {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if k.previous == nil {
+		return [][]byte{k.current}
+	}
+
+	return [][]byte{k.current, k.previous}
+}
+
+var (
+	accessTokenKeys  = newKeyRing(devAccessTokenKey)
+	refreshTokenKeys = newKeyRing(devRefreshTokenKey)
+	otpHashKeys      = newKeyRing(devOTPHashKey)
+	secretStore      *secrets.Store
+)
+
+// UseSecrets takes the token signing keys, the otp hash key and the captcha secret from
+// store and follows their rotation. Outside production the dev keys stand in for missing
+// keys, in production they are an error.
+func UseSecrets(store *secrets.Store) error {
+	problems := make([]string, 0)
+	for name, ring := range keyRings() {
+		name, ring := name, ring
+		key := store.Get(name)
+		switch {
+		case appConfig.Environment != EnvProduction && key == "":
+			logger.Warnln(context.Background(), "UseSecrets", "secret "+name+" not found, using the dev key")
+			ring.set(devKeys()[name])
+		case key == "":
+			problems = append(problems, fmt.Sprintf("%s: not found", name))
+		default:
+			if err := checkKey(name, key); err != nil {
+				problems = append(problems, err.Error())
+				break
+			}
+			ring.set(key)
+		}
+
+		store.OnChange(name, func(key string) {
+			if err := checkKey(name, key); err != nil {
+				logger.Errorln(context.Background(), "UseSecrets", "keeping the current key, the rotated "+err.Error())
+				return
+			}
+			ring.rotate(key)
+		})
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid secrets:\n  %s", strings.Join(problems, "\n  "))
+	}
+	secretStore = store
+
+	return nil
+}
+
+// checkKey tells why key can not be the secret name, in production a key shorter than
+// minSigningKeyLength or a dev key
+func checkKey(name, key string) error {
+	switch {
+	case appConfig.Environment != EnvProduction:
+		return nil
+	case len(key) < minSigningKeyLength:
+		return fmt.Errorf("%s: must be at least %d characters", name, minSigningKeyLength)
+	case key == devKeys()[name]:
+		return fmt.Errorf("%s: must not be the dev key", name)
+	}
+
+	return nil
+}
+
+// devKeys returns the keys of the repo by the name of their secret
+func devKeys() map[string]string {
+	return map[string]string{
+		secrets.JWTAccessKey:  devAccessTokenKey,
+		secrets.JWTRefreshKey: devRefreshTokenKey,
+		secrets.OTPHashKey:    devOTPHashKey,
+	}
+}
+
+// CheckSigningKeys reports signing keys that can not be used, like a dev key in production
+// because UseSecrets was not called. Every key a ring still verifies with is checked, not
+// only the one it signs with.
+func CheckSigningKeys() error {
+	devKeys := devKeys()
+
+	problems := make([]string, 0)
+	for name, ring := range keyRings() {
+		if len(ring.signingKey()) == 0 {
+			problems = append(problems, name+" is not set")
+			continue
+		}
+		if appConfig.Environment != EnvProduction {
+			continue
+		}
+		for _, key := range ring.verificationKeys() {
+			if string(key) == devKeys[name] {
+				problems = append(problems, name+" accepts the dev key")
+				break
+			}
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return errors.New(strings.Join(problems, ", "))
+	}
+
+	return nil
+}
+
+// keyRings returns the key rings by the name of their secret
+func keyRings() map[string]*keyRing {
+	return map[string]*keyRing{
+		secrets.JWTAccessKey:  accessTokenKeys,
+		secrets.JWTRefreshKey: refreshTokenKeys,
+		secrets.OTPHashKey:    otpHashKeys,
+	}
+}
+
+// captchaSecret returns the secret the captcha service expects
+func captchaSecret() string {
+	if secretStore == nil {
+		return ""
+	}
+
+	return secretStore.Get(secrets.CaptchaKey)
+}
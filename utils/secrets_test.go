@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"context"
+	"github.com/iamrz1/ab-auth/config"
+	"github.com/iamrz1/ab-auth/secrets"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+// fakeSecrets is a provider whose Watch reports the secrets in changed as rotated
+type fakeSecrets struct {
+	values  map[string]string
+	changed []string
+}
+
+func (p *fakeSecrets) Get(name string) (string, error) {
+	if v, ok := p.values[name]; ok {
+		return v, nil
+	}
+	return "", secrets.ErrNotFound
+}
+
+func (p *fakeSecrets) Watch(ctx context.Context, onChange func(name string)) {
+	for _, name := range p.changed {
+		onChange(name)
+	}
+}
+
+// useEnvironment configures the package for env and puts the dev keys back when the test
+// is done
+func useEnvironment(t *testing.T, env string) {
+	cfg := config.Defaults()
+	cfg.Environment = env
+	old := appConfig
+	Configure(cfg)
+
+	t.Cleanup(func() {
+		Configure(old)
+		for name, ring := range keyRings() {
+			ring.set(devKeys()[name])
+		}
+	})
+}
+
+func prodSecrets() *fakeSecrets {
+	return &fakeSecrets{values: map[string]string{
+		secrets.JWTAccessKey:  strings.Repeat("a", minSigningKeyLength),
+		secrets.JWTRefreshKey: strings.Repeat("r", minSigningKeyLength),
+		secrets.OTPHashKey:    strings.Repeat("o", minSigningKeyLength),
+	}}
+}
+
+func TestUseSecrets_DropsDevKeys(t *testing.T) {
+	useEnvironment(t, EnvProduction)
+	devAccess, devRefresh := GenerateTokens("01700000001", "", "customer")
+
+	assert.NoError(t, UseSecrets(secrets.NewStore(prodSecrets())))
+	assert.NoError(t, CheckSigningKeys())
+
+	_, err := VerifyToken(devAccess, false)
+	assert.Error(t, err, "tokens signed with the dev key of the repo are refused")
+	_, err = VerifyToken(devRefresh, true)
+	assert.Error(t, err)
+
+	access, _ := GenerateTokens("01700000001", "", "customer")
+	_, err = VerifyToken(access, false)
+	assert.NoError(t, err)
+}
+
+func TestUseSecrets_RefusesWeakKeys(t *testing.T) {
+	useEnvironment(t, EnvProduction)
+
+	provider := prodSecrets()
+	provider.values[secrets.JWTRefreshKey] = devRefreshTokenKey
+	provider.values[secrets.OTPHashKey] = "short"
+	err := UseSecrets(secrets.NewStore(provider))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), secrets.JWTRefreshKey+": must not be the dev key")
+		assert.Contains(t, err.Error(), secrets.OTPHashKey+": must be at least")
+	}
+}
+
+func TestUseSecrets_Rotation(t *testing.T) {
+	useEnvironment(t, EnvProduction)
+
+	provider := prodSecrets()
+	store := secrets.NewStore(provider)
+	assert.NoError(t, UseSecrets(store))
+	before, _ := GenerateTokens("01700000001", "", "customer")
+
+	provider.values[secrets.JWTAccessKey] = "short"
+	provider.changed = []string{secrets.JWTAccessKey}
+	store.Watch(context.Background())
+	assert.Len(t, accessTokenKeys.verificationKeys(), 1, "a short key is not rotated in")
+
+	provider.values[secrets.JWTAccessKey] = strings.Repeat("b", minSigningKeyLength)
+	store.Watch(context.Background())
+	_, err := VerifyToken(before, false)
+	assert.NoError(t, err, "tokens of the previous key verify until the next rotation")
+	assert.Len(t, accessTokenKeys.verificationKeys(), 2)
+}
+
+func TestCheckSigningKeys_PreviousDevKey(t *testing.T) {
+	useEnvironment(t, EnvProduction)
+	assert.Error(t, CheckSigningKeys(), "the dev keys are in use")
+
+	accessTokenKeys.rotate(strings.Repeat("a", minSigningKeyLength))
+	err := CheckSigningKeys()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), secrets.JWTAccessKey+" accepts the dev key", "the dev key verifies until the next rotation")
+	}
+
+	assert.NoError(t, UseSecrets(secrets.NewStore(prodSecrets())))
+	assert.NoError(t, CheckSigningKeys())
+}
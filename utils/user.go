@@ -1,9 +1,11 @@
 package utils
 
 import (
-	"fmt"
-	"log"
-	"os"
+	"context"
+	rest_error "github.com/iamrz1/ab-auth/error"
+	"github.com/iamrz1/ab-auth/i18n"
+	"github.com/iamrz1/ab-auth/logger"
+	"net/http"
 	"regexp"
 	"strings"
 	"unicode"
@@ -19,8 +21,8 @@ func IsGenderValid(gender string) bool {
 }
 
 func IsValidPhoneNumber(phoneNumber string) bool {
-	if os.Getenv("ENV") == "dev" {
-		log.Println(">============xxx NO PHONE NUMBER VALIDATION IN DEV BUILDS xxx============<")
+	if appConfig.Environment == EnvDevelopment {
+		logger.Warnln(context.Background(), "IsValidPhoneNumber", "no phone number validation in dev builds")
 		return true
 	}
 	reg := regexp.MustCompile(`^(01)[3-9][0-9]{8}$`)
@@ -32,9 +34,9 @@ func IsValidPhoneNumber(phoneNumber string) bool {
 }
 
 const (
-	InvalidCharErrorMessage = "Password contains invalid characters"
-	SpecialCharErrorMessage = "Must contain at least one special character"
-	CharLenErrorMessage     = "Must be at least 8 characters long"
+	InvalidCharErrorMessage = i18n.MsgPasswordInvalidChar
+	SpecialCharErrorMessage = i18n.MsgPasswordSpecialChar
+	CharLenErrorMessage     = i18n.MsgPasswordTooShort
 )
 
 func ValidatePassword(password string) error {
@@ -55,14 +57,36 @@ func ValidatePassword(password string) error {
 	eightOrMore = characters >= 8
 
 	if !special {
-		err = fmt.Errorf("%s", SpecialCharErrorMessage)
+		err = rest_error.NewCodedValidationError(rest_error.CodeWeakPassword, SpecialCharErrorMessage, nil)
 	}
 	if !eightOrMore {
-		err = fmt.Errorf("%s", CharLenErrorMessage)
+		err = rest_error.NewCodedValidationError(rest_error.CodeWeakPassword, CharLenErrorMessage, nil)
 	}
 	if invalid {
-		err = fmt.Errorf("%s", InvalidCharErrorMessage)
+		err = rest_error.NewCodedValidationError(rest_error.CodeWeakPassword, InvalidCharErrorMessage, nil)
 	}
 
 	return err
 }
+
+// IsValidAccountStatus reports whether an account can be moved to status
+func IsValidAccountStatus(status string) bool {
+	return ExistsInSlice([]string{StatusActive, StatusBlocked, StatusInactive, StatusPending}, status)
+}
+
+// ValidateAccountStatus returns an error when an account in status is not allowed to authenticate.
+// Accounts without a status predate status tracking and are treated as active.
+func ValidateAccountStatus(status string, allowPending bool) error {
+	switch status {
+	case StatusBlocked:
+		return rest_error.NewCodedError(http.StatusForbidden, rest_error.CodeAccountBlocked, i18n.MsgAccountBlocked)
+	case StatusInactive:
+		return rest_error.NewCodedError(http.StatusForbidden, rest_error.CodeAccountInactive, i18n.MsgAccountInactive)
+	case StatusPending:
+		if !allowPending {
+			return rest_error.NewCodedError(http.StatusForbidden, rest_error.CodeAccountPending, i18n.MsgAccountPending)
+		}
+	}
+
+	return nil
+}
@@ -11,10 +11,11 @@ import (
 	"fmt"
 	"github.com/go-chi/chi/middleware"
 	"github.com/google/uuid"
+	"github.com/iamrz1/ab-auth/logger"
 	jsoniter "github.com/json-iterator/go"
 	hashers "github.com/meehow/go-django-hashers"
+	"go.opentelemetry.io/otel/trace"
 	"io"
-	"log"
 	"math"
 	"math/rand"
 	"reflect"
@@ -77,14 +78,14 @@ func BoolPToString(value *bool) string {
 func DeepCopyStruct(in interface{}) interface{} {
 	b, err := json.Marshal(in)
 	if err != nil {
-		log.Println(err)
+		logger.Errorln(context.Background(), "DeepCopyStruct", err.Error())
 		return nil
 	}
 
 	var out interface{}
 	err = json.Unmarshal(b, &out)
 	if err != nil {
-		log.Println(err)
+		logger.Errorln(context.Background(), "DeepCopyStruct", err.Error())
 		return nil
 	}
 
@@ -106,7 +107,13 @@ func CustomJsonMarshal(data interface{}, tag string) ([]byte, error) {
 	return json.Marshal(data)
 }
 
+// GetTracingID returns the trace id of the span in ctx, so logs can be found next to the
+// trace, or the request id when ctx holds no span
 func GetTracingID(ctx context.Context) string {
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+
 	return middleware.GetReqID(ctx)
 }
 
@@ -227,7 +234,7 @@ func VerifyPassword(plainPass, encodedPass string) bool {
 	hashers.Iter = DefaultHashingIteration
 	bl, err := hashers.CheckPassword(plainPass, encodedPass)
 	if err != nil {
-		log.Println(err)
+		logger.Errorln(context.Background(), "VerifyPassword", err.Error())
 		return false
 	}
 
@@ -273,8 +280,7 @@ func getRandomText(count int, seed []byte) string {
 }
 
 func generateHash(data string) string {
-	secretKey := "uJ9eZCNwg9NxJ2rmY6nN"
-	h := hmac.New(sha256.New, []byte(secretKey))
+	h := hmac.New(sha256.New, otpHashKeys.signingKey())
 	h.Write([]byte(data))
 
 	sha := hex.EncodeToString(h.Sum(nil))